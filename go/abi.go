@@ -0,0 +1,182 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var ErrLeafEncoding = errors.New("merkletree: value does not match the leaf encoding")
+
+// A Solidity type supported in leaf encodings, see abiEncode.
+type abiType struct {
+	name string
+	kind abiKind
+	size int // bits of an integer, bytes of a fixed-size byte array
+}
+
+type abiKind int
+
+const (
+	abiAddress abiKind = iota
+	abiBool
+	abiUint
+	abiInt
+	abiFixedBytes
+	abiBytes
+	abiString
+)
+
+// Parses a leaf encoding such as ["address", "uint256"]. Arrays and tuples are not supported.
+func parseABITypes(names []string) ([]abiType, error) {
+	types := make([]abiType, len(names))
+	for i, name := range names {
+		t, ok := parseABIType(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported type %q", ErrLeafEncoding, name)
+		}
+		types[i] = t
+	}
+	return types, nil
+}
+
+func parseABIType(name string) (abiType, bool) {
+	switch name {
+	case "address":
+		return abiType{name, abiAddress, 0}, true
+	case "bool":
+		return abiType{name, abiBool, 0}, true
+	case "bytes":
+		return abiType{name, abiBytes, 0}, true
+	case "string":
+		return abiType{name, abiString, 0}, true
+	case "uint":
+		return abiType{name, abiUint, 256}, true
+	case "int":
+		return abiType{name, abiInt, 256}, true
+	}
+
+	for _, sized := range []struct {
+		prefix   string
+		kind     abiKind
+		min, max int
+		step     int
+	}{
+		{"uint", abiUint, 8, 256, 8},
+		{"int", abiInt, 8, 256, 8},
+		{"bytes", abiFixedBytes, 1, 32, 1},
+	} {
+		digits, ok := strings.CutPrefix(name, sized.prefix)
+		if !ok || digits == "" || digits[0] == '0' {
+			continue
+		}
+		size, err := strconv.Atoi(digits)
+		if err == nil && size >= sized.min && size <= sized.max && size%sized.step == 0 {
+			return abiType{name, sized.kind, size}, true
+		}
+	}
+	return abiType{}, false
+}
+
+// Encodes the values as Solidity's abi.encode does for the types: a head of one 32-byte word
+// per value, holding either the value or the offset of its tail for bytes and string.
+// Integers may be decimal or 0x hex, addresses and bytes 0x hex, and bools true or false.
+func abiEncode(types []abiType, values []string) ([]byte, error) {
+	if len(values) != len(types) {
+		return nil, fmt.Errorf("%w: %d values for %d types", ErrLeafEncoding, len(values), len(types))
+	}
+
+	head := make([]byte, 0, 32*len(types))
+	var tail []byte
+	for i, t := range types {
+		if t.kind == abiBytes || t.kind == abiString {
+			head = append(head, abiWord(uint64(32*len(types)+len(tail)))...)
+			data := []byte(values[i])
+			if t.kind == abiBytes {
+				var err error
+				if data, err = decodeABIHex(values[i]); err != nil {
+					return nil, fmt.Errorf("%w: value %d: %v", ErrLeafEncoding, i, err)
+				}
+			}
+			tail = append(tail, abiWord(uint64(len(data)))...)
+			tail = append(tail, data...)
+			tail = append(tail, make([]byte, (32-len(data)%32)%32)...)
+			continue
+		}
+
+		word, err := t.encodeStatic(values[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: value %d as %s: %v", ErrLeafEncoding, i, t.name, err)
+		}
+		head = append(head, word[:]...)
+	}
+	return append(head, tail...), nil
+}
+
+func (t abiType) encodeStatic(value string) ([32]byte, error) {
+	var word [32]byte
+	switch t.kind {
+	case abiAddress:
+		raw, err := decodeABIHex(value)
+		if err != nil {
+			return word, err
+		}
+		if len(raw) != 20 {
+			return word, fmt.Errorf("got %d bytes, want 20", len(raw))
+		}
+		copy(word[12:], raw)
+	case abiBool:
+		switch value {
+		case "true":
+			word[31] = 1
+		case "false":
+		default:
+			return word, fmt.Errorf("%q is neither true nor false", value)
+		}
+	case abiUint, abiInt:
+		n, ok := new(big.Int).SetString(value, 0)
+		if !ok {
+			return word, fmt.Errorf("%q is not an integer", value)
+		}
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(t.size))
+		if t.kind == abiInt {
+			limit.Rsh(limit, 1)
+		}
+		if n.Cmp(limit) >= 0 || (t.kind == abiUint && n.Sign() < 0) || (t.kind == abiInt && n.Cmp(new(big.Int).Neg(limit)) < 0) {
+			return word, fmt.Errorf("%s is out of range", value)
+		}
+		if n.Sign() < 0 {
+			// two's complement over the whole word
+			n.Add(n, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		n.FillBytes(word[:])
+	case abiFixedBytes:
+		raw, err := decodeABIHex(value)
+		if err != nil {
+			return word, err
+		}
+		if len(raw) != t.size {
+			return word, fmt.Errorf("got %d bytes, want %d", len(raw), t.size)
+		}
+		copy(word[:], raw)
+	}
+	return word, nil
+}
+
+func decodeABIHex(value string) ([]byte, error) {
+	digits, ok := strings.CutPrefix(value, "0x")
+	if !ok {
+		return nil, fmt.Errorf("%q lacks the 0x prefix", value)
+	}
+	return hex.DecodeString(digits)
+}
+
+func abiWord(n uint64) []byte {
+	var word [32]byte
+	binary.BigEndian.PutUint64(word[24:], n)
+	return word[:]
+}
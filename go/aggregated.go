@@ -0,0 +1,210 @@
+package merkletree
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Leaf indices from Start, inclusive, to End, exclusive.
+type Range struct {
+	Start uint64
+	End   uint64
+}
+
+// Proves the inclusion of one or more runs of contiguous elements of the same tree.
+// Siblings which can be derived from the proven leaves themselves are left out, and those
+// the runs share are given once, so the proof is smaller than the proofs of its elements.
+type AggregatedProof struct {
+	depth    int      // levels between the leaves and the root
+	ranges   []Range  // runs of proven leaf indices, ascending, disjoint and not adjacent
+	leaves   []string // hash of every element of the runs, in index order
+	siblings []string // nodes not derivable from the leaves, ordered by level then index
+	tag      string   // application tag of the tree
+
+	algorithm AlgorithmID // algorithm the tree hashes with
+}
+
+// Returns the runs of leaf indices proven, ascending.
+func (p AggregatedProof) Ranges() []Range {
+	return append([]Range(nil), p.ranges...)
+}
+
+// Generates a single proof of the elements of every range, which may be given in any
+// order and overlap: ranges are sorted and those overlapping or adjacent merged, so an
+// element is proven once and the proof names the merged runs. Fails with
+// ErrIndexOutOfBounds for no ranges, an empty range or one reaching past the last element.
+func (t *MerkleTree) GetAggregatedProofMulti(ranges []Range) (AggregatedProof, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return AggregatedProof{}, err
+	}
+	defer t.mu.RUnlock()
+	return t.getAggregatedProof(ranges)
+}
+
+func (t *MerkleTree) getAggregatedProof(ranges []Range) (AggregatedProof, error) {
+	if len(ranges) == 0 {
+		return AggregatedProof{}, fmt.Errorf("%w: no ranges given", ErrIndexOutOfBounds)
+	}
+	for _, r := range ranges {
+		if r.Start >= r.End || r.End > t.leafCount() {
+			return AggregatedProof{}, fmt.Errorf("%w: range [%d, %d), element count %d", ErrIndexOutOfBounds, r.Start, r.End, t.leafCount())
+		}
+	}
+
+	proof := AggregatedProof{
+		depth:     t.height(),
+		ranges:    mergeRanges(ranges),
+		tag:       t.cfg.tag,
+		algorithm: t.cfg.algorithm(),
+	}
+	for _, r := range proof.ranges {
+		for index := r.Start; index < r.End; index++ {
+			proof.leaves = append(proof.leaves, t.node(0, index).String())
+		}
+	}
+
+	// the nodes known at each level form runs, each needing the sibling of a first node on
+	// the right of its pair and of a last node on the left of its pair
+	runs := proof.ranges
+	for level := 0; level < proof.depth; level++ {
+		parents := make([]Range, 0, len(runs))
+		for _, r := range runs {
+			if r.Start%2 == 1 {
+				proof.siblings = append(proof.siblings, t.node(level, r.Start-1).String())
+			}
+			if r.End%2 == 1 {
+				proof.siblings = append(proof.siblings, t.node(level, r.End).String())
+			}
+			parents = append(parents, Range{r.Start / 2, (r.End + 1) / 2})
+		}
+		runs = mergeRanges(parents)
+	}
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.ProofGenerated(len(proof.siblings))
+	}
+	return proof, nil
+}
+
+// Returns the ranges, which must not be empty, sorted with those overlapping or adjacent
+// merged. The ranges given are not modified.
+func mergeRanges(ranges []Range) []Range {
+	sorted := slices.Clone(ranges)
+	slices.SortFunc(sorted, func(a, b Range) int {
+		switch {
+		case a.Start < b.Start:
+			return -1
+		case a.Start > b.Start:
+			return 1
+		}
+		return 0
+	})
+
+	merged := sorted[:1]
+	for _, r := range sorted[1:] {
+		if last := &merged[len(merged)-1]; r.Start <= last.End {
+			last.End = max(last.End, r.End)
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// Verifies that every element of the proof's ranges is included under root.
+// A proof from a tree with another application tag never verifies, nor one naming
+// another algorithm than the options hash with, unless WithAlgorithmAutoDetect is given.
+func VerifyAggregatedProof(root string, proof AggregatedProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyAggregatedProof(root, proof)
+}
+
+// Verifies the aggregated proof as VerifyAggregatedProof does, checking ctx every few
+// hundred hashes so that verifying a proof of a very large range stops soon after ctx is
+// done. Failures are classified as VerifyMultiProofCtx classifies them: once ctx is done,
+// context.Cause(ctx) rather than ErrInvalidProof, so a deadline tells apart from a proof
+// that failed.
+func VerifyAggregatedProofCtx(ctx context.Context, root string, proof AggregatedProof, opts ...Option) error {
+	return newVerifier(opts).VerifyAggregatedProofCtx(ctx, root, proof)
+}
+
+// Verifies that every element of the proof's ranges is included under root, as
+// VerifyAggregatedProof does.
+func (v *Verifier) VerifyAggregatedProof(root string, proof AggregatedProof) bool {
+	return v.VerifyAggregatedProofCtx(context.Background(), root, proof) == nil
+}
+
+// Verifies the aggregated proof under ctx, as VerifyAggregatedProofCtx does.
+func (v *Verifier) VerifyAggregatedProofCtx(ctx context.Context, root string, proof AggregatedProof) error {
+	_, err := v.cfg.verifyAggregatedProof(ctx, root, proof)
+	return err
+}
+
+// Verifies the aggregated proof as VerifyAggregatedProof does and returns the leaf hashes
+// it proves, in canonical form: the hash of every element of proof.Ranges(), in index
+// order, as the tree under root commits to them, so data checked against them needs no
+// second pass. Proofs that do not verify return nil and false, never any of their leaves.
+func VerifyAndExtract(root string, proof AggregatedProof, opts ...Option) ([]string, bool) {
+	return newVerifier(opts).VerifyAndExtract(root, proof)
+}
+
+// Verifies the aggregated proof and returns its leaf hashes, as VerifyAndExtract does.
+func (v *Verifier) VerifyAndExtract(root string, proof AggregatedProof) ([]string, bool) {
+	leaves, err := v.cfg.verifyAggregatedProof(context.Background(), root, proof)
+	if err != nil {
+		return nil, false
+	}
+	extracted := make([]string, len(leaves))
+	for i, leaf := range leaves {
+		extracted[i] = leaf.String()
+	}
+	return extracted, true
+}
+
+// Verifies the aggregated proof under cfg as its MultiProof, failing as verifyMultiProof
+// does and with ErrInvalidProof for ranges the leaves do not fill. Returns the leaves parsed.
+func (cfg config) verifyAggregatedProof(ctx context.Context, root string, proof AggregatedProof) ([]Hash, error) {
+	multi, ok := proof.multiProof()
+	if !ok {
+		return nil, ErrInvalidProof
+	}
+	return cfg.verifyMultiProof(ctx, root, multi)
+}
+
+// Returns the MultiProof of the same leaves and siblings, which verifies exactly when the
+// aggregated proof does, or false for ranges that are not ascending and disjoint, do not
+// fit the depth or do not hold as many indices as there are leaves.
+func (p AggregatedProof) multiProof() (MultiProof, bool) {
+	if len(p.ranges) == 0 || p.depth > maxProofDepth {
+		return MultiProof{}, false
+	}
+	count := uint64(0)
+	for i, r := range p.ranges {
+		if r.Start >= r.End || (i > 0 && r.Start < p.ranges[i-1].End) || (p.depth < 64 && r.End > 1<<p.depth) {
+			return MultiProof{}, false
+		}
+		// bounds the indices made by the leaves given, whatever the ranges claim
+		if r.End-r.Start > uint64(len(p.leaves))-count {
+			return MultiProof{}, false
+		}
+		count += r.End - r.Start
+	}
+	if count != uint64(len(p.leaves)) {
+		return MultiProof{}, false
+	}
+
+	multi := MultiProof{
+		depth:     p.depth,
+		indices:   make([]uint64, 0, count),
+		leaves:    p.leaves,
+		siblings:  p.siblings,
+		tag:       p.tag,
+		algorithm: p.algorithm,
+	}
+	for _, r := range p.ranges {
+		for index := r.Start; index < r.End; index++ {
+			multi.indices = append(multi.indices, index)
+		}
+	}
+	return multi, true
+}
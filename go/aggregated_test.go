@@ -0,0 +1,233 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetAggregatedProof(t *testing.T) {
+	cases := []struct {
+		size       int
+		start, end uint64
+	}{
+		{1, 0, 1},
+		{2, 0, 2},
+		{8, 2, 4},
+		{8, 1, 7},
+		{13, 0, 13},
+		{13, 5, 12},
+		{32, 31, 32},
+	}
+
+	for _, c := range cases {
+		testname := fmt.Sprintf("proves [%d, %d) of %d elements", c.start, c.end, c.size)
+		t.Run(testname, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(c.size))
+			proof, err := mt.GetAggregatedProof(c.start, c.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyAggregatedProof(mt.GetRoot(), proof) {
+				t.Error("invalid aggregated proof")
+			}
+			if VerifyAggregatedProof(hashLeaf("other"), proof) {
+				t.Error("verified against the wrong root")
+			}
+
+			naive := 0
+			for index := c.start; index < c.end; index++ {
+				single, _ := mt.GetProof(index)
+				naive += len(single.siblings)
+			}
+			if c.end-c.start > 1 && len(proof.siblings) >= naive {
+				t.Errorf("got %d siblings, not fewer than the %d of the individual proofs", len(proof.siblings), naive)
+			}
+		})
+	}
+}
+
+func TestGetAggregatedProofRejectsRanges(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(8))
+	for _, ranges := range [][]Range{nil, {{3, 3}}, {{4, 2}}, {{6, 9}}, {{0, 2}, {8, 9}}} {
+		if _, err := mt.GetAggregatedProofMulti(ranges); !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("%v: got %v, want %v", ranges, err, ErrIndexOutOfBounds)
+		}
+	}
+	if _, err := mt.GetAggregatedProof(5, 5); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+}
+
+func TestGetAggregatedProofMulti(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(1000))
+	cases := []struct {
+		ranges []Range
+		want   []Range
+	}{
+		{[]Range{{10, 20}, {500, 510}}, []Range{{10, 20}, {500, 510}}},
+		{[]Range{{500, 510}, {10, 20}}, []Range{{10, 20}, {500, 510}}},
+		{[]Range{{10, 20}, {15, 30}, {30, 31}}, []Range{{10, 31}}},
+		{[]Range{{0, 1}, {2, 3}, {999, 1000}}, []Range{{0, 1}, {2, 3}, {999, 1000}}},
+		{[]Range{{100, 101}, {102, 103}, {104, 105}, {106, 107}}, []Range{{100, 101}, {102, 103}, {104, 105}, {106, 107}}},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprint(c.ranges), func(t *testing.T) {
+			proof, err := mt.GetAggregatedProofMulti(c.ranges)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(proof.Ranges(), c.want) {
+				t.Errorf("got ranges %v, want %v", proof.Ranges(), c.want)
+			}
+			if !VerifyAggregatedProof(mt.GetRoot(), proof) {
+				t.Error("invalid aggregated proof")
+			}
+
+			// every run shares the root's subtree with the others, so one proof beats as many
+			perRange := 0
+			for _, r := range c.want {
+				single, _ := mt.GetAggregatedProof(r.Start, r.End)
+				perRange += len(single.siblings)
+			}
+			if len(c.want) > 1 && len(proof.siblings) >= perRange {
+				t.Errorf("got %d siblings, not fewer than the %d of a proof per range", len(proof.siblings), perRange)
+			}
+		})
+	}
+}
+
+func TestVerifyAggregatedProofRejectsTampering(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(64))
+	proof, _ := mt.GetAggregatedProofMulti([]Range{{10, 20}, {40, 42}})
+	root := mt.GetRoot()
+
+	tamper := map[string]func(p *AggregatedProof){
+		"leaf":            func(p *AggregatedProof) { p.leaves[3] = hashLeaf("forged") },
+		"sibling":         func(p *AggregatedProof) { p.siblings[0] = hashLeaf("forged") },
+		"missing sibling": func(p *AggregatedProof) { p.siblings = p.siblings[1:] },
+		"missing leaf":    func(p *AggregatedProof) { p.leaves = p.leaves[1:] },
+		"shifted range":   func(p *AggregatedProof) { p.ranges[0] = Range{11, 21} },
+		"overlapping":     func(p *AggregatedProof) { p.ranges[1] = Range{19, 21} },
+		"unsorted":        func(p *AggregatedProof) { p.ranges[0], p.ranges[1] = p.ranges[1], p.ranges[0] },
+		"empty range":     func(p *AggregatedProof) { p.ranges = append(p.ranges, Range{50, 50}) },
+		"no ranges":       func(p *AggregatedProof) { p.ranges = nil },
+		"past the depth":  func(p *AggregatedProof) { p.ranges[1] = Range{1 << 6, 1<<6 + 2} },
+		"huge range":      func(p *AggregatedProof) { p.ranges[1] = Range{20, ^uint64(0)} },
+		"tag":             func(p *AggregatedProof) { p.tag = "other" },
+	}
+	for name, modify := range tamper {
+		t.Run(name, func(t *testing.T) {
+			forged := proof
+			forged.ranges = proof.Ranges()
+			forged.leaves = append([]string(nil), proof.leaves...)
+			forged.siblings = append([]string(nil), proof.siblings...)
+			modify(&forged)
+			if VerifyAggregatedProof(root, forged) {
+				t.Error("verified a tampered proof")
+			}
+		})
+	}
+}
+
+func TestVerifyAggregatedProofCtx(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(1 << 12))
+	proof, _ := mt.GetAggregatedProofMulti([]Range{{0, 1500}, {2000, 4000}})
+	if err := VerifyAggregatedProofCtx(context.Background(), mt.GetRoot(), proof); err != nil {
+		t.Errorf("got %v, want the proof verified", err)
+	}
+	if err := VerifyAggregatedProofCtx(context.Background(), hashLeaf("other"), proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v", err, ErrInvalidProof)
+	}
+	unfilled := proof
+	unfilled.leaves = proof.leaves[1:]
+	if err := VerifyAggregatedProofCtx(context.Background(), mt.GetRoot(), unfilled); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v", err, ErrInvalidProof)
+	}
+
+	var full, calls atomic.Int64
+	if err := VerifyAggregatedProofCtx(context.Background(), mt.GetRoot(), proof, withSlowBackend(0, &full)); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := VerifyAggregatedProofCtx(ctx, mt.GetRoot(), proof, withSlowBackend(50*time.Microsecond, &calls))
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v rather than an invalid proof", err, context.DeadlineExceeded)
+	}
+	if calls.Load() >= full.Load() {
+		t.Errorf("got %d nodes hashed, want verification stopping before the %d of the whole proof", calls.Load(), full.Load())
+	}
+}
+
+func TestAggregatedProofHonorsTreeOptions(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(20), WithKeccak256(), WithApplicationTag("app"))
+	proof, err := mt.GetAggregatedProofMulti([]Range{{3, 7}, {15, 19}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mt.Verifier().VerifyAggregatedProof(mt.GetRoot(), proof) {
+		t.Error("got the proof rejected by the tree's verifier")
+	}
+	if VerifyAggregatedProof(mt.GetRoot(), proof) {
+		t.Error("got the proof verified under the default options")
+	}
+}
+
+func TestVerifyAndExtract(t *testing.T) {
+	elements := testElements(64)
+	mt, _ := NewMerkleTree(elements)
+	proof, _ := mt.GetAggregatedProof(10, 20)
+
+	leaves, ok := VerifyAndExtract(mt.GetRoot(), proof)
+	if !ok || len(leaves) != 10 {
+		t.Fatalf("got %d leaves, %v, want 10 verified", len(leaves), ok)
+	}
+	for i, leaf := range leaves {
+		if want := hashLeaf(elements[10+i]); leaf != want {
+			t.Errorf("leaf %d: got %s, want %s", 10+i, leaf, want)
+		}
+	}
+	leaves[0] = hashLeaf("changed")
+	if again, _ := VerifyAndExtract(mt.GetRoot(), proof); again[0] != hashLeaf(elements[10]) {
+		t.Error("got the proof's leaves changed through the returned slice")
+	}
+
+	// leaves in another form of the same digest verify, and come back canonical
+	prefixed := proof
+	prefixed.leaves = append([]string(nil), proof.leaves...)
+	prefixed.leaves[2] = "0x" + strings.ToUpper(proof.leaves[2])
+	if leaves, ok := VerifyAndExtract(mt.GetRoot(), prefixed); !ok || leaves[2] != hashLeaf(elements[12]) {
+		t.Errorf("got %v, %v, want leaf 12 verified as %s", leaves, ok, hashLeaf(elements[12]))
+	}
+
+	tampered := proof
+	tampered.leaves = append([]string(nil), proof.leaves...)
+	tampered.leaves[3] = hashLeaf("forged")
+	garbled := proof
+	garbled.siblings = append([]string(nil), proof.siblings...)
+	garbled.siblings[0] = "zz"
+	for name, c := range map[string]struct {
+		root  string
+		proof AggregatedProof
+	}{
+		"other root":      {hashLeaf("other"), proof},
+		"malformed root":  {"root", proof},
+		"tampered leaf":   {mt.GetRoot(), tampered},
+		"garbled sibling": {mt.GetRoot(), garbled},
+		"empty proof":     {mt.GetRoot(), AggregatedProof{}},
+	} {
+		if leaves, ok := VerifyAndExtract(c.root, c.proof); ok || leaves != nil {
+			t.Errorf("%s: got %d leaves, %v, want nil and false", name, len(leaves), ok)
+		}
+	}
+	if _, ok := VerifyAndExtract(mt.GetRoot(), proof, WithApplicationTag("other")); ok {
+		t.Error("got the proof verified under another application tag")
+	}
+}
@@ -0,0 +1,146 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownAlgorithm  = errors.New("merkletree: unknown hash algorithm")
+	ErrAlgorithmMismatch = errors.New("merkletree: proof was hashed with a different algorithm")
+)
+
+// Identifies the hash algorithm proofs fold with, so that commitments, exported nodes and
+// serialized proofs name it and a deployment can roll to another algorithm without a new
+// wire format. Values are registered: each keeps its number in every encoding for good,
+// and new algorithms take the next free one. The zero value names none, as decoded from
+// data that predates the field; verifiers check such proofs under their own algorithm.
+type AlgorithmID uint8
+
+const (
+	AlgorithmUnspecified AlgorithmID = iota
+	AlgorithmSHA256                  // SHA-256, the default
+	AlgorithmSHA512_256              // SHA-512/256, see WithSHA512_256
+	AlgorithmKeccak256               // Keccak-256, see WithKeccak256
+	AlgorithmBLAKE3                  // BLAKE3, see WithBLAKE3
+	AlgorithmPoseidon                // Poseidon, see WithPoseidon; its parameters are in the Scheme
+)
+
+var algorithmNames = [...]string{
+	AlgorithmUnspecified: "",
+	AlgorithmSHA256:      "sha256",
+	AlgorithmSHA512_256:  "sha512_256",
+	AlgorithmKeccak256:   "keccak256",
+	AlgorithmBLAKE3:      "blake3",
+	AlgorithmPoseidon:    "poseidon",
+}
+
+// The hash function of each algorithm hashing bytes.
+var algorithmHashFunctions = map[AlgorithmID]HashFunction{
+	AlgorithmSHA256:     HashSHA256,
+	AlgorithmSHA512_256: HashSHA512_256,
+	AlgorithmKeccak256:  HashKeccak256,
+	AlgorithmBLAKE3:     HashBLAKE3,
+}
+
+// Returns the registered name of the algorithm, as it appears in JSON.
+func (a AlgorithmID) String() string {
+	if a.valid() {
+		return algorithmNames[a]
+	}
+	return fmt.Sprintf("AlgorithmID(%d)", uint8(a))
+}
+
+func (a AlgorithmID) valid() bool {
+	return int(a) < len(algorithmNames)
+}
+
+func (a AlgorithmID) MarshalText() ([]byte, error) {
+	if !a.valid() {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownAlgorithm, uint8(a))
+	}
+	return []byte(algorithmNames[a]), nil
+}
+
+func (a *AlgorithmID) UnmarshalText(text []byte) error {
+	for i, name := range algorithmNames {
+		if string(text) == name {
+			*a = AlgorithmID(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownAlgorithm, text)
+}
+
+// Fails with ErrUnknownAlgorithm for an identifier no algorithm is registered under.
+func (a AlgorithmID) check() error {
+	if !a.valid() {
+		return fmt.Errorf("%w: %d", ErrUnknownAlgorithm, uint8(a))
+	}
+	return nil
+}
+
+// Returns the algorithm the proof's siblings were hashed with, AlgorithmUnspecified for a
+// proof decoded from data that does not name one.
+func (p MerkleProof) Algorithm() AlgorithmID {
+	return p.algorithm
+}
+
+// Returns the algorithm of a hash function.
+func (f HashFunction) algorithm() AlgorithmID {
+	for a, function := range algorithmHashFunctions {
+		if function == f {
+			return a
+		}
+	}
+	return AlgorithmUnspecified
+}
+
+// Verifies proofs naming another algorithm than the verifier's under the one they name,
+// rather than failing with ErrAlgorithmMismatch, and imports exported nodes likewise.
+// Only the node hash function follows the proof, so an algorithm hashing bytes replaces
+// the configured one while every other option stays as given; Poseidon, whose parameters
+// a proof does not carry, is never detected. Proofs naming no algorithm verify as before.
+func WithAlgorithmAutoDetect() Option {
+	return func(cfg *config) {
+		cfg.detectAlgorithm = true
+	}
+}
+
+// Returns the algorithm proofs of the configuration fold with: that of the node hash
+// function, or of the hash backend.
+func (cfg config) algorithm() AlgorithmID {
+	if cfg.backend != nil {
+		return cfg.backend.algorithm()
+	}
+	return cfg.nodeHash.algorithm()
+}
+
+// Returns the configuration to check data naming the algorithm under: the configured one
+// when they agree or the data names none, the named one under WithAlgorithmAutoDetect.
+// Fails with ErrUnknownAlgorithm for an unregistered algorithm and with
+// ErrAlgorithmMismatch for any other.
+func (cfg config) forAlgorithm(a AlgorithmID) (config, error) {
+	own := cfg.algorithm()
+	if a == AlgorithmUnspecified || a == own {
+		return cfg, nil
+	}
+	if err := a.check(); err != nil {
+		return cfg, err
+	}
+	if f, ok := algorithmHashFunctions[a]; ok && cfg.detectAlgorithm {
+		cfg.nodeHash, cfg.backend = f, nil
+		return cfg, nil
+	}
+	return cfg, fmt.Errorf("%w: %v, verifier hashes with %v", ErrAlgorithmMismatch, a, own)
+}
+
+// Returns the configuration hashing under the tag and, when it hashes bytes, the
+// algorithm, for code working from a proof alone.
+func proofConfig(tag string, a AlgorithmID) config {
+	cfg := tagConfig(tag)
+	if f, ok := algorithmHashFunctions[a]; ok {
+		cfg.setHashFunction(f)
+	}
+	return cfg
+}
@@ -0,0 +1,217 @@
+package merkletree
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSHA512_256(t *testing.T) {
+	pair, _ := NewMerkleTree([]string{"a", "b"}, WithSHA512_256())
+	left, right := sha512.Sum512_256([]byte("a")), sha512.Sum512_256([]byte("b"))
+	if want := Hash(sha512.Sum512_256([]byte(hex.EncodeToString(left[:]) + hex.EncodeToString(right[:])))); pair.GetRoot() != want.String() {
+		t.Errorf("got %s, want %s", pair.GetRoot(), want)
+	}
+
+	mt, _ := NewMerkleTree(testElements(7), WithSHA512_256(), WithApplicationTag("rollout"))
+	for i := uint64(0); i < mt.LeafCount(); i++ {
+		proof, _ := mt.GetProof(i)
+		if !VerifyProof(mt.GetRoot(), proof, WithSHA512_256(), WithApplicationTag("rollout")) {
+			t.Errorf("proof %d rejected", i)
+		}
+		if VerifyProof(mt.GetRoot(), proof, WithApplicationTag("rollout")) {
+			t.Errorf("proof %d verified under SHA-256", i)
+		}
+	}
+	if got := mt.Stats().HashAlgorithm; got != "sha512_256" {
+		t.Errorf("got hash algorithm %s, want sha512_256", got)
+	}
+
+	scheme := mt.Scheme()
+	if scheme.LeafHash != HashSHA512_256 || scheme.NodeHash != HashSHA512_256 {
+		t.Fatalf("got %+v", scheme)
+	}
+	proof, _ := mt.GetProof(3)
+	text, _ := mt.Commitment().MarshalText()
+	var commitment Commitment
+	if err := commitment.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if commitment.Algorithm != AlgorithmSHA512_256 {
+		t.Errorf("got algorithm %v, want %v", commitment.Algorithm, AlgorithmSHA512_256)
+	}
+	if err := VerifyCommitmentProof(commitment, proof); err != nil {
+		t.Errorf("got %v against the decoded commitment, want nil", err)
+	}
+}
+
+func TestAlgorithmProofs(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6), WithKeccak256())
+	proof, _ := mt.GetProof(4)
+	if proof.Algorithm() != AlgorithmKeccak256 {
+		t.Fatalf("got algorithm %v, want %v", proof.Algorithm(), AlgorithmKeccak256)
+	}
+
+	for _, encoding := range []Encoding{EncodingBinary, EncodingJSON, EncodingBinaryCompressed, EncodingJSONCompressed} {
+		t.Run(fmt.Sprintf("round trips with encoding %d", encoding), func(t *testing.T) {
+			data, err := EncodeProof(proof, encoding, WithKeccak256())
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeProof(data, encoding, WithKeccak256())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Algorithm() != AlgorithmKeccak256 {
+				t.Errorf("got algorithm %v, want %v", decoded.Algorithm(), AlgorithmKeccak256)
+			}
+		})
+	}
+
+	cases := []struct {
+		name string
+		opts []Option
+		want error
+	}{
+		{"same algorithm", []Option{WithKeccak256()}, nil},
+		{"other algorithm", nil, ErrAlgorithmMismatch},
+		{"other algorithm detected", []Option{WithAlgorithmAutoDetect()}, nil},
+		{"detected over BLAKE3", []Option{WithBLAKE3(), WithAlgorithmAutoDetect()}, nil},
+		{"detected over Poseidon", []Option{WithPoseidon(PoseidonBN254()), WithAlgorithmAutoDetect()}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := VerifyProofWithReason(mt.GetRoot(), proof, c.opts...); !errors.Is(err, c.want) {
+				t.Errorf("got %v, want %v", err, c.want)
+			}
+			raw, _ := proof.Bytes()
+			if got := VerifyProofBytes(mt.GetRootHash(), raw, c.opts...); got != (c.want == nil) {
+				t.Errorf("got %v verifying bytes", got)
+			}
+			if _, ok := VerifyProofAgainstRoots([]string{mt.GetRoot()}, proof, c.opts...); ok != (c.want == nil) {
+				t.Errorf("got %v against roots", ok)
+			}
+		})
+	}
+
+	// Poseidon parameters are not in the proof, so Poseidon is never detected
+	field, _ := NewMerkleTree([]string{fieldElement(1), fieldElement(2), fieldElement(3)}, WithPoseidon(PoseidonBN254()))
+	fieldProof, _ := field.GetProof(1)
+	if err := VerifyProofWithReason(field.GetRoot(), fieldProof, WithAlgorithmAutoDetect()); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Errorf("got %v for a Poseidon proof, want ErrAlgorithmMismatch", err)
+	}
+
+	// a proof naming no algorithm is checked under the verifier's own
+	unnamed := proof
+	unnamed.algorithm = AlgorithmUnspecified
+	if !VerifyProof(mt.GetRoot(), unnamed, WithKeccak256()) || VerifyProof(mt.GetRoot(), unnamed, WithAlgorithmAutoDetect()) {
+		t.Error("proof naming no algorithm verified under the wrong options")
+	}
+
+	// proofs alone fold with the algorithm they name
+	if root, err := DeriveRoot(proof); err != nil || root != mt.GetRoot() {
+		t.Errorf("got %s, %v, want %s", root, err, mt.GetRoot())
+	}
+}
+
+func TestAlgorithmUnknown(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	proof, _ := mt.GetProof(1)
+
+	data, _ := proof.MarshalBinary()
+	if data[0]&proofBinaryAlgorithmFlag == 0 || data[2] != byte(AlgorithmSHA256) {
+		t.Fatalf("got %x, want the algorithm flag and byte", data[:3])
+	}
+	for _, id := range []byte{0, 200} {
+		data[2] = id
+		if err := new(MerkleProof).UnmarshalBinary(data); !errors.Is(err, ErrMalformedProof) {
+			t.Errorf("got %v decoding algorithm %d, want ErrMalformedProof", err, id)
+		}
+	}
+	if err := new(MerkleProof).UnmarshalBinary(data); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("got %v, want ErrUnknownAlgorithm", err)
+	}
+
+	encoded, _ := json.Marshal(proof)
+	var fields map[string]any
+	json.Unmarshal(encoded, &fields)
+	fields["algorithm"] = "md5"
+	encoded, _ = json.Marshal(fields)
+	if err := json.Unmarshal(encoded, new(MerkleProof)); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("got %v, want ErrUnknownAlgorithm", err)
+	}
+
+	text, _ := mt.Commitment().MarshalText()
+	token, _ := base64.RawURLEncoding.DecodeString(string(text))
+	if token[0] != commitmentTextAlgorithmVersion || token[1+digestSize+1] != byte(AlgorithmSHA256) {
+		t.Fatalf("got %x, want the algorithm version and byte", token)
+	}
+	for _, c := range []struct {
+		id   AlgorithmID
+		want error
+	}{{AlgorithmID(200), ErrUnknownAlgorithm}, {AlgorithmBLAKE3, ErrAlgorithmMismatch}} {
+		token[1+digestSize+1] = byte(c.id)
+		if err := new(Commitment).UnmarshalText([]byte(base64.RawURLEncoding.EncodeToString(token))); !errors.Is(err, c.want) || !errors.Is(err, ErrMalformedProof) {
+			t.Errorf("got %v decoding algorithm %d, want %v", err, c.id, c.want)
+		}
+	}
+	mismatched := mt.Commitment()
+	mismatched.Algorithm = AlgorithmKeccak256
+	if err := VerifyCommitmentProof(mismatched, proof); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Errorf("got %v, want ErrAlgorithmMismatch", err)
+	}
+
+	// commitments naming no algorithm keep their original encoding
+	unnamed := mt.Commitment()
+	unnamed.Algorithm = AlgorithmUnspecified
+	text, _ = unnamed.MarshalText()
+	if token, _ := base64.RawURLEncoding.DecodeString(string(text)); token[0] != commitmentTextVersion {
+		t.Errorf("got version %#x, want %#x", token[0], commitmentTextVersion)
+	}
+}
+
+func TestAlgorithmImportNodes(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6), WithBLAKE3())
+	layout, data := mt.ExportNodes()
+	if layout.Algorithm != AlgorithmBLAKE3 {
+		t.Fatalf("got algorithm %v, want %v", layout.Algorithm, AlgorithmBLAKE3)
+	}
+
+	if _, err := ImportNodes(layout, data); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Errorf("got %v, want ErrAlgorithmMismatch", err)
+	}
+	imported, err := ImportNodes(layout, data, WithAlgorithmAutoDetect())
+	if err != nil || imported.GetRoot() != mt.GetRoot() {
+		t.Fatalf("got %v importing with detection", err)
+	}
+	proof, _ := imported.GetProof(2)
+	if !VerifyProof(mt.GetRoot(), proof, WithBLAKE3()) {
+		t.Error("proof of the imported tree rejected")
+	}
+
+	layout.Algorithm = AlgorithmID(200)
+	if _, err := ImportNodes(layout, data, WithBLAKE3()); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("got %v, want ErrUnknownAlgorithm", err)
+	}
+	layout.Algorithm = AlgorithmUnspecified
+	if _, err := ImportNodes(layout, data, WithBLAKE3()); err != nil {
+		t.Errorf("got %v importing nodes naming no algorithm, want nil", err)
+	}
+}
+
+func TestAlgorithmIDText(t *testing.T) {
+	for a := AlgorithmSHA256; a <= AlgorithmPoseidon; a++ {
+		text, err := a.MarshalText()
+		var decoded AlgorithmID
+		if err != nil || decoded.UnmarshalText(text) != nil || decoded != a {
+			t.Errorf("got %v, %v round tripping %d", decoded, err, a)
+		}
+	}
+	if _, err := AlgorithmID(200).MarshalText(); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("got %v, want ErrUnknownAlgorithm", err)
+	}
+}
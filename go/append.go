@@ -0,0 +1,106 @@
+package merkletree
+
+// Appends an element after the last one, growing the height when the tree is full.
+// Each level keeps room for further appends, doubled whenever it runs out, so an append
+// costs the hashes along one path plus amortized constant copying.
+// With WithSetSemantics the element is instead inserted at its sorted position by rebuilding.
+// Fails with ErrCapacityExceeded beyond the fixed depth and ErrElementsUnknown on imported trees.
+func (t *MerkleTree) Append(element string) error {
+	return t.mutate(func(rec *journalRecord) error {
+		if rec != nil {
+			*rec = journalRecord{op: journalAppend, values: []string{element}}
+		}
+		return t.append(element)
+	})
+}
+
+func (t *MerkleTree) append(element string) error {
+	if t.elements == nil {
+		return ErrElementsUnknown
+	}
+	if t.cfg.setSemantics {
+		// the rebuild is at the minimum height, so regrow any height ExtendCapacity added
+		height := t.height()
+		if err := t.build(append(append([]string(nil), t.elements...), element)); err != nil {
+			return err
+		}
+		if height > t.height() {
+			t.grow(height, t.reserved())
+		}
+		t.epoch++
+		return nil
+	}
+
+	height, err := t.appendHeight()
+	if err != nil {
+		return err
+	}
+	if err := t.cfg.checkElement(element); err != nil {
+		return err
+	}
+	if err := t.checkDuplicate(t.count, element); err != nil {
+		return err
+	}
+	t.elements = append(t.elements, element)
+	t.indices[element] = append(t.indices[element], t.count)
+	if t.data != nil {
+		t.data = append(t.data, nil)
+	}
+	t.appendLeaf(height, t.cfg.leafDigest(element))
+	return nil
+}
+
+// Returns the height of the tree once a leaf is appended, failing beyond the fixed depth.
+func (t *MerkleTree) appendHeight() (int, error) {
+	height, err := t.cfg.checkedHeight(t.count + 1)
+	if err != nil {
+		return 0, err
+	}
+	return max(height, t.height()), nil
+}
+
+// Stores a leaf hash after the last one at the height appendHeight returned, then hashes
+// its path to the root, or leaves it for later under WithLazyRecompute.
+func (t *MerkleTree) appendLeaf(height int, leaf Hash) {
+	index := t.count
+	if height != t.height() || index == t.reserved() {
+		t.relayout(height, min(max(2*t.reserved(), index+1), uint64(1)<<height))
+	}
+
+	t.count++
+	t.nodes[t.nodeIndex(0, index)] = leaf
+	t.bloomAdd(index)
+	t.epoch++
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.LeafHashed(1)
+	}
+	if t.cfg.lazyRecompute {
+		t.markDirty(index)
+		return
+	}
+
+	// the new leaf's path holds every node this append adds to a level
+	for level := 1; level <= t.height(); level++ {
+		index /= 2
+		t.nodes[t.nodeIndex(level, index)] = t.cfg.nodeDigest(t.node(level-1, 2*index), t.node(level-1, 2*index+1))
+	}
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.NodeHashed(t.height())
+	}
+}
+
+// Moves the stored levels into a layout of the given height with room for reserve leaves.
+// Levels above a lower height are dropped.
+func (t *MerkleTree) relayout(height int, reserve uint64) {
+	offsets := levelOffsets(reserve, height, nil)
+	nodes := make([]Hash, offsets[height+1])
+	for level := 0; level <= min(height, t.height()); level++ {
+		copy(nodes[offsets[level]:], t.level(level))
+	}
+
+	t.nodes, t.offsets = nodes, offsets
+	if len(t.zero) != height+1 {
+		t.zero = nil
+	}
+}
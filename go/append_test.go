@@ -0,0 +1,72 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithLazyRecompute()}, {WithEmptyLeaf([]byte("pad"))}} {
+		mt, _ := NewMerkleTree(testElements(1), opts...)
+
+		for n := 2; n <= 40; n++ {
+			testname := fmt.Sprintf("append to %d elements with %d options", n, len(opts))
+			t.Run(testname, func(t *testing.T) {
+				if err := mt.Append(fmt.Sprintf("element-%d", n-1)); err != nil {
+					t.Fatal(err)
+				}
+
+				expected, _ := NewMerkleTree(testElements(n), opts...)
+				if mt.GetRoot() != expected.GetRoot() {
+					t.Errorf("got %s, want %s", mt.GetRoot(), expected.GetRoot())
+				}
+				if mt.Height() != expected.Height() || mt.LeafCount() != uint64(n) {
+					t.Errorf("got height %d over %d leaves, want %d over %d", mt.Height(), mt.LeafCount(), expected.Height(), n)
+				}
+				proof, _ := mt.GetProof(uint64(n - 1))
+				if !VerifyProof(expected.GetRoot(), proof) {
+					t.Error("invalid proof for the appended element")
+				}
+				if index, _ := mt.IndexOf(fmt.Sprintf("element-%d", n-1)); index != uint64(n-1) {
+					t.Errorf("got index %d, want %d", index, n-1)
+				}
+			})
+		}
+	}
+}
+
+func TestAppendExportsCompactLayout(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	mt.Append("element-5")
+
+	layout, data := mt.ExportNodes()
+	expected, _ := NewMerkleTree(testElements(6))
+	wantLayout, wantData := expected.ExportNodes()
+	if fmt.Sprint(layout) != fmt.Sprint(wantLayout) || string(data) != string(wantData) {
+		t.Errorf("got layout %v, want %v", layout, wantLayout)
+	}
+}
+
+func TestAppendFailures(t *testing.T) {
+	fixed, _ := NewMerkleTree(testElements(2), WithFixedDepth(1))
+	if err := fixed.Append("element-2"); !errors.Is(err, ErrCapacityExceeded) {
+		t.Errorf("got %v, want %v", err, ErrCapacityExceeded)
+	}
+
+	strict, _ := NewMerkleTree(testElements(2), WithRejectDuplicates())
+	if err := strict.Append("element-0"); !errors.Is(err, ErrDuplicateLeaf) {
+		t.Errorf("got %v, want %v", err, ErrDuplicateLeaf)
+	}
+	if strict.LeafCount() != 2 || strict.Epoch() != 0 {
+		t.Error("failed append changed the tree")
+	}
+
+	set, _ := NewMerkleTree(testElements(3), WithSetSemantics())
+	set.Append("element-1")
+	set.Append("element-9")
+	expected, _ := NewMerkleTree([]string{"element-9", "element-2", "element-1", "element-0"}, WithSetSemantics())
+	if set.GetRoot() != expected.GetRoot() {
+		t.Errorf("got %s, want %s", set.GetRoot(), expected.GetRoot())
+	}
+}
@@ -0,0 +1,105 @@
+package merkletree
+
+// Replaces every element with f(index, element) and recommits the tree, rehashing only
+// the leaves f changed and their ancestors, in one bottom-up pass. Padding is not visited.
+// If f returns an error, or the results break WithRejectDuplicates, Apply returns it and
+// the tree is left unchanged. When f changes nothing the epoch does not advance.
+// Trees built with WithSetSemantics are rebuilt over the results, sorted and deduplicated again.
+// f runs with the tree locked and must not call its methods.
+func (t *MerkleTree) Apply(f func(index uint64, element string) (string, error)) error {
+	return t.mutate(func(rec *journalRecord) error {
+		if rec == nil {
+			return t.apply(f)
+		}
+		rec.op = journalApply
+		return t.apply(func(index uint64, element string) (string, error) {
+			result, err := f(index, element)
+			if err == nil && result != element {
+				rec.indices = append(rec.indices, index)
+				rec.values = append(rec.values, element, result)
+			}
+			return result, err
+		})
+	})
+}
+
+func (t *MerkleTree) apply(f func(index uint64, element string) (string, error)) error {
+	if t.elements == nil {
+		return ErrElementsUnknown
+	}
+
+	var changed []uint64
+	var results []string
+	for i, element := range t.elements {
+		result, err := f(uint64(i), element)
+		if err != nil {
+			return err
+		}
+		if result != element {
+			if err := t.cfg.checkElement(result); err != nil {
+				return err
+			}
+			changed = append(changed, uint64(i))
+			results = append(results, result)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	if t.cfg.setSemantics {
+		return t.applySorted(changed, results)
+	}
+	if t.cfg.rejectDuplicates {
+		if err := t.checkApplied(changed, results); err != nil {
+			return err
+		}
+	}
+
+	for k, index := range changed {
+		t.reindex(index, t.elements[index], results[k])
+		t.elements[index] = results[k]
+		t.nodes[t.nodeIndex(0, index)] = t.elementDigest(index, results[k])
+		t.bloomAdd(index)
+		t.markDirty(index)
+	}
+	t.epoch++
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.LeafHashed(len(changed))
+	}
+	if !t.cfg.lazyRecompute {
+		t.recomputeDirty()
+	}
+
+	return nil
+}
+
+// Checks that writing results at the changed indices would leave no element at two indices.
+func (t *MerkleTree) checkApplied(changed []uint64, results []string) error {
+	final := make(map[string]uint64, len(t.elements))
+	result := 0
+	for i, element := range t.elements {
+		if result < len(changed) && changed[result] == uint64(i) {
+			element = results[result]
+			result++
+		}
+		if existing, ok := final[element]; ok {
+			return &DuplicateLeafError{Element: element, Existing: existing, Index: uint64(i)}
+		}
+		final[element] = uint64(i)
+	}
+	return nil
+}
+
+// Rebuilds a set tree over its elements with the changed ones replaced.
+func (t *MerkleTree) applySorted(changed []uint64, results []string) error {
+	elements := append([]string(nil), t.elements...)
+	for k, index := range changed {
+		elements[index] = results[k]
+	}
+	if err := t.build(elements); err != nil {
+		return err
+	}
+	t.epoch++
+	return nil
+}
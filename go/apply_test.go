@@ -0,0 +1,95 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	elements := []string{"Some", "test", "ELEMENTS", "Here", "too"}
+	mt, _ := NewMerkleTree(elements)
+
+	err := mt.Apply(func(index uint64, element string) (string, error) {
+		return strings.ToLower(element), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := NewMerkleTree([]string{"some", "test", "elements", "here", "too"})
+	if mt.GetRoot() != expected.GetRoot() {
+		t.Errorf("got %s, want %s", mt.GetRoot(), expected.GetRoot())
+	}
+	if index, _ := mt.IndexOf("elements"); index != 2 {
+		t.Errorf("got index %d, want 2", index)
+	}
+	if _, ok := mt.IndexOf("ELEMENTS"); ok {
+		t.Error("replaced element is still indexed")
+	}
+	if mt.Epoch() != 1 {
+		t.Errorf("got epoch %d, want 1", mt.Epoch())
+	}
+}
+
+func TestApplyUnchanged(t *testing.T) {
+	metrics := &CountingMetrics{}
+	mt, _ := NewMerkleTree(testElements(9), WithMetrics(metrics))
+	root := mt.GetRoot()
+	metrics.Reset()
+
+	visited := 0
+	err := mt.Apply(func(index uint64, element string) (string, error) {
+		visited++
+		return element, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if visited != 9 {
+		t.Errorf("got %d calls, want 9", visited)
+	}
+	if mt.GetRoot() != root || mt.Epoch() != 0 {
+		t.Error("unchanged elements changed the tree")
+	}
+	if metrics.LeafHashes.Load()+metrics.NodeHashes.Load() != 0 {
+		t.Errorf("got %d leaf and %d node hashes, want none", metrics.LeafHashes.Load(), metrics.NodeHashes.Load())
+	}
+}
+
+func TestApplyFailureLeavesTree(t *testing.T) {
+	failure := errors.New("cannot transform")
+
+	for _, c := range []struct {
+		name string
+		opts []Option
+		f    func(index uint64, element string) (string, error)
+		want error
+	}{
+		{"transform error", nil, func(index uint64, element string) (string, error) {
+			if index == 4 {
+				return "", failure
+			}
+			return "changed-" + element, nil
+		}, failure},
+		{"duplicate result", []Option{WithRejectDuplicates()}, func(index uint64, element string) (string, error) {
+			return fmt.Sprintf("bucket-%d", index%3), nil
+		}, ErrDuplicateLeaf},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(6), c.opts...)
+			root := mt.GetRoot()
+
+			if err := mt.Apply(c.f); !errors.Is(err, c.want) {
+				t.Errorf("got %v, want %v", err, c.want)
+			}
+			if mt.GetRoot() != root || mt.Epoch() != 0 {
+				t.Error("failed transform changed the tree")
+			}
+			if index, _ := mt.IndexOf("element-1"); index != 1 {
+				t.Errorf("got index %d, want 1", index)
+			}
+		})
+	}
+}
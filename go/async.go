@@ -0,0 +1,146 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+var ErrNotReady = errors.New("merkletree: build has not finished")
+
+// Hashes an asynchronous build performs between checks for cancellation and progress updates.
+const asyncBuildChunk = 1 << 12
+
+// A build running in the background, started by BuildAsync. Every method is safe to call
+// from any number of goroutines, before and after the build finishes.
+type BuildHandle struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	hashed atomic.Uint64
+	total  atomic.Uint64
+	tree   *MerkleTree // set, with err, before done is closed
+	err    error
+}
+
+// Starts building the tree NewMerkleTree would over the elements, on a goroutine of its
+// own, and returns straight away. The build stops at the next batch of hashes once ctx
+// is done or the handle is cancelled, failing with context.Cause(ctx); it fails as
+// NewMerkleTree does for invalid elements and options. The elements are copied before
+// BuildAsync returns, so the caller may reuse the slice straight away.
+// WithParallelism is ignored, the build being one goroutine.
+func BuildAsync(ctx context.Context, elements []string, opts ...Option) *BuildHandle {
+	elements = slices.Clone(elements)
+	ctx, cancel := context.WithCancel(ctx)
+	h := &BuildHandle{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		h.tree, h.err = h.build(ctx, elements, opts)
+	}()
+	return h
+}
+
+// Returns a channel closed once the build has finished, whether or not it succeeded.
+func (h *BuildHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Returns the built tree, or the error the build failed with. Result does not wait for
+// the build, failing with ErrNotReady until Done is closed, so it can be polled.
+func (h *BuildHandle) Result() (*MerkleTree, error) {
+	select {
+	case <-h.done:
+		return h.tree, h.err
+	default:
+		return nil, ErrNotReady
+	}
+}
+
+// Returns the number of hashes computed so far and the number the build needs in all,
+// one per stored leaf and node. Both are zero until the elements have been checked.
+func (h *BuildHandle) Progress() (done uint64, total uint64) {
+	return h.hashed.Load(), h.total.Load()
+}
+
+// Stops the build, making it fail with context.Canceled unless it finished first.
+// The goroutine building holds on to nothing once Done is closed, soon after.
+func (h *BuildHandle) Cancel() {
+	h.cancel()
+}
+
+func (h *BuildHandle) build(ctx context.Context, elements []string, opts []Option) (_ *MerkleTree, err error) {
+	var start time.Time
+	t := &MerkleTree{cfg: newConfig(opts)}
+	if t.cfg.tracer != nil {
+		_, span := t.cfg.tracer.Start(ctx, spanBuildAsync)
+		defer func() { t.endSpan(span, err) }()
+	}
+	if t.cfg.timed() {
+		start = time.Now()
+	}
+	if len(elements) == 0 {
+		return nil, ErrEmptyTree
+	}
+
+	height, leaves, err := t.prepare(elements)
+	if err != nil {
+		return nil, err
+	}
+	t.layoutLevels(height, leaves)
+	h.total.Store(uint64(len(t.nodes)))
+
+	if leaves != nil {
+		copy(t.level(0), leaves)
+		if t.cfg.metrics != nil {
+			t.cfg.metrics.LeafHashed(len(leaves))
+		}
+		h.hashed.Add(uint64(len(leaves)))
+	} else {
+		nodes := t.level(0)
+		cache := t.cfg.newLeafCache()
+		err := chunked(ctx, h, len(nodes), func(i int) {
+			nodes[i] = cache.digest(t.cfg, t.elements[i])
+		})
+		if err != nil {
+			return nil, err
+		}
+		cache.flush(t.cfg.metrics, len(nodes))
+	}
+
+	for level := 1; level <= height; level++ {
+		parents := t.level(level)
+		err := chunked(ctx, h, len(parents), func(i int) {
+			parents[i] = t.cfg.nodeDigest(t.node(level-1, uint64(2*i)), t.node(level-1, uint64(2*i+1)))
+		})
+		if err != nil {
+			return nil, err
+		}
+		if t.cfg.metrics != nil {
+			t.cfg.metrics.NodeHashed(len(parents))
+		}
+	}
+	t.buildBloom()
+
+	if t.cfg.timed() {
+		t.reportBuild(time.Since(start))
+	}
+	return t, nil
+}
+
+// Calls hash for every index below n, in batches, counting them as hashed after each
+// and failing with the cause of ctx once it is done.
+func chunked(ctx context.Context, h *BuildHandle, n int, hash func(i int)) error {
+	for start := 0; start < n; start += asyncBuildChunk {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		end := min(start+asyncBuildChunk, n)
+		for i := start; i < end; i++ {
+			hash(i)
+		}
+		h.hashed.Add(uint64(end - start))
+	}
+	return nil
+}
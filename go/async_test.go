@@ -0,0 +1,143 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Holds a build before it starts until release is closed.
+func blockUntil(release <-chan struct{}) Option {
+	return func(cfg *config) {
+		<-release
+	}
+}
+
+func waitDone(t *testing.T, h *BuildHandle) {
+	t.Helper()
+	select {
+	case <-h.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("build did not finish")
+	}
+}
+
+func TestBuildAsyncCompletes(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}},
+		{"set semantics", []Option{WithSetSemantics()}},
+		{"fixed depth", []Option{WithFixedDepth(20)}},
+	}
+
+	elements := testElements(3<<12 + 5)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			release := make(chan struct{})
+			h := BuildAsync(context.Background(), elements, append(c.opts, blockUntil(release))...)
+
+			// polled from several goroutines while it runs
+			var polling sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				polling.Add(1)
+				go func() {
+					defer polling.Done()
+					for {
+						done, total := h.Progress()
+						if done > total {
+							t.Errorf("got progress %d of %d", done, total)
+						}
+						if _, err := h.Result(); err != ErrNotReady {
+							return
+						}
+						runtime.Gosched()
+					}
+				}()
+			}
+			if _, err := h.Result(); !errors.Is(err, ErrNotReady) {
+				t.Errorf("got %v before the build started, want ErrNotReady", err)
+			}
+			close(release)
+			waitDone(t, h)
+			polling.Wait()
+
+			got, err := h.Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := NewMerkleTree(elements, c.opts...)
+			if got.GetRoot() != want.GetRoot() {
+				t.Errorf("got root %s, want %s", got.GetRoot(), want.GetRoot())
+			}
+			if done, total := h.Progress(); done != total || total == 0 {
+				t.Errorf("got progress %d of %d once done", done, total)
+			}
+			h.Cancel()
+			if again, err := h.Result(); again != got || err != nil {
+				t.Errorf("got %p, %v after a late Cancel, want the built tree", again, err)
+			}
+		})
+	}
+}
+
+func TestBuildAsyncCancel(t *testing.T) {
+	elements := testElements(1 << 20)
+	before := runtime.NumGoroutine()
+
+	release := make(chan struct{})
+	h := BuildAsync(context.Background(), elements, blockUntil(release))
+	h.Cancel()
+	close(release)
+	waitDone(t, h)
+	if tree, err := h.Result(); tree != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, %v, want context.Canceled", tree, err)
+	}
+	if done, total := h.Progress(); done >= total && total != 0 {
+		t.Errorf("got progress %d of %d, want the build stopped early", done, total)
+	}
+
+	cause := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	h = BuildAsync(ctx, elements)
+	cancel(cause)
+	waitDone(t, h)
+	if _, err := h.Result(); !errors.Is(err, cause) {
+		t.Errorf("got %v, want the cause", err)
+	}
+
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("got %d goroutines, want %d", after, before)
+	}
+}
+
+func TestBuildAsyncErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		elements []string
+		opts     []Option
+		want     error
+	}{
+		{"empty", nil, nil, ErrEmptyTree},
+		{"duplicate", []string{"a", "b", "a"}, []Option{WithRejectDuplicates()}, ErrDuplicateLeaf},
+		{"capacity", testElements(5), []Option{WithFixedDepth(2)}, ErrCapacityExceeded},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := BuildAsync(context.Background(), c.elements, c.opts...)
+			waitDone(t, h)
+			if tree, err := h.Result(); tree != nil || !errors.Is(err, c.want) {
+				t.Errorf("got %v, %v, want %v", tree, err, c.want)
+			}
+		})
+	}
+}
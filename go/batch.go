@@ -0,0 +1,114 @@
+package merkletree
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Generates proofs for several indices at once, walking the tree once per level for all
+// of them: siblings the proofs share are read and encoded once, and the siblings and
+// directions of every proof are carved out of a single allocation each.
+// Each proof is identical to the one GetProof returns for its index.
+func (t *MerkleTree) GetProofs(indices []uint64) (_ map[uint64]MerkleProof, err error) {
+	if err := t.rlockBuilt(); err != nil {
+		return nil, err
+	}
+	defer t.mu.RUnlock()
+	if t.cfg.tracer != nil {
+		_, span := t.cfg.tracer.Start(context.Background(), spanGetProofs, slog.Int(attrIndexCount, len(indices)))
+		defer func() { t.endSpan(span, err) }()
+	}
+
+	for _, index := range indices {
+		if index >= t.leafCount() {
+			return nil, t.outOfBounds(index)
+		}
+	}
+
+	unique := make([]uint64, 0, len(indices))
+	proofs := make(map[uint64]MerkleProof, len(indices))
+	for _, index := range indices {
+		if _, ok := proofs[index]; !ok {
+			proofs[index] = MerkleProof{}
+			unique = append(unique, index)
+		}
+	}
+
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+	for i, proof := range t.proofsFor(unique) {
+		proofs[unique[i]] = proof
+	}
+
+	return proofs, nil
+}
+
+// Generates a proof for every element, indexed by element position.
+func (t *MerkleTree) GetAllProofs() []MerkleProof {
+	if t.rlockBuilt() != nil {
+		return nil
+	}
+	defer t.mu.RUnlock()
+
+	indices := make([]uint64, t.leafCount())
+	for i := range indices {
+		indices[i] = uint64(i)
+	}
+	return t.proofsFor(indices)
+}
+
+// Builds the proofs for in-bounds indices, which must be ascending, in that order.
+// The tree is walked once per level for every proof at a time, so neighbouring proofs
+// read neighbouring nodes, and a sibling shared by consecutive proofs, as the upper
+// levels mostly are, is converted to its hex digest once and shared by all of them.
+func (t *MerkleTree) proofsFor(indices []uint64) []MerkleProof {
+	var start time.Time
+	if t.cfg.timed() {
+		start = time.Now()
+	}
+
+	height := t.height()
+	siblings := make([]string, len(indices)*height)
+	directions := make([]bool, len(indices)*height)
+
+	algorithm := t.cfg.algorithm()
+	proofs := make([]MerkleProof, len(indices))
+	for i, index := range indices {
+		proofs[i] = MerkleProof{
+			hElement:   t.node(0, index).String(),
+			siblings:   siblings[i*height : (i+1)*height : (i+1)*height],
+			directions: directions[i*height : (i+1)*height : (i+1)*height],
+			epoch:      t.epoch,
+			tag:        t.cfg.tag,
+			algorithm:  algorithm,
+			data:       slices.Clone(t.leafData(index)),
+		}
+	}
+
+	positions := slices.Clone(indices)
+	for level := 0; level < height; level++ {
+		var shared string
+		var previous uint64
+		for i, position := range positions {
+			if sibling := position ^ 1; i == 0 || sibling != previous {
+				shared, previous = t.node(level, sibling).String(), sibling
+			}
+			proofs[i].siblings[level] = shared
+			proofs[i].directions[level] = position%2 == 1
+			positions[i] = position / 2
+		}
+	}
+
+	if t.cfg.metrics != nil {
+		for range proofs {
+			t.cfg.metrics.ProofGenerated(height)
+		}
+	}
+	if t.cfg.timed() && len(indices) > 0 {
+		t.reportProofs(indices[0], len(indices), height, time.Since(start))
+	}
+
+	return proofs
+}
@@ -0,0 +1,86 @@
+package merkletree
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGetProofsMatchesGetProof(t *testing.T) {
+	for _, size := range []int{1, 2, 5, 16, 33} {
+		mt, err := NewMerkleTree(testElements(size))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		all := mt.GetAllProofs()
+		if len(all) != size {
+			t.Fatalf("got %d proofs, want %d", len(all), size)
+		}
+
+		indices := []uint64{uint64(size - 1), 0, uint64(size / 2), 0}
+		proofs, err := mt.GetProofs(indices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(proofs) != 3 && size > 2 {
+			t.Errorf("got %d proofs, want 3 distinct", len(proofs))
+		}
+
+		for i := range all {
+			want, _ := mt.GetProof(uint64(i))
+			if !reflect.DeepEqual(all[i], want) {
+				t.Errorf("size %d index %d: got %+v, want %+v", size, i, all[i], want)
+			}
+			if proof, ok := proofs[uint64(i)]; ok && !reflect.DeepEqual(proof, want) {
+				t.Errorf("size %d index %d: got %+v, want %+v", size, i, proof, want)
+			}
+		}
+	}
+}
+
+func TestGetProofsAreIndependent(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(8))
+	all := mt.GetAllProofs()
+
+	// growing one proof must not overwrite its neighbour in the shared allocation
+	all[0].siblings = append(all[0].siblings, "extra")
+	want, _ := mt.GetProof(1)
+	if !reflect.DeepEqual(all[1], want) {
+		t.Errorf("got %+v, want %+v", all[1], want)
+	}
+}
+
+func TestGetProofsOutOfBounds(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	if _, err := mt.GetProofs([]uint64{1, 5}); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+}
+
+func BenchmarkProofsLoop(b *testing.B) {
+	mt, _ := NewMerkleTree(testElements(1 << 19))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		proofs := make([]MerkleProof, mt.LeafCount())
+		for index := range proofs {
+			proof, err := mt.GetProof(uint64(index))
+			if err != nil {
+				b.Fatal(err)
+			}
+			proofs[index] = proof
+		}
+	}
+}
+
+func BenchmarkGetAllProofs(b *testing.B) {
+	mt, _ := NewMerkleTree(testElements(1 << 19))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		mt.GetAllProofs()
+	}
+}
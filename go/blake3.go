@@ -0,0 +1,227 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// Hashes with BLAKE3, 32 bytes of output in its default unkeyed mode, rather than
+// SHA-256: leaves, nodes and padding. The implementation is portable Go without SIMD,
+// so where the CPU accelerates SHA-256 it builds slower than the default; compare with
+// BenchmarkBuildHashFunctions before choosing it for speed.
+// It sets the hash function of leaves and nodes alike, see WithLeafHasher.
+func WithBLAKE3() Option {
+	return func(cfg *config) {
+		cfg.setHashFunction(HashBLAKE3)
+	}
+}
+
+const (
+	blake3BlockLen = 64
+	blake3ChunkLen = 1024
+
+	blake3ChunkStart = 1 << 0
+	blake3ChunkEnd   = 1 << 1
+	blake3Parent     = 1 << 2
+	blake3Root       = 1 << 3
+)
+
+var blake3IV = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+// Order the message words are taken in by each round after the first.
+var blake3Permutation = [16]int{2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8}
+
+func blake3G(s *[16]uint32, a, b, c, d int, x, y uint32) {
+	s[a] += s[b] + x
+	s[d] = bits.RotateLeft32(s[d]^s[a], -16)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -12)
+	s[a] += s[b] + y
+	s[d] = bits.RotateLeft32(s[d]^s[a], -8)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -7)
+}
+
+// Compresses one block into the chaining value, returning the full 16-word output whose
+// first eight words are the next chaining value.
+func blake3Compress(cv *[8]uint32, block *[16]uint32, counter uint64, blockLen uint32, flags uint32) [16]uint32 {
+	s := [16]uint32{
+		cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7],
+		blake3IV[0], blake3IV[1], blake3IV[2], blake3IV[3],
+		uint32(counter), uint32(counter >> 32), blockLen, flags,
+	}
+	m := *block
+	for round := 0; round < 7; round++ {
+		blake3G(&s, 0, 4, 8, 12, m[0], m[1])
+		blake3G(&s, 1, 5, 9, 13, m[2], m[3])
+		blake3G(&s, 2, 6, 10, 14, m[4], m[5])
+		blake3G(&s, 3, 7, 11, 15, m[6], m[7])
+		blake3G(&s, 0, 5, 10, 15, m[8], m[9])
+		blake3G(&s, 1, 6, 11, 12, m[10], m[11])
+		blake3G(&s, 2, 7, 8, 13, m[12], m[13])
+		blake3G(&s, 3, 4, 9, 14, m[14], m[15])
+		if round < 6 {
+			var permuted [16]uint32
+			for i, j := range blake3Permutation {
+				permuted[i] = m[j]
+			}
+			m = permuted
+		}
+	}
+	for i := 0; i < 8; i++ {
+		s[i] ^= s[i+8]
+		s[i+8] ^= cv[i]
+	}
+	return s
+}
+
+func blake3Words(block []byte) [16]uint32 {
+	var padded [blake3BlockLen]byte
+	copy(padded[:], block)
+	var words [16]uint32
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(padded[4*i:])
+	}
+	return words
+}
+
+// What the last compression of a chunk or parent node takes, kept back until it is known
+// whether that compression is the root.
+type blake3Output struct {
+	cv       [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+func (o *blake3Output) chainingValue() [8]uint32 {
+	out := blake3Compress(&o.cv, &o.block, o.counter, o.blockLen, o.flags)
+	return [8]uint32(out[:8])
+}
+
+func (o *blake3Output) root() Hash {
+	out := blake3Compress(&o.cv, &o.block, 0, o.blockLen, o.flags|blake3Root)
+	var digest Hash
+	for i := 0; i < digestSize/4; i++ {
+		binary.LittleEndian.PutUint32(digest[4*i:], out[i])
+	}
+	return digest
+}
+
+func blake3ParentOutput(left [8]uint32, right [8]uint32) blake3Output {
+	o := blake3Output{cv: blake3IV, blockLen: blake3BlockLen, flags: blake3Parent}
+	copy(o.block[:8], left[:])
+	copy(o.block[8:], right[:])
+	return o
+}
+
+// A hash.Hash computing BLAKE3 in its default mode, one chunk of 1024 bytes at a time,
+// merging the chaining values of completed chunks up a stack as the spec's reference
+// implementation does.
+type blake3 struct {
+	cv        [8]uint32            // chaining value of the current chunk
+	chunk     uint64               // index of the current chunk
+	buf       [blake3BlockLen]byte // input of the current chunk not yet compressed
+	n         int                  // bytes of buf filled
+	blocks    int                  // blocks of the current chunk compressed
+	stack     [54][8]uint32        // chaining values of completed subtrees, enough for 2^64 bytes
+	stackSize int
+}
+
+func newBLAKE3() hash.Hash {
+	h := &blake3{}
+	h.Reset()
+	return h
+}
+
+func (h *blake3) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		if h.chunkLen() == blake3ChunkLen {
+			output := h.chunkOutput()
+			h.pushChunk(output.chainingValue())
+			h.cv, h.chunk, h.blocks, h.n = blake3IV, h.chunk+1, 0, 0
+		}
+		// the last block of a chunk is kept back, to be flagged as its end
+		if h.n == blake3BlockLen {
+			block := blake3Words(h.buf[:])
+			out := blake3Compress(&h.cv, &block, h.chunk, blake3BlockLen, h.startFlag())
+			h.cv = [8]uint32(out[:8])
+			h.blocks++
+			h.n = 0
+		}
+		c := copy(h.buf[h.n:], p[:min(len(p), blake3ChunkLen-h.chunkLen())])
+		h.n += c
+		p = p[c:]
+	}
+	return written, nil
+}
+
+func (h *blake3) chunkLen() int {
+	return h.blocks*blake3BlockLen + h.n
+}
+
+func (h *blake3) startFlag() uint32 {
+	if h.blocks == 0 {
+		return blake3ChunkStart
+	}
+	return 0
+}
+
+func (h *blake3) chunkOutput() blake3Output {
+	return blake3Output{
+		cv:       h.cv,
+		block:    blake3Words(h.buf[:h.n]),
+		counter:  h.chunk,
+		blockLen: uint32(h.n),
+		flags:    h.startFlag() | blake3ChunkEnd,
+	}
+}
+
+// Pushes the chaining value of a completed chunk, first merging it with every completed
+// subtree of its size, as the number of chunks so far tells.
+func (h *blake3) pushChunk(cv [8]uint32) {
+	for total := h.chunk + 1; total&1 == 0; total >>= 1 {
+		h.stackSize--
+		parent := blake3ParentOutput(h.stack[h.stackSize], cv)
+		cv = parent.chainingValue()
+	}
+	h.stack[h.stackSize] = cv
+	h.stackSize++
+}
+
+// Appends the digest of the data written so far, which Sum leaves in place.
+func (h *blake3) Sum(b []byte) []byte {
+	output := h.chunkOutput()
+	for i := h.stackSize - 1; i >= 0; i-- {
+		output = blake3ParentOutput(h.stack[i], output.chainingValue())
+	}
+	digest := output.root()
+	return append(b, digest[:]...)
+}
+
+func (h *blake3) Reset() {
+	*h = blake3{cv: blake3IV}
+}
+
+func (h *blake3) Size() int {
+	return digestSize
+}
+
+func (h *blake3) BlockSize() int {
+	return blake3BlockLen
+}
+
+func blake3Sum(data []byte) Hash {
+	var h blake3
+	h.Reset()
+	h.Write(data)
+
+	var digest Hash
+	h.Sum(digest[:0])
+	return digest
+}
@@ -0,0 +1,164 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Returns the input of the reference implementation's test vectors: bytes counting up
+// modulo 251.
+func blake3Input(n int) []byte {
+	input := make([]byte, n)
+	for i := range input {
+		input[i] = byte(i % 251)
+	}
+	return input
+}
+
+func TestBLAKE3(t *testing.T) {
+	// from test_vectors.json of the reference implementation, first 32 bytes of each hash
+	cases := []struct {
+		length int
+		want   string
+	}{
+		{0, "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+		{1, "2d3adedff11b61f14c886e35afa036736dcd87a74d27b5c1510225d0f592e213"},
+		{1023, "10108970eeda3eb932baac1428c7a2163b0e924c9a9e25b35bba72b28f70bd11"},
+		{1024, "42214739f095a406f3fc83deb889744ac00df831c10daa55189b5d121c855af7"},
+		{1025, "d00278ae47eb27b34faecf67b4fe263f82d5412916c1ffd97c8cb7fb814b8444"},
+		{2048, "e776b6028c7cd22a4d0ba182a8bf62205d2ef576467e838ed6f2529b85fba24a"},
+		{102400, "bc3e3d41a1146b069abffad3c0d44860cf664390afce4d9661f7902e7943e085"},
+	}
+	for _, c := range cases {
+		if got := blake3Sum(blake3Input(c.length)); got.String() != c.want {
+			t.Errorf("got %s for %d bytes, want %s", got, c.length, c.want)
+		}
+	}
+	if got := blake3Sum([]byte("abc")).String(); got != "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85" {
+		t.Errorf("got %s for abc", got)
+	}
+}
+
+func TestBLAKE3Streaming(t *testing.T) {
+	input := blake3Input(5000)
+	want := blake3Sum(input)
+	for _, size := range []int{1, 63, 64, 65, 1024, 1500} {
+		h := newBLAKE3()
+		for data := input; len(data) > 0; {
+			n := min(size, len(data))
+			h.Write(data[:n])
+			data = data[n:]
+			// Sum leaves the state as it was
+			h.Sum(nil)
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Errorf("got %x writing %d bytes at a time, want %s", got, size, want)
+		}
+	}
+}
+
+func TestBLAKE3Tree(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5), WithBLAKE3())
+	left, right := blake3Sum([]byte("A")), blake3Sum([]byte("B"))
+	pair, _ := NewMerkleTree([]string{"A", "B"}, WithBLAKE3())
+	if want := blake3Sum([]byte(left.String() + right.String())); pair.GetRoot() != want.String() {
+		t.Errorf("got %s, want %s", pair.GetRoot(), want)
+	}
+
+	others := []struct {
+		name string
+		opts []Option
+	}{
+		{"SHA-256", nil},
+		{"Keccak-256", []Option{WithKeccak256()}},
+		{"BLAKE3 replaced by Keccak-256", []Option{WithBLAKE3(), WithKeccak256()}},
+	}
+	proof, _ := mt.GetProof(3)
+	if !VerifyProof(mt.GetRoot(), proof, WithBLAKE3()) {
+		t.Error("failed to verify")
+	}
+	for _, other := range others {
+		if VerifyProof(mt.GetRoot(), proof, other.opts...) {
+			t.Errorf("verified under %s", other.name)
+		}
+	}
+	if got := mt.Stats().HashAlgorithm; got != "blake3" {
+		t.Errorf("got hash algorithm %s, want blake3", got)
+	}
+}
+
+func TestBLAKE3Scheme(t *testing.T) {
+	schemes := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"tagged", []Option{WithApplicationTag("fast"), WithEmptyLeaf([]byte("empty"))}},
+		{"rfc6962", []Option{WithRFC6962Hashing()}},
+	}
+	for _, c := range schemes {
+		mt, err := NewMerkleTree(testElements(6), append(c.opts, WithBLAKE3())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mt.Scheme().BLAKE3 {
+			t.Fatalf("got %+v, want BLAKE3 in the scheme", mt.Scheme())
+		}
+
+		text, _ := mt.Commitment().MarshalText()
+		var commitment Commitment
+		if err := commitment.UnmarshalText(text); err != nil {
+			t.Fatal(err)
+		}
+		if commitment.String() != mt.Commitment().String() || !commitment.Scheme.BLAKE3 {
+			t.Errorf("got %+v, want %+v", commitment.Scheme, mt.Scheme())
+		}
+		proof, _ := mt.GetProof(5)
+		if verifier, _ := NewVerifier(WithScheme(commitment.Scheme)); !verifier.VerifyProof(mt.GetRoot(), proof) {
+			t.Errorf("proof rejected under the decoded %s scheme", c.name)
+		}
+		if err := VerifyCommitmentProof(commitment, proof); err != nil {
+			t.Errorf("got %v under the %s scheme, want nil", err, c.name)
+		}
+	}
+
+	// the modes' presets fix their hash function
+	if _, err := NewMerkleTree(testElements(2), WithMode(ModeBitcoin), WithBLAKE3()); !errors.Is(err, ErrModeConflict) {
+		t.Errorf("got %v, want ErrModeConflict", err)
+	}
+
+	// Keccak-256 and BLAKE3 at once, as no scheme of a tree has them
+	mt, _ := NewMerkleTree(testElements(2), WithKeccak256())
+	commitment := mt.Commitment()
+	commitment.Scheme.BLAKE3 = true
+	text, _ := commitment.MarshalText()
+	if err := new(Commitment).UnmarshalText(text); err == nil {
+		t.Errorf("decoded %s holding both Keccak-256 and BLAKE3", hex.EncodeToString(text))
+	}
+}
+
+// Compares building 2^20 leaves under each built-in hash function:
+//
+//	go test -run ^$ -bench BuildHashFunctions
+func BenchmarkBuildHashFunctions(b *testing.B) {
+	elements := testElements(1 << 20)
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"SHA-256", nil},
+		{"Keccak-256", []Option{WithKeccak256()}},
+		{"BLAKE3", []Option{WithBLAKE3()}},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := NewMerkleTree(elements, c.opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
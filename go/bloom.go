@@ -0,0 +1,241 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const maxBloomBitsPerLeaf = 64 // bits per leaf beyond which a filter stops paying for its size
+
+// Keeps a bloom filter of bitsPerLeaf bits per leaf over the leaf hash of every element,
+// so MightContain and Contains answer for most absent elements without the element map,
+// at a false-positive rate Stats reports: about 1% at 10 bits per leaf, 0.1% at 15.
+// The filter follows the leaves through every mutation. Bits cannot be cleared, so
+// elements replaced or deleted still pass it until the next rebuild: Reset or, as the
+// tree grows to twice the leaves it was sized for, a resize over its current leaves.
+// A value of zero or below keeps no filter; values above 64 are taken as 64.
+// ExportNodes carries the filter so ImportNodes restores it without rehashing a leaf.
+func WithBloomFilter(bitsPerLeaf float64) Option {
+	return func(cfg *config) {
+		cfg.bloomBitsPerLeaf = 0
+		if bitsPerLeaf > 0 {
+			cfg.bloomBitsPerLeaf = min(bitsPerLeaf, maxBloomBitsPerLeaf)
+		}
+	}
+}
+
+// Reports whether the element may be in the tree: false is certain, true may be a
+// false positive at the rate Stats reports. The element is hashed once as a leaf and
+// looked up in the filter of WithBloomFilter, whatever else the tree holds, so this
+// works on trees imported without their elements too. Without a filter every element
+// may be in the tree.
+func (t *MerkleTree) MightContain(element string) bool {
+	if t.rlockBuiltAsIs() != nil {
+		return false
+	}
+	defer t.mu.RUnlock()
+	return t.bloom == nil || t.bloom.has(t.cfg.leafDigest(element))
+}
+
+// Reports whether the tree holds the element at any index. The filter of
+// WithBloomFilter, when kept, turns most absent elements away first; the rest are
+// looked up in the element map, or on trees without elements, such as those of
+// ImportNodes and NewMerkleTreeFromReaders, by comparing every leaf hash.
+func (t *MerkleTree) Contains(element string) bool {
+	if t.rlockBuiltAsIs() != nil {
+		return false
+	}
+	defer t.mu.RUnlock()
+
+	leaf := t.cfg.leafDigest(element)
+	if t.bloom != nil && !t.bloom.has(leaf) {
+		return false
+	}
+	if t.elements != nil {
+		return len(t.indices[element]) > 0
+	}
+	return slices.Contains(t.level(0), leaf)
+}
+
+// A bloom filter over leaf hashes, of k bits per hash out of len(bits)*64.
+type bloomFilter struct {
+	k     int
+	bits  []uint64
+	sized uint64 // leaves the filter was sized for
+	added uint64 // hashes added, stale ones included
+}
+
+func newBloomFilter(leaves uint64, bitsPerLeaf float64) *bloomFilter {
+	words := max(uint64(math.Ceil(float64(leaves)*bitsPerLeaf/64)), 1)
+	return &bloomFilter{
+		k:     min(max(int(math.Round(bitsPerLeaf*math.Ln2)), 1), 30),
+		bits:  make([]uint64, words),
+		sized: leaves,
+	}
+}
+
+func (f *bloomFilter) add(leaf Hash) {
+	h1, h2 := bloomHashes(leaf)
+	m := uint64(len(f.bits)) * 64
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.added++
+}
+
+func (f *bloomFilter) has(leaf Hash) bool {
+	h1, h2 := bloomHashes(leaf)
+	m := uint64(len(f.bits)) * 64
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Estimates the chance that a hash never added passes the filter.
+func (f *bloomFilter) falsePositiveRate() float64 {
+	m := float64(len(f.bits)) * 64
+	return math.Pow(1-math.Exp(-float64(f.k)*float64(f.added)/m), float64(f.k))
+}
+
+// Returns the bits per leaf the filter was sized with, for resizing it alike.
+func (f *bloomFilter) bitsPerLeaf() float64 {
+	return min(float64(len(f.bits))*64/float64(max(f.sized, 1)), maxBloomBitsPerLeaf)
+}
+
+func (f *bloomFilter) clone() *bloomFilter {
+	if f == nil {
+		return nil
+	}
+	c := *f
+	c.bits = slices.Clone(f.bits)
+	return &c
+}
+
+// Derives the two hashes whose combinations h1 + i*h2 pick the k bits of a leaf.
+// Leaf hashes are mixed first as the chunks of WithMode(ModeSSZ) are not uniform.
+func bloomHashes(leaf Hash) (h1, h2 uint64) {
+	for i := 0; i < digestSize; i += 8 {
+		h1 = mix64(h1 ^ binary.BigEndian.Uint64(leaf[i:]))
+	}
+	return h1, mix64(h1^0x9e3779b97f4a7c15) | 1
+}
+
+// The finalizer of splitmix64, which spreads every input bit over the whole word.
+func mix64(x uint64) uint64 {
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// Encodes the filter for NodeLayout as
+//
+//	k (1 byte) | sized (uvarint) | added (uvarint) | bits (8 bytes per word, big-endian)
+func (f *bloomFilter) encode() []byte {
+	buf := make([]byte, 0, 1+2*binary.MaxVarintLen64+8*len(f.bits))
+	buf = append(buf, byte(f.k))
+	buf = binary.AppendUvarint(buf, f.sized)
+	buf = binary.AppendUvarint(buf, f.added)
+	for _, word := range f.bits {
+		buf = binary.BigEndian.AppendUint64(buf, word)
+	}
+	return buf
+}
+
+// Decodes a filter encoded by encode, failing with ErrMalformedNodes.
+func decodeBloomFilter(data []byte) (*bloomFilter, error) {
+	if len(data) == 0 || data[0] < 1 || data[0] > 30 {
+		return nil, fmt.Errorf("%w: bloom filter without a valid hash count", ErrMalformedNodes)
+	}
+	f := &bloomFilter{k: int(data[0])}
+	data = data[1:]
+	for _, field := range []*uint64{&f.sized, &f.added} {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: truncated bloom filter", ErrMalformedNodes)
+		}
+		*field, data = v, data[n:]
+	}
+	if f.sized == 0 || len(data) == 0 || len(data)%8 != 0 {
+		return nil, fmt.Errorf("%w: bloom filter of %d bytes for %d leaves", ErrMalformedNodes, len(data), f.sized)
+	}
+	f.bits = make([]uint64, len(data)/8)
+	for i := range f.bits {
+		f.bits[i] = binary.BigEndian.Uint64(data[8*i:])
+	}
+	return f, nil
+}
+
+// Fills a new filter over every leaf, as WithBloomFilter or a filter the tree was
+// imported with sizes it, or drops the filter when there is neither.
+func (t *MerkleTree) buildBloom() {
+	bitsPerLeaf := t.cfg.bloomBitsPerLeaf
+	if bitsPerLeaf <= 0 && t.bloom != nil {
+		bitsPerLeaf = t.bloom.bitsPerLeaf()
+	}
+	if bitsPerLeaf <= 0 {
+		t.bloom = nil
+		return
+	}
+	t.bloom = newBloomFilter(t.count, bitsPerLeaf)
+	for i := uint64(0); i < t.count; i++ {
+		t.bloom.add(t.bloomKey(i))
+	}
+}
+
+// Adds the leaf at index to the filter, resizing it once the tree outgrows it twice over.
+func (t *MerkleTree) bloomAdd(index uint64) {
+	switch {
+	case t.bloom == nil:
+	case t.count > 2*t.bloom.sized:
+		t.buildBloom()
+	default:
+		t.bloom.add(t.bloomKey(index))
+	}
+}
+
+// Returns the hash the filter holds for the leaf at index: its element hashed alone,
+// so a leaf committed with data is found by its element, or the leaf hash itself.
+func (t *MerkleTree) bloomKey(index uint64) Hash {
+	if len(t.leafData(index)) > 0 {
+		return t.cfg.leafDigest(t.elements[index])
+	}
+	return t.node(0, index)
+}
+
+// Returns the estimated false-positive rate of the filter, zero without one.
+func (t *MerkleTree) bloomFalsePositiveRate() float64 {
+	if t.bloom == nil {
+		return 0
+	}
+	return t.bloom.falsePositiveRate()
+}
+
+// Returns the filter encoded for NodeLayout, nil without one.
+func (t *MerkleTree) bloomBytes() []byte {
+	if t.bloom == nil {
+		return nil
+	}
+	return t.bloom.encode()
+}
+
+// Restores the filter a NodeLayout carries, or fills one under WithBloomFilter when it
+// carries none.
+func (t *MerkleTree) importBloom(data []byte) error {
+	if data == nil {
+		t.buildBloom()
+		return nil
+	}
+	f, err := decodeBloomFilter(data)
+	if err != nil {
+		return err
+	}
+	t.bloom = f
+	return nil
+}
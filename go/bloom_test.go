@@ -0,0 +1,128 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestBloomFilterNoFalseNegatives(t *testing.T) {
+	rng := rand.New(rand.NewSource(187))
+	elements := make([]string, 5000)
+	for i := range elements {
+		elements[i] = fmt.Sprintf("%x", rng.Uint64())
+	}
+	mt, err := NewMerkleTree(elements, WithBloomFilter(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, element := range elements {
+		if !mt.MightContain(element) || !mt.Contains(element) {
+			t.Fatalf("got %q missing from the filter", element)
+		}
+	}
+
+	rate := mt.Stats().BloomFalsePositiveRate
+	if rate <= 0 || rate > 0.02 {
+		t.Fatalf("got estimated false-positive rate %v, want about 0.01", rate)
+	}
+	passed := 0
+	for i := 0; i < 20000; i++ {
+		absent := fmt.Sprintf("absent-%d", i)
+		if mt.MightContain(absent) {
+			passed++
+		}
+		if mt.Contains(absent) {
+			t.Fatalf("got %q contained", absent)
+		}
+	}
+	if got := float64(passed) / 20000; got > 3*rate {
+		t.Errorf("got false-positive rate %v, estimated %v", got, rate)
+	}
+}
+
+func TestBloomFilterFollowsMutations(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	mt, _ := NewMerkleTree(testElements(4), WithBloomFilter(12))
+	var added []string
+	for i := 0; i < 200; i++ {
+		element := fmt.Sprintf("added-%d", rng.Int())
+		switch i % 4 {
+		case 0:
+			mt.Append(element)
+		case 1:
+			mt.InsertAt(uint64(rng.Intn(int(mt.LeafCount()))), element)
+		case 2:
+			mt.UpdateElement(uint64(rng.Intn(int(mt.LeafCount()))), element)
+		default:
+			index := uint64(rng.Intn(int(mt.LeafCount())))
+			mt.Apply(func(i uint64, e string) (string, error) {
+				if i == index {
+					return element, nil
+				}
+				return e, nil
+			})
+		}
+		added = append(added, element)
+	}
+	mt.SetLeafData(3, []byte("data"))
+	mt.mu.RLock()
+	current := append([]string(nil), mt.elements...)
+	mt.mu.RUnlock()
+	for _, element := range current {
+		if !mt.MightContain(element) {
+			t.Fatalf("got %q missing from the filter after mutations", element)
+		}
+	}
+
+	// the filter outgrew its size and was rebuilt over the current leaves
+	if sized := mt.bloom.sized; sized == 4 {
+		t.Errorf("got a filter sized for %d leaves, want it resized", sized)
+	}
+	clone, _ := mt.clone()
+	mt.Append("after clone")
+	if clone.bloom.added == mt.bloom.added {
+		t.Error("got the filter shared with a clone")
+	}
+}
+
+func TestBloomFilterExported(t *testing.T) {
+	elements := testElements(300)
+	mt, _ := NewMerkleTree(elements, WithBloomFilter(16))
+	layout, data := mt.ExportNodes()
+	if layout.Bloom == nil {
+		t.Fatal("got no filter in the layout")
+	}
+
+	imported, err := ImportNodes(layout, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := imported.Stats().BloomFalsePositiveRate, mt.Stats().BloomFalsePositiveRate; got != want {
+		t.Errorf("got false-positive rate %v, want %v", got, want)
+	}
+	for _, element := range elements {
+		if !imported.MightContain(element) || !imported.Contains(element) {
+			t.Fatalf("got %q missing from the imported tree", element)
+		}
+	}
+	if imported.Contains("absent") {
+		t.Error("got an absent element contained by the imported tree")
+	}
+
+	layout.Bloom = nil
+	rebuilt, _ := ImportNodes(layout, data, WithBloomFilter(16))
+	if rebuilt.bloom == nil || !rebuilt.MightContain("element-7") {
+		t.Error("got no filter filled under WithBloomFilter")
+	}
+	plain, _ := ImportNodes(layout, data)
+	if plain.Stats().BloomFalsePositiveRate != 0 || !plain.MightContain("absent") {
+		t.Error("got a filter without WithBloomFilter")
+	}
+
+	layout.Bloom = []byte{0}
+	if _, err := ImportNodes(layout, data); !errors.Is(err, ErrMalformedNodes) {
+		t.Errorf("got %v, want %v", err, ErrMalformedNodes)
+	}
+}
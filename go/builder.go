@@ -0,0 +1,117 @@
+package merkletree
+
+// Folds elements into a root one at a time, holding only one pending subtree hash per level,
+// so memory grows with the logarithm of the element count rather than the count itself.
+type IncrementalBuilder struct {
+	cfg      config
+	count    uint64
+	frontier []Hash            // completed left subtree awaiting its sibling per level, held where count has a set bit
+	seen     map[string]uint64 // index of each element, only kept to enforce WithRejectDuplicates
+	cache    *leafCache        // memoized leaf hashes, nil without WithLeafHashCache
+}
+
+func NewIncrementalBuilder(opts ...Option) *IncrementalBuilder {
+	b := &IncrementalBuilder{cfg: newConfig(opts)}
+	b.cache = b.cfg.newLeafCache()
+	if b.cfg.rejectDuplicates {
+		b.seen = make(map[string]uint64)
+	}
+	return b
+}
+
+// Appends the next element.
+// Builders created with WithSetSemantics fail with ErrSetOrder, as elements arrive unsorted.
+func (b *IncrementalBuilder) Add(element string) error {
+	if b.cfg.setSemantics {
+		return ErrSetOrder
+	}
+	if err := b.cfg.checkElement(element); err != nil {
+		return err
+	}
+	if b.seen != nil {
+		if existing, ok := b.seen[element]; ok {
+			return &DuplicateLeafError{Element: element, Existing: existing, Index: b.count}
+		}
+		b.seen[element] = b.count
+	}
+
+	carry := b.cache.digest(b.cfg, element)
+	hashed := 0
+	level := 0
+
+	for ; b.count>>level&1 == 1; level++ {
+		carry = b.cfg.nodeDigest(b.frontier[level], carry)
+		hashed++
+	}
+	if level == len(b.frontier) {
+		b.frontier = append(b.frontier, Hash{})
+	}
+	b.frontier[level] = carry
+	b.count++
+
+	b.cache.flush(b.cfg.metrics, 1)
+	if b.cfg.metrics != nil {
+		b.cfg.metrics.NodeHashed(hashed)
+	}
+
+	return nil
+}
+
+// Returns the number of elements added so far.
+func (b *IncrementalBuilder) Count() uint64 {
+	return b.count
+}
+
+// Returns the root of a tree over the elements added so far, padding the
+// pending subtrees up to the next power of two as NewMerkleTree does.
+// Fails with ErrModeConflict when the options conflict with WithMode.
+func (b *IncrementalBuilder) Root() (string, error) {
+	height, err := b.cfg.checkedHeight(b.count)
+	if err != nil {
+		return "", err
+	}
+	if b.count == uint64(1)<<height {
+		return b.frontier[height].String(), nil
+	}
+
+	padding := b.cfg.zeroHashes(height)
+	var carry Hash
+	carrying := false
+	hashed := 0
+
+	for level := 0; level < height; level++ {
+		pending := level < len(b.frontier) && b.count>>level&1 == 1
+		switch {
+		case pending && !carrying:
+			carry = b.cfg.nodeDigest(b.frontier[level], b.cfg.edgeSibling(b.frontier[level], padding[level]))
+			carrying = true
+		case pending:
+			carry = b.cfg.nodeDigest(b.frontier[level], carry)
+		case carrying:
+			carry = b.cfg.nodeDigest(carry, b.cfg.edgeSibling(carry, padding[level]))
+		}
+		if pending || carrying {
+			hashed++
+		}
+	}
+
+	if b.cfg.metrics != nil {
+		b.cfg.metrics.LeafHashed(1)
+		b.cfg.metrics.NodeHashed(hashed + height)
+	}
+
+	return carry.String(), nil
+}
+
+// Computes the root NewMerkleTree would produce for the elements, without building the tree.
+// Memory is logarithmic in the number of elements, unless WithRejectDuplicates is set,
+// which requires remembering every element.
+func ComputeRoot(elements []string, opts ...Option) (string, error) {
+	b := NewIncrementalBuilder(opts...)
+	for _, element := range elements {
+		if err := b.Add(element); err != nil {
+			return "", err
+		}
+	}
+	return b.Root()
+}
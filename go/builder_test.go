@@ -0,0 +1,59 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestComputeRootMatchesTree(t *testing.T) {
+	optionSets := map[string][]Option{
+		"default":           nil,
+		"reject duplicates": {WithRejectDuplicates()},
+		"metrics":           {WithMetrics(&CountingMetrics{})},
+		"empty leaf":        {WithEmptyLeaf([]byte("nothing"))},
+		"fixed depth":       {WithFixedDepth(10), WithEmptyLeaf([]byte("nothing"))},
+	}
+
+	for name, opts := range optionSets {
+		t.Run(name, func(t *testing.T) {
+			for size := 1; size <= 130; size++ {
+				elements := testElements(size)
+				mt, err := NewMerkleTree(elements, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				root, err := ComputeRoot(elements, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if root != mt.GetRoot() {
+					t.Fatalf("size %d: got %s, want %s", size, root, mt.GetRoot())
+				}
+			}
+		})
+	}
+}
+
+func TestComputeRootErrors(t *testing.T) {
+	if _, err := ComputeRoot(nil); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+	if _, err := ComputeRoot([]string{"a", "b", "a"}, WithRejectDuplicates()); !errors.Is(err, ErrDuplicateLeaf) {
+		t.Errorf("got %v, want %v", err, ErrDuplicateLeaf)
+	}
+}
+
+func TestIncrementalBuilderMemory(t *testing.T) {
+	b := NewIncrementalBuilder()
+	for i := 0; i < 1<<12+3; i++ {
+		if err := b.Add("element"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(b.frontier); got != 13 {
+		t.Errorf("got %d pending levels, want 13", got)
+	}
+	if b.Count() != 1<<12+3 {
+		t.Errorf("got %d, want %d", b.Count(), 1<<12+3)
+	}
+}
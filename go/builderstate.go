@@ -0,0 +1,122 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+var (
+	ErrMalformedBuilderState = errors.New("merkletree: malformed builder state")
+	ErrBuilderStateMismatch  = errors.New("merkletree: builder state was saved under other options")
+)
+
+const (
+	builderStateVersion  = 0x01 // leading byte of a saved builder state, with builderStateSeenFlag
+	builderStateSeenFlag = 0x80 // set when the elements seen under WithRejectDuplicates follow the frontier
+)
+
+// Captures what the builder needs to carry on after a restart: the element count and the
+// pending subtree hash of each level, at most 64 hashes whatever the count.
+// Builders under WithRejectDuplicates also save every element they have seen, as resumed
+// builders must go on rejecting them. The state is
+//
+//	version (1 byte) | count (uvarint) | fixed depth (uvarint) | scheme (see appendScheme)
+//	| probe digest (32 bytes) | pending hash per set bit of count, lowest level first (32 bytes each)
+//	| [seen elements (uvarint) | (element length (uvarint) | element | index (uvarint)) per element]
+//
+// where the probe digest hashes a fixed node under the options, recording an HMAC key
+// without revealing it. Pass the state to ResumeIncrementalBuilder.
+func (b *IncrementalBuilder) SaveState() ([]byte, error) {
+	version := byte(builderStateVersion)
+	if b.seen != nil {
+		version |= builderStateSeenFlag
+	}
+	out := []byte{version}
+	out = binary.AppendUvarint(out, b.count)
+	out = binary.AppendUvarint(out, uint64(max(b.cfg.fixedDepth, 0)))
+	out = appendScheme(out, b.cfg.scheme())
+	probe := b.cfg.builderProbe()
+	out = append(out, probe[:]...)
+	for level := range b.frontier {
+		if b.count>>level&1 == 1 {
+			out = append(out, b.frontier[level][:]...)
+		}
+	}
+	if b.seen != nil {
+		out = binary.AppendUvarint(out, uint64(len(b.seen)))
+		for element, index := range b.seen {
+			out = binary.AppendUvarint(out, uint64(len(element)))
+			out = append(out, element...)
+			out = binary.AppendUvarint(out, index)
+		}
+	}
+	return out, nil
+}
+
+// Creates a builder carrying on from a state SaveState returned, so that adding the
+// remaining elements yields the root an uninterrupted build would. The options must
+// hash as the saved builder's did, HMAC key included, and fix the same depth; otherwise
+// resuming fails with ErrBuilderStateMismatch, and for a state that does not decode,
+// with ErrMalformedBuilderState. Metrics and caching options need not match.
+func ResumeIncrementalBuilder(state []byte, opts ...Option) (*IncrementalBuilder, error) {
+	if len(state) == 0 || state[0]&^builderStateSeenFlag != builderStateVersion {
+		return nil, fmt.Errorf("%w: unsupported version", ErrMalformedBuilderState)
+	}
+	b := NewIncrementalBuilder(opts...)
+
+	r := byteReader{data: state[1:]}
+	count := r.uvarint(^uint64(0))
+	depth := r.uvarint(63)
+	scheme := r.scheme()
+	probe := r.digest()
+	frontier := make([]Hash, bits.Len64(count))
+	for level := range frontier {
+		if count>>level&1 == 1 {
+			frontier[level] = r.digest()
+		}
+	}
+	var seen map[string]uint64
+	if state[0]&builderStateSeenFlag != 0 {
+		n := r.uvarint(min(count, uint64(len(r.data))))
+		seen = make(map[string]uint64, n)
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			element := string(r.bytes(int(r.uvarint(uint64(len(r.data))))))
+			if index := r.uvarint(count - 1); r.err == nil {
+				seen[element] = index
+			}
+		}
+	}
+	if r.err == nil && len(r.data) > 0 {
+		r.fail(fmt.Sprintf("%d bytes after the state", len(r.data)))
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedBuilderState, r.err)
+	}
+
+	switch {
+	case !bytes.Equal(appendScheme(nil, scheme), appendScheme(nil, b.cfg.scheme())):
+		return nil, fmt.Errorf("%w: saved scheme %+v, options scheme %+v", ErrBuilderStateMismatch, scheme, b.cfg.scheme())
+	case probe != b.cfg.builderProbe():
+		return nil, fmt.Errorf("%w: options hash differently, as under another HMAC key", ErrBuilderStateMismatch)
+	case depth != uint64(max(b.cfg.fixedDepth, 0)):
+		return nil, fmt.Errorf("%w: saved fixed depth %d, options depth %d", ErrBuilderStateMismatch, depth, b.cfg.fixedDepth)
+	case (seen != nil) != (b.seen != nil):
+		return nil, fmt.Errorf("%w: state and options disagree on WithRejectDuplicates", ErrBuilderStateMismatch)
+	}
+
+	b.count, b.frontier = count, frontier
+	if seen != nil {
+		b.seen = seen
+	}
+	return b, nil
+}
+
+// Returns the digest of a node over two empty leaves, which tells apart options hashing
+// differently, even by the HMAC key alone.
+func (cfg config) builderProbe() Hash {
+	leaf := cfg.leafDigest("")
+	return cfg.nodeDigest(leaf, leaf)
+}
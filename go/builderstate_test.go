@@ -0,0 +1,107 @@
+package merkletree
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestResumedBuilderMatchesUninterrupted(t *testing.T) {
+	options := map[string][]Option{
+		"default":     nil,
+		"tagged":      {WithApplicationTag("ingest")},
+		"keccak":      {WithKeccak256()},
+		"fixed depth": {WithFixedDepth(12)},
+		"hmac":        {WithHMACKey([]byte("secret"))},
+		"bitcoin":     {WithMode(ModeBitcoin)},
+		"duplicates":  {WithRejectDuplicates()},
+	}
+	elements := testElements(700)
+	rng := rand.New(rand.NewSource(190))
+	for name, opts := range options {
+		t.Run(name, func(t *testing.T) {
+			for run := 0; run < 5; run++ {
+				n := 1 + rng.Intn(len(elements))
+				want, err := ComputeRoot(elements[:n], opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				b := NewIncrementalBuilder(opts...)
+				var snapshots []int
+				for i, element := range elements[:n] {
+					if rng.Intn(50) == 0 {
+						state, err := b.SaveState()
+						if err != nil {
+							t.Fatal(err)
+						}
+						if b, err = ResumeIncrementalBuilder(state, opts...); err != nil {
+							t.Fatalf("resuming after %d elements: %v", i, err)
+						}
+						snapshots = append(snapshots, i)
+					}
+					if err := b.Add(element); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if got, _ := b.Root(); got != want || b.Count() != uint64(n) {
+					t.Errorf("%d elements resumed after %v: got root %s, want %s", n, snapshots, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResumedBuilderRejectsSeenElements(t *testing.T) {
+	b := NewIncrementalBuilder(WithRejectDuplicates())
+	for _, element := range testElements(5) {
+		b.Add(element)
+	}
+	state, _ := b.SaveState()
+	resumed, err := ResumeIncrementalBuilder(state, WithRejectDuplicates())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dup *DuplicateLeafError
+	if err := resumed.Add("element-2"); !errors.As(err, &dup) || dup.Existing != 2 || dup.Index != 5 {
+		t.Errorf("got %v, want element-2 rejected as at index 2", err)
+	}
+}
+
+func TestResumeBuilderErrors(t *testing.T) {
+	b := NewIncrementalBuilder(WithApplicationTag("ingest"), WithHMACKey([]byte("secret")))
+	for _, element := range testElements(11) {
+		b.Add(element)
+	}
+	state, _ := b.SaveState()
+
+	mismatched := map[string][]Option{
+		"no options":  nil,
+		"other tag":   {WithApplicationTag("other"), WithHMACKey([]byte("secret"))},
+		"other key":   {WithApplicationTag("ingest"), WithHMACKey([]byte("guessed"))},
+		"fixed depth": {WithApplicationTag("ingest"), WithHMACKey([]byte("secret")), WithFixedDepth(8)},
+		"duplicates":  {WithApplicationTag("ingest"), WithHMACKey([]byte("secret")), WithRejectDuplicates()},
+	}
+	for name, opts := range mismatched {
+		if _, err := ResumeIncrementalBuilder(state, opts...); !errors.Is(err, ErrBuilderStateMismatch) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrBuilderStateMismatch)
+		}
+	}
+	if _, err := ResumeIncrementalBuilder(state, WithApplicationTag("ingest"), WithHMACKey([]byte("secret")), WithMetrics(&CountingMetrics{})); err != nil {
+		t.Errorf("got %v with metrics added, want the state resumed", err)
+	}
+
+	for i := range state {
+		if _, err := ResumeIncrementalBuilder(state[:i], WithApplicationTag("ingest"), WithHMACKey([]byte("secret"))); !errors.Is(err, ErrMalformedBuilderState) {
+			t.Fatalf("truncated to %d bytes: got %v, want %v", i, err, ErrMalformedBuilderState)
+		}
+	}
+	for name, corrupt := range map[string][]byte{
+		"version":  append([]byte{0x02}, state[1:]...),
+		"trailing": append(append([]byte(nil), state...), 0),
+	} {
+		if _, err := ResumeIncrementalBuilder(corrupt, WithApplicationTag("ingest"), WithHMACKey([]byte("secret"))); !errors.Is(err, ErrMalformedBuilderState) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrMalformedBuilderState)
+		}
+	}
+}
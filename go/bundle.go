@@ -0,0 +1,21 @@
+package merkletree
+
+// A proof to verify as part of a bundle, against the root of the tree it came from
+// and under that tree's hashing scheme.
+type BundleItem struct {
+	Root   string      `json:"root"`
+	Proof  MerkleProof `json:"proof"`
+	Scheme Scheme      `json:"scheme"`
+}
+
+// Verifies every item of a bundle, which may come from different trees hashed under
+// different schemes, returning for each the error VerifyProofWithReason would, or nil.
+// The options apply to every item, with each item's scheme taking precedence.
+func VerifyBundle(items []BundleItem, opts ...Option) []error {
+	errs := make([]error, len(items))
+	for i, item := range items {
+		itemOpts := append(opts[:len(opts):len(opts)], WithScheme(item.Scheme))
+		errs[i] = VerifyProofWithReason(item.Root, item.Proof, itemOpts...)
+	}
+	return errs
+}
@@ -0,0 +1,72 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestVerifyBundle(t *testing.T) {
+	plain, _ := NewMerkleTree(testElements(5))
+	ledger, _ := NewMerkleTree(testElements(7), WithApplicationTag("ledger"))
+	padded, _ := NewMerkleTree(testElements(3), WithEmptyLeaf([]byte{0}))
+
+	var items []BundleItem
+	for _, mt := range []*MerkleTree{plain, ledger, padded} {
+		proof, _ := mt.GetProof(2)
+		items = append(items, BundleItem{Root: mt.GetRoot(), Proof: proof, Scheme: mt.Scheme()})
+	}
+	stale, _ := plain.GetProof(1)
+	items = append(items,
+		BundleItem{Root: ledger.GetRoot(), Proof: items[1].Proof},
+		BundleItem{Root: ledger.GetRoot(), Proof: stale, Scheme: ledger.Scheme()},
+		BundleItem{Root: "not a root", Proof: stale},
+	)
+
+	// the bundle survives the trip between producer and verifier
+	encoded, err := json.Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []BundleItem
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	var digestErr *InvalidDigestError
+	wants := []func(error) bool{
+		func(err error) bool { return err == nil },
+		func(err error) bool { return err == nil },
+		func(err error) bool { return err == nil },
+		func(err error) bool { return errors.Is(err, ErrDomainMismatch) },
+		func(err error) bool { return errors.Is(err, ErrDomainMismatch) },
+		func(err error) bool { return errors.As(err, &digestErr) },
+	}
+	errs := VerifyBundle(decoded)
+	if len(errs) != len(wants) {
+		t.Fatalf("got %d errors, want %d", len(errs), len(wants))
+	}
+	for i, want := range wants {
+		testname := fmt.Sprintf("item %d", i)
+		t.Run(testname, func(t *testing.T) {
+			if !want(errs[i]) {
+				t.Errorf("got %v", errs[i])
+			}
+		})
+	}
+}
+
+func TestSchemeRoundTrip(t *testing.T) {
+	opts := []Option{WithApplicationTag("ledger"), WithEmptyLeaf([]byte("empty"))}
+	mt, _ := NewMerkleTree(testElements(5), opts...)
+	other, _ := NewMerkleTree(testElements(5), WithScheme(mt.Scheme()))
+	if other.GetRoot() != mt.GetRoot() {
+		t.Errorf("got %s, want %s", other.GetRoot(), mt.GetRoot())
+	}
+
+	encoded, _ := json.Marshal(Scheme{})
+	if string(encoded) != "{}" {
+		t.Errorf("got %s, want {}", encoded)
+	}
+}
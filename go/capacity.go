@@ -0,0 +1,76 @@
+package merkletree
+
+// Grows the tree to hold targetLeaves elements, so that appends up to that count fill
+// reserved slots along one path each, never moving the levels or growing the height.
+// The elements and LeafCount stay as they are, but once the height grows the root
+// does too, as every element sits one padding subtree deeper per added level, and
+// the epoch advances, so proofs generated before then fail VerifyProofEpoch with
+// ErrStaleProof. A target the tree already has room for changes nothing.
+// Under WithFixedDepth only storage is reserved, and targets beyond the fixed depth
+// fail with ErrCapacityExceeded. Reset and Rehash build at the minimum height again.
+func (t *MerkleTree) ExtendCapacity(targetLeaves uint64) error {
+	return t.mutate(func(rec *journalRecord) error {
+		if rec != nil {
+			*rec = journalRecord{op: journalExtend, indices: []uint64{targetLeaves}}
+		}
+		return t.extendCapacity(targetLeaves)
+	})
+}
+
+func (t *MerkleTree) extendCapacity(target uint64) error {
+	if target <= t.reserved() {
+		return nil
+	}
+	height, err := t.cfg.checkedHeight(target)
+	if err != nil {
+		return err
+	}
+
+	if height <= t.height() {
+		t.relayout(t.height(), target)
+		return nil
+	}
+	t.grow(height, target)
+	t.epoch++
+	return nil
+}
+
+// Moves the stored levels into a layout of a greater height with room for reserve
+// leaves, then hashes the levels added above the old root.
+func (t *MerkleTree) grow(height int, reserve uint64) {
+	from := t.height()
+	t.relayout(height, reserve)
+	for level := from + 1; level <= height; level++ {
+		t.nodes[t.nodeIndex(level, 0)] = t.cfg.nodeDigest(t.node(level-1, 0), t.node(level-1, 1))
+	}
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.NodeHashed(height - from)
+	}
+}
+
+// Shrinks the tree back to the minimum height covering its elements, as after
+// ExtendCapacity, dropping the upper levels and any storage reserved beyond that
+// height. The root, Height and proofs are then those of a fresh build over the
+// elements, and the epoch advances. Reports whether the height changed; a tree
+// already at its minimum height, or fixed by WithFixedDepth, is left as it is.
+func (t *MerkleTree) Compact() (bool, error) {
+	var changed bool
+	err := t.mutate(func(rec *journalRecord) error {
+		if rec != nil {
+			*rec = journalRecord{op: journalCompact}
+		}
+		changed = t.compact()
+		return nil
+	})
+	return changed, err
+}
+
+func (t *MerkleTree) compact() bool {
+	height := t.cfg.height(t.count)
+	if height >= t.height() {
+		return false
+	}
+	t.relayout(height, min(t.reserved(), uint64(1)<<height))
+	t.epoch++
+	return true
+}
@@ -0,0 +1,152 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExtendCapacity(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	proof, _ := mt.GetProof(2)
+	epoch := mt.Epoch()
+
+	if err := mt.ExtendCapacity(100); err != nil {
+		t.Fatal(err)
+	}
+	if mt.LeafCount() != 5 || mt.Height() != 7 || mt.PaddedLeafCount() != 128 {
+		t.Errorf("got %d elements at height %d, want 5 at height 7", mt.LeafCount(), mt.Height())
+	}
+	deep, _ := NewMerkleTree(testElements(5), WithFixedDepth(7))
+	if mt.GetRoot() != deep.GetRoot() {
+		t.Errorf("got root %s, want %s", mt.GetRoot(), deep.GetRoot())
+	}
+	if mt.Epoch() != epoch+1 {
+		t.Errorf("got epoch %d, want %d", mt.Epoch(), epoch+1)
+	}
+	if err := VerifyProofEpoch(mt.GetRoot(), mt.Epoch(), proof); !errors.Is(err, ErrStaleProof) {
+		t.Errorf("proof from before the extension: got %v, want %v", err, ErrStaleProof)
+	}
+
+	// appends up to the target fill reserved slots in place
+	storage := &mt.nodes[0]
+	for i := 5; i < 100; i++ {
+		if err := mt.Append(testElements(100)[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if &mt.nodes[0] != storage {
+		t.Error("appends within the capacity moved the node storage")
+	}
+	want, _ := NewMerkleTree(testElements(100), WithFixedDepth(7))
+	if mt.Height() != 7 || mt.GetRoot() != want.GetRoot() {
+		t.Errorf("got root %s at height %d, want %s at 7", mt.GetRoot(), mt.Height(), want.GetRoot())
+	}
+	last, _ := mt.GetLastLeafProof()
+	if !VerifyLastLeafProof(mt.GetRoot(), 100, last) {
+		t.Error("last leaf proof of an extended tree failed")
+	}
+
+	before := mt.Epoch()
+	if err := mt.ExtendCapacity(50); err != nil || mt.Epoch() != before {
+		t.Errorf("got %v at epoch %d for a smaller target, want no change at %d", err, mt.Epoch(), before)
+	}
+
+	mt.Reset(testElements(5))
+	if mt.Height() != 3 {
+		t.Errorf("got height %d after reset, want 3", mt.Height())
+	}
+}
+
+func TestExtendCapacityRootsMatch(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}},
+		{"set semantics", []Option{WithSetSemantics()}},
+		{"lazy", []Option{WithLazyRecompute()}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(3), c.opts...)
+			mt.UpdateElement(1, "updated") // fails under set semantics, leaving element-1
+			if err := mt.ExtendCapacity(20); err != nil {
+				t.Fatal(err)
+			}
+			mt.Append("appended")
+
+			want, _ := NewMerkleTree(mt.OriginalElements(), append(c.opts, WithFixedDepth(5))...)
+			if mt.Height() != 5 || mt.GetRoot() != want.GetRoot() {
+				t.Errorf("got root %s at height %d, want %s at 5", mt.GetRoot(), mt.Height(), want.GetRoot())
+			}
+		})
+	}
+}
+
+func TestExtendCapacityFixedDepth(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(3), WithFixedDepth(4))
+	root := mt.GetRoot()
+
+	if err := mt.ExtendCapacity(16); err != nil || mt.GetRoot() != root || mt.Epoch() != 0 {
+		t.Errorf("got %v, root %s at epoch %d, want storage reserved alone", err, mt.GetRoot(), mt.Epoch())
+	}
+	if err := mt.ExtendCapacity(17); !errors.Is(err, ErrCapacityExceeded) {
+		t.Errorf("got %v, want %v", err, ErrCapacityExceeded)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}},
+		{"lazy", []Option{WithLazyRecompute()}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(5), c.opts...)
+			mt.ExtendCapacity(1000)
+			mt.UpdateElement(4, "updated")
+			mt.Append("appended")
+			epoch := mt.Epoch()
+
+			changed, err := mt.Compact()
+			if err != nil || !changed {
+				t.Fatalf("got %v, %v, want a change", changed, err)
+			}
+			want, _ := NewMerkleTree(mt.OriginalElements(), c.opts...)
+			if mt.Height() != 3 || mt.GetRoot() != want.GetRoot() {
+				t.Errorf("got root %s at height %d, want %s at 3", mt.GetRoot(), mt.Height(), want.GetRoot())
+			}
+			if mt.Epoch() != epoch+1 {
+				t.Errorf("got epoch %d, want %d", mt.Epoch(), epoch+1)
+			}
+			proof, _ := mt.GetProof(5)
+			if len(proof.siblings) != 3 || !VerifyProof(mt.GetRoot(), proof, c.opts...) {
+				t.Errorf("got a proof of depth %d, want a valid one of depth 3", len(proof.siblings))
+			}
+
+			// a minimal tree is left alone
+			if changed, err := mt.Compact(); changed || err != nil || mt.Epoch() != epoch+1 {
+				t.Errorf("got %v, %v at epoch %d, want no change", changed, err, mt.Epoch())
+			}
+			mt.Append("after")
+			want, _ = NewMerkleTree(mt.OriginalElements(), c.opts...)
+			if mt.GetRoot() != want.GetRoot() {
+				t.Errorf("after append: got root %s, want %s", mt.GetRoot(), want.GetRoot())
+			}
+		})
+	}
+}
+
+func TestCompactFixedDepth(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(3), WithFixedDepth(6))
+	if changed, err := mt.Compact(); changed || err != nil || mt.Height() != 6 {
+		t.Errorf("got %v, %v at height %d, want the fixed depth kept", changed, err, mt.Height())
+	}
+}
@@ -0,0 +1,87 @@
+package merkletree
+
+import "context"
+
+// Builds the tree NewMerkleTree would over the elements received from ch, in order,
+// once ch is closed. Elements are appended as they arrive rather than gathered first,
+// though the tree itself keeps every element and node as any tree does; for the root
+// alone, ComputeRootFromChannel needs memory logarithmic in the element count.
+//
+// A channel closed before sending anything fails with ErrEmptyTree. When ctx is done
+// before ch closes, the elements received so far are discarded and the function fails
+// with context.Cause(ctx), leaving ch to its producer. A producer failing midway should
+// therefore cancel ctx with its error, through context.WithCancelCause, rather than just
+// close ch, which would commit to the elements sent until then.
+func NewMerkleTreeFromChannel(ctx context.Context, ch <-chan string, opts ...Option) (*MerkleTree, error) {
+	return buildStreamed(ctx, spanFromChannel, opts, func(add func(element string) error) error {
+		return receive(ctx, ch, add)
+	})
+}
+
+// Builds a tree over the elements feed passes to add, appending each as it comes,
+// once feed returns, or fails with feed's error. The build's span, named name, nests
+// under ctx.
+func buildStreamed(ctx context.Context, name string, opts []Option, feed func(add func(element string) error) error) (t *MerkleTree, err error) {
+	cfg := newConfig(opts)
+	if cfg.tracer != nil {
+		_, span := cfg.tracer.Start(ctx, name)
+		defer func() { t.endSpan(span, err) }()
+	}
+
+	var pending []string // elements of a WithSetSemantics tree, sorted once all have arrived
+	err = feed(func(element string) error {
+		switch {
+		case cfg.setSemantics:
+			pending = append(pending, element)
+		case t == nil:
+			var err error
+			t, err = newMerkleTree([]string{element}, cfg)
+			return err
+		default:
+			return t.append(element)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.setSemantics || t == nil {
+		return newMerkleTree(pending, cfg)
+	}
+	t.epoch = 0
+	return t, nil
+}
+
+// Computes the root NewMerkleTreeFromChannel would produce for the elements received
+// from ch, without building the tree, failing as it does. Memory is logarithmic in the
+// number of elements, as for ComputeRoot. Options with WithSetSemantics fail with
+// ErrSetOrder, as IncrementalBuilder does.
+func ComputeRootFromChannel(ctx context.Context, ch <-chan string, opts ...Option) (string, error) {
+	b := NewIncrementalBuilder(opts...)
+	if err := receive(ctx, ch, b.Add); err != nil {
+		return "", err
+	}
+	return b.Root()
+}
+
+// Passes every element received from ch to add until ch is closed, failing with add's
+// first error or with the cause of ctx once it is done, even if ch closed meanwhile.
+func receive(ctx context.Context, ch <-chan string, add func(element string) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case element, ok := <-ch:
+			if !ok {
+				if ctx.Err() != nil {
+					return context.Cause(ctx)
+				}
+				return nil
+			}
+			if err := add(element); err != nil {
+				return err
+			}
+		}
+	}
+}
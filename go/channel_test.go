@@ -0,0 +1,101 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Returns a channel yielding the elements, closed after the last one.
+func elementChannel(elements []string) <-chan string {
+	ch := make(chan string, len(elements))
+	for _, element := range elements {
+		ch <- element
+	}
+	close(ch)
+	return ch
+}
+
+func TestNewMerkleTreeFromChannel(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"set semantics", []Option{WithSetSemantics()}},
+		{"lazy recompute", []Option{WithLazyRecompute()}},
+		{"fixed depth", []Option{WithFixedDepth(8)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, n := range []int{1, 2, 7, 33} {
+				elements := testElements(n)
+				want, _ := NewMerkleTree(elements, c.opts...)
+
+				mt, err := NewMerkleTreeFromChannel(context.Background(), elementChannel(elements), c.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if mt.GetRoot() != want.GetRoot() || mt.LeafCount() != want.LeafCount() || mt.Epoch() != 0 {
+					t.Errorf("%d elements: got %s over %d at epoch %d, want %s over %d at epoch 0",
+						n, mt.GetRoot(), mt.LeafCount(), mt.Epoch(), want.GetRoot(), want.LeafCount())
+				}
+
+				// elements arrive unsorted, which an IncrementalBuilder cannot take
+				root, err := ComputeRootFromChannel(context.Background(), elementChannel(elements), c.opts...)
+				if c.name == "set semantics" {
+					if !errors.Is(err, ErrSetOrder) {
+						t.Errorf("got %v, want %v", err, ErrSetOrder)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if root != want.GetRoot() {
+					t.Errorf("%d elements: ComputeRootFromChannel got %s, want %s", n, root, want.GetRoot())
+				}
+			}
+		})
+	}
+}
+
+func TestNewMerkleTreeFromChannelFailures(t *testing.T) {
+	if _, err := NewMerkleTreeFromChannel(context.Background(), elementChannel(nil)); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("empty channel: got %v, want %v", err, ErrEmptyTree)
+	}
+	if _, err := ComputeRootFromChannel(context.Background(), elementChannel(nil)); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("empty channel: got %v, want %v", err, ErrEmptyTree)
+	}
+
+	duplicates := elementChannel([]string{"a", "b", "a"})
+	var dup *DuplicateLeafError
+	if _, err := NewMerkleTreeFromChannel(context.Background(), duplicates, WithRejectDuplicates()); !errors.As(err, &dup) {
+		t.Errorf("duplicates: got %v, want a *DuplicateLeafError", err)
+	}
+
+	// a producer failing midway cancels with its error after sending some elements
+	failure := errors.New("upstream failed")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	ch := make(chan string)
+	go func() {
+		for _, element := range testElements(3) {
+			ch <- element
+		}
+		cancel(failure)
+	}()
+	if _, err := NewMerkleTreeFromChannel(ctx, ch); !errors.Is(err, failure) {
+		t.Errorf("producer failure: got %v, want %v", err, failure)
+	}
+
+	// cancellation wins over a channel closed meanwhile
+	canceled, stop := context.WithCancel(context.Background())
+	stop()
+	if _, err := NewMerkleTreeFromChannel(canceled, elementChannel(testElements(3))); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled: got %v, want %v", err, context.Canceled)
+	}
+	if _, err := ComputeRootFromChannel(canceled, elementChannel(testElements(3))); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled: got %v, want %v", err, context.Canceled)
+	}
+}
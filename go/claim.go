@@ -0,0 +1,111 @@
+package merkletree
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	claimTokenVersion   = 0x01 // leading byte of a claim token, with claimElementFlag
+	claimElementFlag    = 0x80 // set when the element follows the scheme
+	maxClaimTokenLength = 64 << 10
+)
+
+// The contents of a verified claim token.
+type ClaimInfo struct {
+	Commitment Commitment
+	Element    string // empty unless HasElement
+	HasElement bool
+	Index      uint64 // index of the proven leaf
+	Proof      MerkleProof
+}
+
+// Bundles a commitment, an optional element and its proof into one URL-safe token, for
+// users to paste wherever their inclusion is checked. The empty element is left out, so
+// the token then proves the proof's leaf hash. Tokens are base64url without padding over
+//
+//	version (1 byte) | root (32 bytes) | leaf count (uvarint) | scheme (see appendScheme)
+//	| [element length (uvarint) | element] | proof (MerkleProof.MarshalBinary)
+//
+// and at most maxClaimTokenLength characters long.
+func EncodeClaim(commitment Commitment, element string, proof MerkleProof) (string, error) {
+	root, err := parseRoot(commitment.Root)
+	if err != nil {
+		return "", err
+	}
+	encodedProof, err := proof.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+
+	out := []byte{claimTokenVersion}
+	if element != "" {
+		out[0] |= claimElementFlag
+	}
+	out = append(out, root[:]...)
+	out = binary.AppendUvarint(out, commitment.LeafCount)
+	out = appendScheme(out, commitment.Scheme)
+	if element != "" {
+		out = binary.AppendUvarint(out, uint64(len(element)))
+		out = append(out, element...)
+	}
+	out = append(out, encodedProof...)
+
+	token := base64.RawURLEncoding.EncodeToString(out)
+	if len(token) > maxClaimTokenLength {
+		return "", fmt.Errorf("merkletree: claim token of %d characters exceeds %d", len(token), maxClaimTokenLength)
+	}
+	return token, nil
+}
+
+// Decodes a token from EncodeClaim and verifies it under the scheme it records: the proof
+// against the root, the element if present against the proof, and the proven index against
+// the leaf count. A token that decodes but fails to verify returns its contents with the
+// error VerifyProofWithReason would, or ErrInvalidProof for the element or index.
+// Tokens that do not decode fail with ErrMalformedProof.
+// The options configure metrics and logging; the token's scheme takes precedence.
+func DecodeAndVerifyClaim(token string, opts ...Option) (ClaimInfo, error) {
+	if len(token) > maxClaimTokenLength {
+		return ClaimInfo{}, fmt.Errorf("%w: claim token of %d characters exceeds %d", ErrMalformedProof, len(token), maxClaimTokenLength)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ClaimInfo{}, fmt.Errorf("%w: claim token: %v", ErrMalformedProof, err)
+	}
+	if len(data) == 0 || data[0]&^claimElementFlag != claimTokenVersion {
+		return ClaimInfo{}, fmt.Errorf("%w: unsupported claim token version", ErrMalformedProof)
+	}
+
+	flags := data[0]
+	r := byteReader{data: data[1:]}
+	root := r.digest()
+	var info ClaimInfo
+	info.Commitment.Root = root.String()
+	info.Commitment.LeafCount = r.uvarint(^uint64(0))
+	info.Commitment.Scheme = r.scheme()
+	if flags&claimElementFlag != 0 {
+		info.Element = string(r.bytes(int(r.uvarint(uint64(len(r.data))))))
+		info.HasElement = true
+		if info.Element == "" {
+			r.fail("element flag set for an empty element")
+		}
+	}
+	if r.err != nil {
+		return ClaimInfo{}, fmt.Errorf("%w: claim token: %v", ErrMalformedProof, r.err)
+	}
+	if err := info.Proof.UnmarshalBinary(r.data); err != nil {
+		return ClaimInfo{}, err
+	}
+	info.Index = proofIndex(info.Proof)
+
+	cfg := newConfig(append(opts[:len(opts):len(opts)], WithScheme(info.Commitment.Scheme)))
+	info.Commitment.Algorithm = cfg.algorithm()
+	if info.HasElement && cfg.dataLeafDigest(info.Element, info.Proof.data).String() != info.Proof.hElement {
+		return info, fmt.Errorf("%w: element does not match the proof", ErrInvalidProof)
+	}
+	if info.Index >= info.Commitment.LeafCount {
+		return info, fmt.Errorf("%w: proves index %d of %d leaves", ErrInvalidProof, info.Index, info.Commitment.LeafCount)
+	}
+	return info, verifyProofHash(cfg, root, info.Proof)
+}
@@ -0,0 +1,104 @@
+package merkletree
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClaimRoundTrip(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithApplicationTag("ledger"), WithEmptyLeaf([]byte("empty"))}, {WithRFC6962Hashing()}} {
+		mt, _ := NewMerkleTree(testElements(5), opts...)
+		for _, index := range []uint64{0, 4} {
+			for _, withElement := range []bool{true, false} {
+				testname := fmt.Sprintf("%+v index %d element %v", mt.Scheme(), index, withElement)
+				t.Run(testname, func(t *testing.T) {
+					proof, _ := mt.GetProof(index)
+					element := ""
+					if withElement {
+						element = mt.elements[index]
+					}
+
+					token, err := EncodeClaim(mt.Commitment(), element, proof)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if strings.ContainsAny(token, "+/=") {
+						t.Errorf("token %q is not URL-safe", token)
+					}
+
+					info, err := DecodeAndVerifyClaim(token)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if info.Commitment.Root != mt.GetRoot() || info.Commitment.LeafCount != mt.LeafCount() {
+						t.Errorf("got %+v, want %+v", info.Commitment, mt.Commitment())
+					}
+					if info.Commitment.Scheme.Tag != mt.Scheme().Tag || string(info.Commitment.Scheme.EmptyLeaf) != string(mt.Scheme().EmptyLeaf) {
+						t.Errorf("got scheme %+v, want %+v", info.Commitment.Scheme, mt.Scheme())
+					}
+					if info.Index != index || info.Element != element || info.HasElement != withElement {
+						t.Errorf("got index %d element %q, want %d %q", info.Index, info.Element, index, element)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestClaimRejectsTampering(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	proof, _ := mt.GetProof(2)
+	token, _ := EncodeClaim(mt.Commitment(), "element-2", proof)
+	data, _ := base64.RawURLEncoding.DecodeString(token)
+
+	for i := range data {
+		testname := fmt.Sprintf("byte %d", i)
+		t.Run(testname, func(t *testing.T) {
+			tampered := append([]byte(nil), data...)
+			tampered[i] ^= 0x04
+			if _, err := DecodeAndVerifyClaim(base64.RawURLEncoding.EncodeToString(tampered)); err == nil {
+				t.Error("verified a tampered token")
+			}
+		})
+	}
+
+	other, _ := EncodeClaim(mt.Commitment(), "element-3", proof)
+	if _, err := DecodeAndVerifyClaim(other); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v", err, ErrInvalidProof)
+	}
+
+	shrunk := mt.Commitment()
+	shrunk.LeafCount = 2
+	beyond, _ := EncodeClaim(shrunk, "", proof)
+	if _, err := DecodeAndVerifyClaim(beyond); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v", err, ErrInvalidProof)
+	}
+}
+
+func TestClaimRejectsAbsurdSizes(t *testing.T) {
+	root := make([]byte, digestSize)
+	absurd := append([]byte{claimTokenVersion}, root...)
+	absurd = binary.AppendUvarint(absurd, 1)
+	absurd = append(absurd, proofBinaryVersion)
+	absurd = binary.AppendUvarint(absurd, 1<<40)
+	if _, err := DecodeAndVerifyClaim(base64.RawURLEncoding.EncodeToString(absurd)); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+
+	if _, err := DecodeAndVerifyClaim(strings.Repeat("A", maxClaimTokenLength+1)); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+
+	mt, _ := NewMerkleTree(testElements(2))
+	proof, _ := mt.GetProof(0)
+	if _, err := EncodeClaim(mt.Commitment(), strings.Repeat("x", maxClaimTokenLength), proof); err == nil {
+		t.Error("encoded an oversized token")
+	}
+	if _, err := DecodeAndVerifyClaim("not base64!"); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
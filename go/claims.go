@@ -0,0 +1,186 @@
+package merkletree
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+var ErrClaimElement = errors.New("merkletree: element is not valid UTF-8, so JSON cannot carry it")
+
+// Selects the layout of a claims file written by ExportClaims.
+type ClaimFormat int
+
+const (
+	ClaimsJSONLines ClaimFormat = iota // one ClaimRecord per line, in index order
+	ClaimsJSONMap                      // {"root": ..., "claims": {element: {"index": ..., "proof": ...}}}, as airdrop distributors publish
+)
+
+// Proves the element at Index, as one line of a ClaimsJSONLines file.
+type ClaimRecord struct {
+	Index   uint64      `json:"index"`
+	Element string      `json:"element"`
+	Proof   MerkleProof `json:"proof"`
+}
+
+// Proofs generated at once while exporting claims, bounding the memory held for them.
+const claimBatchSize = 1024
+
+// Writes a proof of every element to w in the given format, as ExportClaimsProgress does.
+func (t *MerkleTree) ExportClaims(w io.Writer, format ClaimFormat) error {
+	return t.ExportClaimsProgress(w, format, nil)
+}
+
+// Writes a proof of every element to w in the given format, for publishing as a claims
+// file. Proofs are generated in batches as GetProofs does, so however large the tree,
+// only one batch of them is held at a time; progress, when not nil, is called with the
+// number of claims written after each batch. The tree is read locked throughout, so
+// every claim is against the same root, and mutations wait until the export is done.
+// Elements are written as JSON strings and must be valid UTF-8, failing with
+// ErrClaimElement before anything is written otherwise. ClaimsJSONMap keys claims by
+// element, so a tree holding an element twice fails with a DuplicateLeafError.
+// Imported trees hold no elements and fail with ErrElementsUnknown.
+func (t *MerkleTree) ExportClaimsProgress(w io.Writer, format ClaimFormat, progress func(written uint64, total uint64)) error {
+	if err := t.rlockBuilt(); err != nil {
+		return err
+	}
+	defer t.mu.RUnlock()
+
+	if t.elements == nil {
+		return ErrElementsUnknown
+	}
+	if format != ClaimsJSONLines && format != ClaimsJSONMap {
+		return fmt.Errorf("merkletree: unknown claim format %d", format)
+	}
+	for i, element := range t.elements {
+		if !utf8.ValidString(element) {
+			return fmt.Errorf("%w: index %d", ErrClaimElement, i)
+		}
+		if indices := t.indices[element]; format == ClaimsJSONMap && len(indices) > 1 {
+			return &DuplicateLeafError{Element: element, Existing: indices[0], Index: indices[1]}
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	if format == ClaimsJSONMap {
+		fmt.Fprintf(out, `{"root":%q,"claims":{`, t.rootHash().String())
+	}
+	indices := make([]uint64, 0, claimBatchSize)
+	var buf []byte
+	total := t.leafCount()
+	for start := uint64(0); start < total; start += claimBatchSize {
+		indices = indices[:0]
+		for index := start; index < min(start+claimBatchSize, total); index++ {
+			indices = append(indices, index)
+		}
+		for i, proof := range t.proofsFor(indices) {
+			var err error
+			if buf, err = writeClaim(out, buf, format, indices[i], t.elements[indices[i]], proof); err != nil {
+				return err
+			}
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(start+uint64(len(indices)), total)
+		}
+	}
+	if format == ClaimsJSONMap {
+		out.WriteString("}}\n")
+	}
+	return out.Flush()
+}
+
+// Writes one claim, separating it from the one before as the format needs.
+// Claims are appended by hand rather than through encoding/json, which would take
+// most of the export; lines decode as ClaimRecord values.
+func writeClaim(out *bufio.Writer, buf []byte, format ClaimFormat, index uint64, element string, proof MerkleProof) ([]byte, error) {
+	buf = buf[:0]
+	switch {
+	case format == ClaimsJSONLines:
+		buf = append(buf, `{"index":`...)
+		buf = strconv.AppendUint(buf, index, 10)
+		buf = append(buf, `,"element":`...)
+		buf = appendJSONString(buf, element)
+		buf = append(buf, `,"proof":`...)
+		buf = appendProofJSON(buf, proof)
+		buf = append(buf, "}\n"...)
+	default:
+		if index > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendJSONString(buf, element)
+		buf = append(buf, `:{"index":`...)
+		buf = strconv.AppendUint(buf, index, 10)
+		buf = append(buf, `,"proof":`...)
+		buf = appendProofJSON(buf, proof)
+		buf = append(buf, '}')
+	}
+	_, err := out.Write(buf)
+	return buf, err
+}
+
+// Appends the proof as MarshalJSON encodes it. The digests of a proof from the tree
+// are hex and algorithm names plain, so need no escaping.
+func appendProofJSON(buf []byte, p MerkleProof) []byte {
+	buf = append(buf, `{"hElement":"`...)
+	buf = append(buf, p.hElement...)
+	buf = append(buf, `","siblings":[`...)
+	for i, sibling := range p.siblings {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '"')
+		buf = append(buf, sibling...)
+		buf = append(buf, '"')
+	}
+	buf = append(buf, `],"directions":[`...)
+	for i, left := range p.directions {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendBool(buf, left)
+	}
+	buf = append(buf, ']')
+	if p.epoch != 0 {
+		buf = append(buf, `,"epoch":`...)
+		buf = strconv.AppendUint(buf, p.epoch, 10)
+	}
+	if p.tag != "" {
+		buf = append(buf, `,"tag":`...)
+		buf = appendJSONString(buf, p.tag)
+	}
+	if p.algorithm != AlgorithmUnspecified {
+		buf = append(buf, `,"algorithm":"`...)
+		buf = append(buf, p.algorithm.String()...)
+		buf = append(buf, '"')
+	}
+	if len(p.data) > 0 {
+		buf = append(buf, `,"data":"`...)
+		buf = append(buf, base64.StdEncoding.EncodeToString(p.data)...)
+		buf = append(buf, '"')
+	}
+	return append(buf, '}')
+}
+
+// Appends s, valid UTF-8, as a JSON string.
+func appendJSONString(buf []byte, s string) []byte {
+	const hexDigits = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return append(buf, '"')
+}
@@ -0,0 +1,35 @@
+package merkletree
+
+import "fmt"
+
+// What a tree commits to: its root over a number of elements, under a hashing scheme.
+// Publishing the commitment lets holders of proofs verify them without the tree.
+type Commitment struct {
+	Root      string `json:"root"`
+	LeafCount uint64 `json:"leafCount"`
+	Scheme    Scheme `json:"scheme"`
+
+	Algorithm AlgorithmID `json:"algorithm,omitempty"` // that of the scheme, naming it for readers that only switch on it
+}
+
+// Returns the current commitment of the tree.
+func (t *MerkleTree) Commitment() Commitment {
+	if t.rlockBuilt() != nil {
+		return Commitment{}
+	}
+	defer t.mu.RUnlock()
+	return Commitment{Root: t.rootHash().String(), LeafCount: t.leafCount(), Scheme: t.cfg.scheme(), Algorithm: t.cfg.algorithm()}
+}
+
+// Fails with ErrUnknownAlgorithm for an unregistered algorithm and with
+// ErrAlgorithmMismatch for one the scheme does not hash with. Commitments naming no
+// algorithm pass.
+func (c Commitment) checkAlgorithm() error {
+	if err := c.Algorithm.check(); err != nil {
+		return err
+	}
+	if own := newConfig([]Option{WithScheme(c.Scheme)}).algorithm(); c.Algorithm != AlgorithmUnspecified && c.Algorithm != own {
+		return fmt.Errorf("%w: commitment names %v, its scheme hashes with %v", ErrAlgorithmMismatch, c.Algorithm, own)
+	}
+	return nil
+}
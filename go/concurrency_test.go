@@ -0,0 +1,123 @@
+package merkletree
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrentProofsDuringUpdates(t *testing.T) {
+	t.Run("eager", func(t *testing.T) { testConcurrentProofsDuringUpdates(t) })
+	t.Run("lazy", func(t *testing.T) { testConcurrentProofsDuringUpdates(t, WithLazyRecompute()) })
+}
+
+func testConcurrentProofsDuringUpdates(t *testing.T, opts ...Option) {
+	const size, updates, readers = 37, 200, 8
+
+	// the root after each number of updates, computed up front on a separate tree
+	elements := testElements(size)
+	reference, _ := NewMerkleTree(elements)
+	roots := []string{reference.GetRoot()}
+	for i := 0; i < updates; i++ {
+		reference.UpdateElement(uint64(i*7%size), fmt.Sprintf("updated-%d", i))
+		roots = append(roots, reference.GetRoot())
+	}
+
+	valid := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		valid[root] = true
+	}
+
+	mt, _ := NewMerkleTree(elements, opts...)
+	var done atomic.Bool
+	var wg sync.WaitGroup
+
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := r; !done.Load(); i++ {
+				index := uint64(i % size)
+				var proof MerkleProof
+				if i%2 == 0 {
+					proof, _ = mt.GetProof(index)
+				} else {
+					proofs, _ := mt.GetProofs([]uint64{index})
+					proof = proofs[index]
+				}
+
+				// each proof must belong to exactly the version of the tree it was stamped with
+				if epoch := proof.Epoch(); !VerifyProof(roots[epoch], proof) {
+					t.Errorf("proof for %d at epoch %d does not verify against that epoch's root", index, epoch)
+					return
+				}
+				if root := mt.GetRoot(); !valid[root] {
+					t.Errorf("root %s is not the root of any version of the tree", root)
+					return
+				}
+			}
+		}(r)
+	}
+
+	for i := 0; i < updates; i++ {
+		if err := mt.UpdateElement(uint64(i*7%size), fmt.Sprintf("updated-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done.Store(true)
+	wg.Wait()
+
+	if mt.GetRoot() != roots[updates] {
+		t.Errorf("got %s, want %s", mt.GetRoot(), roots[updates])
+	}
+}
+
+func TestConcurrentReset(t *testing.T) {
+	small, large := testElements(5), testElements(300)
+	smallTree, _ := NewMerkleTree(small)
+	largeTree, _ := NewMerkleTree(large)
+	valid := map[string]bool{smallTree.GetRoot(): true, largeTree.GetRoot(): true}
+
+	mt, _ := NewMerkleTree(small)
+	var done atomic.Bool
+	var wg sync.WaitGroup
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !done.Load() {
+				proofs := mt.GetAllProofs()
+				root := ""
+				for _, candidate := range []string{smallTree.GetRoot(), largeTree.GetRoot()} {
+					if VerifyProof(candidate, proofs[0]) {
+						root = candidate
+					}
+				}
+				if !valid[root] {
+					t.Error("proof verifies against neither root")
+					return
+				}
+				for _, proof := range proofs {
+					if !VerifyProof(root, proof) {
+						t.Error("proofs of one call verify against different roots")
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		next := small
+		if i%2 == 0 {
+			next = large
+		}
+		if err := mt.Reset(next); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done.Store(true)
+	wg.Wait()
+}
@@ -0,0 +1,96 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrTooManyNodes = errors.New("merkletree: tree has too many nodes to export")
+
+// Addresses a node by its level, counted up from the leaves (0), and its index within that level.
+type NodeCoord struct {
+	Level int
+	Index uint64
+}
+
+// Returns the hash of the node at the given coordinate, with padding nodes included.
+func (t *MerkleTree) NodeAt(coord NodeCoord) (string, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return "", err
+	}
+	defer t.mu.RUnlock()
+	return t.nodeAt(coord)
+}
+
+func (t *MerkleTree) nodeAt(coord NodeCoord) (string, error) {
+	if coord.Level < 0 || coord.Level > t.height() || coord.Index >= t.paddedLeafCount()>>coord.Level {
+		return "", fmt.Errorf("%w: node %d at level %d", ErrIndexOutOfBounds, coord.Index, coord.Level)
+	}
+	return t.node(coord.Level, coord.Index).String(), nil
+}
+
+// Returns the number of levels from the leaves to the root inclusive, Height() + 1.
+func (t *MerkleTree) LevelCount() int {
+	if t.rlockBuiltAsIs() != nil {
+		return 0
+	}
+	defer t.mu.RUnlock()
+	return t.height() + 1
+}
+
+// Returns a copy of every level, from the leaves (level 0) up to the root, which is the
+// only node of the last: Levels()[l][i] is NodeAt(NodeCoord{l, i}). Each level holds
+// all PaddedLeafCount() >> l of its nodes, padding included, as hex strings.
+// The copy reflects the tree when called and does not follow later mutations.
+// Fails with ErrTooManyNodes for trees of more than 2^32 nodes, such as those of a
+// large WithFixedDepth; export their stored nodes with ExportNodes instead.
+func (t *MerkleTree) Levels() ([][]string, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return nil, err
+	}
+	defer t.mu.RUnlock()
+
+	if t.height() > 31 {
+		return nil, fmt.Errorf("%w: %d levels hold more than 2^32 nodes", ErrTooManyNodes, t.height()+1)
+	}
+	levels := make([][]string, t.height()+1)
+	for level := range levels {
+		width := t.paddedLeafCount() >> level
+		levels[level] = make([]string, width)
+		// past the stored nodes, and the one ModeBitcoin duplicates, every slot holds the padding hash
+		var padding string
+		for index := uint64(0); index < width; index++ {
+			if index <= t.levelSize(level) {
+				levels[level][index] = t.node(level, index).String()
+				continue
+			}
+			if padding == "" {
+				padding = t.node(level, index).String()
+			}
+			levels[level][index] = padding
+		}
+	}
+	return levels, nil
+}
+
+// Returns the coordinates of the nodes on the path from a leaf to the root of a tree of the given
+// depth, and of the siblings a proof for that leaf carries, both ordered from the leaves upwards.
+func ProofPath(leafIndex uint64, depth int) (path []NodeCoord, siblings []NodeCoord) {
+	path = make([]NodeCoord, 0, depth+1)
+	siblings = make([]NodeCoord, 0, depth)
+
+	for level := 0; level < depth; level++ {
+		path = append(path, NodeCoord{level, leafIndex})
+		siblings = append(siblings, NodeCoord{level, leafIndex ^ 1})
+		leafIndex /= 2
+	}
+	path = append(path, NodeCoord{depth, leafIndex})
+
+	return path, siblings
+}
+
+// Returns the coordinates of the proof's siblings, ordered from the leaves upwards.
+func (p MerkleProof) SiblingCoordinates() []NodeCoord {
+	_, siblings := ProofPath(proofIndex(p), len(p.directions))
+	return siblings
+}
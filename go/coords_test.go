@@ -0,0 +1,126 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSiblingCoordinatesReconstructProof(t *testing.T) {
+	for _, size := range []int{1, 2, 7, 16} {
+		mt, _ := NewMerkleTree(testElements(size))
+
+		for i := 0; i < size; i++ {
+			testname := fmt.Sprintf("reconstructs proof %d of %d elements", i, size)
+			t.Run(testname, func(t *testing.T) {
+				want, _ := mt.GetProof(uint64(i))
+
+				path, coords := ProofPath(uint64(i), mt.Height())
+				if !reflect.DeepEqual(coords, want.SiblingCoordinates()) {
+					t.Errorf("got %v, want %v", want.SiblingCoordinates(), coords)
+				}
+
+				leaf, err := mt.NodeAt(path[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := MerkleProof{hElement: leaf, siblings: []string{}, directions: []bool{}, algorithm: AlgorithmSHA256}
+				for _, coord := range coords {
+					sibling, err := mt.NodeAt(coord)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got.siblings = append(got.siblings, sibling)
+					got.directions = append(got.directions, coord.Index%2 == 0)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("got %+v, want %+v", got, want)
+				}
+
+				root, _ := mt.NodeAt(path[len(path)-1])
+				if root != mt.GetRoot() {
+					t.Errorf("path ends at %s, want the root %s", root, mt.GetRoot())
+				}
+			})
+		}
+	}
+}
+
+func TestNodeAtOutOfBounds(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+
+	if _, err := mt.NodeAt(NodeCoord{Level: 0, Index: 7}); err != nil {
+		t.Errorf("padding leaf should be addressable: %v", err)
+	}
+	for _, coord := range []NodeCoord{{0, 8}, {3, 1}, {4, 0}, {-1, 0}} {
+		if _, err := mt.NodeAt(coord); !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("%v: got %v, want %v", coord, err, ErrIndexOutOfBounds)
+		}
+	}
+}
+
+func TestLevelsMatchNodeAt(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"default":     nil,
+		"fixed depth": {WithFixedDepth(5)},
+		"bitcoin":     {WithMode(ModeBitcoin)},
+		"lazy":        {WithLazyRecompute()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(11), opts...)
+			mt.UpdateElement(4, "updated")
+			mt.Append("appended")
+
+			levels, err := mt.Levels()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(levels) != mt.LevelCount() || mt.LevelCount() != mt.Height()+1 {
+				t.Fatalf("got %d levels, LevelCount %d, height %d", len(levels), mt.LevelCount(), mt.Height())
+			}
+			for level, nodes := range levels {
+				if uint64(len(nodes)) != mt.PaddedLeafCount()>>level {
+					t.Errorf("level %d: got %d nodes, want %d", level, len(nodes), mt.PaddedLeafCount()>>level)
+				}
+				for index, node := range nodes {
+					if want, _ := mt.NodeAt(NodeCoord{level, uint64(index)}); node != want {
+						t.Fatalf("level %d index %d: got %s, want %s", level, index, node, want)
+					}
+				}
+			}
+			if root := levels[len(levels)-1]; len(root) != 1 || root[0] != mt.GetRoot() {
+				t.Errorf("got last level %v, want the root %s", root, mt.GetRoot())
+			}
+		})
+	}
+}
+
+func TestLevelsRebuildRoot(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6))
+	before, _ := mt.Levels()
+	mt.UpdateElement(2, "updated")
+	levels, _ := mt.Levels()
+	if before[0][2] == levels[0][2] {
+		t.Fatal("got the leaf from before the update")
+	}
+
+	// the leaf level alone, padding included, builds the same tree
+	rebuilt, err := NewMerkleTreeFromRoots(levels[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt.GetRoot() != mt.GetRoot() {
+		t.Errorf("got root %s from the leaves, want %s", rebuilt.GetRoot(), mt.GetRoot())
+	}
+
+	levels[0][0] = "changed"
+	if again, _ := mt.Levels(); again[0][0] == "changed" {
+		t.Error("got the levels aliased by the tree")
+	}
+
+	deep, _ := NewMerkleTree(testElements(2), WithFixedDepth(40))
+	if _, err := deep.Levels(); !errors.Is(err, ErrTooManyNodes) {
+		t.Errorf("got %v, want %v", err, ErrTooManyNodes)
+	}
+}
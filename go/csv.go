@@ -0,0 +1,169 @@
+package merkletree
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrMalformedCSV = errors.New("merkletree: malformed CSV")
+	ErrCSVConfig    = errors.New("merkletree: invalid CSV configuration")
+)
+
+// Byte order mark spreadsheet exports often begin with, which encoding/csv would take
+// as part of the first field.
+const utf8BOM = "\uFEFF"
+
+// Selects how NewMerkleTreeFromCSV encodes the chosen fields of a row as its element.
+// Either way the element depends on the field values alone, not on how the file
+// quoted them, its delimiter or its line endings.
+type CSVEncoding uint8
+
+const (
+	// Joins the fields with commas, as RFC 4180 writes a record without its line break:
+	// a field holding a comma, a double quote, a CR or an LF is enclosed in double quotes,
+	// with its double quotes doubled, and every other field is written as is.
+	// The row account,"1,000" is the element `account,"1,000"`.
+	CSVQuoted CSVEncoding = iota
+
+	// Concatenates the fields, each as its length in bytes (uvarint) then its bytes,
+	// for consumers that would rather split elements than parse them.
+	CSVLengthPrefixed
+)
+
+// Configures NewMerkleTreeFromCSV. The zero value makes one leaf of every row, all its
+// columns included, encoded with CSVQuoted.
+type CSVConfig struct {
+	Columns     []int    // columns included, counted from zero, in this order; nil for every column
+	ColumnNames []string // columns included by their header name, in this order, instead of Columns
+	Header      bool     // the first row names the columns and is no leaf; required by ColumnNames
+	Comma       rune     // field delimiter of the file; 0 for ','
+	Encoding    CSVEncoding
+
+	Options []Option // options of the tree built
+}
+
+// Builds a tree with one leaf per row of r, a CSV file as RFC 4180 describes it, whose
+// element is the fields cfg selects encoded as cfg.Encoding describes. Rows are read
+// and appended one at a time, as NewMerkleTreeFromChannel does, so memory is that of
+// the tree and one row. Line endings are normalized, CRLF and LF alike, quoted fields
+// included, and a leading UTF-8 byte order mark is skipped, so a file yields one root
+// wherever it was exported.
+//
+// Every row must hold as many fields as the first, valid UTF-8, and the selected
+// columns; rows that do not, or do not parse, fail with ErrMalformedCSV naming their
+// line. A file without rows fails with ErrEmptyTree, and a configuration that cannot
+// select columns, such as ColumnNames without a Header, with ErrCSVConfig.
+func NewMerkleTreeFromCSV(r io.Reader, cfg CSVConfig) (*MerkleTree, error) {
+	switch {
+	case cfg.ColumnNames != nil && cfg.Columns != nil:
+		return nil, fmt.Errorf("%w: both Columns and ColumnNames set", ErrCSVConfig)
+	case cfg.ColumnNames != nil && !cfg.Header:
+		return nil, fmt.Errorf("%w: ColumnNames without a Header", ErrCSVConfig)
+	case cfg.Encoding > CSVLengthPrefixed:
+		return nil, fmt.Errorf("%w: unknown encoding %d", ErrCSVConfig, cfg.Encoding)
+	}
+	for _, column := range cfg.Columns {
+		if column < 0 {
+			return nil, fmt.Errorf("%w: column %d", ErrCSVConfig, column)
+		}
+	}
+
+	buffered := bufio.NewReader(r)
+	if head, _ := buffered.Peek(len(utf8BOM)); string(head) == utf8BOM {
+		buffered.Discard(len(utf8BOM))
+	}
+	in := csv.NewReader(buffered)
+	if cfg.Comma != 0 {
+		in.Comma = cfg.Comma
+	}
+	in.ReuseRecord = true
+
+	columns := cfg.Columns
+	return buildStreamed(context.Background(), spanFromCSV, cfg.Options, func(add func(element string) error) error {
+		var element []byte
+		for row := 0; ; row++ {
+			record, err := in.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrMalformedCSV, err)
+			}
+			for i, field := range record {
+				if !utf8.ValidString(field) {
+					line, _ := in.FieldPos(i)
+					return fmt.Errorf("%w: line %d, field %d is not valid UTF-8", ErrMalformedCSV, line, i)
+				}
+			}
+
+			if row == 0 {
+				if columns, err = csvColumns(cfg, record); err != nil {
+					line, _ := in.FieldPos(0)
+					return fmt.Errorf("%w: line %d: %w", ErrMalformedCSV, line, err)
+				}
+				if cfg.Header {
+					continue
+				}
+			}
+			element = element[:0]
+			for i, column := range columns {
+				element = appendCSVField(element, cfg.Encoding, i, record[column])
+			}
+			if err := add(string(element)); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// Resolves the columns cfg selects against the first row, the header with cfg.Header.
+func csvColumns(cfg CSVConfig, first []string) ([]int, error) {
+	if cfg.ColumnNames != nil {
+		columns := make([]int, len(cfg.ColumnNames))
+		for i, name := range cfg.ColumnNames {
+			if columns[i] = slices.Index(first, name); columns[i] < 0 {
+				return nil, fmt.Errorf("header has no column %q", name)
+			}
+		}
+		return columns, nil
+	}
+	if cfg.Columns == nil {
+		columns := make([]int, len(first))
+		for i := range columns {
+			columns[i] = i
+		}
+		return columns, nil
+	}
+	for _, column := range cfg.Columns {
+		if column >= len(first) {
+			return nil, fmt.Errorf("column %d of a row of %d fields", column, len(first))
+		}
+	}
+	return cfg.Columns, nil
+}
+
+// Appends field, the i-th of the element, to out under encoding.
+func appendCSVField(out []byte, encoding CSVEncoding, i int, field string) []byte {
+	if encoding == CSVLengthPrefixed {
+		out = binary.AppendUvarint(out, uint64(len(field)))
+		return append(out, field...)
+	}
+	if i > 0 {
+		out = append(out, ',')
+	}
+	if !strings.ContainsAny(field, ",\"\r\n") {
+		return append(out, field...)
+	}
+	out = append(out, '"')
+	out = append(out, strings.ReplaceAll(field, `"`, `""`)...)
+	return append(out, '"')
+}
@@ -0,0 +1,109 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Root of testdata/accounts.csv under the default options, header skipped, every column
+// included with CSVQuoted.
+const accountsCSVRoot = "817b7645e82270f9bd8c31b2d2ce48a1af1d2145c3a9e37a220606a316189e6e"
+
+func TestNewMerkleTreeFromCSVFixture(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/accounts.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mt, err := NewMerkleTreeFromCSV(bytes.NewReader(fixture), CSVConfig{Header: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements := []string{
+		"alice,100,plain",
+		`"bob, jr.",2500,"says ""hi"""`,
+		"zoë,0.5,ünïcode 日本語",
+		"\"multi\nline\",7,\"trailing, comma,\"",
+		",0,empty account",
+		"  spaced  ,-1,",
+	}
+	want, _ := NewMerkleTree(elements)
+	if mt.GetRoot() != want.GetRoot() || mt.Epoch() != 0 {
+		t.Errorf("got elements %q, want %q", mt.OriginalElements(), elements)
+	}
+	if mt.GetRoot() != accountsCSVRoot {
+		t.Errorf("got root %s, want %s", mt.GetRoot(), accountsCSVRoot)
+	}
+
+	// the same rows exported elsewhere
+	variants := map[string][]byte{
+		"LF":  bytes.ReplaceAll(fixture, []byte("\r\n"), []byte("\n")),
+		"BOM": append([]byte(utf8BOM), fixture...),
+	}
+	for name, data := range variants {
+		mt, err := NewMerkleTreeFromCSV(bytes.NewReader(data), CSVConfig{Header: true})
+		if err != nil || mt.GetRoot() != accountsCSVRoot {
+			t.Errorf("%s: got %v, want root %s", name, err, accountsCSVRoot)
+		}
+	}
+}
+
+func TestNewMerkleTreeFromCSVColumns(t *testing.T) {
+	const data = "account;balance;note\nalice;100;x\n\"bob;\";2,5;y\n"
+	byName, err := NewMerkleTreeFromCSV(strings.NewReader(data), CSVConfig{ColumnNames: []string{"balance", "account"}, Header: true, Comma: ';'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byIndex, _ := NewMerkleTreeFromCSV(strings.NewReader(data), CSVConfig{Columns: []int{1, 0}, Header: true, Comma: ';'})
+	want, _ := NewMerkleTree([]string{"100,alice", `"2,5",bob;`})
+	if byName.GetRoot() != want.GetRoot() || byIndex.GetRoot() != want.GetRoot() {
+		t.Errorf("got elements %q and %q, want %q", byName.OriginalElements(), byIndex.OriginalElements(), want.OriginalElements())
+	}
+
+	prefixed, _ := NewMerkleTreeFromCSV(strings.NewReader(data), CSVConfig{Columns: []int{0, 1}, Comma: ';', Encoding: CSVLengthPrefixed})
+	want, _ = NewMerkleTree([]string{"\x07account\x07balance", "\x05alice\x03100", "\x04bob;\x032,5"})
+	if prefixed.GetRoot() != want.GetRoot() {
+		t.Errorf("got elements %q, want %q", prefixed.OriginalElements(), want.OriginalElements())
+	}
+}
+
+func TestNewMerkleTreeFromCSVErrors(t *testing.T) {
+	malformed := []struct {
+		name, data, line string
+		cfg              CSVConfig
+	}{
+		{"short row", "a,b\nc,d\ne\n", "line 3", CSVConfig{}},
+		{"bare quote", "a,b\nc,d\"\n", "line 2", CSVConfig{}},
+		{"unterminated quote", "a,b\n\"c,d\n", "line 2", CSVConfig{}},
+		{"invalid UTF-8", "a,b\nc,\xff\n", "line 2", CSVConfig{}},
+		{"unknown column name", "\n\naccount\nalice\n", "line 3", CSVConfig{ColumnNames: []string{"balance"}, Header: true}},
+		{"column beyond the row", "a,b\n", "line 1", CSVConfig{Columns: []int{2}}},
+	}
+	for _, c := range malformed {
+		_, err := NewMerkleTreeFromCSV(strings.NewReader(c.data), c.cfg)
+		if !errors.Is(err, ErrMalformedCSV) || !strings.Contains(err.Error(), c.line) {
+			t.Errorf("%s: got %v, want %v naming %s", c.name, err, ErrMalformedCSV, c.line)
+		}
+	}
+
+	for _, data := range []string{"", "account,balance\n", utf8BOM} {
+		if _, err := NewMerkleTreeFromCSV(strings.NewReader(data), CSVConfig{Header: data != ""}); !errors.Is(err, ErrEmptyTree) {
+			t.Errorf("%q: got %v, want %v", data, err, ErrEmptyTree)
+		}
+	}
+	for _, cfg := range []CSVConfig{
+		{ColumnNames: []string{"a"}},
+		{ColumnNames: []string{"a"}, Columns: []int{0}, Header: true},
+		{Columns: []int{-1}},
+		{Encoding: CSVLengthPrefixed + 1},
+	} {
+		if _, err := NewMerkleTreeFromCSV(strings.NewReader("a\n"), cfg); !errors.Is(err, ErrCSVConfig) {
+			t.Errorf("%+v: got %v, want %v", cfg, err, ErrCSVConfig)
+		}
+	}
+	if _, err := NewMerkleTreeFromCSV(strings.NewReader("a\nb\na\n"), CSVConfig{Options: []Option{WithRejectDuplicates()}}); err == nil {
+		t.Error("got a tree of duplicate rows under WithRejectDuplicates")
+	}
+}
@@ -0,0 +1,46 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrDepthMismatch = errors.New("merkletree: proof depth does not match the tree height")
+
+// Verifies a Merkle proof against a known root of a tree of the given height, failing
+// with ErrDepthMismatch for a proof of any other depth before hashing anything, and
+// otherwise as VerifyProofWithReason does. A proof cut short may still fold into a
+// node of the tree, which VerifyProof alone cannot tell from the root.
+func VerifyProofAtDepth(root string, depth int, proof MerkleProof, opts ...Option) error {
+	return newVerifier(opts).VerifyProofAtDepth(root, depth, proof)
+}
+
+// Verifies a Merkle proof against a known root of a tree of the given height, as
+// VerifyProofAtDepth does.
+func (v *Verifier) VerifyProofAtDepth(root string, depth int, proof MerkleProof) error {
+	if len(proof.siblings) != depth {
+		err := fmt.Errorf("%w: proof of depth %d, tree of height %d", ErrDepthMismatch, len(proof.siblings), depth)
+		if v.cfg.metrics != nil {
+			v.cfg.metrics.ProofVerified(false)
+		}
+		v.cfg.logVerifyFailure(root, proof, err)
+		return err
+	}
+	return v.VerifyProofWithReason(root, proof)
+}
+
+// Verifies a Merkle proof against a published commitment, under its scheme and at the
+// height of a tree over its element count, failing as VerifyProofAtDepth does, and with
+// ErrAlgorithmMismatch for a commitment naming another algorithm than its scheme's.
+// Trees built with WithFixedDepth need the option here too, the scheme not recording it.
+func VerifyCommitmentProof(c Commitment, proof MerkleProof, opts ...Option) error {
+	if err := c.checkAlgorithm(); err != nil {
+		return err
+	}
+	v := newVerifier(append(opts[:len(opts):len(opts)], WithScheme(c.Scheme)))
+	depth, err := v.cfg.checkedHeight(c.LeafCount)
+	if err != nil {
+		return err
+	}
+	return v.VerifyProofAtDepth(c.Root, depth, proof)
+}
@@ -0,0 +1,99 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyZeroSiblings(t *testing.T) {
+	single, _ := NewMerkleTree([]string{"only"})
+	proof, _ := single.GetProof(0)
+	if len(proof.siblings) != 0 {
+		t.Fatalf("got %d siblings, want none", len(proof.siblings))
+	}
+	if !VerifyProof(single.GetRoot(), proof) {
+		t.Error("proof of the only element rejected")
+	}
+	if err := VerifyProofAtDepth(single.GetRoot(), 0, proof); err != nil {
+		t.Errorf("got %v at depth 0, want nil", err)
+	}
+	if err := VerifyCommitmentProof(single.Commitment(), proof); err != nil {
+		t.Errorf("got %v against the commitment, want nil", err)
+	}
+
+	other, _ := NewMerkleTree([]string{"other"})
+	if err := VerifyProofWithReason(other.GetRoot(), proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v against another root, want ErrInvalidProof", err)
+	}
+
+	// the root of a larger tree posing as a leaf passes only where depth is not checked
+	mt, _ := NewMerkleTree(testElements(8))
+	bare := MerkleProof{hElement: mt.GetRoot(), siblings: []string{}, directions: []bool{}}
+	if !VerifyProof(mt.GetRoot(), bare) {
+		t.Error("bare root rejected without a depth")
+	}
+	if err := VerifyCommitmentProof(mt.Commitment(), bare); !errors.Is(err, ErrDepthMismatch) {
+		t.Errorf("got %v, want ErrDepthMismatch", err)
+	}
+}
+
+func TestVerifyProofAtDepth(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(8))
+	proof, _ := mt.GetProof(5)
+	height := mt.Height()
+
+	// omitting the top level folds into the node below the root, which then verifies
+	truncated := MerkleProof{hElement: proof.hElement, siblings: proof.siblings[:height-1], directions: proof.directions[:height-1]}
+	inner, _ := mt.NodeAt(NodeCoord{Level: height - 1, Index: 1})
+	if !VerifyProof(inner, truncated) {
+		t.Fatal("truncated proof does not reach its inner node")
+	}
+
+	extended := MerkleProof{
+		hElement:   proof.hElement,
+		siblings:   append(append([]string(nil), proof.siblings...), proof.hElement),
+		directions: append(append([]bool(nil), proof.directions...), false),
+	}
+	cases := []struct {
+		name  string
+		root  string
+		depth int
+		proof MerkleProof
+		want  error
+	}{
+		{"exact", mt.GetRoot(), height, proof, nil},
+		{"one level short", inner, height, truncated, ErrDepthMismatch},
+		{"one level long", mt.GetRoot(), height, extended, ErrDepthMismatch},
+		{"depth one less", mt.GetRoot(), height - 1, proof, ErrDepthMismatch},
+		{"depth one more", mt.GetRoot(), height + 1, proof, ErrDepthMismatch},
+		{"wrong root", inner, height, proof, ErrInvalidProof},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := VerifyProofAtDepth(c.root, c.depth, c.proof); !errors.Is(err, c.want) || (c.want == nil && err != nil) {
+				t.Errorf("got %v, want %v", err, c.want)
+			}
+		})
+	}
+
+	if err := VerifyCommitmentProof(mt.Commitment(), truncated); !errors.Is(err, ErrDepthMismatch) {
+		t.Errorf("got %v for a truncated proof against the commitment, want ErrDepthMismatch", err)
+	}
+	if err := VerifyCommitmentProof(mt.Commitment(), proof); err != nil {
+		t.Errorf("got %v against the commitment, want nil", err)
+	}
+}
+
+func TestVerifyCommitmentProofFixedDepth(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5), WithFixedDepth(6))
+	proof, _ := mt.GetProof(4)
+	if err := VerifyCommitmentProof(mt.Commitment(), proof, WithFixedDepth(6)); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+	if err := VerifyCommitmentProof(mt.Commitment(), proof); !errors.Is(err, ErrDepthMismatch) {
+		t.Errorf("got %v without the fixed depth, want ErrDepthMismatch", err)
+	}
+	if err := VerifyCommitmentProof(Commitment{Root: mt.GetRoot()}, proof); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v for a commitment to no elements, want ErrEmptyTree", err)
+	}
+}
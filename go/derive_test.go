@@ -0,0 +1,172 @@
+package merkletree
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDeriveRoot(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6))
+
+	for i := uint64(0); i < 6; i++ {
+		proof, _ := mt.GetProof(i)
+		root, err := DeriveRoot(proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != mt.GetRoot() {
+			t.Errorf("got %s, want %s", root, mt.GetRoot())
+		}
+	}
+}
+
+func TestDeriveRootUnderOptions(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"raw nodes":    {WithRawNodeHashing()},
+		"sorted pairs": {WithSortedPairs()},
+		"rfc6962":      {WithRFC6962Hashing()},
+		"hmac":         {WithHMACKey([]byte("key"))},
+		"bitcoin":      {WithMode(ModeBitcoin)},
+		"keccak, tag":  {WithKeccak256(), WithApplicationTag("app")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(7), opts...)
+			for i := uint64(0); i < 7; i++ {
+				proof, _ := mt.GetProof(i)
+				if root, err := DeriveRoot(proof, opts...); err != nil || root != mt.GetRoot() {
+					t.Errorf("proof %d: got %s, %v, want %s", i, root, err, mt.GetRoot())
+				}
+				if root, err := mt.Verifier().DeriveRoot(proof); err != nil || root != mt.GetRoot() {
+					t.Errorf("proof %d from the tree's verifier: got %s, %v, want %s", i, root, err, mt.GetRoot())
+				}
+			}
+		})
+	}
+
+	// options the proof cannot verify under fail as VerifyProofWithReason reports them
+	mt, _ := NewMerkleTree(testElements(4), WithApplicationTag("app"))
+	proof, _ := mt.GetProof(1)
+	if _, err := DeriveRoot(proof, WithApplicationTag("other")); !errors.Is(err, ErrDomainMismatch) {
+		t.Errorf("got %v, want %v", err, ErrDomainMismatch)
+	}
+}
+
+func TestDeriveRootOfTamperedProof(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6))
+	proof, _ := mt.GetProof(3)
+	proof.siblings = append([]string(nil), proof.siblings...)
+	proof.siblings[1] = hashLeaf("tampered")
+
+	root, err := DeriveRoot(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root == mt.GetRoot() {
+		t.Error("tampered proof derived the original root")
+	}
+	if !VerifyProof(root, proof) {
+		t.Error("proof should verify against the root it derives")
+	}
+}
+
+func TestDeriveRootChainsSubtrees(t *testing.T) {
+	inner, _ := NewMerkleTree(testElements(3))
+	innerProof, _ := inner.GetProof(2)
+	innerRoot, _ := DeriveRoot(innerProof)
+
+	// the inner root, committed as a leaf hash, continues up through the outer proof
+	outer, _ := NewMerkleTree([]string{"a", "b"})
+	outerProof, _ := outer.GetProof(1)
+	outerProof.hElement = innerRoot
+	chained, err := DeriveRoot(outerProof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := hashNode(hashLeaf("a"), innerRoot); chained != want {
+		t.Errorf("got %s, want %s", chained, want)
+	}
+}
+
+func TestDeriveRootMalformed(t *testing.T) {
+	proof := MerkleProof{hElement: hashLeaf("a"), siblings: []string{hashLeaf("b")}}
+	if _, err := DeriveRoot(proof); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
+
+func TestDeriveRootWithTrace(t *testing.T) {
+	for _, size := range []int{1, 2, 6, 33} {
+		mt, _ := NewMerkleTree(testElements(size), WithBLAKE3())
+		for i := uint64(0); i < uint64(size); i++ {
+			proof, _ := mt.GetProof(i)
+			root, trace, err := DeriveRootWithTrace(proof)
+			if err != nil || root != mt.GetRoot() || len(trace) != mt.Height() {
+				t.Fatalf("size %d, proof %d: got %s with %d entries, %v", size, i, root, len(trace), err)
+			}
+
+			path, _ := ProofPath(i, mt.Height())
+			for level, node := range trace {
+				want, _ := mt.NodeAt(path[level+1])
+				if node != want {
+					t.Errorf("size %d, proof %d: got %s at level %d, want %s", size, i, node, level+1, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDeriveRootWithTraceLocatesCorruption(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(16))
+	proof, _ := mt.GetProof(9)
+	_, honest, _ := DeriveRootWithTrace(proof)
+
+	proof.siblings = append([]string(nil), proof.siblings...)
+	proof.siblings[2] = hashLeaf("corrupted")
+	root, trace, err := DeriveRootWithTrace(proof)
+	if err != nil || root == mt.GetRoot() {
+		t.Fatalf("got %s, %v, want another root", root, err)
+	}
+	for i := range trace {
+		if (trace[i] == honest[i]) != (i < 2) {
+			t.Errorf("entry %d: got %s, honest %s, want entries to differ from sibling 2 on", i, trace[i], honest[i])
+		}
+	}
+
+	proof.directions = proof.directions[:1]
+	if _, _, err := DeriveRootWithTrace(proof); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
+
+func TestDeriveRootWithTraceUnderOptions(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"raw nodes":    {WithRawNodeHashing()},
+		"sorted pairs": {WithSortedPairs()},
+		"rfc6962":      {WithRFC6962Hashing()},
+		"hmac":         {WithHMACKey([]byte("key"))},
+		"bitcoin":      {WithMode(ModeBitcoin)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(6), opts...)
+			for i := uint64(0); i < 6; i++ {
+				proof, _ := mt.GetProof(i)
+				root, trace, err := DeriveRootWithTrace(proof, opts...)
+				if err != nil || root != mt.GetRoot() {
+					t.Fatalf("proof %d: got %s, %v, want %s", i, root, err, mt.GetRoot())
+				}
+				if _, again, _ := mt.Verifier().DeriveRootWithTrace(proof); !slices.Equal(again, trace) {
+					t.Errorf("proof %d: got trace %v from the tree's verifier, want %v", i, again, trace)
+				}
+
+				path, _ := ProofPath(i, mt.Height())
+				for level, node := range trace {
+					want, _ := mt.NodeAt(path[level+1])
+					if node != want {
+						t.Errorf("proof %d: got %s at level %d, want %s", i, node, level+1, want)
+					}
+				}
+			}
+		})
+	}
+}
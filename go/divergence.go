@@ -0,0 +1,81 @@
+package merkletree
+
+import "errors"
+
+var ErrIncompatibleTrees = errors.New("merkletree: trees hash under different options")
+
+// Optionally implemented by a MetricsSink to also count the nodes read while comparing
+// trees, by FirstDivergence. Each tree's sink counts the reads of its own nodes.
+type NodeReadMetrics interface {
+	NodeRead(n int) // n stored nodes were read without being hashed
+}
+
+// Returns the first index at which two trees over the same log hold different elements,
+// or at which the shorter of them ends when they agree on all of it. Found is false only
+// for equal trees. Subtrees within both trees whose digests match are skipped whole, so
+// the search reads O(log n) nodes however large the trees are. Both trees are read locked
+// while searching, a first and b second.
+// Fails with ErrIncompatibleTrees for trees hashing under different options, whose
+// digests would differ everywhere.
+func FirstDivergence(a, b *MerkleTree) (index uint64, found bool, err error) {
+	if a == b {
+		return 0, false, nil
+	}
+	if err := a.rlockBuilt(); err != nil {
+		return 0, false, err
+	}
+	defer a.mu.RUnlock()
+	if err := b.rlockBuilt(); err != nil {
+		return 0, false, err
+	}
+	defer b.mu.RUnlock()
+
+	if a.cfg.hashing != b.cfg.hashing || a.cfg.hmacKey != b.cfg.hmacKey {
+		return 0, false, ErrIncompatibleTrees
+	}
+
+	shared := min(a.leafCount(), b.leafCount())
+	// the shorter tree holds one node at its height, covering all it shares with the other
+	search := divergenceSearch{a: a, b: b, shared: shared}
+	index, found = search.first(min(a.height(), b.height()), 0)
+	for _, t := range []*MerkleTree{a, b} {
+		if m, ok := t.cfg.metrics.(NodeReadMetrics); ok && search.reads > 0 {
+			m.NodeRead(search.reads)
+		}
+	}
+
+	if !found && a.leafCount() != b.leafCount() {
+		return shared, true, nil
+	}
+	return index, found, nil
+}
+
+// The state of a FirstDivergence search, over the elements both trees hold.
+type divergenceSearch struct {
+	a, b   *MerkleTree
+	shared uint64
+	reads  int // nodes read from each tree
+}
+
+// Returns the first index below the node at level and index where the trees disagree.
+// Nodes reaching past the shared elements cover padding in one tree and elements in the
+// other, so they are searched child by child rather than compared.
+func (s *divergenceSearch) first(level int, index uint64) (uint64, bool) {
+	start := index << level
+	if start >= s.shared {
+		return 0, false
+	}
+	if start+1<<level <= s.shared {
+		s.reads++
+		if s.a.node(level, index) == s.b.node(level, index) {
+			return 0, false
+		}
+		if level == 0 {
+			return index, true
+		}
+	}
+	if diverged, found := s.first(level-1, 2*index); found {
+		return diverged, true
+	}
+	return s.first(level-1, 2*index+1)
+}
@@ -0,0 +1,93 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestFirstDivergence(t *testing.T) {
+	cases := []struct {
+		name      string
+		opts      []Option
+		a, b      int
+		diff      []int // indices at which b holds other elements
+		wantIndex uint64
+		wantFound bool
+	}{
+		{"identical", nil, 100, 100, nil, 0, false},
+		{"first element", nil, 100, 100, []int{0}, 0, true},
+		{"last element", nil, 100, 100, []int{99}, 99, true},
+		{"earliest of several", nil, 1000, 1000, []int{513, 700, 999}, 513, true},
+		{"a ends", nil, 10, 1000, nil, 10, true},
+		{"b ends", nil, 1000, 10, nil, 10, true},
+		{"difference before b ends", nil, 1000, 10, []int{4}, 4, true},
+		{"single elements", nil, 1, 1, []int{0}, 0, true},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}, 99, 101, []int{97}, 97, true},
+		{"fixed depth", []Option{WithFixedDepth(12)}, 300, 200, nil, 200, true},
+		{"different heights", nil, 16, 17, []int{15}, 15, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			elements := testElements(c.b)
+			for _, i := range c.diff {
+				elements[i] = fmt.Sprintf("changed %d", i)
+			}
+			a, err := NewMerkleTree(testElements(c.a), c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := NewMerkleTree(elements, c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			index, found, err := FirstDivergence(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if index != c.wantIndex || found != c.wantFound {
+				t.Errorf("got %d, %v, want %d, %v", index, found, c.wantIndex, c.wantFound)
+			}
+		})
+	}
+}
+
+func TestFirstDivergenceIncompatible(t *testing.T) {
+	a, _ := NewMerkleTree(testElements(8))
+	b, _ := NewMerkleTree(testElements(8), WithKeccak256())
+	if _, _, err := FirstDivergence(a, b); !errors.Is(err, ErrIncompatibleTrees) {
+		t.Errorf("got %v, want ErrIncompatibleTrees", err)
+	}
+	if _, found, err := FirstDivergence(a, a); found || err != nil {
+		t.Errorf("got %v, %v comparing a tree with itself, want false, nil", found, err)
+	}
+}
+
+func TestFirstDivergenceReadsLogarithmically(t *testing.T) {
+	const size = 1 << 20
+	elements := testElements(size)
+	var m CountingMetrics
+	a, _ := NewMerkleTree(elements, WithMetrics(&m))
+
+	index := uint64(rand.New(rand.NewSource(5)).Intn(size))
+	b, _ := NewMerkleTree(elements)
+	if err := b.UpdateElement(index, "changed"); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Reset()
+	got, found, err := FirstDivergence(a, b)
+	if err != nil || !found || got != index {
+		t.Fatalf("got %d, %v, %v, want %d, true, nil", got, found, err, index)
+	}
+	// the root, then two children per level at most
+	if limit := uint64(2*a.Height() + 1); m.NodeReads.Load() > limit {
+		t.Errorf("got %d node reads, want at most %d", m.NodeReads.Load(), limit)
+	}
+	if m.Hashes() != 0 {
+		t.Errorf("got %d hashes, want none", m.Hashes())
+	}
+}
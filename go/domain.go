@@ -0,0 +1,54 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+var ErrDomainMismatch = errors.New("merkletree: proof belongs to a different application tag")
+
+// Prefixed to the tag before hashing it into the fixed-length tag digest.
+const applicationTagLabel = "merkletree application tag\x00"
+
+// Separates the hashes of this tree from those of every deployment using another tag.
+// Every leaf and node hash is prefixed with the tag digest,
+//
+//	sha256(applicationTagLabel | tag) (32 bytes)
+//
+// so identical data under different tags commits to unrelated roots, and proofs carry
+// the tag so that verifying one under another tag fails with ErrDomainMismatch.
+// The empty tag, the default, hashes without any prefix.
+func WithApplicationTag(tag string) Option {
+	return func(cfg *config) {
+		cfg.setTag(tag)
+	}
+}
+
+// Returns the application tag of the tree the proof was generated from.
+func (p MerkleProof) Tag() string {
+	return p.tag
+}
+
+func (cfg *config) setTag(tag string) {
+	cfg.tag = tag
+	cfg.tagDigest = Hash{}
+	if tag != "" {
+		cfg.tagDigest = sha256.Sum256([]byte(applicationTagLabel + tag))
+	}
+}
+
+// Returns the configuration hashing under the given tag, for code working from a proof alone.
+func tagConfig(tag string) config {
+	var cfg config
+	cfg.setTag(tag)
+	return cfg
+}
+
+// Fails with ErrDomainMismatch unless the proof's tag is the configured one.
+func (cfg config) checkTag(tag string) error {
+	if tag != cfg.tag {
+		return fmt.Errorf("%w: proof tag %q, verifier tag %q", ErrDomainMismatch, tag, cfg.tag)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestApplicationTagSeparatesRoots(t *testing.T) {
+	elements := testElements(7)
+	untagged, _ := NewMerkleTree(elements)
+	plain, _ := NewMerkleTree(elements, WithApplicationTag(""))
+	if plain.GetRoot() != untagged.GetRoot() {
+		t.Errorf("got %s, want %s", plain.GetRoot(), untagged.GetRoot())
+	}
+
+	ledger, _ := NewMerkleTree(elements, WithApplicationTag("ledger"))
+	audit, _ := NewMerkleTree(elements, WithApplicationTag("audit"))
+	roots := map[string]string{"untagged": untagged.GetRoot(), "ledger": ledger.GetRoot(), "audit": audit.GetRoot()}
+	seen := make(map[string]string)
+	for name, root := range roots {
+		if other, ok := seen[root]; ok {
+			t.Errorf("%s and %s share the root %s", name, other, root)
+		}
+		seen[root] = name
+	}
+
+	built, _ := NewBuilder(7, WithApplicationTag("ledger")).Build(elements)
+	if built.GetRoot() != ledger.GetRoot() {
+		t.Errorf("got %s, want %s", built.GetRoot(), ledger.GetRoot())
+	}
+}
+
+func TestApplicationTagProofs(t *testing.T) {
+	ledger, _ := NewMerkleTree(testElements(5), WithApplicationTag("ledger"))
+	for i := uint64(0); i < ledger.LeafCount(); i++ {
+		testname := fmt.Sprintf("proof %d", i)
+		t.Run(testname, func(t *testing.T) {
+			proof, _ := ledger.GetProof(i)
+			if proof.Tag() != "ledger" {
+				t.Errorf("got tag %q, want %q", proof.Tag(), "ledger")
+			}
+			if err := VerifyProofWithReason(ledger.GetRoot(), proof, WithApplicationTag("ledger")); err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+			for _, opts := range [][]Option{nil, {WithApplicationTag("audit")}} {
+				if err := VerifyProofWithReason(ledger.GetRoot(), proof, opts...); !errors.Is(err, ErrDomainMismatch) {
+					t.Errorf("got %v, want %v", err, ErrDomainMismatch)
+				}
+				if VerifyProof(ledger.GetRoot(), proof, opts...) {
+					t.Error("verified under another tag")
+				}
+			}
+			if err := VerifyProofEpoch(ledger.GetRoot(), ledger.Epoch(), proof); !errors.Is(err, ErrDomainMismatch) {
+				t.Errorf("got %v, want %v", err, ErrDomainMismatch)
+			}
+		})
+	}
+
+	// relabelling a proof does not carry it across: it then fails as any proof of another root
+	proof, _ := ledger.GetProof(2)
+	proof.tag = "audit"
+	if err := VerifyProofWithReason(ledger.GetRoot(), proof, WithApplicationTag("audit")); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v", err, ErrInvalidProof)
+	}
+}
+
+func TestApplicationTagEncodings(t *testing.T) {
+	opts := []Option{WithApplicationTag("ledger")}
+	ledger, _ := NewMerkleTree(testElements(5), opts...)
+	proof, _ := ledger.GetProof(4)
+
+	encodings := []Encoding{EncodingBinary, EncodingJSON, EncodingBinaryCompressed, EncodingJSONCompressed}
+	for _, encoding := range encodings {
+		testname := fmt.Sprintf("encoding %d", encoding)
+		t.Run(testname, func(t *testing.T) {
+			data, err := EncodeProof(proof, encoding, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeProof(data, encoding, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Tag() != "ledger" {
+				t.Errorf("got tag %q, want %q", decoded.Tag(), "ledger")
+			}
+			if err := VerifyProofWithReason(ledger.GetRoot(), decoded, opts...); err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+		})
+	}
+
+	// an untagged proof sets no tag flag
+	untagged, _ := NewMerkleTree(testElements(5))
+	plain, _ := untagged.GetProof(4)
+	data, _ := plain.MarshalBinary()
+	if want := byte(proofBinaryVersion | proofBinaryAlgorithmFlag); data[0] != want {
+		t.Errorf("got version %#x, want %#x", data[0], want)
+	}
+	encoded, _ := json.Marshal(plain)
+	var fields map[string]any
+	json.Unmarshal(encoded, &fields)
+	if _, ok := fields["tag"]; ok {
+		t.Errorf("untagged proof encodes a tag: %s", encoded)
+	}
+
+	var truncated MerkleProof
+	data, _ = proof.MarshalBinary()
+	if err := truncated.UnmarshalBinary(data[:len(data)-3]); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
+
+func TestApplicationTagMultiProof(t *testing.T) {
+	opts := []Option{WithApplicationTag("ledger")}
+	ledger, _ := NewMerkleTree(testElements(6), opts...)
+	first, _ := ledger.GetProof(1)
+	second, _ := ledger.GetProof(4)
+
+	multi, err := CombineProofs([]MerkleProof{first, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := multi.MarshalBinary()
+	var decoded MultiProof
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyMultiProof(ledger.GetRoot(), decoded, opts...) {
+		t.Error("tagged multiproof failed to verify")
+	}
+	if VerifyMultiProof(ledger.GetRoot(), decoded) {
+		t.Error("tagged multiproof verified without its tag")
+	}
+
+	untagged, _ := NewMerkleTree(testElements(6))
+	other, _ := untagged.GetProof(4)
+	if _, err := CombineProofs([]MerkleProof{first, other}); !errors.Is(err, ErrInconsistentProofs) {
+		t.Errorf("got %v, want %v", err, ErrInconsistentProofs)
+	}
+}
+
+func TestApplicationTagPartialTreeAndNodes(t *testing.T) {
+	opts := []Option{WithApplicationTag("ledger")}
+	ledger, _ := NewMerkleTree(testElements(8), opts...)
+
+	partial, err := ledger.Extract([]uint64{0, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, _ := partial.MarshalBinary()
+	var decoded PartialTree
+	if err := decoded.UnmarshalBinary(encoded); err != nil {
+		t.Fatal(err)
+	}
+	proof, err := decoded.GetProof(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyProofWithReason(ledger.GetRoot(), proof, opts...); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+	added, _ := ledger.GetProof(3)
+	if err := decoded.AddLeaf(3, "element-3", added); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+
+	layout, data := ledger.ExportNodes()
+	if _, err := ImportNodes(layout, data); !errors.Is(err, ErrDomainMismatch) {
+		t.Errorf("got %v, want %v", err, ErrDomainMismatch)
+	}
+	imported, err := ImportNodes(layout, data, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.GetRoot() != ledger.GetRoot() {
+		t.Errorf("got %s, want %s", imported.GetRoot(), ledger.GetRoot())
+	}
+}
@@ -0,0 +1,135 @@
+package merkletree
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrGraphTooLarge = errors.New("merkletree: tree has too many nodes to render")
+
+const (
+	defaultDotMaxNodes   = 1024 // nodes WriteDOT renders at most by default, a tree of 512 leaves
+	defaultDotHashDigits = 8
+	dotValueLength       = 24 // bytes of an element shown in its leaf's label
+)
+
+// Configures WriteDOT. The zero value labels nodes with 8 hex digits of their hash and
+// renders trees of up to 1024 nodes.
+type DotOptions struct {
+	HashDigits int  // hex digits of each hash shown; 0 for 8, 64 or more for the whole digest
+	ShowValues bool // label leaves with their element too, cut to 24 bytes
+	MaxNodes   int  // most nodes rendered, padding included; 0 for 1024
+
+	Highlight      bool   // mark the path of HighlightIndex to the root and its siblings, as its proof carries them
+	HighlightIndex uint64 // leaf whose proof is highlighted
+}
+
+// Writes the tree to w as a Graphviz DOT digraph, for documenting and debugging small
+// trees: one node per tree node from the root down, labeled with its level, index and
+// truncated hash, and an edge to each child, labeled L or R. Padding nodes, which cover
+// no element, are dashed and grey; in ModeBitcoin the duplicated odd nodes are, and the
+// absent ones are left out. With Highlight set, the path from HighlightIndex to the root
+// is filled blue and the siblings its proof carries are filled gold.
+//
+// Fails with ErrGraphTooLarge, before writing anything, for a tree of more nodes than
+// opts.MaxNodes, and with ErrIndexOutOfBounds for a highlighted index beyond the elements.
+// Imported trees render without element values.
+func (t *MerkleTree) WriteDOT(w io.Writer, opts DotOptions) error {
+	if err := t.rlockBuilt(); err != nil {
+		return err
+	}
+	defer t.mu.RUnlock()
+
+	limit := uint64(defaultDotMaxNodes)
+	if opts.MaxNodes > 0 {
+		limit = uint64(opts.MaxNodes)
+	}
+	var nodes uint64
+	for level := t.height(); level >= 0; level-- {
+		if nodes += t.dotLevelWidth(level); nodes > limit {
+			return fmt.Errorf("%w: more than %d", ErrGraphTooLarge, limit)
+		}
+	}
+	if opts.Highlight && opts.HighlightIndex >= t.leafCount() {
+		return t.outOfBounds(opts.HighlightIndex)
+	}
+	digits := opts.HashDigits
+	if digits <= 0 {
+		digits = defaultDotHashDigits
+	}
+
+	out := bufio.NewWriter(w)
+	out.WriteString("digraph merkletree {\n\tnode [shape=box, fontname=monospace];\n")
+	for level := t.height(); level >= 0; level-- {
+		for index := uint64(0); index < t.dotLevelWidth(level); index++ {
+			hash := t.node(level, index).String()
+			label := fmt.Sprintf("L%d #%d\\n%s", level, index, hash[:min(digits, len(hash))])
+			if level == 0 && opts.ShowValues && index < uint64(len(t.elements)) {
+				label += "\\n" + dotValue(t.elements[index])
+			}
+
+			var styles, attrs []string
+			if index<<level >= t.leafCount() {
+				styles, attrs = append(styles, "dashed"), append(attrs, "color=grey", "fontcolor=grey")
+			}
+			if opts.Highlight {
+				switch path := opts.HighlightIndex >> level; index {
+				case path:
+					styles, attrs = append(styles, "filled"), append(attrs, "fillcolor=lightblue")
+				case path ^ 1:
+					styles, attrs = append(styles, "filled"), append(attrs, "fillcolor=gold")
+				}
+			}
+			if len(styles) > 0 {
+				attrs = append(attrs, `style="`+strings.Join(styles, ",")+`"`)
+			}
+			fmt.Fprintf(out, "\t%s [%s];\n", dotID(level, index), strings.Join(append([]string{`label="` + label + `"`}, attrs...), ", "))
+
+			// a duplicated odd node is a copy, not the parent of nodes drawn below it
+			if level == 0 || 2*index >= t.dotLevelWidth(level-1) {
+				continue
+			}
+			for child, side := range [...]string{"L", "R"} {
+				childIndex := 2*index + uint64(child)
+				edge := ""
+				if opts.Highlight && opts.HighlightIndex>>(level-1) == childIndex {
+					edge = ", penwidth=2"
+				}
+				fmt.Fprintf(out, "\t%s -> %s [label=%s%s];\n", dotID(level, index), dotID(level-1, childIndex), side, edge)
+			}
+		}
+	}
+	out.WriteString("}\n")
+	return out.Flush()
+}
+
+// Returns the number of nodes WriteDOT renders at the level: every position of the
+// padded tree, or in ModeBitcoin the stored nodes and any duplicated odd one.
+func (t *MerkleTree) dotLevelWidth(level int) uint64 {
+	if !t.cfg.duplicateOddNodes {
+		return t.paddedLeafCount() >> level
+	}
+	size := t.levelSize(level)
+	if size%2 == 1 && level < t.height() {
+		size++
+	}
+	return size
+}
+
+func dotID(level int, index uint64) string {
+	return "n" + strconv.Itoa(level) + "_" + strconv.FormatUint(index, 10)
+}
+
+// Returns the element as a quoted label line, cut short. Go's escapes are valid inside a
+// DOT string once the enclosing quotes are escaped too.
+func dotValue(element string) string {
+	if len(element) > dotValueLength {
+		element = element[:dotValueLength] + "..."
+	}
+	quoted := strconv.Quote(element)
+	return `\"` + quoted[1:len(quoted)-1] + `\"`
+}
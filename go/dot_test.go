@@ -0,0 +1,109 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	mt, _ := NewMerkleTree([]string{"a", "b", `say "hi"`, "d", "e"})
+	var out bytes.Buffer
+	if err := mt.WriteDOT(&out, DotOptions{ShowValues: true, Highlight: true, HighlightIndex: 2}); err != nil {
+		t.Fatal(err)
+	}
+	dot := out.String()
+	if !strings.HasPrefix(dot, "digraph merkletree {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("got %s", dot)
+	}
+	lines := strings.Split(dot, "\n")
+	nodes, edges := 0, 0
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, " -> "):
+			edges++
+		case strings.Contains(line, `[label="L`):
+			nodes++
+		}
+	}
+	if nodes != 15 || edges != 14 {
+		t.Errorf("got %d nodes and %d edges, want 15 and 14", nodes, edges)
+	}
+
+	root := mt.GetRoot()
+	node := func(id string) string {
+		for _, line := range lines {
+			if strings.HasPrefix(line, "\t"+id+" [") {
+				return line
+			}
+		}
+		t.Fatalf("no node %s in %s", id, dot)
+		return ""
+	}
+	if line := node("n3_0"); !strings.Contains(line, root[:8]) || strings.Contains(line, root[:9]) || !strings.Contains(line, "lightblue") {
+		t.Errorf("got root %s", line)
+	}
+	for _, id := range []string{"n2_0", "n1_1", "n0_2"} {
+		if !strings.Contains(node(id), "fillcolor=lightblue") {
+			t.Errorf("got %s, want it on the path", node(id))
+		}
+	}
+	for _, id := range []string{"n2_1", "n1_0", "n0_3"} {
+		if !strings.Contains(node(id), "fillcolor=gold") {
+			t.Errorf("got %s, want it a sibling", node(id))
+		}
+	}
+	for _, id := range []string{"n0_5", "n0_7", "n1_3"} {
+		if !strings.Contains(node(id), "dashed") {
+			t.Errorf("got %s, want it padding", node(id))
+		}
+	}
+	if line := node("n2_1"); !strings.Contains(line, `style="filled"`) {
+		t.Errorf("got %s, want the sibling covering element 4 solid", line)
+	}
+	if line := node("n0_2"); !strings.Contains(line, `\"say \"hi\"\"`) {
+		t.Errorf("got %s, want the escaped value", line)
+	}
+	if !strings.Contains(dot, "\tn1_1 -> n0_2 [label=L, penwidth=2];\n") || !strings.Contains(dot, "\tn1_1 -> n0_3 [label=R];\n") {
+		t.Errorf("got %s, want the path edges bold", dot)
+	}
+}
+
+func TestWriteDOTBitcoin(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5), WithMode(ModeBitcoin))
+	var out bytes.Buffer
+	if err := mt.WriteDOT(&out, DotOptions{HashDigits: 64}); err != nil {
+		t.Fatal(err)
+	}
+	// the duplicated odd nodes are drawn, the absent positions are not
+	if got := strings.Count(out.String(), "label=\"L"); got != 13 {
+		t.Errorf("got %d nodes, want 13", got)
+	}
+	if !strings.Contains(out.String(), mt.GetRoot()) || strings.Contains(out.String(), "n0_6") {
+		t.Errorf("got %s", out.String())
+	}
+}
+
+func TestWriteDOTLimits(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	var out bytes.Buffer
+	if err := mt.WriteDOT(&out, DotOptions{MaxNodes: 14}); !errors.Is(err, ErrGraphTooLarge) || out.Len() != 0 {
+		t.Errorf("got %v after writing %d bytes, want ErrGraphTooLarge before anything", err, out.Len())
+	}
+	if err := mt.WriteDOT(&out, DotOptions{MaxNodes: 15}); err != nil {
+		t.Errorf("got %v at the limit, want nil", err)
+	}
+	if err := mt.WriteDOT(&out, DotOptions{Highlight: true, HighlightIndex: 5}); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want ErrIndexOutOfBounds", err)
+	}
+
+	large, _ := NewMerkleTree(testElements(513))
+	if err := large.WriteDOT(&out, DotOptions{}); !errors.Is(err, ErrGraphTooLarge) {
+		t.Errorf("got %v for 2047 nodes, want ErrGraphTooLarge by default", err)
+	}
+	deep, _ := NewMerkleTree(testElements(2), WithFixedDepth(63))
+	if err := deep.WriteDOT(&out, DotOptions{MaxNodes: 1 << 40}); !errors.Is(err, ErrGraphTooLarge) {
+		t.Errorf("got %v at depth 63, want ErrGraphTooLarge", err)
+	}
+}
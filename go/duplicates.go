@@ -0,0 +1,159 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrDuplicateLeaf   = errors.New("merkletree: duplicate element")
+	ErrElementNotFound = errors.New("merkletree: element not found")
+)
+
+// Reports an element which appears at more than one index while duplicates are rejected.
+type DuplicateLeafError struct {
+	Element  string
+	Existing uint64 // index already holding the element
+	Index    uint64 // index at which the element was about to be placed
+}
+
+func (e *DuplicateLeafError) Error() string {
+	return fmt.Sprintf("%v: indices %d and %d hold the same element", ErrDuplicateLeaf, e.Existing, e.Index)
+}
+
+func (e *DuplicateLeafError) Unwrap() error {
+	return ErrDuplicateLeaf
+}
+
+// Makes NewMerkleTree and UpdateElement fail with a DuplicateLeafError
+// rather than commit to the same element at two indices.
+func WithRejectDuplicates() Option {
+	return func(cfg *config) {
+		cfg.rejectDuplicates = true
+	}
+}
+
+// Returns the lowest index holding the element.
+func (t *MerkleTree) IndexOf(element string) (uint64, bool) {
+	if t.rlockBuiltAsIs() != nil {
+		return 0, false
+	}
+	defer t.mu.RUnlock()
+
+	indices := t.indices[element]
+	if len(indices) == 0 {
+		return 0, false
+	}
+	return indices[0], true
+}
+
+// Generates the proof for the lowest index holding the element.
+// Trees holding no elements, such as those of NewMerkleTreeFromReaders, have nothing to
+// look it up in and fail with ErrElementsUnknown; prove their leaves by index.
+func (t *MerkleTree) GetProofByElement(element string) (MerkleProof, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return MerkleProof{}, err
+	}
+	defer t.mu.RUnlock()
+
+	if t.elements == nil {
+		return MerkleProof{}, ErrElementsUnknown
+	}
+	indices := t.indices[element]
+	if len(indices) == 0 {
+		return MerkleProof{}, fmt.Errorf("%w: %q", ErrElementNotFound, element)
+	}
+	return t.getProof(indices[0])
+}
+
+// Returns every index holding the element, in ascending order.
+func (t *MerkleTree) AllIndices(element string) []uint64 {
+	if t.rlockBuiltAsIs() != nil {
+		return nil
+	}
+	defer t.mu.RUnlock()
+	return append([]uint64(nil), t.indices[element]...)
+}
+
+// Builds the element to indices lookup, enforcing WithRejectDuplicates.
+// An existing lookup is refilled in place, keeping the storage of elements seen before.
+func (t *MerkleTree) indexElements(elements []string) error {
+	if t.indices == nil {
+		t.indices = make(map[string][]uint64, len(elements))
+	}
+	for element, indices := range t.indices {
+		t.indices[element] = indices[:0]
+	}
+
+	// elements new to the index get a slot of one shared array, capped so that a
+	// second index reallocates rather than overwrite its neighbour, as Builder does
+	var slab []uint64
+	for i, element := range elements {
+		existing, ok := t.indices[element]
+		if t.cfg.rejectDuplicates && len(existing) > 0 {
+			return &DuplicateLeafError{Element: element, Existing: existing[0], Index: uint64(i)}
+		}
+		if !ok {
+			if len(slab) == 0 {
+				slab = make([]uint64, len(elements)-i)
+			}
+			existing, slab = slab[:0:1], slab[1:]
+		}
+		t.indices[element] = append(existing, uint64(i))
+	}
+
+	for element, indices := range t.indices {
+		if len(indices) == 0 {
+			delete(t.indices, element)
+		}
+	}
+
+	return nil
+}
+
+// Checks that placing element at index would not create a rejected duplicate.
+func (t *MerkleTree) checkDuplicate(index uint64, element string) error {
+	if !t.cfg.rejectDuplicates {
+		return nil
+	}
+	for _, existing := range t.indices[element] {
+		if existing != index {
+			return &DuplicateLeafError{Element: element, Existing: existing, Index: index}
+		}
+	}
+	return nil
+}
+
+// Moves index from the lookup entry of the old element to that of the new one.
+func (t *MerkleTree) reindex(index uint64, old string, element string) {
+	if old == element {
+		return
+	}
+	t.removeIndex(old, index)
+	t.addIndex(element, index)
+}
+
+// Removes index from the element's sorted indices, dropping the entry once none are left.
+func (t *MerkleTree) removeIndex(element string, index uint64) {
+	indices := t.indices[element]
+	i := sort.Search(len(indices), func(i int) bool { return indices[i] >= index })
+	if i < len(indices) && indices[i] == index {
+		indices = append(indices[:i], indices[i+1:]...)
+	}
+	if len(indices) == 0 {
+		delete(t.indices, element)
+	} else {
+		t.indices[element] = indices
+	}
+}
+
+// Adds index to the element's sorted indices.
+func (t *MerkleTree) addIndex(element string, index uint64) {
+	indices := t.indices[element]
+	i := sort.Search(len(indices), func(i int) bool { return indices[i] >= index })
+	indices = append(indices, 0)
+	copy(indices[i+1:], indices[i:])
+	indices[i] = index
+	t.indices[element] = indices
+}
@@ -0,0 +1,90 @@
+package merkletree
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAllIndices(t *testing.T) {
+	mt, err := NewMerkleTree([]string{"a", "b", "a", "c", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := mt.AllIndices("a"), []uint64{0, 2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := mt.AllIndices("missing"); len(got) != 0 {
+		t.Errorf("got %v, want no indices", got)
+	}
+	if index, ok := mt.IndexOf("c"); !ok || index != 3 {
+		t.Errorf("got %d %t, want 3 true", index, ok)
+	}
+	if _, ok := mt.IndexOf("missing"); ok {
+		t.Error("found an element which was never committed")
+	}
+}
+
+func TestAllIndicesFollowsUpdates(t *testing.T) {
+	mt, _ := NewMerkleTree([]string{"a", "b", "a", "c"})
+
+	// removes a duplicate
+	if err := mt.UpdateElement(0, "d"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mt.AllIndices("a"), []uint64{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// creates a duplicate
+	if err := mt.UpdateElement(1, "c"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mt.AllIndices("c"), []uint64{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := mt.AllIndices("b"); len(got) != 0 {
+		t.Errorf("got %v, want no indices", got)
+	}
+
+	// the returned slice is a copy
+	mt.AllIndices("c")[0] = 99
+	if index, _ := mt.IndexOf("c"); index != 1 {
+		t.Errorf("got %d, want 1", index)
+	}
+}
+
+func TestRejectDuplicates(t *testing.T) {
+	_, err := NewMerkleTree([]string{"a", "b", "a"}, WithRejectDuplicates())
+
+	var dup *DuplicateLeafError
+	if !errors.As(err, &dup) || !errors.Is(err, ErrDuplicateLeaf) {
+		t.Fatalf("got %v, want %v", err, ErrDuplicateLeaf)
+	}
+	if dup.Existing != 0 || dup.Index != 2 {
+		t.Errorf("got indices %d and %d, want 0 and 2", dup.Existing, dup.Index)
+	}
+
+	mt, err := NewMerkleTree([]string{"a", "b", "c"}, WithRejectDuplicates())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := mt.GetRoot()
+
+	if err := mt.UpdateElement(2, "a"); !errors.Is(err, ErrDuplicateLeaf) {
+		t.Errorf("got %v, want %v", err, ErrDuplicateLeaf)
+	}
+	if mt.GetRoot() != root {
+		t.Error("rejected update modified the tree")
+	}
+	if err := mt.UpdateElement(0, "a"); err != nil {
+		t.Errorf("rewriting an element in place should not be a duplicate: %v", err)
+	}
+	if err := mt.UpdateElement(0, "d"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mt.UpdateElement(2, "a"); err != nil {
+		t.Errorf("element freed by an update should be usable: %v", err)
+	}
+}
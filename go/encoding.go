@@ -0,0 +1,569 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Selects a wire format for proofs.
+type Encoding int
+
+const (
+	EncodingBinary           Encoding = iota // versioned, raw digests with a direction bitmap
+	EncodingJSON                             // hex digests and boolean directions
+	EncodingBinaryCompressed                 // as EncodingBinary, omitting siblings which equal the padding hash for their level
+	EncodingJSONCompressed                   // as EncodingJSON, omitting siblings which equal the padding hash for their level
+)
+
+const (
+	digestSize                   = 32   // bytes in a sha256 digest
+	proofBinaryVersion           = 0x01 // leading byte of the binary proof format
+	proofBinaryCompressedVersion = 0x02 // leading byte of the compressed binary proof format
+	proofBinaryEpochFlag         = 0x80 // set in the leading byte when an epoch follows the depth
+	binaryTagFlag                = 0x40 // set in the leading byte when an application tag follows the depth and any epoch
+	proofBinaryAlgorithmFlag     = 0x20 // set in the leading byte when an algorithm follows any tag
+	proofBinaryDataFlag          = 0x10 // set in the leading byte when leaf data follows any algorithm
+	multiProofBinaryVersion      = 0x01 // leading byte of the binary multiproof format
+	maxProofDepth                = 256  // deepest proof the decoders accept
+)
+
+var ErrMalformedProof = errors.New("merkletree: malformed proof")
+
+type proofJSON struct {
+	Element       string   `json:"hElement"`
+	Siblings      []string `json:"siblings"`
+	Directions    []bool   `json:"directions"`
+	DefaultLevels []int    `json:"defaultLevels,omitempty"` // levels whose sibling is the padding hash and was omitted
+	Epoch         uint64   `json:"epoch,omitempty"`
+	Tag           string   `json:"tag,omitempty"`
+
+	Algorithm AlgorithmID `json:"algorithm,omitempty"` // unknown names fail to decode with ErrUnknownAlgorithm
+	Data      []byte      `json:"data,omitempty"`      // committed with the element, see leafdata.go
+}
+
+// Encodes the proof in the given wire format.
+// Compressed formats take the padding hash of each level from the same options as the tree.
+func EncodeProof(proof MerkleProof, encoding Encoding, opts ...Option) ([]byte, error) {
+	switch encoding {
+	case EncodingBinary:
+		return proof.MarshalBinary()
+	case EncodingJSON:
+		return proof.MarshalJSON()
+	case EncodingBinaryCompressed:
+		return proof.marshalBinary(proofBinaryCompressedVersion, newConfig(opts))
+	case EncodingJSONCompressed:
+		return proof.marshalJSON(true, newConfig(opts))
+	default:
+		return nil, fmt.Errorf("merkletree: unknown encoding %d", encoding)
+	}
+}
+
+// Decodes a proof from the given wire format, restoring any omitted padding siblings.
+func DecodeProof(data []byte, encoding Encoding, opts ...Option) (MerkleProof, error) {
+	var proof MerkleProof
+	var err error
+
+	switch encoding {
+	case EncodingBinary, EncodingBinaryCompressed:
+		err = proof.unmarshalBinary(data, newConfig(opts))
+	case EncodingJSON, EncodingJSONCompressed:
+		err = proof.unmarshalJSON(data, newConfig(opts))
+	default:
+		err = fmt.Errorf("merkletree: unknown encoding %d", encoding)
+	}
+
+	return proof, err
+}
+
+func (p MerkleProof) MarshalJSON() ([]byte, error) {
+	return p.marshalJSON(false, config{})
+}
+
+func (p MerkleProof) marshalJSON(compress bool, cfg config) ([]byte, error) {
+	raw := proofJSON{p.hElement, []string{}, p.directions, nil, p.epoch, p.tag, p.algorithm, p.data}
+	if raw.Directions == nil {
+		raw.Directions = []bool{}
+	}
+
+	var padding []string
+	if compress {
+		cfg.setTag(p.tag)
+		padding = cfg.paddingHashes(len(p.siblings))
+	}
+	for level, sibling := range p.siblings {
+		if compress && sibling == padding[level] {
+			raw.DefaultLevels = append(raw.DefaultLevels, level)
+		} else {
+			raw.Siblings = append(raw.Siblings, sibling)
+		}
+	}
+
+	return json.Marshal(raw)
+}
+
+func (p *MerkleProof) UnmarshalJSON(data []byte) error {
+	return p.unmarshalJSON(data, config{})
+}
+
+func (p *MerkleProof) unmarshalJSON(data []byte, cfg config) error {
+	var raw proofJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	depth := len(raw.Siblings) + len(raw.DefaultLevels)
+	if depth != len(raw.Directions) {
+		return fmt.Errorf("%w: %d siblings but %d directions", ErrMalformedProof, depth, len(raw.Directions))
+	}
+	if depth > maxProofDepth {
+		return fmt.Errorf("%w: depth %d exceeds %d", ErrMalformedProof, depth, maxProofDepth)
+	}
+
+	proof := MerkleProof{
+		hElement:   raw.Element,
+		siblings:   raw.Siblings,
+		directions: raw.Directions,
+		epoch:      raw.Epoch,
+		tag:        raw.Tag,
+		algorithm:  raw.Algorithm,
+	}
+	if len(raw.Data) > 0 {
+		proof.data = raw.Data
+	}
+
+	if len(raw.DefaultLevels) > 0 {
+		isDefault := make([]bool, depth)
+		for _, level := range raw.DefaultLevels {
+			if level < 0 || level >= depth || isDefault[level] {
+				return fmt.Errorf("%w: invalid default level %d", ErrMalformedProof, level)
+			}
+			isDefault[level] = true
+		}
+
+		cfg.setTag(raw.Tag)
+		padding := cfg.paddingHashes(depth)
+		proof.siblings = make([]string, depth)
+		explicit := raw.Siblings
+		for level := range proof.siblings {
+			if isDefault[level] {
+				proof.siblings[level] = padding[level]
+			} else {
+				proof.siblings[level], explicit = explicit[0], explicit[1:]
+			}
+		}
+	}
+
+	if err := proof.validateShape(); err != nil {
+		return err
+	}
+
+	*p = proof
+	return nil
+}
+
+// Encodes the proof as:
+//
+//	version (1 byte) | depth (uvarint) | [epoch (uvarint)] | [tag length (uvarint) | tag]
+//	| [algorithm (1 byte)] | [data length (uvarint) | data] | element digest | direction bitmap
+//	| sibling digests
+//
+// Proofs from a tree that has been mutated set proofBinaryEpochFlag in the version byte
+// and carry their epoch, so proofs from unmutated trees keep their original encoding.
+// Likewise proofs from a tree with an application tag set binaryTagFlag and carry the tag,
+// and proofs naming their algorithm, as every tree's do, set proofBinaryAlgorithmFlag.
+// Proofs of a leaf committed with data, see SetLeafData, set proofBinaryDataFlag.
+// Compressed encodings restore padding under the proof's own tag.
+// The direction bitmap holds one bit per level, least significant bit first.
+// The compressed version places a second bitmap before the siblings, marking the levels
+// whose sibling is present; siblings equal to the padding hash for their level are left out.
+func (p MerkleProof) MarshalBinary() ([]byte, error) {
+	return p.marshalBinary(proofBinaryVersion, config{})
+}
+
+func (p MerkleProof) marshalBinary(version byte, cfg config) ([]byte, error) {
+	raw, err := p.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return raw.marshalBinary(version, cfg)
+}
+
+func (p *MerkleProof) UnmarshalBinary(data []byte) error {
+	return p.unmarshalBinary(data, config{})
+}
+
+func (p *MerkleProof) unmarshalBinary(data []byte, cfg config) error {
+	var raw MerkleProofBytes
+	if err := raw.unmarshalBinary(data, cfg); err != nil {
+		return err
+	}
+	*p = raw.Proof()
+	return nil
+}
+
+// Encodes the proof in the format of MerkleProof.MarshalBinary, so either form decodes it.
+func (p MerkleProofBytes) MarshalBinary() ([]byte, error) {
+	return p.marshalBinary(proofBinaryVersion, config{})
+}
+
+func (p MerkleProofBytes) marshalBinary(version byte, cfg config) ([]byte, error) {
+	depth := len(p.Siblings)
+	if err := checkProofShape(depth, len(p.Directions)); err != nil {
+		return nil, err
+	}
+
+	compressed := version == proofBinaryCompressedVersion
+	out := make([]byte, 0, binaryProofSize(depth)+3*binary.MaxVarintLen64+len(p.Tag)+1+len(p.Data))
+	if p.Epoch != 0 {
+		version |= proofBinaryEpochFlag
+	}
+	if p.Tag != "" {
+		version |= binaryTagFlag
+	}
+	if p.Algorithm != AlgorithmUnspecified {
+		if err := p.Algorithm.check(); err != nil {
+			return nil, err
+		}
+		version |= proofBinaryAlgorithmFlag
+	}
+	if len(p.Data) > 0 {
+		version |= proofBinaryDataFlag
+	}
+	out = append(out, version)
+	out = binary.AppendUvarint(out, uint64(depth))
+	if p.Epoch != 0 {
+		out = binary.AppendUvarint(out, p.Epoch)
+	}
+	out = appendTag(out, p.Tag)
+	if p.Algorithm != AlgorithmUnspecified {
+		out = append(out, byte(p.Algorithm))
+	}
+	if len(p.Data) > 0 {
+		out = binary.AppendUvarint(out, uint64(len(p.Data)))
+		out = append(out, p.Data...)
+	}
+
+	out = append(out, p.Element[:]...)
+	out = append(out, packBits(p.Directions)...)
+
+	explicit := make([]bool, depth)
+	if compressed {
+		cfg.setTag(p.Tag)
+		padding := cfg.zeroHashes(depth)
+		for level, sibling := range p.Siblings {
+			explicit[level] = sibling != padding[level]
+		}
+		out = append(out, packBits(explicit)...)
+	}
+
+	for i, sibling := range p.Siblings {
+		if compressed && !explicit[i] {
+			continue
+		}
+		out = append(out, sibling[:]...)
+	}
+
+	return out, nil
+}
+
+func (p *MerkleProofBytes) UnmarshalBinary(data []byte) error {
+	return p.unmarshalBinary(data, config{})
+}
+
+func (p *MerkleProofBytes) unmarshalBinary(data []byte, cfg config) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: unsupported version", ErrMalformedProof)
+	}
+	version := data[0] &^ (proofBinaryEpochFlag | binaryTagFlag | proofBinaryAlgorithmFlag | proofBinaryDataFlag)
+	if version != proofBinaryVersion && version != proofBinaryCompressedVersion {
+		return fmt.Errorf("%w: unsupported version", ErrMalformedProof)
+	}
+	compressed := version == proofBinaryCompressedVersion
+
+	r := byteReader{data: data[1:]}
+	depth := int(r.uvarint(maxProofDepth))
+	var epoch uint64
+	if data[0]&proofBinaryEpochFlag != 0 {
+		if epoch = r.uvarint(^uint64(0)); epoch == 0 {
+			r.fail("epoch flag set for epoch 0")
+		}
+	}
+	tag := r.tag(data[0])
+	var algorithm AlgorithmID
+	if data[0]&proofBinaryAlgorithmFlag != 0 {
+		if algorithm = AlgorithmID(r.byte()); algorithm == AlgorithmUnspecified {
+			r.fail("algorithm flag set for no algorithm")
+		}
+	}
+	var leafData []byte
+	if data[0]&proofBinaryDataFlag != 0 {
+		if leafData = r.bytes(int(r.uvarint(uint64(len(r.data))))); len(leafData) == 0 {
+			r.fail("data flag set for no data")
+		}
+	}
+	element := r.digest()
+	directions := unpackBits(r.bytes((depth+7)/8), depth)
+
+	explicit := make([]bool, depth)
+	if compressed {
+		explicit = unpackBits(r.bytes((depth+7)/8), depth)
+	} else {
+		for level := range explicit {
+			explicit[level] = true
+		}
+	}
+
+	proof := MerkleProofBytes{
+		Element:    element,
+		Siblings:   make([]Hash, depth),
+		Directions: directions,
+		Epoch:      epoch,
+		Tag:        tag,
+		Algorithm:  algorithm,
+		Data:       slices.Clone(leafData),
+	}
+	var padding []Hash
+	if compressed {
+		cfg.setTag(tag)
+		padding = cfg.zeroHashes(depth)
+	}
+	for level := range proof.Siblings {
+		if explicit[level] {
+			proof.Siblings[level] = r.digest()
+		} else {
+			proof.Siblings[level] = padding[level]
+		}
+	}
+
+	if r.err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedProof, r.err)
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrMalformedProof, len(r.data))
+	}
+	if err := algorithm.check(); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedProof, err)
+	}
+
+	*p = proof
+	return nil
+}
+
+// Encodes the multiproof as:
+//
+//	version (1 byte) | depth (uvarint) | [tag length (uvarint) | tag] | [algorithm (1 byte)] | leaf count (uvarint) |
+//	indices (uvarint each) | leaf digests | sibling count (uvarint) | sibling digests
+//
+// The tag is present when binaryTagFlag is set in the version byte, and the algorithm
+// when proofBinaryAlgorithmFlag is.
+func (p MultiProof) MarshalBinary() ([]byte, error) {
+	if len(p.indices) != len(p.leaves) {
+		return nil, fmt.Errorf("%w: %d indices but %d leaves", ErrMalformedProof, len(p.indices), len(p.leaves))
+	}
+	if err := p.algorithm.check(); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, 2+4*binary.MaxVarintLen64+len(p.tag)+len(p.indices)*(binary.MaxVarintLen64+digestSize)+len(p.siblings)*digestSize)
+	version := byte(multiProofBinaryVersion)
+	if p.tag != "" {
+		version |= binaryTagFlag
+	}
+	if p.algorithm != AlgorithmUnspecified {
+		version |= proofBinaryAlgorithmFlag
+	}
+	out = append(out, version)
+	out = binary.AppendUvarint(out, uint64(p.depth))
+	out = appendTag(out, p.tag)
+	if p.algorithm != AlgorithmUnspecified {
+		out = append(out, byte(p.algorithm))
+	}
+	out = binary.AppendUvarint(out, uint64(len(p.indices)))
+	for _, index := range p.indices {
+		out = binary.AppendUvarint(out, index)
+	}
+
+	var err error
+	for i, leaf := range p.leaves {
+		if out, err = appendDigest(out, leaf); err != nil {
+			return nil, fmt.Errorf("%w: leaf %d: %v", ErrMalformedProof, i, err)
+		}
+	}
+	out = binary.AppendUvarint(out, uint64(len(p.siblings)))
+	for i, sibling := range p.siblings {
+		if out, err = appendDigest(out, sibling); err != nil {
+			return nil, fmt.Errorf("%w: sibling %d: %v", ErrMalformedProof, i, err)
+		}
+	}
+
+	return out, nil
+}
+
+func (p *MultiProof) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0]&^(binaryTagFlag|proofBinaryAlgorithmFlag) != multiProofBinaryVersion {
+		return fmt.Errorf("%w: unsupported version", ErrMalformedProof)
+	}
+
+	r := byteReader{data: data[1:]}
+	proof := MultiProof{depth: int(r.uvarint(maxProofDepth))}
+	proof.tag = r.tag(data[0])
+	if data[0]&proofBinaryAlgorithmFlag != 0 {
+		if proof.algorithm = AlgorithmID(r.byte()); proof.algorithm == AlgorithmUnspecified {
+			r.fail("algorithm flag set for no algorithm")
+		}
+	}
+
+	// every leaf takes at least a byte of index and a digest, which bounds the count by the input
+	count := r.uvarint(uint64(len(r.data)) / (1 + digestSize))
+	proof.indices = make([]uint64, count)
+	for i := range proof.indices {
+		proof.indices[i] = r.uvarint(^uint64(0))
+	}
+	proof.leaves = make([]string, count)
+	for i := range proof.leaves {
+		leaf := r.digest()
+		proof.leaves[i] = leaf.String()
+	}
+	proof.siblings = make([]string, r.uvarint(uint64(len(r.data))/digestSize))
+	for i := range proof.siblings {
+		sibling := r.digest()
+		proof.siblings[i] = sibling.String()
+	}
+
+	if r.err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedProof, r.err)
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrMalformedProof, len(r.data))
+	}
+	if err := proof.algorithm.check(); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedProof, err)
+	}
+
+	*p = proof
+	return nil
+}
+
+// Packs one bit per flag, least significant bit first.
+func packBits(flags []bool) []byte {
+	packed := make([]byte, (len(flags)+7)/8)
+	for i, set := range flags {
+		if set {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
+
+func unpackBits(packed []byte, n int) []bool {
+	flags := make([]bool, n)
+	for i := range flags {
+		if i/8 < len(packed) {
+			flags[i] = packed[i/8]&(1<<(i%8)) != 0
+		}
+	}
+	return flags
+}
+
+// Appends the tag, length prefixed as a uvarint, or nothing for the empty tag.
+func appendTag(out []byte, tag string) []byte {
+	if tag == "" {
+		return out
+	}
+	out = binary.AppendUvarint(out, uint64(len(tag)))
+	return append(out, tag...)
+}
+
+func appendDigest(out []byte, digest string) ([]byte, error) {
+	if len(digest) != 2*digestSize {
+		return nil, fmt.Errorf("expected %d hex characters, got %d", 2*digestSize, len(digest))
+	}
+	raw, err := hex.DecodeString(digest)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, raw...), nil
+}
+
+// Returns the exact size of a binary encoded proof with the given depth.
+func binaryProofSize(depth int) int {
+	var scratch [binary.MaxVarintLen64]byte
+	return 1 + binary.PutUvarint(scratch[:], uint64(depth)) + digestSize + (depth+7)/8 + depth*digestSize
+}
+
+// Reads the primitives of the binary formats, remembering the first error.
+type byteReader struct {
+	data []byte
+	err  error
+}
+
+func (r *byteReader) byte() byte {
+	if r.err != nil || len(r.data) == 0 {
+		r.fail("unexpected end of data")
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *byteReader) uvarint(max uint64) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail("invalid varint")
+		return 0
+	}
+	if v > max {
+		r.fail(fmt.Sprintf("value %d exceeds %d", v, max))
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *byteReader) bytes(n int) []byte {
+	if r.err != nil || len(r.data) < n {
+		r.fail("unexpected end of data")
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *byteReader) digest() Hash {
+	var h Hash
+	if r.err != nil || len(r.data) < digestSize {
+		r.fail("unexpected end of data")
+		return h
+	}
+	copy(h[:], r.data)
+	r.data = r.data[digestSize:]
+	return h
+}
+
+// Reads the application tag following binaryTagFlag in the leading byte, or none without it.
+// An empty tag is never encoded, so a flag followed by one is rejected.
+func (r *byteReader) tag(leading byte) string {
+	if leading&binaryTagFlag == 0 {
+		return ""
+	}
+	tag := r.bytes(int(r.uvarint(uint64(len(r.data)))))
+	if len(tag) == 0 {
+		r.fail("tag flag set for an empty tag")
+	}
+	return string(tag)
+}
+
+func (r *byteReader) fail(reason string) {
+	if r.err == nil {
+		r.err = errors.New(reason)
+	}
+}
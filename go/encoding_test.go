@@ -0,0 +1,225 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestProofEncodingRoundTrip(t *testing.T) {
+	mt, err := NewMerkleTree(testElements(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := uint64(0); i < 11; i++ {
+		proof, err := mt.GetProof(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testname := fmt.Sprintf("round trips proof for element: %d", i)
+		t.Run(testname, func(t *testing.T) {
+			encoded, err := proof.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fromBinary MerkleProof
+			if err := fromBinary.UnmarshalBinary(encoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(fromBinary, proof) {
+				t.Errorf("got %+v, want %+v", fromBinary, proof)
+			}
+
+			encoded, err = proof.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fromJSON MerkleProof
+			if err := fromJSON.UnmarshalJSON(encoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(fromJSON, proof) {
+				t.Errorf("got %+v, want %+v", fromJSON, proof)
+			}
+			if !VerifyProof(mt.GetRoot(), fromJSON) {
+				t.Error("invalid proof")
+			}
+		})
+	}
+}
+
+func TestProofEncodingRejectsMalformed(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(4))
+	proof, _ := mt.GetProof(1)
+	encoded, _ := proof.MarshalBinary()
+
+	cases := map[string][]byte{
+		"empty":     {},
+		"version":   append([]byte{0x7f}, encoded[1:]...),
+		"truncated": encoded[:len(encoded)-1],
+		"trailing":  append(append([]byte{}, encoded...), 0),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			var p MerkleProof
+			if err := p.UnmarshalBinary(data); !errors.Is(err, ErrMalformedProof) {
+				t.Errorf("got %v, want %v", err, ErrMalformedProof)
+			}
+		})
+	}
+
+	var p MerkleProof
+	if err := p.UnmarshalJSON([]byte(`{"hElement":"","siblings":["a"],"directions":[]}`)); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
+
+func TestCompressedEncodingRoundTrip(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+
+	for i := uint64(0); i < 5; i++ {
+		proof, _ := mt.GetProof(i)
+
+		for _, encoding := range []Encoding{EncodingBinaryCompressed, EncodingJSONCompressed} {
+			testname := fmt.Sprintf("round trips proof %d with encoding %d", i, encoding)
+			t.Run(testname, func(t *testing.T) {
+				encoded, err := EncodeProof(proof, encoding)
+				if err != nil {
+					t.Fatal(err)
+				}
+				decoded, err := DecodeProof(encoded, encoding)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(decoded, proof) {
+					t.Errorf("got %+v, want %+v", decoded, proof)
+				}
+				if !VerifyProof(mt.GetRoot(), decoded) {
+					t.Error("invalid proof")
+				}
+			})
+		}
+	}
+}
+
+func TestCompressedEncodingOfSparseProof(t *testing.T) {
+	const depth = 256
+	padding := config{}.paddingHashes(depth)
+
+	proof := MerkleProof{
+		hElement:   hashLeaf("leaf"),
+		siblings:   append([]string(nil), padding[:depth]...),
+		directions: make([]bool, depth),
+	}
+	for _, level := range []int{0, 17, 255} {
+		proof.siblings[level] = hashLeaf(fmt.Sprintf("sibling-%d", level))
+		proof.directions[level] = true
+	}
+
+	compressed, err := EncodeProof(proof, EncodingBinaryCompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, _ := EncodeProof(proof, EncodingBinary)
+
+	// version, depth, element, two bitmaps and three siblings
+	if want := 1 + 2 + 32 + 32 + 32 + 3*32; len(compressed) != want {
+		t.Errorf("got %d bytes, want %d", len(compressed), want)
+	}
+	if len(compressed) >= len(full)/30 {
+		t.Errorf("compressed %d bytes is not much smaller than %d", len(compressed), len(full))
+	}
+
+	for _, encoding := range []Encoding{EncodingBinaryCompressed, EncodingJSONCompressed} {
+		encoded, _ := EncodeProof(proof, encoding)
+		decoded, err := DecodeProof(encoded, encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, proof) {
+			t.Errorf("encoding %d: decoded proof differs", encoding)
+		}
+	}
+}
+
+func TestCompressedJSONRejectsBadDefaultLevels(t *testing.T) {
+	for _, raw := range []string{
+		`{"hElement":"","siblings":[],"directions":[false],"defaultLevels":[1]}`,
+		`{"hElement":"","siblings":[],"directions":[false,false],"defaultLevels":[0,0]}`,
+		`{"hElement":"","siblings":[],"directions":[false],"defaultLevels":[-1]}`,
+	} {
+		if _, err := DecodeProof([]byte(raw), EncodingJSONCompressed); !errors.Is(err, ErrMalformedProof) {
+			t.Errorf("%s: got %v, want %v", raw, err, ErrMalformedProof)
+		}
+	}
+}
+
+func TestMultiProofEncodingRoundTrip(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(13))
+
+	for _, indices := range [][]uint64{{0}, {0, 1}, {2, 11, 12}, {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}} {
+		testname := fmt.Sprintf("round trips multiproof for: %v", indices)
+		t.Run(testname, func(t *testing.T) {
+			proofs, _ := mt.GetProofs(indices)
+			var list []MerkleProof
+			for _, index := range indices {
+				list = append(list, proofs[index])
+			}
+			multi, err := CombineProofs(list)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			encoded, err := multi.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded MultiProof
+			if err := decoded.UnmarshalBinary(encoded); err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyMultiProof(mt.GetRoot(), decoded) {
+				t.Error("invalid multiproof")
+			}
+			if !reflect.DeepEqual(decoded.Indices(), indices) {
+				t.Errorf("got %v, want %v", decoded.Indices(), indices)
+			}
+			if decoded.Algorithm() != AlgorithmSHA256 {
+				t.Errorf("got algorithm %v, want %v", decoded.Algorithm(), AlgorithmSHA256)
+			}
+			reencoded, _ := decoded.MarshalBinary()
+			if !reflect.DeepEqual(reencoded, encoded) {
+				t.Error("re-encoding differs")
+			}
+		})
+	}
+}
+
+func TestMultiProofEncodingRejectsMalformed(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(8))
+	left, _ := mt.GetProof(2)
+	right, _ := mt.GetProof(5)
+	multi, _ := CombineProofs([]MerkleProof{left, right})
+	encoded, _ := multi.MarshalBinary()
+
+	cases := map[string][]byte{
+		"empty":        {},
+		"version":      append([]byte{0x7f}, encoded[1:]...),
+		"truncated":    encoded[:len(encoded)-1],
+		"trailing":     append(append([]byte{}, encoded...), 0),
+		"count":        {multiProofBinaryVersion, 3, 0xff, 0xff, 0xff, 0xff, 0x0f},
+		"algorithm":    append([]byte{encoded[0], encoded[1], 0x7f}, encoded[3:]...),
+		"no algorithm": append([]byte{encoded[0], encoded[1], 0}, encoded[3:]...),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			var p MultiProof
+			if err := p.UnmarshalBinary(data); !errors.Is(err, ErrMalformedProof) {
+				t.Errorf("got %v, want %v", err, ErrMalformedProof)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrStaleProof   = errors.New("merkletree: proof was generated at a different epoch")
+	ErrInvalidProof = errors.New("merkletree: proof does not match the root")
+)
+
+// Returns the number of mutations applied since the tree was built.
+// Proofs are stamped with the epoch they were generated at.
+func (t *MerkleTree) Epoch() uint64 {
+	if t.rlockBuiltAsIs() != nil {
+		return 0
+	}
+	defer t.mu.RUnlock()
+	return t.epoch
+}
+
+// Returns the epoch of the tree the proof was generated from.
+func (p MerkleProof) Epoch() uint64 {
+	return p.epoch
+}
+
+// Verifies a proof against the root of the tree at the given epoch.
+// A well-formed proof stamped with another epoch fails with ErrStaleProof rather than
+// ErrInvalidProof, so callers can tell an outdated proof from a forged one.
+func VerifyProofEpoch(root string, epoch uint64, proof MerkleProof, opts ...Option) error {
+	cfg := newConfig(opts)
+
+	err := cfg.verifyProofEpoch(root, epoch, proof)
+	if cfg.metrics != nil {
+		cfg.metrics.NodeHashed(len(proof.siblings))
+		cfg.metrics.ProofVerified(err == nil)
+	}
+	switch {
+	case errors.Is(err, ErrInvalidProof):
+		cfg.logVerifyFailure(root, proof, nil)
+	case err != nil:
+		cfg.logVerifyFailure(root, proof, err)
+	}
+
+	return err
+}
+
+func (cfg config) verifyProofEpoch(root string, epoch uint64, proof MerkleProof) error {
+	parsed, err := parseRoot(root)
+	if err != nil {
+		return err
+	}
+
+	proof, err = proof.normalized()
+	if err != nil {
+		return err
+	}
+	if err := cfg.checkTag(proof.tag); err != nil {
+		return err
+	}
+	if cfg, err = cfg.forAlgorithm(proof.algorithm); err != nil {
+		return err
+	}
+	if proof.epoch != epoch {
+		return fmt.Errorf("%w: proof epoch %d, tree epoch %d", ErrStaleProof, proof.epoch, epoch)
+	}
+	if cfg.foldProof(proof) != parsed.String() {
+		return ErrInvalidProof
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestEpochAdvancesOnMutation(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	if mt.Epoch() != 0 {
+		t.Errorf("got epoch %d, want 0", mt.Epoch())
+	}
+
+	for i := uint64(1); i <= 3; i++ {
+		if err := mt.UpdateElement(i, fmt.Sprintf("updated-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+		if mt.Epoch() != i {
+			t.Errorf("got epoch %d, want %d", mt.Epoch(), i)
+		}
+	}
+
+	// failed mutations leave the epoch alone
+	mt.UpdateElement(99, "out of bounds")
+	if mt.Epoch() != 3 {
+		t.Errorf("got epoch %d, want 3", mt.Epoch())
+	}
+
+	proof, _ := mt.GetProof(0)
+	proofs, _ := mt.GetProofs([]uint64{0, 4})
+	for _, p := range append([]MerkleProof{proof}, proofs[0], proofs[4]) {
+		if p.Epoch() != 3 {
+			t.Errorf("got proof epoch %d, want 3", p.Epoch())
+		}
+	}
+}
+
+func TestVerifyProofEpoch(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	proof, _ := mt.GetProof(2)
+
+	if err := VerifyProofEpoch(mt.GetRoot(), mt.Epoch(), proof); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+
+	mt.UpdateElement(4, "updated")
+
+	if err := VerifyProofEpoch(mt.GetRoot(), mt.Epoch(), proof); !errors.Is(err, ErrStaleProof) {
+		t.Errorf("got %v, want %v", err, ErrStaleProof)
+	}
+
+	fresh, _ := mt.GetProof(2)
+	if err := VerifyProofEpoch(mt.GetRoot(), mt.Epoch(), fresh); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+
+	forged := fresh
+	forged.hElement = hashLeaf("forged")
+	if err := VerifyProofEpoch(mt.GetRoot(), mt.Epoch(), forged); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v", err, ErrInvalidProof)
+	}
+
+	malformed := proof
+	malformed.directions = malformed.directions[1:]
+	if err := VerifyProofEpoch(mt.GetRoot(), mt.Epoch(), malformed); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+
+	if err := VerifyProofEpoch("not a root", mt.Epoch(), fresh); !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("got %v, want %v", err, ErrInvalidHash)
+	}
+}
+
+func TestEpochEncoding(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	unmutated, _ := mt.GetProof(2)
+	original, _ := unmutated.MarshalBinary()
+
+	for i := 0; i < 300; i++ {
+		mt.UpdateElement(4, fmt.Sprintf("updated-%d", i))
+	}
+	proof, _ := mt.GetProof(2)
+
+	for _, encoding := range []Encoding{EncodingBinary, EncodingJSON, EncodingBinaryCompressed, EncodingJSONCompressed} {
+		testname := fmt.Sprintf("round trips epoch with encoding: %d", encoding)
+		t.Run(testname, func(t *testing.T) {
+			encoded, err := EncodeProof(proof, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeProof(encoded, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, proof) {
+				t.Errorf("got %+v, want %+v", decoded, proof)
+			}
+			if err := VerifyProofEpoch(mt.GetRoot(), 300, decoded); err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+		})
+	}
+
+	// proofs from unmutated trees carry no epoch
+	if want := byte(proofBinaryVersion | proofBinaryAlgorithmFlag); original[0] != want {
+		t.Errorf("got version %#x, want %#x", original[0], want)
+	}
+	stamped, _ := proof.MarshalBinary()
+	if want := original[0] | proofBinaryEpochFlag; stamped[0] != want || len(stamped) != len(original)+2 {
+		t.Errorf("got version %#x and %d bytes, want %#x and %d", stamped[0], len(stamped), want, len(original)+2)
+	}
+
+	// a flagged epoch of zero has a shorter encoding, so is rejected
+	flagged := append([]byte{original[0] | proofBinaryEpochFlag, original[1], 0}, original[2:]...)
+	var p MerkleProof
+	if err := p.UnmarshalBinary(flagged); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
@@ -0,0 +1,95 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"math/bits"
+)
+
+const (
+	stringHeaderSize = 16 // bytes in a string header on 64-bit platforms
+	sliceHeaderSize  = 24 // bytes in a slice header on 64-bit platforms
+	indexEntrySize   = 96 // approximate bytes per distinct element in the index lookup, including map overhead
+	offsetSize       = 8  // bytes per level offset into the node array
+)
+
+// Estimates the encoded size in bytes of a proof for any leaf of a tree with leafCount elements,
+// naming its algorithm as proofs of every tree do. JSON estimates assume every direction
+// encodes as "false" and the longest algorithm name, and compressed estimates assume no
+// sibling can be omitted, so both are upper bounds. Proofs of leaves committed with data,
+// see SetLeafData, also carry it, which the estimate leaves out.
+func EstimateProofSize(leafCount uint64, encoding Encoding) int {
+	depth := treeHeight(leafCount)
+
+	switch encoding {
+	case EncodingJSON:
+		skeleton, _ := json.Marshal(proofJSON{Siblings: []string{}, Directions: []bool{}, Algorithm: AlgorithmSHA512_256})
+		separators := 0
+		if depth > 0 {
+			separators = 2 * (depth - 1)
+		}
+		return len(skeleton) + 2*digestSize + depth*(2*digestSize+2) + depth*len("false") + separators
+	case EncodingBinaryCompressed:
+		return binaryProofSize(depth) + 1 + (depth+7)/8
+	case EncodingJSONCompressed:
+		return EstimateProofSize(leafCount, EncodingJSON)
+	default:
+		return binaryProofSize(depth) + 1 // the algorithm byte
+	}
+}
+
+// Estimates the bytes a tree over leafCount distinct elements holds in node storage,
+// element headers and the index lookup.
+// The contents of the elements themselves are not known up front and are excluded.
+func EstimateTreeMemory(leafCount uint64, opts ...Option) uint64 {
+	if leafCount == 0 {
+		return 0
+	}
+
+	height := newConfig(opts).height(leafCount)
+
+	// each level stores the nodes covering elements, plus one padding hash per level
+	nodes := leafCount
+	for stored, level := leafCount, 0; level < height; level++ {
+		stored = (stored + 1) / 2
+		nodes += stored
+	}
+	if leafCount < uint64(1)<<height {
+		nodes += uint64(height + 1)
+	}
+
+	return nodes*digestSize +
+		2*sliceHeaderSize + uint64(height+2)*offsetSize +
+		leafCount*(stringHeaderSize+indexEntrySize)
+}
+
+// Measures the bytes held by the tree's node storage and elements, including element contents.
+// The index lookup's share is approximated from its number of entries.
+func (t *MerkleTree) MemoryFootprint() uint64 {
+	if t.rlockBuiltAsIs() != nil {
+		return 0
+	}
+	defer t.mu.RUnlock()
+
+	var total uint64
+
+	total += sliceHeaderSize + uint64(len(t.nodes))*digestSize
+	total += sliceHeaderSize + uint64(len(t.offsets))*offsetSize
+
+	for _, element := range t.elements {
+		total += stringHeaderSize + uint64(len(element))
+	}
+
+	total += uint64(len(t.indices)) * indexEntrySize
+	total += uint64(len(t.zero)) * digestSize
+	total += uint64(cap(t.dirty)) * offsetSize
+
+	return total
+}
+
+// Returns the height of the smallest perfect binary tree with at least leafCount leaves.
+func treeHeight(leafCount uint64) int {
+	if leafCount <= 1 {
+		return 0
+	}
+	return bits.Len64(leafCount - 1)
+}
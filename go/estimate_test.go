@@ -0,0 +1,103 @@
+package merkletree
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func TestEstimateProofSize(t *testing.T) {
+	for _, size := range []int{1, 2, 3, 8, 100, 1025} {
+		mt, err := NewMerkleTree(testElements(size))
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, err := mt.GetProof(uint64(size - 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testname := fmt.Sprintf("binary estimate is exact for %d elements", size)
+		t.Run(testname, func(t *testing.T) {
+			encoded, err := proof.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := EstimateProofSize(uint64(size), EncodingBinary); got != len(encoded) {
+				t.Errorf("got %d, want %d", got, len(encoded))
+			}
+		})
+
+		testname = fmt.Sprintf("json estimate bounds %d elements", size)
+		t.Run(testname, func(t *testing.T) {
+			encoded, err := proof.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := EstimateProofSize(uint64(size), EncodingJSON)
+			if got < len(encoded) || float64(got) > 1.1*float64(len(encoded)) {
+				t.Errorf("got %d, want within 10%% above %d", got, len(encoded))
+			}
+		})
+	}
+}
+
+func TestEstimateTreeMemory(t *testing.T) {
+	for _, size := range []int{1, 3, 64, 1000} {
+		testname := fmt.Sprintf("estimate matches footprint for %d elements", size)
+		t.Run(testname, func(t *testing.T) {
+			elements := testElements(size)
+			mt, err := NewMerkleTree(elements)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var contents uint64
+			for _, element := range elements {
+				contents += uint64(len(element))
+			}
+
+			want := mt.MemoryFootprint() - contents
+			if got := EstimateTreeMemory(uint64(size)); got != want {
+				t.Errorf("got %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestMemoryFootprintMatchesHeap(t *testing.T) {
+	elements := testElements(1 << 14)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	mt, err := NewMerkleTree(elements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	var contents uint64
+	for _, element := range elements {
+		contents += uint64(len(element))
+	}
+
+	// element contents are shared with the caller, so they are not part of the heap growth
+	footprint := float64(mt.MemoryFootprint() - contents)
+	measured := float64(after.HeapAlloc - before.HeapAlloc)
+	if measured < 0.9*footprint || measured > 1.1*footprint {
+		t.Errorf("measured %.0f bytes, footprint reports %.0f", measured, footprint)
+	}
+	runtime.KeepAlive(mt)
+}
+
+func testElements(n int) []string {
+	elements := make([]string, n)
+	for i := range elements {
+		elements[i] = fmt.Sprintf("element-%d", i)
+	}
+	return elements
+}
@@ -0,0 +1,81 @@
+package merkletree
+
+// An immutable snapshot of a tree, taken by Freeze. Mutations of the tree it was taken
+// from never reach it, so proofs of a FrozenTree stay valid against its root for as
+// long as it is held. A FrozenTree is safe for concurrent use without any locking by
+// its callers, and is passed by value. The zero FrozenTree behaves as a tree no
+// constructor built.
+type FrozenTree struct {
+	t *MerkleTree // never mutated, nor locked, once frozen
+}
+
+// Returns an immutable snapshot of the tree as it is now, for handing to goroutines
+// which must not see it change under them. Freezing copies nothing: the snapshot shares
+// the tree's state, and the tree copies it at its next mutation, once per Freeze, so
+// the cost of a copy falls on the writer and only when it mutates.
+// Under WithLazyRecompute, pending ancestors are recomputed first.
+func (t *MerkleTree) Freeze() FrozenTree {
+	if t.lockBuilt() != nil {
+		return FrozenTree{}
+	}
+	defer t.mu.Unlock()
+
+	// every node the snapshot can read is computed now, as nothing may write it later
+	t.recomputeDirty()
+	t.zeroHashes()
+	f := &MerkleTree{
+		cfg:      t.cfg,
+		elements: t.elements,
+		indices:  t.indices,
+		data:     t.data,
+		keys:     t.keys,
+		nodes:    t.nodes,
+		count:    t.count,
+		offsets:  t.offsets,
+		zero:     t.zero,
+		epoch:    t.epoch,
+		workers:  t.workers,
+		bloom:    t.bloom,
+	}
+	f.cfg.journal = nil
+	t.shared = true
+	return FrozenTree{t: f}
+}
+
+// Replaces the state shared with a FrozenTree by a copy of its own, before a mutation.
+func (t *MerkleTree) unshare() {
+	t.adopt(t.copyState())
+	t.shared = false
+}
+
+// Returns the root as hex, or the empty string for the zero FrozenTree.
+func (f FrozenTree) GetRoot() string {
+	if f.t == nil {
+		return ""
+	}
+	return f.t.rootHash().String()
+}
+
+// Generates the proof of the element at index, as MerkleTree.GetProof does.
+func (f FrozenTree) GetProof(index uint64) (MerkleProof, error) {
+	if f.t == nil {
+		return MerkleProof{}, ErrUninitializedTree
+	}
+	return f.t.getProof(index)
+}
+
+// Returns the hash of the node at the given coordinate, as MerkleTree.NodeAt does.
+func (f FrozenTree) NodeAt(coord NodeCoord) (string, error) {
+	if f.t == nil {
+		return "", ErrUninitializedTree
+	}
+	return f.t.nodeAt(coord)
+}
+
+// Returns a summary of the snapshot's shape, as MerkleTree.Stats does.
+func (f FrozenTree) Stats() Stats {
+	if f.t == nil {
+		return Stats{}
+	}
+	return f.t.stats()
+}
@@ -0,0 +1,104 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestFrozenTreeUnderConcurrentMutation(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"eager": nil,
+		"lazy":  {WithLazyRecompute(), WithProofCache(8)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(100), opts...)
+			mt.UpdateElement(3, "pending")
+			frozen := mt.Freeze()
+			root := mt.GetRoot()
+			if frozen.GetRoot() != root {
+				t.Fatalf("got frozen root %s, want %s", frozen.GetRoot(), root)
+			}
+			proofs := make([]MerkleProof, 100)
+			for i := range proofs {
+				proofs[i], _ = mt.GetProof(uint64(i))
+			}
+
+			var wg sync.WaitGroup
+			for r := 0; r < 8; r++ {
+				wg.Add(1)
+				go func(r int) {
+					defer wg.Done()
+					for i := 0; i < 300; i++ {
+						index := uint64(r*31+i) % 100
+						proof, err := frozen.GetProof(index)
+						if err != nil || !reflect.DeepEqual(proof, proofs[index]) || !VerifyProof(frozen.GetRoot(), proof) {
+							t.Errorf("got proof of index %d changed in the snapshot: %v", index, err)
+							return
+						}
+						if node, _ := frozen.NodeAt(NodeCoord{Level: 0, Index: index}); node != proof.hElement {
+							t.Errorf("got leaf %s at index %d, proof has %s", node, index, proof.hElement)
+							return
+						}
+						if frozen.Stats().LeafCount != 100 {
+							t.Error("got the snapshot's leaf count changed")
+							return
+						}
+					}
+				}(r)
+			}
+			for i := 0; i < 300; i++ {
+				switch i % 3 {
+				case 0:
+					mt.UpdateElement(uint64(i%100), fmt.Sprintf("updated-%d", i))
+				case 1:
+					mt.Append(fmt.Sprintf("appended-%d", i))
+				default:
+					mt.GetProof(uint64(i % 100))
+				}
+				if i == 150 {
+					// freezing again shares the state once more, copied at the next mutation
+					again := mt.Freeze()
+					if again.GetRoot() != mt.GetRoot() {
+						t.Error("got a second snapshot of another root")
+					}
+				}
+			}
+			wg.Wait()
+
+			if frozen.GetRoot() != root || mt.GetRoot() == root {
+				t.Error("got the snapshot following the live tree")
+			}
+		})
+	}
+}
+
+func TestFrozenTreeZeroValue(t *testing.T) {
+	var frozen FrozenTree
+	if _, err := frozen.GetProof(0); !errors.Is(err, ErrUninitializedTree) {
+		t.Errorf("got %v, want %v", err, ErrUninitializedTree)
+	}
+	if _, err := frozen.NodeAt(NodeCoord{}); !errors.Is(err, ErrUninitializedTree) {
+		t.Errorf("got %v, want %v", err, ErrUninitializedTree)
+	}
+	if frozen.GetRoot() != "" || frozen.Stats() != (Stats{}) {
+		t.Error("got a root or stats from the zero FrozenTree")
+	}
+	var uninitialized MerkleTree
+	if uninitialized.Freeze() != (FrozenTree{}) {
+		t.Error("got a snapshot of a tree no constructor built")
+	}
+
+	mt, _ := NewMerkleTree(testElements(5))
+	frozen = mt.Freeze()
+	if _, err := frozen.GetProof(5); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	// a failed mutation copies the state too, leaving the snapshot as it was
+	mt.UpdateElement(9, "beyond")
+	if frozen.GetRoot() != mt.GetRoot() {
+		t.Error("got the roots apart without a mutation applied")
+	}
+}
@@ -0,0 +1,292 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"merkletree/merklecore"
+)
+
+var (
+	ErrInvalidHash  = errors.New("merkletree: invalid hash")
+	ErrHashConflict = errors.New("merkletree: option cannot be combined with the hash function")
+)
+
+// Reports a digest supplied for verification that is not a valid hash.
+type InvalidDigestError struct {
+	Field string // "root", "element" for the proof's element hash, "leaf" for a multiproof's, or "sibling"
+	Index int    // level of the sibling, counted from the leaves, or position of a multiproof's leaf or sibling; zero for other fields
+	Err   error  // the ParseHash error, wrapping ErrInvalidHash
+}
+
+func (e *InvalidDigestError) Error() string {
+	if e.Field == "sibling" || e.Field == "leaf" {
+		return fmt.Sprintf("%v (in %s %d)", e.Err, e.Field, e.Index)
+	}
+	return fmt.Sprintf("%v (in %s)", e.Err, e.Field)
+}
+
+func (e *InvalidDigestError) Unwrap() error {
+	return e.Err
+}
+
+// A digest of a leaf or node.
+type Hash [digestSize]byte
+
+// Parses a hex encoded digest, accepting either case and an optional 0x prefix.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+
+	digits := s
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		digits = digits[2:]
+		if digits == "" {
+			return h, fmt.Errorf("%w: prefix: no hex digits follow 0x", ErrInvalidHash)
+		}
+		if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+			return h, fmt.Errorf("%w: prefix: 0x appears twice", ErrInvalidHash)
+		}
+	}
+
+	for i, r := range digits {
+		if !isHexDigit(r) {
+			return h, fmt.Errorf("%w: character: %q at offset %d is not a hex digit", ErrInvalidHash, r, len(s)-len(digits)+i)
+		}
+	}
+	if len(digits) != 2*digestSize {
+		return h, fmt.Errorf("%w: length: got %d hex digits, want %d", ErrInvalidHash, len(digits), 2*digestSize)
+	}
+
+	hex.Decode(h[:], []byte(digits))
+	return h, nil
+}
+
+// Parses a root supplied for verification, reporting a failure as an *InvalidDigestError.
+func parseRoot(root string) (Hash, error) {
+	h, err := ParseHash(root)
+	if err != nil {
+		return h, &InvalidDigestError{Field: "root", Err: err}
+	}
+	return h, nil
+}
+
+// Returns the digest in canonical form, without reallocating one that already is.
+func canonicalDigest(s string) (string, error) {
+	if len(s) == 2*digestSize && isCanonicalHex(s) {
+		return s, nil
+	}
+	h, err := ParseHash(s)
+	if err != nil {
+		return "", err
+	}
+	return h.String(), nil
+}
+
+// Returns the canonical lowercase hex encoding, without prefix.
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// Reports whether every byte of the digest is zero.
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+func (h Hash) Equal(other Hash) bool {
+	return h == other
+}
+
+func isCanonicalHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9') && !('a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexDigit(r rune) bool {
+	return ('0' <= r && r <= '9') || ('a' <= r && r <= 'f') || ('A' <= r && r <= 'F')
+}
+
+// Hashes an element into a leaf digest, as merklecore does.
+func leafDigest(leaf string) Hash {
+	return merklecore.LeafDigest(leaf)
+}
+
+// Hashes two child digests into their parent, over their hex encodings as hashNode does.
+func nodeDigest(left Hash, right Hash) Hash {
+	return merklecore.NodeDigest(left, right)
+}
+
+// Longest element a leaf digest is computed for without allocating.
+const shortLeaf = 256
+
+// Hashes an element into a leaf digest under the configured tag and hashing.
+// Under ModeSSZ an element of up to a chunk is its own leaf, while a longer one,
+// which trees reject, is hashed so that verifying it cannot match a truncation.
+func (cfg config) leafDigest(leaf string) Hash {
+	if cfg.backend != nil {
+		return cfg.backend.leaf(leaf)
+	}
+	if cfg.chunkLeaves && len(leaf) <= digestSize {
+		var chunk Hash
+		copy(chunk[:], leaf)
+		return chunk
+	}
+	if cfg.tag == "" && !cfg.rfc6962 && cfg.leafHash == HashSHA256 && !cfg.doubleHashLeaves && !cfg.keyed {
+		return leafDigest(leaf)
+	}
+	// assembled on the stack for most elements, where a hash.Hash would allocate per leaf
+	var buf [digestSize + 1 + shortLeaf]byte
+	data := buf[:0]
+	if len(leaf) > shortLeaf {
+		data = make([]byte, 0, digestSize+1+len(leaf))
+	}
+	if cfg.tag != "" {
+		data = append(data, cfg.tagDigest[:]...)
+	}
+	if cfg.rfc6962 {
+		data = append(data, rfc6962LeafPrefix)
+	}
+	data = append(data, leaf...)
+
+	digest := cfg.digest(cfg.leafHash, hmacLeafInfo, data)
+	if cfg.doubleHashLeaves {
+		digest = cfg.digest(cfg.leafHash, hmacLeafInfo, digest[:])
+	}
+	return digest
+}
+
+// Hashes two child digests into their parent under the configured tag and hashing.
+func (cfg config) nodeDigest(left Hash, right Hash) Hash {
+	return cfg.nodeDigestWith(nil, left, right)
+}
+
+// Hashes two child digests into their parent as nodeDigest does, keyed hashing going
+// through mac rather than allocating its own state when mac is not nil.
+func (cfg config) nodeDigestWith(mac *macScratch, left Hash, right Hash) Hash {
+	if cfg.sortedPairs && bytes.Compare(left[:], right[:]) > 0 {
+		left, right = right, left
+	}
+	if cfg.backend != nil {
+		return cfg.backend.node(left, right)
+	}
+	if cfg.defaultNodes() {
+		return nodeDigest(left, right)
+	}
+
+	var buf [digestSize + 1 + 4*digestSize]byte
+	n := 0
+	if cfg.tag != "" {
+		n += copy(buf[n:], cfg.tagDigest[:])
+	}
+	if cfg.rfc6962 {
+		buf[n] = rfc6962NodePrefix
+		n++
+	}
+	if cfg.rawNodes() {
+		n += copy(buf[n:], left[:])
+		n += copy(buf[n:], right[:])
+	} else {
+		hex.Encode(buf[n:], left[:])
+		hex.Encode(buf[n+2*digestSize:], right[:])
+		n += 4 * digestSize
+	}
+	digest := cfg.digestWith(mac, cfg.nodeHash, hmacNodeInfo, buf[:n])
+	if cfg.doubleHashNodes {
+		digest = cfg.digestWith(mac, cfg.nodeHash, hmacNodeInfo, digest[:])
+	}
+	return digest
+}
+
+// Reports whether nodes hash as nodeDigest does, with no option changing how.
+func (cfg config) defaultNodes() bool {
+	return cfg.tag == "" && !cfg.rawNodes() && cfg.nodeHash == HashSHA256 && !cfg.doubleHashNodes && !cfg.keyed && cfg.backend == nil
+}
+
+// Reports whether nodes hash over the raw bytes of their children rather than their hex.
+func (cfg config) rawNodes() bool {
+	return cfg.rawNodeHashing || cfg.rfc6962
+}
+
+// Hashes data with f, or under WithHMACKey authenticates info followed by data with an
+// HMAC over f, info telling leaves and nodes apart.
+func (cfg config) digest(f HashFunction, info string, data []byte) Hash {
+	if cfg.keyed {
+		// copied, as handing data to the HMAC would move every caller's buffer to the heap
+		return cfg.hmac(f, info+string(data))
+	}
+	return f.sum(data)
+}
+
+// Returns the digest of data as digest does, keyed hashing with f going through mac
+// when it is not nil and computes f.
+func (cfg config) digestWith(mac *macScratch, f HashFunction, info string, data []byte) Hash {
+	if cfg.keyed && mac != nil && mac.mac != nil && mac.f == f {
+		return mac.sum(info, data)
+	}
+	return cfg.digest(f, info, data)
+}
+
+// Computes leaf and node digests in place of the hash functions over bytes, which every
+// other hashing option configures. A backend may read elements and children as field
+// elements, say, failing for elements it cannot take. Instances must be comparable, and
+// equal exactly when they hash alike, as configurations are compared.
+type hashBackend interface {
+	name() string // as Stats reports it
+	algorithm() AlgorithmID
+	check() error // fails for parameters the backend cannot hash under
+	checkElement(element string) error
+	leaf(element string) Hash // digest of an element, which checkElement may have refused
+	node(left Hash, right Hash) Hash
+}
+
+// Fails for an invalid hash backend, for options it cannot be combined with, and for a
+// padding element it cannot take.
+func (cfg config) checkBackend() error {
+	if cfg.backend == nil {
+		return nil
+	}
+	if err := cfg.backend.check(); err != nil {
+		return err
+	}
+	conflict := ""
+	switch {
+	case cfg.tag != "":
+		conflict = "an application tag"
+	case cfg.rfc6962:
+		conflict = "RFC 6962 hashing"
+	case cfg.rawNodeHashing:
+		conflict = "raw node hashing"
+	case cfg.doubleHashLeaves || cfg.doubleHashNodes:
+		conflict = "double hashing"
+	case cfg.keyed:
+		conflict = "an HMAC key"
+	}
+	if conflict != "" {
+		return fmt.Errorf("%w: %s under %s hashing", ErrHashConflict, conflict, cfg.backend.name())
+	}
+	if err := cfg.backend.checkElement(cfg.emptyLeaf); err != nil {
+		return fmt.Errorf("padding element: %w", err)
+	}
+	return nil
+}
+
+func (cfg config) hashLeaf(leaf string) string {
+	return cfg.leafDigest(leaf).String()
+}
+
+// Hashes two hex digests into their parent under the configured tag and hashing, as hashNode does.
+// The digests must be canonical, as the tree and normalized proofs keep them.
+func (cfg config) hashNode(a string, b string) string {
+	if cfg.defaultNodes() && !cfg.sortedPairs {
+		return hashNode(a, b)
+	}
+	left, _ := ParseHash(a)
+	right, _ := ParseHash(b)
+	return cfg.nodeDigest(left, right).String()
+}
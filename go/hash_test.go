@@ -0,0 +1,115 @@
+package merkletree
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseHash(t *testing.T) {
+	canonical := hashLeaf("some")
+
+	for _, input := range []string{canonical, strings.ToUpper(canonical), "0x" + canonical, "0X" + strings.ToUpper(canonical)} {
+		h, err := ParseHash(input)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		if h.String() != canonical {
+			t.Errorf("got %s, want %s", h.String(), canonical)
+		}
+		if !h.Equal(leafDigest("some")) {
+			t.Errorf("%s does not equal the leaf digest", input)
+		}
+	}
+}
+
+func TestParseHashErrors(t *testing.T) {
+	canonical := hashLeaf("some")
+
+	cases := map[string]string{
+		"0x":                                  "prefix",
+		"0x0x" + canonical:                    "prefix",
+		canonical[:62]:                        "length",
+		canonical + "00":                      "length",
+		"":                                    "length",
+		"g" + canonical[1:]:                   "character",
+		canonical[:10] + "é" + canonical[12:]: "character",
+	}
+	for input, problem := range cases {
+		_, err := ParseHash(input)
+		if !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("%q: got %v, want %v", input, err, ErrInvalidHash)
+			continue
+		}
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("%q: error %q does not mention %s", input, err, problem)
+		}
+	}
+}
+
+func FuzzParseHash(f *testing.F) {
+	canonical := hashLeaf("some")
+	f.Add(canonical)
+	f.Add("0X" + strings.ToUpper(canonical))
+	f.Add(canonical[:10] + "é" + canonical[12:])
+	f.Add("0x\xff")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		h, err := ParseHash(input)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidHash) {
+				t.Errorf("got %v, want %v", err, ErrInvalidHash)
+			}
+			return
+		}
+
+		digits := strings.TrimPrefix(strings.TrimPrefix(input, "0x"), "0X")
+		if h.String() != strings.ToLower(digits) {
+			t.Errorf("got %s, want %s", h.String(), strings.ToLower(digits))
+		}
+	})
+}
+
+func TestHashIsZero(t *testing.T) {
+	var zero Hash
+	if !zero.IsZero() {
+		t.Error("zero value should be zero")
+	}
+	if leafDigest("").IsZero() {
+		t.Error("digest of the empty string should not be zero")
+	}
+}
+
+func TestTypedRootAndVerification(t *testing.T) {
+	mt, _ := NewMerkleTree([]string{"some", "test", "elements"})
+	if mt.GetRootHash().String() != mt.GetRoot() {
+		t.Errorf("got %s, want %s", mt.GetRootHash(), mt.GetRoot())
+	}
+
+	proof, _ := mt.GetProof(2)
+	if !VerifyProofHash(mt.GetRootHash(), proof) {
+		t.Error("invalid proof")
+	}
+	if VerifyProofHash(Hash{}, proof) {
+		t.Error("verified against the zero hash")
+	}
+}
+
+func TestNodeDigestMatchesHashNode(t *testing.T) {
+	a, b := leafDigest("a"), leafDigest("b")
+	if got, want := nodeDigest(a, b).String(), hashNode(a.String(), b.String()); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestVerifyProofNormalizesRoot(t *testing.T) {
+	mt, _ := NewMerkleTree([]string{"some", "test", "elements"})
+	proof, _ := mt.GetProof(0)
+
+	if !VerifyProof("0x"+strings.ToUpper(mt.GetRoot()), proof) {
+		t.Error("rejected a prefixed uppercase root")
+	}
+	if VerifyProof("not_a_valid_hash", proof) {
+		t.Error("verified against an unparseable root")
+	}
+}
@@ -0,0 +1,132 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+var ErrUnknownHashFunction = errors.New("merkletree: unknown hash function")
+
+// A hash function leaves or nodes can be hashed with, see WithLeafHasher and WithNodeHasher.
+type HashFunction uint8
+
+const (
+	HashSHA256     HashFunction = iota // SHA-256, the default
+	HashKeccak256                      // Keccak-256, as WithKeccak256 sets
+	HashBLAKE3                         // BLAKE3, as WithBLAKE3 sets
+	HashSHA512_256                     // SHA-512/256, as WithSHA512_256 sets
+)
+
+var hashFunctionNames = [...]string{
+	HashSHA256:     "sha256",
+	HashKeccak256:  "keccak256",
+	HashBLAKE3:     "blake3",
+	HashSHA512_256: "sha512_256",
+}
+
+// Returns the name of the hash function, as it appears in JSON and Stats.
+func (f HashFunction) String() string {
+	if f.valid() {
+		return hashFunctionNames[f]
+	}
+	return fmt.Sprintf("HashFunction(%d)", uint8(f))
+}
+
+func (f HashFunction) valid() bool {
+	return int(f) < len(hashFunctionNames)
+}
+
+func (f HashFunction) MarshalText() ([]byte, error) {
+	if !f.valid() {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownHashFunction, uint8(f))
+	}
+	return []byte(hashFunctionNames[f]), nil
+}
+
+func (f *HashFunction) UnmarshalText(text []byte) error {
+	for i, name := range hashFunctionNames {
+		if string(text) == name {
+			*f = HashFunction(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownHashFunction, text)
+}
+
+// Hashes leaves with f, leaving how nodes hash as it was, for specs mandating one function
+// for the leaf layer and another for interior nodes, as with SHA-256 leaves under
+// Keccak-256 nodes. The padding element hashes as any leaf. Options hashing both alike,
+// WithKeccak256 and WithBLAKE3, replace it, as it replaces them and WithPoseidon for the
+// leaves. Verifiers need the same option, which the tree's Scheme carries. An unknown
+// function makes constructors fail with ErrUnknownHashFunction.
+func WithLeafHasher(f HashFunction) Option {
+	return func(cfg *config) {
+		cfg.leafHash = f
+		cfg.backend = nil
+	}
+}
+
+// Hashes interior nodes with f, leaving how leaves hash as it was; see WithLeafHasher.
+func WithNodeHasher(f HashFunction) Option {
+	return func(cfg *config) {
+		cfg.nodeHash = f
+		cfg.backend = nil
+	}
+}
+
+// Hashes leaves and nodes with SHA-512/256 instead of SHA-256: SHA-512 truncated to 32
+// bytes under its own initial values, as FIPS 180-4 defines it. It is faster than SHA-256
+// on 64-bit processors without SHA extensions and, unlike SHA-256, not open to length
+// extension. Digests keep their 32 bytes, so every proof format is unchanged. It replaces
+// the hash functions other options set, as they replace it. Verifiers need the same
+// option, which the tree's Scheme carries, or WithAlgorithmAutoDetect.
+func WithSHA512_256() Option {
+	return func(cfg *config) {
+		cfg.setHashFunction(HashSHA512_256)
+	}
+}
+
+// Sets both the leaf and node hash functions.
+func (cfg *config) setHashFunction(f HashFunction) {
+	cfg.leafHash, cfg.nodeHash = f, f
+	cfg.backend = nil
+}
+
+// Fails for a hash function set to an unknown value.
+func (cfg config) checkHashFunctions() error {
+	for _, f := range [...]HashFunction{cfg.leafHash, cfg.nodeHash} {
+		if !f.valid() {
+			return fmt.Errorf("%w: %d", ErrUnknownHashFunction, uint8(f))
+		}
+	}
+	return nil
+}
+
+// Returns the digest of data.
+func (f HashFunction) sum(data []byte) Hash {
+	switch f {
+	case HashKeccak256:
+		return keccak256(data)
+	case HashBLAKE3:
+		return blake3Sum(data)
+	case HashSHA512_256:
+		return sha512.Sum512_256(data)
+	}
+	return sha256.Sum256(data)
+}
+
+// Returns a hash.Hash computing the function, as HMAC takes it.
+func (f HashFunction) newHash() hash.Hash {
+	switch f {
+	case HashKeccak256:
+		return newKeccak256()
+	case HashBLAKE3:
+		return newBLAKE3()
+	case HashSHA512_256:
+		return sha512.New512_256()
+	}
+	return sha256.New()
+}
@@ -0,0 +1,149 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestLeafAndNodeHashers(t *testing.T) {
+	mixed := []Option{WithLeafHasher(HashSHA256), WithNodeHasher(HashKeccak256)}
+	pair, _ := NewMerkleTree([]string{"a", "b"}, mixed...)
+	left, right := sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b"))
+	if want := keccak256([]byte(hex.EncodeToString(left[:]) + hex.EncodeToString(right[:]))); pair.GetRoot() != want.String() {
+		t.Errorf("got %s, want %s", pair.GetRoot(), want)
+	}
+
+	// hashers set alike hash as the options setting both
+	same := []struct {
+		name       string
+		opts, want []Option
+	}{
+		{"sha256", []Option{WithLeafHasher(HashSHA256), WithNodeHasher(HashSHA256)}, nil},
+		{"keccak256", []Option{WithNodeHasher(HashKeccak256), WithLeafHasher(HashKeccak256)}, []Option{WithKeccak256()}},
+		{"blake3", []Option{WithLeafHasher(HashBLAKE3), WithNodeHasher(HashBLAKE3)}, []Option{WithBLAKE3()}},
+		{"replaced", []Option{WithLeafHasher(HashBLAKE3), WithKeccak256()}, []Option{WithKeccak256()}},
+	}
+	for _, c := range same {
+		got, _ := NewMerkleTree(testElements(5), c.opts...)
+		want, _ := NewMerkleTree(testElements(5), c.want...)
+		if got.GetRoot() != want.GetRoot() {
+			t.Errorf("%s: got %s, want %s", c.name, got.GetRoot(), want.GetRoot())
+		}
+	}
+
+	mt, _ := NewMerkleTree(testElements(6), mixed...)
+	verifier, _ := NewVerifier(mixed...)
+	// proofs carry their leaf digest, so only the node function decides their paths
+	others := [][]Option{nil, {WithBLAKE3()}, {WithLeafHasher(HashKeccak256), WithNodeHasher(HashSHA256)}}
+	proofs := make([]MerkleProof, mt.LeafCount())
+	for i := range proofs {
+		proofs[i], _ = mt.GetProof(uint64(i))
+		if !VerifyProof(mt.GetRoot(), proofs[i], mixed...) || !verifier.VerifyProof(mt.GetRoot(), proofs[i]) {
+			t.Errorf("proof %d rejected", i)
+		}
+		for k, opts := range others {
+			if VerifyProof(mt.GetRoot(), proofs[i], opts...) {
+				t.Errorf("proof %d verified under options %d", i, k)
+			}
+		}
+	}
+	element := testElements(6)[2]
+	if proofs[2].hElement != newConfig(mixed).hashLeaf(element) || proofs[2].hElement == newConfig([]Option{WithKeccak256()}).hashLeaf(element) {
+		t.Errorf("got leaf %s, want it hashed with SHA-256", proofs[2].hElement)
+	}
+	multi, err := CombineProofs(proofs[1:4], mixed...)
+	if err != nil || !verifier.VerifyMultiProof(mt.GetRoot(), multi) {
+		t.Errorf("got %v, combined proof rejected", err)
+	}
+	if got := mt.Stats().HashAlgorithm; got != "sha256/keccak256" {
+		t.Errorf("got hash algorithm %s", got)
+	}
+}
+
+func TestLeafAndNodeHashersScheme(t *testing.T) {
+	for _, c := range []struct{ leaf, node HashFunction }{
+		{HashSHA256, HashKeccak256},
+		{HashKeccak256, HashSHA256},
+		{HashBLAKE3, HashKeccak256},
+	} {
+		t.Run(fmt.Sprintf("%v leaves, %v nodes", c.leaf, c.node), func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(5), WithLeafHasher(c.leaf), WithNodeHasher(c.node), WithApplicationTag("split"))
+			scheme := mt.Scheme()
+			if scheme.LeafHash != c.leaf || scheme.NodeHash != c.node || scheme.Keccak256 || scheme.BLAKE3 {
+				t.Fatalf("got %+v", scheme)
+			}
+			proof, _ := mt.GetProof(4)
+
+			text, _ := mt.Commitment().MarshalText()
+			var commitment Commitment
+			if err := commitment.UnmarshalText(text); err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyCommitmentProof(commitment, proof); err != nil {
+				t.Errorf("got %v against the decoded commitment, want nil", err)
+			}
+
+			data, _ := json.Marshal(scheme)
+			var decoded Scheme
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyProof(mt.GetRoot(), proof, WithScheme(decoded)) {
+				t.Errorf("proof rejected under the scheme decoded from %s", data)
+			}
+		})
+	}
+}
+
+// OpenZeppelin's StandardMerkleTree hashes leaves twice with Keccak-256 and nodes once,
+// over their sorted raw bytes, which the hashers and leaf options reproduce separately.
+func TestLeafAndNodeHashersStandardMerkleTree(t *testing.T) {
+	f, err := os.Open("testdata/standard-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	st, err := LoadStandardMerkleTreeDump(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, _ := st.GetProof(0)
+	opts := []Option{WithLeafHasher(HashKeccak256), WithNodeHasher(HashKeccak256), WithDoubleHashedLeaves(), WithRawNodeHashing(), WithSortedPairs()}
+	if !VerifyProof(standardReadmeRoot, proof, opts...) {
+		t.Error("README proof rejected")
+	}
+	if VerifyProof(standardReadmeRoot, proof, append(opts, WithNodeHasher(HashSHA256))...) {
+		t.Error("README proof verified with SHA-256 nodes")
+	}
+	tree, _ := NewMerkleTree(testElements(7), opts...)
+	preset, _ := NewMerkleTree(testElements(7), WithMode(ModeOpenZeppelin))
+	if tree.GetRoot() != preset.GetRoot() {
+		t.Errorf("got %s, want the ModeOpenZeppelin root %s", tree.GetRoot(), preset.GetRoot())
+	}
+}
+
+func TestHashFunctionUnknown(t *testing.T) {
+	if _, err := NewMerkleTree(testElements(2), WithNodeHasher(HashFunction(9))); !errors.Is(err, ErrUnknownHashFunction) {
+		t.Errorf("got %v, want ErrUnknownHashFunction", err)
+	}
+	if _, err := NewVerifier(WithLeafHasher(HashFunction(9))); !errors.Is(err, ErrUnknownHashFunction) {
+		t.Errorf("got %v creating a verifier, want ErrUnknownHashFunction", err)
+	}
+	if _, err := NewMerkleTree(testElements(2), WithMode(ModeOpenZeppelin), WithLeafHasher(HashSHA256)); !errors.Is(err, ErrModeConflict) {
+		t.Errorf("got %v, want ErrModeConflict", err)
+	}
+
+	var f HashFunction
+	if err := f.UnmarshalText([]byte("sha3-256")); !errors.Is(err, ErrUnknownHashFunction) {
+		t.Errorf("got %v, want ErrUnknownHashFunction", err)
+	}
+	if err := f.UnmarshalText([]byte("blake3")); err != nil || f != HashBLAKE3 {
+		t.Errorf("got %v, %v", f, err)
+	}
+}
@@ -0,0 +1,83 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Creates a tree whose leaf hashes are the given roots, taken as they are rather than
+// hashed, so a proof from it carries a root as its element hash. This builds a master
+// tree over per-shard trees, whose proofs ChainedProof joins to those of the shards.
+// The roots are not elements: element lookups find nothing and UpdateElement, Append
+// and the like fail with ErrElementsUnknown, as for a tree restored with ImportNodes.
+// Under WithSetSemantics the roots are sorted and exact duplicates dropped.
+// Fails with ErrEmptyTree for no roots and an *InvalidDigestError for one not a digest.
+func NewMerkleTreeFromRoots(roots []string, opts ...Option) (*MerkleTree, error) {
+	t := &MerkleTree{cfg: newConfig(opts)}
+	var start time.Time
+	if t.cfg.timed() {
+		start = time.Now()
+	}
+
+	leaves := make([]Hash, len(roots))
+	for i, root := range roots {
+		leaf, err := parseRoot(root)
+		if err != nil {
+			return nil, fmt.Errorf("%w (root %d)", err, i)
+		}
+		leaves[i] = leaf
+	}
+	if err := t.buildLeaves(leaves); err != nil {
+		return nil, err
+	}
+
+	if t.cfg.timed() {
+		t.reportBuild(time.Since(start))
+	}
+	return t, nil
+}
+
+// Builds the tree over leaf hashes alone, holding no elements. Under WithSetSemantics
+// the leaves are sorted and exact duplicates dropped.
+func (t *MerkleTree) buildLeaves(leaves []Hash) error {
+	if t.cfg.setSemantics {
+		slices.SortFunc(leaves, func(a, b Hash) int { return bytes.Compare(a[:], b[:]) })
+		leaves = slices.Compact(leaves)
+	}
+
+	height, err := t.cfg.checkedHeight(uint64(len(leaves)))
+	if err != nil {
+		return err
+	}
+	t.hashLevels(height, leaves)
+	return nil
+}
+
+// Proves an element all the way to a master root: Inner proves the element in its own
+// tree, and Outer proves that tree's root, as a leaf of a tree built by
+// NewMerkleTreeFromRoots, in the master tree.
+type ChainedProof struct {
+	Inner MerkleProof
+	Outer MerkleProof
+}
+
+// Verifies that the element of the inner proof is included under masterRoot, through
+// the root the outer proof carries. Both layers are verified under the same options.
+func VerifyChainedProof(masterRoot string, proof ChainedProof, opts ...Option) bool {
+	v := newVerifier(opts)
+	return ChainedVerifier{Inner: v, Outer: v}.VerifyChainedProof(masterRoot, proof)
+}
+
+// Verifies chained proofs whose layers hash differently, with a verifier for each.
+type ChainedVerifier struct {
+	Inner *Verifier // hashing as the tree holding the element does
+	Outer *Verifier // hashing as the master tree does
+}
+
+// Verifies a chained proof as VerifyChainedProof does: the inner proof must derive
+// exactly the root the outer proof carries, and that proof must reach masterRoot.
+func (c ChainedVerifier) VerifyChainedProof(masterRoot string, proof ChainedProof) bool {
+	return c.Inner.VerifyProof(proof.Outer.hElement, proof.Inner) && c.Outer.VerifyProof(masterRoot, proof.Outer)
+}
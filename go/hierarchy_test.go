@@ -0,0 +1,95 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Builds a tree per day over its own elements, and the master tree over their roots.
+func dailyTrees(t *testing.T, days int, inner []Option, outer []Option) ([]*MerkleTree, *MerkleTree) {
+	t.Helper()
+	trees := make([]*MerkleTree, days)
+	roots := make([]string, days)
+	for day := range trees {
+		elements := testElements(3 + day)
+		for i := range elements {
+			elements[i] = fmt.Sprintf("day-%d/%s", day, elements[i])
+		}
+		trees[day], _ = NewMerkleTree(elements, inner...)
+		roots[day] = trees[day].GetRoot()
+	}
+	master, err := NewMerkleTreeFromRoots(roots, outer...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trees, master
+}
+
+func TestNewMerkleTreeFromRoots(t *testing.T) {
+	roots := []string{hashLeaf("a"), hashLeaf("b"), hashLeaf("c")}
+	master, err := NewMerkleTreeFromRoots(roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := hashNode(hashNode(roots[0], roots[1]), hashNode(roots[2], hashLeaf("")))
+	if master.GetRoot() != want {
+		t.Errorf("got root %s, want %s", master.GetRoot(), want)
+	}
+	if tree, _ := NewMerkleTree([]string{"a", "b", "c"}); master.GetRoot() != tree.GetRoot() {
+		t.Errorf("got root %s, want that of the tree over the elements, %s", master.GetRoot(), tree.GetRoot())
+	}
+	if err := master.UpdateElement(0, "a"); !errors.Is(err, ErrElementsUnknown) {
+		t.Errorf("got %v, want %v", err, ErrElementsUnknown)
+	}
+
+	if _, err := NewMerkleTreeFromRoots(nil); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+	var derr *InvalidDigestError
+	if _, err := NewMerkleTreeFromRoots([]string{roots[0], "not a root"}); !errors.As(err, &derr) {
+		t.Errorf("got %v, want an *InvalidDigestError", err)
+	}
+}
+
+func TestVerifyChainedProof(t *testing.T) {
+	trees, master := dailyTrees(t, 5, nil, nil)
+
+	for day, tree := range trees {
+		inner, _ := tree.GetProof(2)
+		outer, _ := master.GetProof(uint64(day))
+		proof := ChainedProof{Inner: inner, Outer: outer}
+		if !VerifyChainedProof(master.GetRoot(), proof) {
+			t.Errorf("day %d: chained proof failed", day)
+		}
+
+		// the inner proof of another day reaches a root the outer proof does not carry
+		other, _ := trees[(day+1)%len(trees)].GetProof(2)
+		if VerifyChainedProof(master.GetRoot(), ChainedProof{Inner: other, Outer: outer}) {
+			t.Errorf("day %d: verified with another day's inner proof", day)
+		}
+	}
+	inner, _ := trees[0].GetProof(0)
+	outer, _ := master.GetProof(0)
+	if VerifyChainedProof(trees[1].GetRoot(), ChainedProof{Inner: inner, Outer: outer}) {
+		t.Error("verified against the wrong master root")
+	}
+}
+
+func TestChainedVerifierMixedModes(t *testing.T) {
+	inner, outer := []Option{WithKeccak256(), WithSortedPairs()}, []Option{WithMode(ModeRFC6962)}
+	trees, master := dailyTrees(t, 3, inner, outer)
+
+	innerProof, _ := trees[2].GetProof(4)
+	outerProof, _ := master.GetProof(2)
+	proof := ChainedProof{Inner: innerProof, Outer: outerProof}
+
+	innerVerifier, _ := NewVerifier(inner...)
+	v := ChainedVerifier{Inner: innerVerifier, Outer: master.Verifier()}
+	if !v.VerifyChainedProof(master.GetRoot(), proof) {
+		t.Error("chained proof failed under per-layer verifiers")
+	}
+	if VerifyChainedProof(master.GetRoot(), proof, outer...) {
+		t.Error("verified with the outer options on both layers")
+	}
+}
@@ -0,0 +1,68 @@
+package merkletree
+
+import (
+	"crypto/hmac"
+	"errors"
+	"hash"
+)
+
+var ErrEmptyHMACKey = errors.New("merkletree: HMAC key is empty")
+
+// Authenticated ahead of every leaf and node hashed under WithHMACKey, so a leaf can
+// never pass for a node under the same key.
+const (
+	hmacLeafInfo = "merkletree hmac leaf\x00"
+	hmacNodeInfo = "merkletree hmac node\x00"
+)
+
+// Keys every leaf and node hash with a secret, computing HMAC-SHA256 under the key over
+// what would otherwise be hashed, prefixed by an info string telling leaves from nodes.
+// Only holders of the key can compute or verify the roots, so third parties cannot grind
+// candidate elements against a published root. Other hashing options apply as before;
+// the HMAC is over the hash function of leaves or nodes, see WithLeafHasher.
+// The key is copied. It is not part of the tree's Scheme, so it never appears in
+// commitments, proofs, exported nodes or anything else the tree serializes, and WithMode
+// and WithScheme leave it in place. Verifiers need the same option, and proofs verify
+// under no other key or none. An empty key makes constructors fail with ErrEmptyHMACKey.
+func WithHMACKey(key []byte) Option {
+	return func(cfg *config) {
+		cfg.hmacKey = string(key)
+		cfg.keyed = true
+	}
+}
+
+// Returns the HMAC over f of the message under the configured key.
+func (cfg config) hmac(f HashFunction, message string) Hash {
+	mac := hmac.New(f.newHash, []byte(cfg.hmacKey))
+	mac.Write([]byte(message))
+
+	var digest Hash
+	mac.Sum(digest[:0])
+	return digest
+}
+
+// HMAC state kept from one digest to the next, sparing each the allocations of cfg.hmac;
+// see VerifyScratch.
+type macScratch struct {
+	f   HashFunction
+	mac hash.Hash // HMAC over f under the key; nil without WithHMACKey
+	buf []byte    // info followed by the data authenticated, copied as for cfg.hmac
+	out Hash      // digest mac last computed, where Sum writes without moving anything to the heap
+}
+
+// Returns the HMAC state over f under the configured key, empty without one.
+func (cfg config) newMACScratch(f HashFunction) macScratch {
+	if !cfg.keyed {
+		return macScratch{}
+	}
+	return macScratch{f: f, mac: hmac.New(f.newHash, []byte(cfg.hmacKey))}
+}
+
+// Returns the HMAC of info followed by data, as cfg.hmac does.
+func (m *macScratch) sum(info string, data []byte) Hash {
+	m.buf = append(append(m.buf[:0], info...), data...)
+	m.mac.Reset()
+	m.mac.Write(m.buf)
+	m.mac.Sum(m.out[:0])
+	return m.out
+}
@@ -0,0 +1,135 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHMACKeySeparatesRoots(t *testing.T) {
+	elements := testElements(7)
+	unkeyed, _ := NewMerkleTree(elements)
+	first, _ := NewMerkleTree(elements, WithHMACKey([]byte("first key")))
+	second, _ := NewMerkleTree(elements, WithHMACKey([]byte("second key")))
+	again, _ := NewMerkleTree(elements, WithHMACKey([]byte("first key")))
+
+	roots := map[string]string{"unkeyed": unkeyed.GetRoot(), "first": first.GetRoot(), "second": second.GetRoot()}
+	seen := make(map[string]string)
+	for name, root := range roots {
+		if other, ok := seen[root]; ok {
+			t.Errorf("%s and %s share the root %s", name, other, root)
+		}
+		seen[root] = name
+	}
+	if again.GetRoot() != first.GetRoot() {
+		t.Errorf("got %s under the same key, want %s", again.GetRoot(), first.GetRoot())
+	}
+	if _, _, err := FirstDivergence(first, second); !errors.Is(err, ErrIncompatibleTrees) {
+		t.Errorf("got %v comparing trees under different keys, want ErrIncompatibleTrees", err)
+	}
+}
+
+func TestHMACKeyDigests(t *testing.T) {
+	key := []byte("secret")
+	mac := func(info string, data string) string {
+		m := hmac.New(sha256.New, key)
+		m.Write([]byte(info + data))
+		return hex.EncodeToString(m.Sum(nil))
+	}
+
+	mt, _ := NewMerkleTree([]string{"a", "b"}, WithHMACKey(key))
+	left, right := mac("merkletree hmac leaf\x00", "a"), mac("merkletree hmac leaf\x00", "b")
+	if want := mac("merkletree hmac node\x00", left+right); mt.GetRoot() != want {
+		t.Errorf("got %s, want %s", mt.GetRoot(), want)
+	}
+
+	// the key stays in place across options replacing the rest of the hashing
+	bitcoin, _ := NewMerkleTree(testElements(5), WithHMACKey(key), WithMode(ModeBitcoin))
+	plain, _ := NewMerkleTree(testElements(5), WithMode(ModeBitcoin))
+	if bitcoin.GetRoot() == plain.GetRoot() {
+		t.Error("WithMode dropped the key")
+	}
+	proof, _ := bitcoin.GetProof(4)
+	if err := VerifyCommitmentProof(bitcoin.Commitment(), proof, WithHMACKey(key)); err != nil {
+		t.Errorf("got %v against the commitment, want nil", err)
+	}
+}
+
+func TestHMACKeyProofs(t *testing.T) {
+	key := []byte("secret")
+	mt, _ := NewMerkleTree(testElements(6), WithHMACKey(key), WithApplicationTag("private"))
+	keyed, err := NewVerifier(WithHMACKey(key), WithApplicationTag("private"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{"matching key", []Option{WithHMACKey(key), WithApplicationTag("private")}, true},
+		{"other key", []Option{WithHMACKey([]byte("guess")), WithApplicationTag("private")}, false},
+		{"no key", []Option{WithApplicationTag("private")}, false},
+	}
+	for i := uint64(0); i < mt.LeafCount(); i++ {
+		proof, _ := mt.GetProof(i)
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%s/proof %d", c.name, i), func(t *testing.T) {
+				if got := VerifyProof(mt.GetRoot(), proof, c.opts...); got != c.want {
+					t.Errorf("got %v, want %v", got, c.want)
+				}
+			})
+		}
+		if !keyed.VerifyProof(mt.GetRoot(), proof) || !mt.Verifier().VerifyProof(mt.GetRoot(), proof) {
+			t.Errorf("proof %d rejected by a keyed verifier", i)
+		}
+	}
+}
+
+func TestHMACKeyEmpty(t *testing.T) {
+	for _, key := range [][]byte{nil, {}} {
+		if _, err := NewMerkleTree(testElements(3), WithHMACKey(key)); !errors.Is(err, ErrEmptyHMACKey) {
+			t.Errorf("got %v building with key %q, want ErrEmptyHMACKey", err, key)
+		}
+		if _, err := NewVerifier(WithHMACKey(key)); !errors.Is(err, ErrEmptyHMACKey) {
+			t.Errorf("got %v creating a verifier with key %q, want ErrEmptyHMACKey", err, key)
+		}
+	}
+}
+
+func TestHMACKeyNeverSerialized(t *testing.T) {
+	key := []byte("do-not-leak-this-key")
+	var journal bytes.Buffer
+	mt, _ := NewMerkleTree(testElements(5), WithHMACKey(key), WithJournal(&journal))
+	mt.UpdateElement(2, "updated")
+	proof, _ := mt.GetProof(2)
+	layout, nodes := mt.ExportNodes()
+
+	var encoded [][]byte
+	binaryProof, _ := proof.MarshalBinary()
+	textProof, _ := proof.MarshalText()
+	commitment, _ := json.Marshal(mt.Commitment())
+	exported, _ := json.Marshal(layout)
+	stats, _ := json.Marshal(mt.Stats())
+	encoded = append(encoded, binaryProof, textProof, commitment, exported, nodes, stats, journal.Bytes(),
+		[]byte(mt.Commitment().String()), []byte(mt.String()), []byte(fmt.Sprintf("%#v %+v", mt, proof)))
+	for i, data := range encoded {
+		if bytes.Contains(data, key) || bytes.Contains(data, []byte(hex.EncodeToString(key))) {
+			t.Errorf("encoding %d holds the key: %q", i, data)
+		}
+	}
+
+	imported, err := ImportNodes(layout, nodes, WithHMACKey(key))
+	if err != nil || imported.GetRoot() != mt.GetRoot() {
+		t.Errorf("got %v importing under the key", err)
+	}
+	if _, err := ImportNodes(layout, nodes); err == nil {
+		t.Error("imported keyed nodes without the key")
+	}
+}
@@ -0,0 +1,52 @@
+package merkletree
+
+import "fmt"
+
+// Builds a proof from the (leaf index, tree size) form other ecosystems express paths in,
+// as RFC 6962 audit paths and most contract verifiers do, computing the directions from
+// the index. The siblings are ordered from the leaves upwards and must number at least
+// the height of a tree over treeSize elements; more reach the root of a tree built with
+// WithFixedDepth. Digests are canonicalized as VerifyProof reads them, and the proof
+// carries no epoch, tag or algorithm. Fails with ErrIndexOutOfBounds for an index beyond
+// the tree, with ErrDepthMismatch for too few or too many siblings, and with
+// ErrMalformedProof, wrapping an *InvalidDigestError, for a digest that is not a valid hash.
+func ProofFromIndex(leafHash string, index uint64, treeSize uint64, siblings []string) (MerkleProof, error) {
+	if index >= treeSize {
+		return MerkleProof{}, fmt.Errorf("%w: leaf %d in a tree of %d", ErrIndexOutOfBounds, index, treeSize)
+	}
+	if height := treeHeight(treeSize); len(siblings) < height || len(siblings) > maxProofDepth {
+		return MerkleProof{}, fmt.Errorf("%w: %d siblings, a tree of %d elements takes %d to %d", ErrDepthMismatch, len(siblings), treeSize, height, maxProofDepth)
+	}
+
+	proof := MerkleProof{
+		hElement:   leafHash,
+		siblings:   append(make([]string, 0, len(siblings)), siblings...),
+		directions: make([]bool, len(siblings)),
+	}
+	for level := range proof.directions {
+		proof.directions[level] = index>>level&1 == 1
+	}
+
+	proof, err := proof.normalized()
+	if err != nil {
+		return MerkleProof{}, fmt.Errorf("%w: %w", ErrMalformedProof, err)
+	}
+	return proof, nil
+}
+
+// Returns the proof in (leaf index, depth) form, the inverse of ProofFromIndex: the index
+// its directions encode, its depth and a copy of its siblings, ordered from the leaves
+// upwards. A padded tree's size does not follow from its proofs, only that it exceeds the
+// index and is at most 2^depth. Fails with ErrMalformedProof for a proof of inconsistent
+// shape and for directions no 64-bit index has, a sibling on the left above level 63.
+func (p MerkleProof) ToIndexForm() (index uint64, depth int, siblings []string, err error) {
+	if err = p.validateShape(); err != nil {
+		return 0, 0, nil, err
+	}
+	for level := 64; level < len(p.directions); level++ {
+		if p.directions[level] {
+			return 0, 0, nil, fmt.Errorf("%w: sibling on the left at level %d, beyond any 64-bit index", ErrMalformedProof, level)
+		}
+	}
+	return proofIndex(p), len(p.siblings), append(make([]string, 0, len(p.siblings)), p.siblings...), nil
+}
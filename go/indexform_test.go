@@ -0,0 +1,97 @@
+package merkletree
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIndexFormRoundTrip(t *testing.T) {
+	for size := 1; size <= 64; size++ {
+		mt, _ := NewMerkleTree(testElements(size))
+		for i := 0; i < size; i++ {
+			proof, _ := mt.GetProof(uint64(i))
+
+			index, depth, siblings, err := proof.ToIndexForm()
+			if err != nil || index != uint64(i) || depth != mt.Height() {
+				t.Fatalf("size %d, index %d: got %d at depth %d, %v", size, i, index, depth, err)
+			}
+			rebuilt, err := ProofFromIndex(proof.hElement, index, uint64(size), siblings)
+			if err != nil {
+				t.Fatalf("size %d, index %d: %v", size, i, err)
+			}
+			// the index form carries the path only, not the tree's algorithm
+			proof.algorithm = AlgorithmUnspecified
+			if !reflect.DeepEqual(rebuilt, proof) {
+				t.Fatalf("size %d, index %d: got %+v, want %+v", size, i, rebuilt, proof)
+			}
+			if !VerifyProof(mt.GetRoot(), rebuilt) {
+				t.Fatalf("size %d, index %d: rebuilt proof rejected", size, i)
+			}
+		}
+	}
+}
+
+func TestIndexFormFixedDepth(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5), WithFixedDepth(8))
+	proof, _ := mt.GetProof(4)
+	index, depth, siblings, _ := proof.ToIndexForm()
+	if depth != 8 {
+		t.Fatalf("got depth %d, want 8", depth)
+	}
+	rebuilt, err := ProofFromIndex(proof.hElement, index, mt.LeafCount(), siblings)
+	if err != nil || !VerifyProof(mt.GetRoot(), rebuilt) {
+		t.Errorf("got %v, rebuilt proof of a fixed depth tree rejected", err)
+	}
+}
+
+func TestIndexFormErrors(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	proof, _ := mt.GetProof(3)
+	_, _, siblings, _ := proof.ToIndexForm()
+
+	cases := []struct {
+		name     string
+		leaf     string
+		index    uint64
+		size     uint64
+		siblings []string
+		want     error
+	}{
+		{"index beyond the tree", proof.hElement, 5, 5, siblings, ErrIndexOutOfBounds},
+		{"empty tree", proof.hElement, 0, 0, nil, ErrIndexOutOfBounds},
+		{"too few siblings", proof.hElement, 3, 5, siblings[:2], ErrDepthMismatch},
+		{"too many siblings", proof.hElement, 3, 5, make([]string, maxProofDepth+1), ErrDepthMismatch},
+		{"invalid leaf", "leaf", 3, 5, siblings, ErrMalformedProof},
+		{"invalid sibling", proof.hElement, 3, 5, append(siblings[:2:2], "zz"), ErrInvalidHash},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := ProofFromIndex(c.leaf, c.index, c.size, c.siblings); !errors.Is(err, c.want) {
+				t.Errorf("got %v, want %v", err, c.want)
+			}
+		})
+	}
+
+	// digests are read as VerifyProof reads them
+	upper, err := ProofFromIndex("0x"+strings.ToUpper(proof.hElement), 3, 5, siblings)
+	if err != nil || upper.hElement != proof.hElement {
+		t.Errorf("got %q, %v, want %q", upper.hElement, err, proof.hElement)
+	}
+
+	// directions no index has
+	uneven := MerkleProof{hElement: proof.hElement, siblings: siblings, directions: []bool{true}}
+	if _, _, _, err := uneven.ToIndexForm(); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+	deep := MerkleProof{hElement: proof.hElement, siblings: make([]string, 65), directions: make([]bool, 65)}
+	deep.directions[64] = true
+	if _, _, _, err := deep.ToIndexForm(); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v for a sibling on the left at level 64, want %v", err, ErrMalformedProof)
+	}
+	deep.directions[64] = false
+	if index, depth, _, err := deep.ToIndexForm(); err != nil || index != 0 || depth != 65 {
+		t.Errorf("got %d at depth %d, %v", index, depth, err)
+	}
+}
@@ -0,0 +1,293 @@
+package merkletree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+var (
+	ErrJournalWrite     = errors.New("merkletree: mutation applied but not journaled")
+	ErrMalformedJournal = errors.New("merkletree: malformed journal")
+	ErrJournalMismatch  = errors.New("merkletree: journal disagrees with the tree")
+)
+
+// Reports the journal record replay stopped at, counted from zero.
+// Records before it were applied; the record itself was not, unless it left the tree
+// at a root other than the one recorded.
+type JournalError struct {
+	Record uint64
+	Err    error // wrapping ErrMalformedJournal or ErrJournalMismatch, or from the mutation
+}
+
+func (e *JournalError) Error() string {
+	return fmt.Sprintf("%v (in journal record %d)", e.Err, e.Record)
+}
+
+func (e *JournalError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	journalUpdate  = 1 + iota // indices: index; values: old, new
+	journalAppend             // values: new
+	journalSwap               // indices: i, j
+	journalApply              // indices: changed; values: old, new per change
+	journalReset              // values: every element
+	journalExtend             // indices: target leaves
+	journalCompact            // nothing
+	journalInsert             // indices: index; values: new
+	journalDelete             // indices: index; values: old
+	journalData               // indices: index; values: old data, new data
+)
+
+// A mutation as the journal records it, see WithJournal.
+type journalRecord struct {
+	op      byte
+	indices []uint64
+	values  []string
+	framed  []byte // records already framed, written in place of this one, see txn.go
+}
+
+// Writes a record of every mutation to w, in the order the mutations happen, so that
+// ReplayJournal can bring a snapshot of the tree up to date. Each record is written
+// with a single Write while the tree is locked:
+//
+//	length (uvarint) | op (1 byte) | index count (uvarint) | indices (uvarint each) |
+//	value count (uvarint) | values (length (uvarint) | bytes each) | resulting root (32 bytes)
+//
+// Mutations that change nothing, leaving the epoch as it is, are not recorded.
+// Under WithLazyRecompute every recorded mutation recomputes the root for its record.
+// When a write fails, the mutation is still applied and fails with ErrJournalWrite;
+// a later replay stops at the gap, as the next record's root no longer follows.
+func WithJournal(w io.Writer) Option {
+	return func(cfg *config) {
+		cfg.journal = w
+	}
+}
+
+// Appends the record, framed as WithJournal describes, to out.
+func appendJournalRecord(out []byte, rec journalRecord, root Hash) []byte {
+	payload := []byte{rec.op}
+	payload = binary.AppendUvarint(payload, uint64(len(rec.indices)))
+	for _, index := range rec.indices {
+		payload = binary.AppendUvarint(payload, index)
+	}
+	payload = binary.AppendUvarint(payload, uint64(len(rec.values)))
+	for _, value := range rec.values {
+		payload = binary.AppendUvarint(payload, uint64(len(value)))
+		payload = append(payload, value...)
+	}
+	payload = append(payload, root[:]...)
+
+	out = binary.AppendUvarint(out, uint64(len(payload)))
+	return append(out, payload...)
+}
+
+// Writes the record of a mutation that advanced the epoch, with the root it produced.
+func (t *MerkleTree) journal(rec journalRecord) error {
+	t.recomputeDirty()
+	framed := rec.framed
+	if framed == nil {
+		framed = appendJournalRecord(nil, rec, t.rootHash())
+	}
+	if _, err := t.cfg.journal.Write(framed); err != nil {
+		return fmt.Errorf("%w: %w", ErrJournalWrite, err)
+	}
+	return nil
+}
+
+// Applies the journal written by WithJournal to a copy of base, checking each record's
+// values against the tree and its resulting root once applied. base is left unchanged.
+// Replay stops at the first record it cannot apply with a *JournalError, returning the
+// tree as replayed so far alongside. Records that are malformed, or whose old values
+// disagree with the tree, are not applied; one leaving another root than recorded is.
+// A journal cut short by a crash thus yields the tree up to the last complete record,
+// and an error wrapping io.ErrUnexpectedEOF.
+// The copy has base's options, except that it neither journals nor has subscribers.
+// A base no constructor built fails with ErrUninitializedTree.
+func ReplayJournal(base *MerkleTree, r io.Reader) (*MerkleTree, error) {
+	t, err := base.clone()
+	if err != nil {
+		return nil, err
+	}
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		buffered := bufio.NewReader(r)
+		br, r = buffered, buffered
+	}
+
+	for record := uint64(0); ; record++ {
+		length, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return t, nil
+		}
+		var payload []byte
+		if err == nil {
+			payload, err = io.ReadAll(io.LimitReader(r, int64(min(length, 1<<62))))
+			if err == nil && uint64(len(payload)) < length {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		if err != nil {
+			return t, &JournalError{Record: record, Err: fmt.Errorf("%w: %w", ErrMalformedJournal, err)}
+		}
+
+		rec, root, err := readJournalRecord(payload)
+		if err == nil {
+			err = t.replay(rec, root)
+		}
+		if err != nil {
+			return t, &JournalError{Record: record, Err: err}
+		}
+	}
+}
+
+func readJournalRecord(payload []byte) (journalRecord, Hash, error) {
+	r := byteReader{data: payload}
+	rec := journalRecord{op: r.byte()}
+	rec.indices = make([]uint64, r.uvarint(uint64(len(r.data))))
+	for i := range rec.indices {
+		rec.indices[i] = r.uvarint(^uint64(0))
+	}
+	rec.values = make([]string, r.uvarint(uint64(len(r.data))))
+	for i := range rec.values {
+		rec.values[i] = string(r.bytes(int(r.uvarint(uint64(len(r.data))))))
+	}
+	root := r.digest()
+	if r.err != nil {
+		return rec, root, fmt.Errorf("%w: %v", ErrMalformedJournal, r.err)
+	}
+	if len(r.data) > 0 {
+		return rec, root, fmt.Errorf("%w: %d bytes after the root", ErrMalformedJournal, len(r.data))
+	}
+
+	indices, values := len(rec.indices), len(rec.values)
+	var valid bool
+	switch rec.op {
+	case journalUpdate:
+		valid = indices == 1 && values == 2
+	case journalAppend:
+		valid = indices == 0 && values == 1
+	case journalSwap:
+		valid = indices == 2 && values == 0
+	case journalApply:
+		valid = values == 2*indices
+	case journalReset:
+		valid = indices == 0
+	case journalExtend:
+		valid = indices == 1 && values == 0
+	case journalCompact:
+		valid = indices == 0 && values == 0
+	case journalInsert, journalDelete:
+		valid = indices == 1 && values == 1
+	case journalData:
+		valid = indices == 1 && values == 2
+	default:
+		return rec, root, fmt.Errorf("%w: unknown op %d", ErrMalformedJournal, rec.op)
+	}
+	if !valid {
+		return rec, root, fmt.Errorf("%w: op %d with %d indices and %d values", ErrMalformedJournal, rec.op, indices, values)
+	}
+	return rec, root, nil
+}
+
+// Applies a journal record, then checks the root it leaves.
+func (t *MerkleTree) replay(rec journalRecord, root Hash) error {
+	var err error
+	switch rec.op {
+	case journalUpdate:
+		index, old := rec.indices[0], rec.values[0]
+		if index < uint64(len(t.elements)) && t.elements[index] != old {
+			return fmt.Errorf("%w: element %d was %q, journal has %q", ErrJournalMismatch, index, t.elements[index], old)
+		}
+		err = t.UpdateElement(index, rec.values[1])
+	case journalAppend:
+		err = t.Append(rec.values[0])
+	case journalSwap:
+		err = t.Swap(rec.indices[0], rec.indices[1])
+	case journalApply:
+		changes := make(map[uint64][2]string, len(rec.indices))
+		for k, index := range rec.indices {
+			changes[index] = [2]string{rec.values[2*k], rec.values[2*k+1]}
+		}
+		err = t.Apply(func(index uint64, element string) (string, error) {
+			change, ok := changes[index]
+			if !ok {
+				return element, nil
+			}
+			if element != change[0] {
+				return "", fmt.Errorf("%w: element %d was %q, journal has %q", ErrJournalMismatch, index, element, change[0])
+			}
+			return change[1], nil
+		})
+	case journalReset:
+		err = t.Reset(rec.values)
+	case journalExtend:
+		err = t.ExtendCapacity(rec.indices[0])
+	case journalCompact:
+		_, err = t.Compact()
+	case journalInsert:
+		err = t.InsertAt(rec.indices[0], rec.values[0])
+	case journalDelete:
+		index, old := rec.indices[0], rec.values[0]
+		if index < uint64(len(t.elements)) && t.elements[index] != old {
+			return fmt.Errorf("%w: element %d was %q, journal has %q", ErrJournalMismatch, index, t.elements[index], old)
+		}
+		err = t.Delete(index)
+	case journalData:
+		index, old := rec.indices[0], rec.values[0]
+		if data := t.leafData(index); string(data) != old {
+			return fmt.Errorf("%w: data of leaf %d was %x, journal has %x", ErrJournalMismatch, index, data, old)
+		}
+		err = t.SetLeafData(index, []byte(rec.values[1]))
+	}
+	if err != nil {
+		return err
+	}
+
+	if got := t.GetRootHash(); got != root {
+		return fmt.Errorf("%w: root %s, journal has %s", ErrJournalMismatch, got, root)
+	}
+	return nil
+}
+
+// Returns a deep copy of the tree, without its journal or subscribers.
+func (t *MerkleTree) clone() (*MerkleTree, error) {
+	if err := t.rlockBuiltAsIs(); err != nil {
+		return nil, err
+	}
+	defer t.mu.RUnlock()
+	return t.copyState(), nil
+}
+
+// Returns a tree holding a deep copy of the state of t, as clone does, for a caller
+// holding t.mu.
+func (t *MerkleTree) copyState() *MerkleTree {
+	c := &MerkleTree{
+		cfg:     t.cfg,
+		nodes:   slices.Clone(t.nodes),
+		count:   t.count,
+		offsets: slices.Clone(t.offsets),
+		zero:    slices.Clone(t.zero),
+		epoch:   t.epoch,
+		dirty:   slices.Clone(t.dirty),
+		workers: t.workers,
+		keys:    maps.Clone(t.keys),
+		data:    slices.Clone(t.data),
+		bloom:   t.bloom.clone(),
+	}
+	c.cfg.journal = nil
+	if t.elements != nil {
+		c.elements = slices.Clone(t.elements)
+		c.indices = make(map[string][]uint64, len(t.indices))
+		for element, indices := range t.indices {
+			c.indices[element] = slices.Clone(indices)
+		}
+	}
+	return c
+}
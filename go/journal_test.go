@@ -0,0 +1,136 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// Applies one mutation of every kind journaled, returning the root after each.
+func mutateForJournal(t *testing.T, mt *MerkleTree) []string {
+	t.Helper()
+	var roots []string
+	steps := []func() error{
+		func() error { return mt.UpdateElement(2, "updated") },
+		func() error { return mt.Append("appended") },
+		func() error { return mt.Swap(0, 5) },
+		func() error {
+			return mt.Apply(func(index uint64, element string) (string, error) {
+				if index%2 == 1 {
+					return element + "!", nil
+				}
+				return element, nil
+			})
+		},
+		func() error { return mt.Reset(testElements(7)) },
+		func() error { return mt.UpdateElement(6, "last") },
+		func() error { return mt.ExtendCapacity(30) },
+		func() error { _, err := mt.Compact(); return err },
+		func() error { return mt.InsertAt(1, "inserted") },
+		func() error { return mt.Delete(3) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, mt.GetRoot())
+	}
+	return roots
+}
+
+func TestReplayJournal(t *testing.T) {
+	var journal bytes.Buffer
+	live, _ := NewMerkleTree(testElements(5), WithJournal(&journal))
+	snapshot, _ := NewMerkleTree(testElements(5))
+
+	// mutations changing nothing are not journaled
+	live.Swap(1, 1)
+	live.Apply(func(index uint64, element string) (string, error) { return element, nil })
+	if journal.Len() != 0 {
+		t.Fatalf("got %d bytes journaled for no-op mutations, want 0", journal.Len())
+	}
+
+	mutateForJournal(t, live)
+
+	replayed, err := ReplayJournal(snapshot, bytes.NewReader(journal.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.GetRoot() != live.GetRoot() {
+		t.Errorf("got %s, want %s", replayed.GetRoot(), live.GetRoot())
+	}
+	if snapshot.LeafCount() != 5 {
+		t.Errorf("snapshot changed to %d elements", snapshot.LeafCount())
+	}
+}
+
+func TestReplayJournalAfterCrash(t *testing.T) {
+	var journal bytes.Buffer
+	live, _ := NewMerkleTree(testElements(5), WithJournal(&journal))
+	snapshot, _ := NewMerkleTree(testElements(5))
+
+	var ends []int // journal length after each record
+	live.Subscribe(func(oldRoot, newRoot string, epoch uint64) { ends = append(ends, journal.Len()) })
+	roots := mutateForJournal(t, live)
+
+	for record, end := range ends {
+		// cut the journal inside the record following this one, as a crash mid-write would
+		partial := journal.Bytes()[:end]
+		if record+1 < len(ends) {
+			partial = journal.Bytes()[:end+3]
+		}
+
+		replayed, err := ReplayJournal(snapshot, bytes.NewReader(partial))
+		if record+1 < len(ends) {
+			var jerr *JournalError
+			if !errors.As(err, &jerr) || jerr.Record != uint64(record+1) || !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("record %d: got %v, want a truncated record %d", record, err, record+1)
+			}
+		} else if err != nil {
+			t.Errorf("record %d: %v", record, err)
+		}
+		if replayed.GetRoot() != roots[record] {
+			t.Errorf("record %d: got %s, want %s", record, replayed.GetRoot(), roots[record])
+		}
+	}
+}
+
+func TestReplayJournalMismatch(t *testing.T) {
+	var journal bytes.Buffer
+	live, _ := NewMerkleTree(testElements(5), WithJournal(&journal))
+	mutateForJournal(t, live)
+
+	wrongBase, _ := NewMerkleTree([]string{"element-0", "element-1", "other", "element-3", "element-4"})
+	replayed, err := ReplayJournal(wrongBase, bytes.NewReader(journal.Bytes()))
+	var jerr *JournalError
+	if !errors.As(err, &jerr) || jerr.Record != 0 || !errors.Is(err, ErrJournalMismatch) {
+		t.Fatalf("got %v, want a mismatch in record 0", err)
+	}
+	if replayed.GetRoot() != wrongBase.GetRoot() {
+		t.Errorf("got %s, want the base root %s", replayed.GetRoot(), wrongBase.GetRoot())
+	}
+
+	tampered := bytes.Clone(journal.Bytes())
+	tampered[len(tampered)-1] ^= 1
+	snapshot, _ := NewMerkleTree(testElements(5))
+	if _, err := ReplayJournal(snapshot, bytes.NewReader(tampered)); !errors.Is(err, ErrJournalMismatch) {
+		t.Errorf("got %v, want %v", err, ErrJournalMismatch)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestJournalWriteFailure(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(4), WithJournal(failingWriter{}))
+	if err := mt.UpdateElement(0, "changed"); !errors.Is(err, ErrJournalWrite) {
+		t.Errorf("got %v, want %v", err, ErrJournalWrite)
+	}
+	if mt.Epoch() != 1 {
+		t.Errorf("got epoch %d, want the update applied", mt.Epoch())
+	}
+}
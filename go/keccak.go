@@ -0,0 +1,136 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// Hashes with Keccak-256, as Ethereum does, rather than SHA-256: leaves, nodes and padding.
+// This is the original Keccak padding, not the SHA3-256 standardised later.
+// It sets the hash function of leaves and nodes alike, see WithLeafHasher.
+func WithKeccak256() Option {
+	return func(cfg *config) {
+		cfg.setHashFunction(HashKeccak256)
+	}
+}
+
+const keccak256Rate = 136 // bytes absorbed per permutation for a 256-bit digest
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// Rotation offsets of the lanes, indexed by x + 5y.
+var keccakRotations = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// Applies the Keccak-f[1600] permutation to the state, whose lanes are indexed by x + 5y.
+func keccakF1600(a *[25]uint64) {
+	var b [25]uint64
+	var c [5]uint64
+	for round := 0; round < 24; round++ {
+		// θ
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+		// ρ and π
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], keccakRotations[x+5*y])
+			}
+		}
+		// χ
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				a[y+x] = b[y+x] ^ (^b[y+(x+1)%5] & b[y+(x+2)%5])
+			}
+		}
+		// ι
+		a[0] ^= keccakRoundConstants[round]
+	}
+}
+
+// A Keccak-256 sponge implementing hash.Hash.
+type keccak struct {
+	state [25]uint64
+	buf   [keccak256Rate]byte
+	n     int // bytes of buf filled
+}
+
+func newKeccak256() hash.Hash {
+	return &keccak{}
+}
+
+func (k *keccak) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		c := copy(k.buf[k.n:], p)
+		k.n += c
+		p = p[c:]
+		if k.n == keccak256Rate {
+			k.absorb()
+		}
+	}
+	return written, nil
+}
+
+func (k *keccak) absorb() {
+	for i := 0; i < keccak256Rate/8; i++ {
+		k.state[i] ^= binary.LittleEndian.Uint64(k.buf[8*i:])
+	}
+	keccakF1600(&k.state)
+	k.n = 0
+}
+
+// Appends the digest of the data written so far, which Sum leaves in place.
+func (k *keccak) Sum(b []byte) []byte {
+	final := *k
+	clear(final.buf[final.n:])
+	final.buf[final.n] ^= 0x01
+	final.buf[keccak256Rate-1] ^= 0x80
+	final.absorb()
+
+	var digest Hash
+	for i := 0; i < digestSize/8; i++ {
+		binary.LittleEndian.PutUint64(digest[8*i:], final.state[i])
+	}
+	return append(b, digest[:]...)
+}
+
+func (k *keccak) Reset() {
+	*k = keccak{}
+}
+
+func (k *keccak) Size() int {
+	return digestSize
+}
+
+func (k *keccak) BlockSize() int {
+	return keccak256Rate
+}
+
+func keccak256(data []byte) Hash {
+	var k keccak
+	k.Write(data)
+
+	var digest Hash
+	k.Sum(digest[:0])
+	return digest
+}
@@ -0,0 +1,58 @@
+package merkletree
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKeccak256(t *testing.T) {
+	for input, want := range map[string]string{
+		"":    "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+		"abc": "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45",
+	} {
+		if got := keccak256([]byte(input)); got.String() != want {
+			t.Errorf("%q: got %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestKeccak256Streaming(t *testing.T) {
+	data := make([]byte, 3*keccak256Rate+5)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	want := keccak256(data)
+
+	for _, chunk := range []int{1, 7, keccak256Rate - 1, keccak256Rate, keccak256Rate + 1} {
+		testname := fmt.Sprintf("chunks of %d", chunk)
+		t.Run(testname, func(t *testing.T) {
+			h := newKeccak256()
+			for i := 0; i < len(data); i += chunk {
+				h.Write(data[i:min(i+chunk, len(data))])
+			}
+			if got := Hash(h.Sum(nil)); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+			// Sum leaves the state to absorb more
+			h.Write(data)
+			if got := Hash(h.Sum(nil)); got != keccak256(append(append([]byte(nil), data...), data...)) {
+				t.Error("Sum changed the state")
+			}
+		})
+	}
+}
+
+func TestKeccak256Tree(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5), WithKeccak256())
+	plain, _ := NewMerkleTree(testElements(5))
+	if mt.GetRoot() == plain.GetRoot() {
+		t.Error("Keccak-256 produced the SHA-256 root")
+	}
+	proof, _ := mt.GetProof(3)
+	if !VerifyProof(mt.GetRoot(), proof, WithKeccak256()) {
+		t.Error("failed to verify")
+	}
+	if VerifyProof(mt.GetRoot(), proof) {
+		t.Error("verified under SHA-256")
+	}
+}
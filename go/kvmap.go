@@ -0,0 +1,79 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrKeyNotFound = errors.New("merkletree: key not found")
+
+// Creates a merkle tree committing to every key/value pair of the map.
+// Keys are sorted lexicographically by byte and each pair becomes the leaf EncodeKeyValue(key, value),
+// so maps with the same contents always produce the same root.
+func NewMerkleTreeFromMap(m map[string]string, opts ...Option) (*MerkleTree, error) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	elements := make([]string, len(keys))
+	positions := make(map[string]uint64, len(keys))
+	for i, key := range keys {
+		elements[i] = EncodeKeyValue(key, m[key])
+		positions[key] = uint64(i)
+	}
+
+	t, err := NewMerkleTree(elements, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if t.cfg.setSemantics {
+		// pairs were reordered by leaf hash; distinct keys mean no pair was dropped
+		for i, key := range keys {
+			positions[key] = t.indices[elements[i]][0]
+		}
+	}
+	t.keys = positions
+
+	return t, nil
+}
+
+// Returns the canonical leaf for a key/value pair:
+//
+//	len(key) (8 byte big-endian) | key | len(value) (8 byte big-endian) | value
+func EncodeKeyValue(key string, value string) string {
+	out := make([]byte, 0, 16+len(key)+len(value))
+	out = binary.BigEndian.AppendUint64(out, uint64(len(key)))
+	out = append(out, key...)
+	out = binary.BigEndian.AppendUint64(out, uint64(len(value)))
+	out = append(out, value...)
+	return string(out)
+}
+
+// Generates a proof of the pair stored under key, for trees built with NewMerkleTreeFromMap.
+func (t *MerkleTree) GetProofForKey(key string) (MerkleProof, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return MerkleProof{}, err
+	}
+	defer t.mu.RUnlock()
+
+	index, ok := t.keys[key]
+	if !ok {
+		return MerkleProof{}, fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+	return t.getProof(index)
+}
+
+// Verifies that the proof commits to the given key/value pair under root.
+func VerifyKeyValueProof(root string, key string, value string, proof MerkleProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyKeyValueProof(root, key, value, proof)
+}
+
+// Verifies that the proof commits to the key/value pair, as VerifyKeyValueProof does.
+func (v *Verifier) VerifyKeyValueProof(root string, key string, value string, proof MerkleProof) bool {
+	leaf, err := canonicalDigest(proof.hElement)
+	return err == nil && leaf == v.cfg.hashLeaf(EncodeKeyValue(key, value)) && v.VerifyProof(root, proof)
+}
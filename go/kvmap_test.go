@@ -0,0 +1,100 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewMerkleTreeFromMap(t *testing.T) {
+	m := map[string]string{"carol": "3", "alice": "1", "bob": "2", "dave": "4", "erin": "5"}
+
+	mt, err := NewMerkleTreeFromMap(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := NewMerkleTree([]string{
+		EncodeKeyValue("alice", "1"),
+		EncodeKeyValue("bob", "2"),
+		EncodeKeyValue("carol", "3"),
+		EncodeKeyValue("dave", "4"),
+		EncodeKeyValue("erin", "5"),
+	})
+	if mt.GetRoot() != expected.GetRoot() {
+		t.Errorf("got %s, want %s", mt.GetRoot(), expected.GetRoot())
+	}
+
+	for key, value := range m {
+		testname := fmt.Sprintf("valid proof for key: %s", key)
+		t.Run(testname, func(t *testing.T) {
+			proof, err := mt.GetProofForKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyKeyValueProof(mt.GetRoot(), key, value, proof) {
+				t.Error("invalid proof")
+			}
+			if VerifyKeyValueProof(mt.GetRoot(), key, value+"x", proof) {
+				t.Error("verified the wrong value")
+			}
+		})
+	}
+
+	if _, err := mt.GetProofForKey("mallory"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("got %v, want %v", err, ErrKeyNotFound)
+	}
+}
+
+func TestVerifyKeyValueProofChecksLikeVerifyProof(t *testing.T) {
+	m := map[string]string{"alice": "1", "bob": "2", "carol": "3"}
+	mt, _ := NewMerkleTreeFromMap(m, WithKeccak256(), WithApplicationTag("accounts"))
+	proof, _ := mt.GetProofForKey("bob")
+	root := mt.GetRoot()
+
+	if !mt.Verifier().VerifyKeyValueProof(root, "bob", "2", proof) {
+		t.Error("got the proof rejected by the tree's verifier")
+	}
+	if VerifyKeyValueProof(root, "bob", "2", proof, WithKeccak256()) {
+		t.Error("got the proof verified without its application tag")
+	}
+
+	upper := proof
+	upper.hElement = "0x" + strings.ToUpper(proof.hElement)
+	if !VerifyKeyValueProof(root, "bob", "2", upper, WithKeccak256(), WithApplicationTag("accounts")) {
+		t.Error("got a leaf in another form of the same digest rejected")
+	}
+	upper.hElement = "not a digest"
+	if VerifyKeyValueProof(root, "bob", "2", upper, WithKeccak256(), WithApplicationTag("accounts")) {
+		t.Error("verified a malformed leaf")
+	}
+}
+
+func TestNewMerkleTreeFromMapIsDeterministic(t *testing.T) {
+	first := map[string]string{}
+	second := map[string]string{}
+	for i := 0; i < 100; i++ {
+		first[fmt.Sprintf("key-%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+	for i := 99; i >= 0; i-- {
+		second[fmt.Sprintf("key-%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+
+	a, _ := NewMerkleTreeFromMap(first)
+	b, _ := NewMerkleTreeFromMap(second)
+	if a.GetRoot() != b.GetRoot() {
+		t.Errorf("got %s, want %s", a.GetRoot(), b.GetRoot())
+	}
+}
+
+func TestEncodeKeyValueIsUnambiguous(t *testing.T) {
+	if EncodeKeyValue("ab", "c") == EncodeKeyValue("a", "bc") {
+		t.Error("different pairs share an encoding")
+	}
+
+	want := "\x00\x00\x00\x00\x00\x00\x00\x01k\x00\x00\x00\x00\x00\x00\x00\x02vv"
+	if got := EncodeKeyValue("k", "vv"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package merkletree
+
+// Proves that an element is the last one committed to, i.e. every slot to its right is padding.
+type LastLeafProof struct {
+	inclusion MerkleProof // inclusion proof for the element at index leafCount-1
+}
+
+// Generates a proof that the element at index LeafCount()-1 is the final element of the tree.
+// Every right-hand sibling along its path is a padding subtree, which the verifier recomputes.
+func (t *MerkleTree) GetLastLeafProof() (LastLeafProof, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return LastLeafProof{}, err
+	}
+	defer t.mu.RUnlock()
+
+	proof, err := t.getProof(t.leafCount() - 1)
+	if err != nil {
+		return LastLeafProof{}, err
+	}
+	return LastLeafProof{inclusion: proof}, nil
+}
+
+// Returns the inclusion proof for the last element.
+func (p LastLeafProof) InclusionProof() MerkleProof {
+	return p.inclusion
+}
+
+// Verifies that the proof shows the last of leafCount elements is included under root,
+// with only padding to its right, or under ModeBitcoin only repeats of its own subtrees.
+func VerifyLastLeafProof(root string, leafCount uint64, proof LastLeafProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyLastLeafProof(root, leafCount, proof)
+}
+
+// Verifies that the proof shows the last of leafCount elements is included under root,
+// as VerifyLastLeafProof does.
+func (v *Verifier) VerifyLastLeafProof(root string, leafCount uint64, proof LastLeafProof) bool {
+	if leafCount == 0 {
+		return false
+	}
+
+	// a tree grown by ExtendCapacity is deeper than its element count needs
+	cfg := v.cfg
+	inclusion := proof.inclusion
+	height := len(inclusion.directions)
+	if inclusion.validateShape() != nil || height < cfg.height(leafCount) || !directionsMatchIndex(inclusion.directions, leafCount-1, height) {
+		return false
+	}
+
+	// digests are compared parsed, so any form of the padding hash is recognized
+	padding := cfg.zeroHashes(height)
+	current, err := ParseHash(inclusion.hElement)
+	if err != nil {
+		return false
+	}
+	for level, siblingIsLeft := range inclusion.directions {
+		sibling, err := ParseHash(inclusion.siblings[level])
+		if err != nil {
+			return false
+		}
+		if siblingIsLeft {
+			current = cfg.nodeDigest(sibling, current)
+			continue
+		}
+		want := padding[level]
+		if cfg.duplicateOddNodes {
+			want = current
+		}
+		if sibling != want {
+			return false
+		}
+		current = cfg.nodeDigest(current, sibling)
+	}
+
+	return v.VerifyProof(root, inclusion)
+}
@@ -0,0 +1,91 @@
+package merkletree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLastLeafProof(t *testing.T) {
+	for _, size := range []int{1, 2, 3, 4, 5, 7, 8, 9, 16, 17} {
+		testname := fmt.Sprintf("proves last of %d elements", size)
+		t.Run(testname, func(t *testing.T) {
+			mt, err := NewMerkleTree(testElements(size))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proof, err := mt.GetLastLeafProof()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyLastLeafProof(mt.GetRoot(), uint64(size), proof) {
+				t.Error("invalid last leaf proof")
+			}
+			if proof.InclusionProof().hElement != hashLeaf(fmt.Sprintf("element-%d", size-1)) {
+				t.Error("proof is not for the last element")
+			}
+			if VerifyLastLeafProof(mt.GetRoot(), uint64(size+1), proof) {
+				t.Error("verified against the wrong leaf count")
+			}
+		})
+	}
+}
+
+func TestLastLeafProofRejectsNonFinalElement(t *testing.T) {
+	// a proof for index 4 of a 6 element tree has real data to its right
+	mt, _ := NewMerkleTree(testElements(6))
+	inclusion, _ := mt.GetProof(4)
+
+	if VerifyLastLeafProof(mt.GetRoot(), 5, LastLeafProof{inclusion: inclusion}) {
+		t.Error("verified an element which is not the last")
+	}
+}
+
+func TestLastLeafProofPowerOfTwo(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(8))
+	proof, _ := mt.GetLastLeafProof()
+
+	for level, siblingIsLeft := range proof.inclusion.directions {
+		if !siblingIsLeft {
+			t.Errorf("sibling at level %d should be on the left", level)
+		}
+	}
+	if !VerifyLastLeafProof(mt.GetRoot(), 8, proof) {
+		t.Error("invalid last leaf proof")
+	}
+}
+
+func TestLastLeafProofParsesSiblings(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"padded":  nil,
+		"bitcoin": {WithMode(ModeBitcoin)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(5), opts...)
+			proof, _ := mt.GetLastLeafProof()
+			siblings := proof.inclusion.siblings
+
+			// the padding to the right of the last element, given in another form
+			prefixed := proof
+			prefixed.inclusion.siblings = make([]string, len(siblings))
+			for i, sibling := range siblings {
+				prefixed.inclusion.siblings[i] = "0x" + strings.ToUpper(sibling)
+			}
+			if !VerifyLastLeafProof(mt.GetRoot(), 5, prefixed, opts...) {
+				t.Error("got a proof with prefixed, uppercase siblings rejected")
+			}
+
+			garbled := proof
+			garbled.inclusion.siblings = append([]string{"zz"}, siblings[1:]...)
+			if VerifyLastLeafProof(mt.GetRoot(), 5, garbled, opts...) {
+				t.Error("got a proof with a malformed sibling verified")
+			}
+			short := proof
+			short.inclusion.siblings = siblings[:1]
+			if VerifyLastLeafProof(mt.GetRoot(), 5, short, opts...) {
+				t.Error("got a proof missing siblings verified")
+			}
+		})
+	}
+}
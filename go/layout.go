@@ -0,0 +1,53 @@
+package merkletree
+
+// Nodes are stored in one array, level by level from the leaves up, using level-offset
+// addressing: level l starts at nodes[offsets[l]] and holds only the nodes covering
+// elements, ceil(leafCount / 2^l) of them. Every other node of the level is the padding
+// hash for it. Offsets may be laid out for more leaves than the tree holds, leaving room
+// at the end of each level for Append. These helpers are the only code translating coordinates.
+
+// Appends the start of each level of a tree of the given height over leafCount
+// elements to dst, followed by the total number of stored nodes.
+func levelOffsets(leafCount uint64, height int, dst []uint64) []uint64 {
+	offset := uint64(0)
+	for level, stored := 0, leafCount; level <= height; level++ {
+		dst = append(dst, offset)
+		offset += stored
+		stored = (stored + 1) / 2
+	}
+	return append(dst, offset)
+}
+
+// Returns the stored nodes of the level.
+func (t *MerkleTree) level(level int) []Hash {
+	start := t.offsets[level]
+	end := start + t.levelSize(level)
+	return t.nodes[start:end:end]
+}
+
+// Returns the number of nodes stored for the level.
+func (t *MerkleTree) levelSize(level int) uint64 {
+	return (t.count + 1<<level - 1) >> level
+}
+
+// Returns the number of leaves the layout has room for.
+func (t *MerkleTree) reserved() uint64 {
+	return t.offsets[1] - t.offsets[0]
+}
+
+// Returns the position in nodes of a stored node.
+func (t *MerkleTree) nodeIndex(level int, index uint64) uint64 {
+	return t.offsets[level] + index
+}
+
+// Returns the node at index within level. Each level stores nodes only up to the last
+// one covering an element; every node beyond that is the padding hash for the level,
+// except that under ModeBitcoin the sibling of the last stored node is that node itself.
+func (t *MerkleTree) node(level int, index uint64) Hash {
+	if size := t.levelSize(level); index < size {
+		return t.nodes[t.nodeIndex(level, index)]
+	} else if t.cfg.duplicateOddNodes && index == size && index%2 == 1 {
+		return t.nodes[t.nodeIndex(level, index-1)]
+	}
+	return t.zeroHashes()[level]
+}
@@ -0,0 +1,68 @@
+package merkletree
+
+import "slices"
+
+// Defers recomputing ancestors on UpdateElement until the tree is next read.
+// Reads that depend on interior nodes, such as GetRoot and GetProof, first recompute
+// every ancestor of the leaves updated since, once each, bottom up. Callers applying many
+// updates between reads hash shared ancestors once rather than once per update.
+func WithLazyRecompute() Option {
+	return func(cfg *config) {
+		cfg.lazyRecompute = true
+	}
+}
+
+// Takes t.mu for reading once no updates are pending, recomputing them first if needed.
+// Every method reading interior nodes locks through here rather than t.mu.RLock.
+func (t *MerkleTree) rlock() {
+	t.mu.RLock()
+	for len(t.dirty) > 0 {
+		t.mu.RUnlock()
+		t.mu.Lock()
+		t.recomputeDirty()
+		t.mu.Unlock()
+		t.mu.RLock()
+	}
+}
+
+// Records a leaf whose ancestors are out of date. Duplicates are compacted away
+// whenever the list outgrows twice the leaf count, keeping its growth bounded.
+func (t *MerkleTree) markDirty(index uint64) {
+	t.dirty = append(t.dirty, index)
+	if uint64(len(t.dirty)) > 2*t.leafCount() {
+		slices.Sort(t.dirty)
+		t.dirty = slices.Compact(t.dirty)
+	}
+}
+
+// Recomputes the ancestors of every dirty leaf, one level at a time,
+// so an ancestor shared by several dirty leaves is hashed once.
+func (t *MerkleTree) recomputeDirty() {
+	if len(t.dirty) == 0 {
+		return
+	}
+
+	slices.Sort(t.dirty)
+	indices := slices.Compact(t.dirty)
+	hashed := 0
+
+	for level := 1; level <= t.height(); level++ {
+		// parents are written over the sorted children, never ahead of the child being read
+		parents := indices[:0]
+		for _, index := range indices {
+			if n := len(parents); n == 0 || parents[n-1] != index/2 {
+				parents = append(parents, index/2)
+			}
+		}
+		for _, parent := range parents {
+			t.nodes[t.nodeIndex(level, parent)] = t.cfg.nodeDigest(t.node(level-1, 2*parent), t.node(level-1, 2*parent+1))
+		}
+		hashed += len(parents)
+		indices = parents
+	}
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.NodeHashed(hashed)
+	}
+	t.dirty = t.dirty[:0]
+}
@@ -0,0 +1,102 @@
+package merkletree
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLazyRecompute(t *testing.T) {
+	for _, size := range []int{1, 2, 5, 13, 64} {
+		testname := fmt.Sprintf("lazy tree matches eager tree: %d elements", size)
+		t.Run(testname, func(t *testing.T) {
+			eager, _ := NewMerkleTree(testElements(size))
+			lazy, _ := NewMerkleTree(testElements(size), WithLazyRecompute())
+
+			for i := 0; i < 3*size; i++ {
+				index, element := uint64(i*5%size), fmt.Sprintf("updated-%d", i)
+				eager.UpdateElement(index, element)
+				lazy.UpdateElement(index, element)
+
+				// read only every so often, so several updates are pending at once
+				if i%4 != 3 {
+					continue
+				}
+				if lazy.GetRoot() != eager.GetRoot() {
+					t.Fatalf("after %d updates: got %s, want %s", i+1, lazy.GetRoot(), eager.GetRoot())
+				}
+			}
+
+			for i := uint64(0); i < uint64(size); i++ {
+				eager.UpdateElement(i, "last")
+				lazy.UpdateElement(i, "last")
+			}
+			proof, _ := lazy.GetProof(uint64(size - 1))
+			if !VerifyProof(eager.GetRoot(), proof) {
+				t.Error("invalid proof")
+			}
+			if lazy.Epoch() != eager.Epoch() {
+				t.Errorf("got epoch %d, want %d", lazy.Epoch(), eager.Epoch())
+			}
+		})
+	}
+}
+
+func TestLazyRecomputeHashesSharedAncestorsOnce(t *testing.T) {
+	metrics := &CountingMetrics{}
+	mt, _ := NewMerkleTree(testElements(8), WithLazyRecompute(), WithMetrics(metrics))
+	metrics.Reset()
+
+	for i := uint64(0); i < 4; i++ {
+		mt.UpdateElement(i, "updated")
+	}
+	if got := metrics.NodeHashes.Load(); got != 0 {
+		t.Errorf("got %d node hashes before reading, want 0", got)
+	}
+
+	mt.GetRoot()
+	// two parents of the four leaves, their common parent, and the root
+	if got := metrics.NodeHashes.Load(); got != 4 {
+		t.Errorf("got %d node hashes, want 4", got)
+	}
+	mt.GetRoot()
+	if got := metrics.NodeHashes.Load(); got != 4 {
+		t.Errorf("got %d node hashes after a second read, want 4", got)
+	}
+}
+
+func TestLazyRecomputeResetDiscardsPending(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6), WithLazyRecompute())
+	mt.UpdateElement(2, "updated")
+	mt.Reset(testElements(3))
+
+	want, _ := NewMerkleTree(testElements(3))
+	if mt.GetRoot() != want.GetRoot() {
+		t.Errorf("got %s, want %s", mt.GetRoot(), want.GetRoot())
+	}
+}
+
+func BenchmarkUpdatesThenRoot(b *testing.B) {
+	const size, updates = 1 << 16, 5000
+
+	for _, lazy := range []bool{false, true} {
+		var opts []Option
+		if lazy {
+			opts = append(opts, WithLazyRecompute())
+		}
+		mt, _ := NewMerkleTree(testElements(size), opts...)
+		values := [2][]string{testElements(updates), make([]string, updates)}
+		for i := range values[1] {
+			values[1][i] = fmt.Sprintf("updated-%d", i)
+		}
+
+		b.Run(fmt.Sprintf("lazy=%t", lazy), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for j, value := range values[i%2] {
+					mt.UpdateElement(uint64(j*7919%size), value)
+				}
+				mt.GetRoot()
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package merkletree
+
+import "container/list"
+
+// Optionally implemented by a MetricsSink to also count leaf hashes served from
+// the cache set up by WithLeafHashCache. Hits are not counted as LeafHashed.
+type LeafCacheMetrics interface {
+	LeafCacheHit(n int) // n leaf hashes were served from the cache
+}
+
+// Memoizes leaf hashes while building, keeping at most maxEntries of the most recently
+// used elements. This only pays off when elements repeat, such as status strings.
+// Trees drop the cache once built; an IncrementalBuilder keeps it for its lifetime.
+// A non-positive maxEntries disables the cache.
+func WithLeafHashCache(maxEntries int) Option {
+	return func(cfg *config) {
+		cfg.leafCacheSize = maxEntries
+	}
+}
+
+// A bounded least-recently-used map from element to leaf hash.
+type leafCache struct {
+	max     int
+	order   *list.List               // most recently used entry at the front
+	entries map[string]*list.Element // values are *leafCacheEntry
+	hits    int                      // served from the cache since the last flush
+	misses  int                      // hashed since the last flush
+}
+
+type leafCacheEntry struct {
+	element string
+	digest  Hash
+}
+
+// Returns a cache for the configuration, or nil when caching is disabled.
+func (cfg config) newLeafCache() *leafCache {
+	if cfg.leafCacheSize <= 0 {
+		return nil
+	}
+	return &leafCache{
+		max:     cfg.leafCacheSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Returns the leaf hash of the element under cfg, from the cache when possible.
+// A nil cache hashes every time. A cache must always be used with the same cfg.
+func (c *leafCache) digest(cfg config, element string) Hash {
+	if c == nil {
+		return cfg.leafDigest(element)
+	}
+
+	if e, ok := c.entries[element]; ok {
+		c.order.MoveToFront(e)
+		c.hits++
+		return e.Value.(*leafCacheEntry).digest
+	}
+
+	digest := cfg.leafDigest(element)
+	c.misses++
+
+	if c.order.Len() >= c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*leafCacheEntry).element)
+	}
+	c.entries[element] = c.order.PushFront(&leafCacheEntry{element: element, digest: digest})
+
+	return digest
+}
+
+// Reports the hits and misses since the last flush to the sink, then clears them.
+// Without a cache every digest is a leaf hash, so n is reported as hashed.
+func (c *leafCache) flush(metrics MetricsSink, n int) {
+	if c == nil {
+		if metrics != nil {
+			metrics.LeafHashed(n)
+		}
+		return
+	}
+
+	if metrics != nil {
+		metrics.LeafHashed(c.misses)
+		if m, ok := metrics.(LeafCacheMetrics); ok && c.hits > 0 {
+			m.LeafCacheHit(c.hits)
+		}
+	}
+	c.hits, c.misses = 0, 0
+}
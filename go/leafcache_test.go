@@ -0,0 +1,100 @@
+package merkletree
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestLeafHashCacheMatchesUncached(t *testing.T) {
+	rng := rand.New(rand.NewSource(120))
+
+	for i := 0; i < 200; i++ {
+		size := 1 + rng.Intn(60)
+		distinct := 1 + rng.Intn(8)
+		maxEntries := 1 + rng.Intn(10)
+
+		elements := make([]string, size)
+		for j := range elements {
+			elements[j] = fmt.Sprintf("status-%d", rng.Intn(distinct))
+		}
+
+		plain, _ := NewMerkleTree(elements)
+		cached, err := NewMerkleTree(elements, WithLeafHashCache(maxEntries))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cached.GetRoot() != plain.GetRoot() {
+			t.Errorf("got %s, want %s for %v with %d entries", cached.GetRoot(), plain.GetRoot(), elements, maxEntries)
+		}
+
+		computed, err := ComputeRoot(elements, WithLeafHashCache(maxEntries))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if computed != plain.GetRoot() {
+			t.Errorf("got %s, want %s for %v with %d entries", computed, plain.GetRoot(), elements, maxEntries)
+		}
+	}
+}
+
+func TestLeafHashCacheMetrics(t *testing.T) {
+	elements := []string{"ok", "ok", "failed", "ok", "failed", "pending", "ok", "ok"}
+
+	var m CountingMetrics
+	if _, err := NewMerkleTree(elements, WithLeafHashCache(8), WithMetrics(&m)); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.LeafHashes.Load(); got != 3 {
+		t.Errorf("got %d leaf hashes, want 3", got)
+	}
+	if got := m.LeafCacheHits.Load(); got != 5 {
+		t.Errorf("got %d cache hits, want 5", got)
+	}
+
+	m.Reset()
+	b := NewIncrementalBuilder(WithLeafHashCache(8), WithMetrics(&m))
+	for _, element := range elements {
+		b.Add(element)
+	}
+	if got := m.LeafHashes.Load(); got != 3 {
+		t.Errorf("got %d leaf hashes, want 3", got)
+	}
+	if got := m.LeafCacheHits.Load(); got != 5 {
+		t.Errorf("got %d cache hits, want 5", got)
+	}
+}
+
+func TestLeafHashCacheBounded(t *testing.T) {
+	cache := config{leafCacheSize: 2}.newLeafCache()
+
+	for _, element := range []string{"a", "b", "a", "c", "b", "a"} {
+		if got, want := cache.digest(config{}, element), leafDigest(element); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+		if len(cache.entries) > 2 || cache.order.Len() > 2 {
+			t.Fatalf("got %d entries, want at most 2", len(cache.entries))
+		}
+	}
+
+	// "b" was evicted by "c", since "a" had been used more recently
+	if cache.hits != 1 || cache.misses != 5 {
+		t.Errorf("got %d hits and %d misses, want 1 and 5", cache.hits, cache.misses)
+	}
+}
+
+func TestLeafHashCacheDisabled(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		testname := fmt.Sprintf("size %d", size)
+		t.Run(testname, func(t *testing.T) {
+			if cache := (config{leafCacheSize: size}).newLeafCache(); cache != nil {
+				t.Error("got a cache, want none")
+			}
+			var m CountingMetrics
+			NewMerkleTree([]string{"ok", "ok"}, WithLeafHashCache(size), WithMetrics(&m))
+			if got := m.LeafHashes.Load(); got != 2 {
+				t.Errorf("got %d leaf hashes, want 2", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Creates a tree over the elements as NewMerkleTree does, committing each leaf to the
+// data at the same index too, such as the amount and address of an airdrop claim.
+// A leaf with data hashes as an element encoded by encodeLeafData, and one with nil or
+// empty data as its element alone, so a tree whose data is all empty equals
+// NewMerkleTree's. Proofs carry the data of their leaf, see MerkleProof.Data, and
+// VerifyElementProof recomputes the leaf from the element and that data.
+// The data is copied. Fails with ErrSetOrder under WithSetSemantics, whose order would
+// follow the data, and when data holds another number of entries than elements.
+func NewMerkleTreeWithLeafData(elements []string, data [][]byte, opts ...Option) (*MerkleTree, error) {
+	if len(elements) == 0 {
+		return nil, ErrEmptyTree
+	}
+	if len(data) != len(elements) {
+		return nil, fmt.Errorf("merkletree: %d data for %d elements", len(data), len(elements))
+	}
+
+	t := &MerkleTree{cfg: newConfig(opts)}
+	if err := t.buildWithData(elements, data); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Commits the leaf at index to data in place of any it had, keeping its element, and
+// recomputes its path as UpdateElement does. Nil or empty data leaves the leaf hashed
+// over its element alone. The data stays with the leaf through UpdateElement, Apply,
+// Swap, InsertAt and Delete, and Reset drops it.
+// The data is copied. Fails with ErrIndexOutOfBounds beyond the elements, ErrSetOrder
+// under WithSetSemantics and ErrElementsUnknown on imported trees.
+func (t *MerkleTree) SetLeafData(index uint64, data []byte) error {
+	return t.mutate(func(rec *journalRecord) error {
+		if rec != nil && index < uint64(len(t.elements)) {
+			*rec = journalRecord{op: journalData, indices: []uint64{index}, values: []string{string(t.leafData(index)), string(data)}}
+		}
+		return t.setLeafData(index, data)
+	})
+}
+
+func (t *MerkleTree) setLeafData(index uint64, data []byte) error {
+	if index >= t.leafCount() {
+		return t.outOfBounds(index)
+	}
+	if t.elements == nil {
+		return ErrElementsUnknown
+	}
+	if t.cfg.setSemantics {
+		return ErrSetOrder
+	}
+	if bytes.Equal(t.leafData(index), data) {
+		return nil
+	}
+	element := t.elements[index]
+	if err := t.cfg.checkLeafData(element, data); err != nil {
+		return err
+	}
+
+	if t.data == nil {
+		t.data = make([][]byte, len(t.elements))
+	}
+	t.data[index] = nil
+	if len(data) > 0 {
+		t.data[index] = slices.Clone(data)
+	}
+	t.nodes[t.nodeIndex(0, index)] = t.cfg.dataLeafDigest(element, data)
+	t.epoch++
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.LeafHashed(1)
+	}
+	t.markDirty(index)
+	if !t.cfg.lazyRecompute {
+		t.recomputeDirty()
+	}
+	return nil
+}
+
+// Returns a copy of the data committed with the leaf at index, nil for a leaf without.
+func (t *MerkleTree) LeafData(index uint64) ([]byte, error) {
+	if err := t.rlockBuiltAsIs(); err != nil {
+		return nil, err
+	}
+	defer t.mu.RUnlock()
+
+	if index >= t.leafCount() {
+		return nil, t.outOfBounds(index)
+	}
+	return slices.Clone(t.leafData(index)), nil
+}
+
+// Returns the data committed with the proven leaf, nil for a leaf without.
+// The data is copied.
+func (p MerkleProof) Data() []byte {
+	return slices.Clone(p.data)
+}
+
+// Verifies that the proof commits to the element, together with the data the proof
+// carries, under root: the leaf hash is recomputed from both, so a proof of a leaf
+// committed with data verifies only while it carries that data.
+func VerifyElementProof(root string, element string, proof MerkleProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyElementProof(root, element, proof)
+}
+
+// Verifies that the proof commits to the element and its data, as VerifyElementProof does.
+func (v *Verifier) VerifyElementProof(root string, element string, proof MerkleProof) bool {
+	leaf, err := canonicalDigest(proof.hElement)
+	return err == nil && leaf == v.cfg.dataLeafDigest(element, proof.data).String() && v.VerifyProof(root, proof)
+}
+
+// Builds the tree over the elements, with the data at each index committed to its leaf.
+func (t *MerkleTree) buildWithData(elements []string, data [][]byte) error {
+	if t.cfg.setSemantics {
+		return ErrSetOrder
+	}
+	for i, d := range data {
+		if err := t.cfg.checkLeafData(elements[i], d); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	var start time.Time
+	if t.cfg.timed() {
+		start = time.Now()
+	}
+
+	height, _, err := t.prepare(elements)
+	if err != nil {
+		return err
+	}
+	t.data = nil
+	for i, d := range data {
+		if len(d) == 0 {
+			continue
+		}
+		if t.data == nil {
+			t.data = make([][]byte, len(elements))
+		}
+		t.data[i] = slices.Clone(d)
+	}
+	t.hashLevels(height, t.dataLeaves())
+
+	if t.cfg.timed() {
+		t.reportBuild(time.Since(start))
+	}
+	return nil
+}
+
+// Returns the leaf hash of every element with its data, or nil when no leaf has data,
+// for hashLevels to hash the elements itself.
+func (t *MerkleTree) dataLeaves() []Hash {
+	if t.data == nil {
+		return nil
+	}
+	leaves := make([]Hash, len(t.elements))
+	for i, element := range t.elements {
+		leaves[i] = t.cfg.dataLeafDigest(element, t.data[i])
+	}
+	return leaves
+}
+
+// Returns the data committed with the leaf at index, nil for a leaf without.
+// The data is the tree's own, never to be modified.
+func (t *MerkleTree) leafData(index uint64) []byte {
+	if index >= uint64(len(t.data)) {
+		return nil
+	}
+	return t.data[index]
+}
+
+// Returns the leaf hash of the element at index, with any data committed there.
+func (t *MerkleTree) elementDigest(index uint64, element string) Hash {
+	return t.cfg.dataLeafDigest(element, t.leafData(index))
+}
+
+// Hashes an element with its data into a leaf digest, or the element alone for no data.
+func (cfg config) dataLeafDigest(element string, data []byte) Hash {
+	if len(data) == 0 {
+		return cfg.leafDigest(element)
+	}
+	return cfg.leafDigest(encodeLeafData(element, data))
+}
+
+// Fails for data a hash backend cannot take with the element.
+func (cfg config) checkLeafData(element string, data []byte) error {
+	if cfg.backend == nil || len(data) == 0 {
+		return nil
+	}
+	return cfg.backend.checkElement(encodeLeafData(element, data))
+}
+
+// Encodes a leaf with data as
+//
+//	element | data | data length (8 bytes, big-endian)
+//
+// The length comes last so the encoding parses from its end whatever bytes the element
+// holds, and no two pairs of element and data encode alike.
+func encodeLeafData(element string, data []byte) string {
+	buf := make([]byte, 0, len(element)+len(data)+8)
+	buf = append(buf, element...)
+	buf = append(buf, data...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(data)))
+	return string(buf)
+}
@@ -0,0 +1,207 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLeafDataCommitted(t *testing.T) {
+	elements := testElements(6)
+	data := [][]byte{[]byte("100 0xabc"), nil, []byte("7 0xdef"), {}, []byte("1 0x123"), nil}
+	mt, err := NewMerkleTreeWithLeafData(elements, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// leaves without data hash as their element alone
+	plain, _ := NewMerkleTree(elements)
+	if mt.GetRoot() == plain.GetRoot() {
+		t.Fatal("got the root of the elements alone")
+	}
+	for i := range elements {
+		withData, _ := mt.GetProof(uint64(i))
+		without, _ := plain.GetProof(uint64(i))
+		if same := withData.hElement == without.hElement; same != (len(data[i]) == 0) {
+			t.Errorf("index %d: got leaf hashes equal %v with data %q", i, same, data[i])
+		}
+	}
+	empty, _ := NewMerkleTreeWithLeafData(elements, make([][]byte, len(elements)))
+	if empty.GetRoot() != plain.GetRoot() {
+		t.Errorf("got root %s for empty data, want %s", empty.GetRoot(), plain.GetRoot())
+	}
+
+	proof, _ := mt.GetProof(2)
+	if !bytes.Equal(proof.Data(), data[2]) {
+		t.Errorf("got data %q, want %q", proof.Data(), data[2])
+	}
+	if !VerifyElementProof(mt.GetRoot(), elements[2], proof) {
+		t.Error("proof with its data rejected")
+	}
+	if VerifyElementProof(mt.GetRoot(), elements[2]+"7 0xdef", proof) {
+		t.Error("proof accepted for another element")
+	}
+	stripped := proof
+	stripped.data = nil
+	if VerifyElementProof(mt.GetRoot(), elements[2], stripped) {
+		t.Error("proof accepted without its data")
+	}
+	stripped.data = []byte("700 0xdef")
+	if VerifyElementProof(mt.GetRoot(), elements[2], stripped) {
+		t.Error("proof accepted with other data")
+	}
+	if got, _ := mt.LeafData(0); !bytes.Equal(got, data[0]) {
+		t.Errorf("got leaf data %q, want %q", got, data[0])
+	}
+}
+
+func TestLeafDataEncodings(t *testing.T) {
+	mt, _ := NewMerkleTreeWithLeafData(testElements(5), [][]byte{nil, nil, nil, []byte("amount=5"), nil})
+	proof, _ := mt.GetProof(3)
+
+	for _, encoding := range []Encoding{EncodingBinary, EncodingJSON, EncodingBinaryCompressed, EncodingJSONCompressed} {
+		encoded, err := EncodeProof(proof, encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeProof(encoded, encoding)
+		if err != nil {
+			t.Fatalf("encoding %d: %v", encoding, err)
+		}
+		if !VerifyElementProof(mt.GetRoot(), "element-3", decoded) {
+			t.Errorf("encoding %d: decoded proof rejected, data %q", encoding, decoded.Data())
+		}
+	}
+	text, _ := proof.MarshalText()
+	var decoded MerkleProof
+	if err := decoded.UnmarshalText(text); err != nil || !bytes.Equal(decoded.Data(), []byte("amount=5")) {
+		t.Errorf("got %q, %v from text", decoded.Data(), err)
+	}
+
+	// a proof whose data was dropped in transit no longer verifies its element
+	encoded, _ := json.Marshal(proof)
+	var fields map[string]any
+	json.Unmarshal(encoded, &fields)
+	delete(fields, "data")
+	encoded, _ = json.Marshal(fields)
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyProof(mt.GetRoot(), decoded) || VerifyElementProof(mt.GetRoot(), "element-3", decoded) {
+		t.Error("got the element verified without its data")
+	}
+
+	binary, _ := proof.MarshalBinary()
+	binary[0] &^= proofBinaryDataFlag
+	if _, err := DecodeProof(binary, EncodingBinary); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v with the data flag cleared, want %v", err, ErrMalformedProof)
+	}
+}
+
+func TestLeafDataMutations(t *testing.T) {
+	elements := testElements(7)
+	mt, _ := NewMerkleTree(elements)
+	if err := mt.SetLeafData(2, []byte("two")); err != nil {
+		t.Fatal(err)
+	}
+	want := func(data [][]byte) string {
+		t.Helper()
+		mt.mu.RLock()
+		current := append([]string(nil), mt.elements...)
+		mt.mu.RUnlock()
+		rebuilt, err := NewMerkleTreeWithLeafData(current, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rebuilt.GetRoot()
+	}
+	data := make([][]byte, 7)
+	data[2] = []byte("two")
+	if mt.GetRoot() != want(data) {
+		t.Fatal("SetLeafData root differs from a build with the data")
+	}
+
+	mt.UpdateElement(2, "updated")
+	if mt.GetRoot() != want(data) {
+		t.Error("UpdateElement dropped the leaf's data")
+	}
+	mt.Swap(2, 5)
+	data[2], data[5] = nil, []byte("two")
+	if mt.GetRoot() != want(data) {
+		t.Error("Swap left the data behind")
+	}
+	mt.InsertAt(0, "first")
+	data = append([][]byte{nil}, data...)
+	if mt.GetRoot() != want(data) {
+		t.Error("InsertAt did not shift the data")
+	}
+	mt.Delete(1)
+	data = append(data[:1], data[2:]...)
+	if mt.GetRoot() != want(data) {
+		t.Error("Delete did not shift the data")
+	}
+	mt.Append("last")
+	data = append(data, nil)
+	if mt.GetRoot() != want(data) {
+		t.Error("Append gave the new leaf data")
+	}
+	rehashed, err := mt.Rehash(WithKeccak256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := rehashed.LeafData(5); !bytes.Equal(got, []byte("two")) {
+		t.Errorf("got data %q after Rehash, want %q", got, "two")
+	}
+
+	mt.SetLeafData(5, nil)
+	plain, _ := NewMerkleTree(mt.elements)
+	if mt.GetRoot() != plain.GetRoot() {
+		t.Error("clearing the data left the leaf committed to it")
+	}
+
+	set, _ := NewMerkleTree(elements, WithSetSemantics())
+	if err := set.SetLeafData(0, []byte("x")); !errors.Is(err, ErrSetOrder) {
+		t.Errorf("got %v, want %v", err, ErrSetOrder)
+	}
+	if _, err := NewMerkleTreeWithLeafData(elements, make([][]byte, 7), WithSetSemantics()); !errors.Is(err, ErrSetOrder) {
+		t.Errorf("got %v, want %v", err, ErrSetOrder)
+	}
+	if _, err := NewMerkleTreeWithLeafData(elements, make([][]byte, 3)); err == nil {
+		t.Error("got no error for data of another length")
+	}
+	if err := mt.SetLeafData(mt.LeafCount(), []byte("x")); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+}
+
+func TestLeafDataJournalAndDump(t *testing.T) {
+	var journal bytes.Buffer
+	mt, _ := NewMerkleTree(testElements(4), WithJournal(&journal))
+	base, _ := NewMerkleTree(testElements(4))
+	mt.Watch(1)
+	mt.SetLeafData(1, []byte("one"))
+
+	replayed, err := ReplayJournal(base, &journal)
+	if err != nil || replayed.GetRoot() != mt.GetRoot() {
+		t.Errorf("got %v, replayed root differs", err)
+	}
+	witness, _ := mt.CurrentProof(1)
+	if proof, _ := mt.GetProof(1); !bytes.Equal(witness.Data(), []byte("one")) || witness.hElement != proof.hElement {
+		t.Errorf("got watched proof with data %q, not patched", witness.Data())
+	}
+
+	var dump bytes.Buffer
+	mt.DumpNDJSON(&dump)
+	if !strings.Contains(dump.String(), `"data":"b25l"`) {
+		t.Fatalf("got %q, want the data in the dump", dump.String())
+	}
+	restored, err := RestoreNDJSON(&dump)
+	if err != nil || restored.GetRoot() != mt.GetRoot() {
+		t.Fatalf("got %v, restored root differs", err)
+	}
+	if got, _ := restored.LeafData(1); !bytes.Equal(got, []byte("one")) {
+		t.Errorf("got data %q, want %q", got, "one")
+	}
+}
@@ -0,0 +1,119 @@
+package merkletree
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Logs builds, proof generation and failed verifications to the logger at debug level.
+// Element contents are left out unless WithLogLeafValues is also given.
+// Without this option no logging work is done at all.
+func WithLogger(logger *slog.Logger) Option {
+	return func(cfg *config) {
+		cfg.logger = logger
+	}
+}
+
+// Includes every element, with its index and leaf hash, in the debug record of a build.
+// Only use this where the elements may appear in logs.
+func WithLogLeafValues() Option {
+	return func(cfg *config) {
+		cfg.logLeafValues = true
+	}
+}
+
+// Reports whether builds and proofs need timing, for latency metrics or logging.
+func (cfg config) timed() bool {
+	return cfg.logger != nil || cfg.latency() != nil
+}
+
+// Returns whether debug records would be kept by the logger.
+func (cfg config) debugging() bool {
+	return cfg.logger != nil && cfg.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// Reports a completed build to the latency metrics and the logger.
+func (t *MerkleTree) reportBuild(d time.Duration) {
+	if latency := t.cfg.latency(); latency != nil {
+		latency.BuildDuration(d)
+	}
+	if !t.cfg.debugging() {
+		return
+	}
+
+	sizes := make([]uint64, t.height()+1)
+	for level := range sizes {
+		sizes[level] = t.levelSize(level)
+	}
+	t.cfg.logger.Debug("merkletree: built tree",
+		slog.Uint64("leaves", t.leafCount()),
+		slog.Int("height", t.height()),
+		slog.Any("levelSizes", sizes),
+		slog.Duration("duration", d),
+		slog.String("root", t.rootHash().String()),
+	)
+
+	if t.cfg.logLeafValues {
+		for i, element := range t.elements {
+			t.cfg.logger.Debug("merkletree: leaf",
+				slog.Int("index", i),
+				slog.String("element", element),
+				slog.String("hash", t.node(0, uint64(i)).String()),
+			)
+		}
+	}
+}
+
+// Reports count proofs of the given depth, generated from first onwards, to the
+// latency metrics and the logger. Batches report their average time per proof.
+func (t *MerkleTree) reportProofs(first uint64, count int, depth int, d time.Duration) {
+	if latency := t.cfg.latency(); latency != nil {
+		average := d / time.Duration(count)
+		for i := 0; i < count; i++ {
+			latency.ProofDuration(average)
+		}
+	}
+	if !t.cfg.debugging() {
+		return
+	}
+
+	if count == 1 {
+		t.cfg.logger.Debug("merkletree: generated proof", slog.Uint64("index", first), slog.Int("depth", depth), slog.Duration("duration", d))
+	} else {
+		t.cfg.logger.Debug("merkletree: generated proofs", slog.Int("count", count), slog.Uint64("firstIndex", first), slog.Int("depth", depth), slog.Duration("duration", d))
+	}
+}
+
+// Logs why a proof failed to verify against expected. On a root mismatch the hash
+// computed at each level is included, so the first level differing from the tree
+// the root came from can be found by comparing against its NodeAt.
+func (cfg config) logVerifyFailure(expected string, proof MerkleProof, err error) {
+	if !cfg.debugging() {
+		return
+	}
+
+	if err != nil {
+		cfg.logger.Debug("merkletree: proof rejected", slog.String("expected", expected), slog.String("reason", err.Error()))
+		return
+	}
+
+	path := make([]string, 0, len(proof.siblings)+1)
+	current := proof.hElement
+	path = append(path, current)
+	for i, sibling := range proof.siblings {
+		if proof.directions[i] {
+			current = cfg.hashNode(sibling, current)
+		} else {
+			current = cfg.hashNode(current, sibling)
+		}
+		path = append(path, current)
+	}
+
+	cfg.logger.Debug("merkletree: proof rejected",
+		slog.String("expected", expected),
+		slog.String("computed", current),
+		slog.Int("depth", len(proof.siblings)),
+		slog.Any("path", path),
+	)
+}
@@ -0,0 +1,144 @@
+package merkletree
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Keeps every record logged through it.
+type recordingHandler struct {
+	mu      sync.Mutex
+	level   slog.Level
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+// Returns the attributes of each record with the given message.
+func (h *recordingHandler) find(message string) []map[string]slog.Value {
+	var found []map[string]slog.Value
+	for _, r := range h.records {
+		if r.Message != message {
+			continue
+		}
+		attrs := make(map[string]slog.Value)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value
+			return true
+		})
+		found = append(found, attrs)
+	}
+	return found
+}
+
+func TestLoggerRecordsBuildAndProofs(t *testing.T) {
+	h := &recordingHandler{level: slog.LevelDebug}
+	mt, _ := NewMerkleTree(testElements(5), WithLogger(slog.New(h)))
+
+	builds := h.find("merkletree: built tree")
+	if len(builds) != 1 {
+		t.Fatalf("got %d build records, want 1", len(builds))
+	}
+	if got, _ := builds[0]["levelSizes"].Any().([]uint64); !slices.Equal(got, []uint64{5, 3, 2, 1}) {
+		t.Errorf("got level sizes %v, want [5 3 2 1]", got)
+	}
+	if got := builds[0]["root"].String(); got != mt.GetRoot() {
+		t.Errorf("got root %s, want %s", got, mt.GetRoot())
+	}
+
+	mt.GetProof(3)
+	mt.GetProofs([]uint64{1, 4})
+	proofs := h.find("merkletree: generated proof")
+	if len(proofs) != 1 || proofs[0]["index"].Uint64() != 3 || proofs[0]["depth"].Int64() != 3 {
+		t.Errorf("got proof records %v, want one for index 3 at depth 3", proofs)
+	}
+	if batches := h.find("merkletree: generated proofs"); len(batches) != 1 || batches[0]["count"].Int64() != 2 {
+		t.Errorf("got batch records %v, want one of 2 proofs", batches)
+	}
+
+	for _, r := range h.records {
+		r.Attrs(func(a slog.Attr) bool {
+			if strings.Contains(a.Value.String(), "element-") {
+				t.Errorf("got element contents in %q without WithLogLeafValues", r.Message)
+			}
+			return true
+		})
+	}
+}
+
+func TestLoggerLeafValues(t *testing.T) {
+	h := &recordingHandler{level: slog.LevelDebug}
+	NewMerkleTree(testElements(3), WithLogger(slog.New(h)), WithLogLeafValues())
+
+	leaves := h.find("merkletree: leaf")
+	if len(leaves) != 3 {
+		t.Fatalf("got %d leaf records, want 3", len(leaves))
+	}
+	if got := leaves[2]["element"].String(); got != "element-2" {
+		t.Errorf("got %s, want element-2", got)
+	}
+	if got := leaves[2]["hash"].String(); got != hashLeaf("element-2") {
+		t.Errorf("got %s, want %s", got, hashLeaf("element-2"))
+	}
+}
+
+func TestLoggerRecordsVerificationFailures(t *testing.T) {
+	h := &recordingHandler{level: slog.LevelDebug}
+	logger := WithLogger(slog.New(h))
+	mt, _ := NewMerkleTree(testElements(4))
+	proof, _ := mt.GetProof(1)
+
+	if !VerifyProof(mt.GetRoot(), proof, logger) {
+		t.Fatal("invalid proof")
+	}
+	if len(h.records) != 0 {
+		t.Errorf("got %d records for a valid proof, want 0", len(h.records))
+	}
+
+	other := hashLeaf("other")
+	VerifyProof(other, proof, logger)
+	rejected := h.find("merkletree: proof rejected")
+	if len(rejected) != 1 {
+		t.Fatalf("got %d rejection records, want 1", len(rejected))
+	}
+	if rejected[0]["expected"].String() != other || rejected[0]["computed"].String() != mt.GetRoot() {
+		t.Errorf("got %v, want expected %s and computed %s", rejected[0], other, mt.GetRoot())
+	}
+	path, _ := rejected[0]["path"].Any().([]string)
+	if len(path) != 3 || path[1] != mt.node(1, 0).String() {
+		t.Errorf("got path %v, want 3 hashes matching the tree", path)
+	}
+
+	VerifyProof("truncated", proof, logger)
+	rejected = h.find("merkletree: proof rejected")
+	if len(rejected) != 2 || rejected[1]["reason"].String() == "" {
+		t.Errorf("got %v, want a reason for the malformed root", rejected)
+	}
+}
+
+func TestLoggerAboveDebugDoesNothing(t *testing.T) {
+	h := &recordingHandler{level: slog.LevelInfo}
+	mt, _ := NewMerkleTree(testElements(4), WithLogger(slog.New(h)))
+	mt.GetProof(0)
+	VerifyProof(hashLeaf("other"), MerkleProof{}, WithLogger(slog.New(h)))
+
+	if len(h.records) != 0 {
+		t.Errorf("got %d records, want 0", len(h.records))
+	}
+}
@@ -0,0 +1,94 @@
+// Verifies proofs of the merkletree package under its default hashing, with nothing
+// but crypto/sha256, encoding/hex and errors, so that it compiles small under TinyGo
+// and to WebAssembly for checking proofs in a browser.
+//
+//	ok := merklecore.VerifyProof(root, proof.HElement, proof.Siblings, proof.Directions)
+//
+// where proof holds the fields of a proof as MerkleProof encodes them in JSON.
+// Digests are hex, in either case and with an optional 0x prefix, as the merkletree
+// package accepts them. Leaves are the SHA-256 of their element and nodes the SHA-256
+// of the hex of their two children; trees hashing under any option need the full package.
+// The merkletree package hashes through LeafDigest and NodeDigest itself, so the two
+// cannot drift apart. Files here must not import anything heavier, which a test checks.
+package merklecore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+var (
+	ErrInvalidHash    = errors.New("merklecore: invalid hash")
+	ErrMalformedProof = errors.New("merklecore: malformed proof")
+)
+
+// Bytes in a digest.
+const Size = sha256.Size
+
+// Hashes an element into its leaf digest.
+func LeafDigest(element string) [Size]byte {
+	return sha256.Sum256([]byte(element))
+}
+
+// Hashes two child digests into their parent, over their hex encodings.
+func NodeDigest(left [Size]byte, right [Size]byte) [Size]byte {
+	var buf [4 * Size]byte
+	hex.Encode(buf[:2*Size], left[:])
+	hex.Encode(buf[2*Size:], right[:])
+	return sha256.Sum256(buf[:])
+}
+
+// Parses a hex digest, in either case and with an optional 0x prefix.
+func ParseDigest(s string) ([Size]byte, error) {
+	var digest [Size]byte
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+	}
+	if len(s) != 2*Size {
+		return digest, ErrInvalidHash
+	}
+	if _, err := hex.Decode(digest[:], []byte(s)); err != nil {
+		return digest, ErrInvalidHash
+	}
+	return digest, nil
+}
+
+// Returns the root a proof produces from the leaf digest, folding in each sibling, which
+// is the left child where leftSiblings says so. Only malformed proofs produce an error:
+// ErrInvalidHash for a digest that does not parse, or ErrMalformedProof.
+func DeriveRoot(leaf string, siblings []string, leftSiblings []bool) (string, error) {
+	if len(siblings) != len(leftSiblings) {
+		return "", ErrMalformedProof
+	}
+	current, err := ParseDigest(leaf)
+	if err != nil {
+		return "", err
+	}
+	for i, s := range siblings {
+		sibling, err := ParseDigest(s)
+		if err != nil {
+			return "", err
+		}
+		if leftSiblings[i] {
+			current = NodeDigest(sibling, current)
+		} else {
+			current = NodeDigest(current, sibling)
+		}
+	}
+	return hex.EncodeToString(current[:]), nil
+}
+
+// Reports whether the proof, as DeriveRoot takes it, produces the root.
+func VerifyProof(root string, leaf string, siblings []string, leftSiblings []bool) bool {
+	want, err := ParseDigest(root)
+	if err != nil {
+		return false
+	}
+	derived, err := DeriveRoot(leaf, siblings, leftSiblings)
+	if err != nil {
+		return false
+	}
+	got, _ := ParseDigest(derived)
+	return got == want
+}
@@ -0,0 +1,160 @@
+package merklecore_test
+
+import (
+	"encoding/json"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"merkletree"
+	"merkletree/merklecore"
+)
+
+// The only packages the core may import, none of them reaching for reflection.
+var allowedImports = map[string]bool{
+	"crypto/sha256": true,
+	"encoding/hex":  true,
+	"errors":        true,
+}
+
+func TestImportsStayMinimal(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, spec := range parsed.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if !allowedImports[path] {
+				t.Errorf("%s imports %s", file, path)
+			}
+		}
+	}
+}
+
+func TestBuildsForWasm(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	native, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets := []struct {
+		name   string
+		goos   string
+		goarch string
+		tags   []string
+	}{
+		{"js", "js", "wasm", nil},
+		{"wasip1", "wasip1", "wasm", nil},
+		{"tinygo", "js", "wasm", []string{"tinygo", "tinygo.wasm", "gc.conservative"}},
+	}
+	for _, target := range targets {
+		t.Run(target.name, func(t *testing.T) {
+			ctx := build.Default
+			ctx.GOOS, ctx.GOARCH, ctx.BuildTags = target.goos, target.goarch, target.tags
+			ctx.CgoEnabled = false
+			pkg, err := ctx.ImportDir(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(pkg.GoFiles, ",") != strings.Join(native.GoFiles, ",") {
+				t.Errorf("got files %v, want %v", pkg.GoFiles, native.GoFiles)
+			}
+			for _, path := range pkg.Imports {
+				if !allowedImports[path] {
+					t.Errorf("imports %s", path)
+				}
+			}
+		})
+	}
+}
+
+// The fields of a proof as MerkleProof encodes them in JSON.
+type proofFields struct {
+	HElement   string   `json:"hElement"`
+	Siblings   []string `json:"siblings"`
+	Directions []bool   `json:"directions"`
+}
+
+func TestMatchesMerkletree(t *testing.T) {
+	for _, size := range []int{1, 2, 7, 16, 33} {
+		elements := make([]string, size)
+		for i := range elements {
+			elements[i] = "element-" + strconv.Itoa(i)
+		}
+		mt, err := merkletree.NewMerkleTree(elements)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < size; i++ {
+			proof, _ := mt.GetProof(uint64(i))
+			encoded, _ := json.Marshal(proof)
+			var p proofFields
+			if err := json.Unmarshal(encoded, &p); err != nil {
+				t.Fatal(err)
+			}
+
+			root, err := merklecore.DeriveRoot(p.HElement, p.Siblings, p.Directions)
+			if err != nil || root != mt.GetRoot() {
+				t.Errorf("got %s, %v for element %d of %d, want %s", root, err, i, size, mt.GetRoot())
+			}
+			if !merklecore.VerifyProof("0x"+strings.ToUpper(mt.GetRoot()), p.HElement, p.Siblings, p.Directions) {
+				t.Errorf("proof of element %d of %d rejected", i, size)
+			}
+			if len(p.Siblings) > 0 {
+				p.Directions[0] = !p.Directions[0]
+				if merklecore.VerifyProof(mt.GetRoot(), p.HElement, p.Siblings, p.Directions) {
+					t.Errorf("proof of element %d of %d accepted with a flipped direction", i, size)
+				}
+			}
+		}
+	}
+}
+
+func TestDeriveRootMalformed(t *testing.T) {
+	leaf := merklecore.LeafDigest("a")
+	hexLeaf := strings.Repeat("ab", merklecore.Size)
+	cases := []struct {
+		name     string
+		leaf     string
+		siblings []string
+		dirs     []bool
+		want     error
+	}{
+		{"short leaf", "abcd", nil, nil, merklecore.ErrInvalidHash},
+		{"not hex", strings.Repeat("zz", merklecore.Size), nil, nil, merklecore.ErrInvalidHash},
+		{"bad sibling", hexLeaf, []string{"0x"}, []bool{true}, merklecore.ErrInvalidHash},
+		{"directions short", hexLeaf, []string{hexLeaf}, nil, merklecore.ErrMalformedProof},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := merklecore.DeriveRoot(c.leaf, c.siblings, c.dirs); err != c.want {
+				t.Errorf("got %v, want %v", err, c.want)
+			}
+		})
+	}
+	if merklecore.VerifyProof("nope", hexLeaf, nil, nil) {
+		t.Error("accepted an invalid root")
+	}
+	if got, _ := merklecore.DeriveRoot(merkletree.Hash(leaf).String(), nil, nil); got != merkletree.Hash(leaf).String() {
+		t.Errorf("got %s, want the leaf of a single element tree", got)
+	}
+}
@@ -0,0 +1,220 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: merkle.proto
+
+package merklegrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	MerkleService_GetRoot_FullMethodName       = "/merkletree.v1.MerkleService/GetRoot"
+	MerkleService_GetProof_FullMethodName      = "/merkletree.v1.MerkleService/GetProof"
+	MerkleService_GetMultiProof_FullMethodName = "/merkletree.v1.MerkleService/GetMultiProof"
+	MerkleService_VerifyProof_FullMethodName   = "/merkletree.v1.MerkleService/VerifyProof"
+)
+
+// MerkleServiceClient is the client API for MerkleService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MerkleServiceClient interface {
+	GetRoot(ctx context.Context, in *GetRootRequest, opts ...grpc.CallOption) (*GetRootResponse, error)
+	GetProof(ctx context.Context, in *GetProofRequest, opts ...grpc.CallOption) (*GetProofResponse, error)
+	GetMultiProof(ctx context.Context, in *GetMultiProofRequest, opts ...grpc.CallOption) (*GetMultiProofResponse, error)
+	VerifyProof(ctx context.Context, in *VerifyProofRequest, opts ...grpc.CallOption) (*VerifyProofResponse, error)
+}
+
+type merkleServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMerkleServiceClient(cc grpc.ClientConnInterface) MerkleServiceClient {
+	return &merkleServiceClient{cc}
+}
+
+func (c *merkleServiceClient) GetRoot(ctx context.Context, in *GetRootRequest, opts ...grpc.CallOption) (*GetRootResponse, error) {
+	out := new(GetRootResponse)
+	err := c.cc.Invoke(ctx, MerkleService_GetRoot_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merkleServiceClient) GetProof(ctx context.Context, in *GetProofRequest, opts ...grpc.CallOption) (*GetProofResponse, error) {
+	out := new(GetProofResponse)
+	err := c.cc.Invoke(ctx, MerkleService_GetProof_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merkleServiceClient) GetMultiProof(ctx context.Context, in *GetMultiProofRequest, opts ...grpc.CallOption) (*GetMultiProofResponse, error) {
+	out := new(GetMultiProofResponse)
+	err := c.cc.Invoke(ctx, MerkleService_GetMultiProof_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merkleServiceClient) VerifyProof(ctx context.Context, in *VerifyProofRequest, opts ...grpc.CallOption) (*VerifyProofResponse, error) {
+	out := new(VerifyProofResponse)
+	err := c.cc.Invoke(ctx, MerkleService_VerifyProof_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MerkleServiceServer is the server API for MerkleService service.
+// All implementations must embed UnimplementedMerkleServiceServer
+// for forward compatibility
+type MerkleServiceServer interface {
+	GetRoot(context.Context, *GetRootRequest) (*GetRootResponse, error)
+	GetProof(context.Context, *GetProofRequest) (*GetProofResponse, error)
+	GetMultiProof(context.Context, *GetMultiProofRequest) (*GetMultiProofResponse, error)
+	VerifyProof(context.Context, *VerifyProofRequest) (*VerifyProofResponse, error)
+	mustEmbedUnimplementedMerkleServiceServer()
+}
+
+// UnimplementedMerkleServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMerkleServiceServer struct {
+}
+
+func (UnimplementedMerkleServiceServer) GetRoot(context.Context, *GetRootRequest) (*GetRootResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRoot not implemented")
+}
+func (UnimplementedMerkleServiceServer) GetProof(context.Context, *GetProofRequest) (*GetProofResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProof not implemented")
+}
+func (UnimplementedMerkleServiceServer) GetMultiProof(context.Context, *GetMultiProofRequest) (*GetMultiProofResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMultiProof not implemented")
+}
+func (UnimplementedMerkleServiceServer) VerifyProof(context.Context, *VerifyProofRequest) (*VerifyProofResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyProof not implemented")
+}
+func (UnimplementedMerkleServiceServer) mustEmbedUnimplementedMerkleServiceServer() {}
+
+// UnsafeMerkleServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MerkleServiceServer will
+// result in compilation errors.
+type UnsafeMerkleServiceServer interface {
+	mustEmbedUnimplementedMerkleServiceServer()
+}
+
+func RegisterMerkleServiceServer(s grpc.ServiceRegistrar, srv MerkleServiceServer) {
+	s.RegisterService(&MerkleService_ServiceDesc, srv)
+}
+
+func _MerkleService_GetRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerkleServiceServer).GetRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerkleService_GetRoot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerkleServiceServer).GetRoot(ctx, req.(*GetRootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerkleService_GetProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerkleServiceServer).GetProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerkleService_GetProof_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerkleServiceServer).GetProof(ctx, req.(*GetProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerkleService_GetMultiProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMultiProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerkleServiceServer).GetMultiProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerkleService_GetMultiProof_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerkleServiceServer).GetMultiProof(ctx, req.(*GetMultiProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerkleService_VerifyProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerkleServiceServer).VerifyProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerkleService_VerifyProof_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerkleServiceServer).VerifyProof(ctx, req.(*VerifyProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MerkleService_ServiceDesc is the grpc.ServiceDesc for MerkleService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MerkleService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "merkletree.v1.MerkleService",
+	HandlerType: (*MerkleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRoot",
+			Handler:    _MerkleService_GetRoot_Handler,
+		},
+		{
+			MethodName: "GetProof",
+			Handler:    _MerkleService_GetProof_Handler,
+		},
+		{
+			MethodName: "GetMultiProof",
+			Handler:    _MerkleService_GetMultiProof_Handler,
+		},
+		{
+			MethodName: "VerifyProof",
+			Handler:    _MerkleService_VerifyProof_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "merkle.proto",
+}
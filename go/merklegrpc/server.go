@@ -0,0 +1,124 @@
+// Serves the roots and proofs of a Merkle tree over gRPC, see merkle.proto.
+//
+// Proofs travel in the binary encodings of the merkletree package, so clients
+// decode them with MerkleProof.UnmarshalBinary and MultiProof.UnmarshalBinary,
+// or with MerkleProofBytes.UnmarshalBinary to keep the siblings as raw digests.
+package merklegrpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"merkletree"
+)
+
+// Provides the data served by the server. *merkletree.MerkleTree implements it.
+// Implementations must be safe for concurrent use if the tree is mutated while served.
+type ProofSource interface {
+	GetRootHash() merkletree.Hash
+	LeafCount() uint64
+	Height() int
+	GetProof(index uint64) (merkletree.MerkleProof, error)
+	GetMultiProof(indices []uint64) (merkletree.MultiProof, error)
+	Verifier() *merkletree.Verifier // verifies under the options the tree was built with
+}
+
+// Implements MerkleServiceServer over a ProofSource.
+// Requests fail with FailedPrecondition while the source holds no elements,
+// and with InvalidArgument for indices out of range or malformed proofs.
+type Server struct {
+	UnimplementedMerkleServiceServer
+	source ProofSource
+}
+
+// Creates a server answering from the source.
+func NewServer(source ProofSource) *Server {
+	return &Server{source: source}
+}
+
+func (s *Server) GetRoot(ctx context.Context, req *GetRootRequest) (*GetRootResponse, error) {
+	if err := s.checkNotEmpty(); err != nil {
+		return nil, err
+	}
+
+	root := s.source.GetRootHash()
+	return &GetRootResponse{
+		Root:      root[:],
+		LeafCount: s.source.LeafCount(),
+		Height:    uint32(s.source.Height()),
+	}, nil
+}
+
+func (s *Server) GetProof(ctx context.Context, req *GetProofRequest) (*GetProofResponse, error) {
+	if err := s.checkNotEmpty(); err != nil {
+		return nil, err
+	}
+
+	proof, err := s.source.GetProof(req.GetIndex())
+	if err != nil {
+		return nil, proofError(err)
+	}
+	data, err := proof.MarshalBinary()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &GetProofResponse{Proof: &Proof{Data: data}}, nil
+}
+
+func (s *Server) GetMultiProof(ctx context.Context, req *GetMultiProofRequest) (*GetMultiProofResponse, error) {
+	if err := s.checkNotEmpty(); err != nil {
+		return nil, err
+	}
+	if len(req.GetIndices()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one index is required")
+	}
+
+	multi, err := s.source.GetMultiProof(req.GetIndices())
+	if err != nil {
+		return nil, proofError(err)
+	}
+	data, err := multi.MarshalBinary()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &GetMultiProofResponse{Proof: &MultiProof{Data: data}}, nil
+}
+
+// Verifies a proof against the given root, not necessarily the source's, under the
+// options of the source's tree.
+func (s *Server) VerifyProof(ctx context.Context, req *VerifyProofRequest) (*VerifyProofResponse, error) {
+	if s.source == nil {
+		return nil, status.Error(codes.FailedPrecondition, merkletree.ErrEmptyTree.Error())
+	}
+	var root merkletree.Hash
+	if len(req.GetRoot()) != len(root) {
+		return nil, status.Errorf(codes.InvalidArgument, "root has %d bytes, want %d", len(req.GetRoot()), len(root))
+	}
+	copy(root[:], req.GetRoot())
+
+	var proof merkletree.MerkleProof
+	if err := proof.UnmarshalBinary(req.GetProof().GetData()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &VerifyProofResponse{Valid: s.source.Verifier().VerifyProofHash(root, proof)}, nil
+}
+
+func (s *Server) checkNotEmpty() error {
+	if s.source == nil || s.source.LeafCount() == 0 {
+		return status.Error(codes.FailedPrecondition, merkletree.ErrEmptyTree.Error())
+	}
+	return nil
+}
+
+func proofError(err error) error {
+	if errors.Is(err, merkletree.ErrIndexOutOfBounds) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
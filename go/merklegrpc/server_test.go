@@ -0,0 +1,193 @@
+package merklegrpc
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"merkletree"
+)
+
+// Serves the source in process and returns a client connected to it.
+func newTestClient(t *testing.T, source ProofSource) MerkleServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterMerkleServiceServer(server, NewServer(source))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return NewMerkleServiceClient(conn)
+}
+
+func newTestTree(t *testing.T) *merkletree.MerkleTree {
+	tree, err := merkletree.NewMerkleTree([]string{"a", "b", "c", "d", "e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func wantCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Errorf("got %v (%v), want %v", got, err, want)
+	}
+}
+
+func TestGetRoot(t *testing.T) {
+	tree := newTestTree(t)
+	client := newTestClient(t, tree)
+
+	resp, err := client.GetRoot(context.Background(), &GetRootRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tree.GetRootHash()
+	if !reflect.DeepEqual(resp.GetRoot(), root[:]) || resp.GetLeafCount() != 5 || resp.GetHeight() != 3 {
+		t.Errorf("got %x with %d leaves and height %d, want %x, 5 and 3", resp.GetRoot(), resp.GetLeafCount(), resp.GetHeight(), root)
+	}
+}
+
+func TestGetProof(t *testing.T) {
+	tree := newTestTree(t)
+	client := newTestClient(t, tree)
+
+	for index := uint64(0); index < 5; index++ {
+		resp, err := client.GetProof(context.Background(), &GetProofRequest{Index: index})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var proof merkletree.MerkleProof
+		if err := proof.UnmarshalBinary(resp.GetProof().GetData()); err != nil {
+			t.Fatal(err)
+		}
+		if !merkletree.VerifyProof(tree.GetRoot(), proof) {
+			t.Errorf("invalid proof for index %d", index)
+		}
+	}
+
+	_, err := client.GetProof(context.Background(), &GetProofRequest{Index: 5})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestGetMultiProof(t *testing.T) {
+	tree := newTestTree(t)
+	client := newTestClient(t, tree)
+
+	resp, err := client.GetMultiProof(context.Background(), &GetMultiProofRequest{Indices: []uint64{4, 0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var multi merkletree.MultiProof
+	if err := multi.UnmarshalBinary(resp.GetProof().GetData()); err != nil {
+		t.Fatal(err)
+	}
+	if !merkletree.VerifyMultiProof(tree.GetRoot(), multi) {
+		t.Error("invalid multiproof")
+	}
+	if got := multi.Indices(); !reflect.DeepEqual(got, []uint64{0, 1, 4}) {
+		t.Errorf("got %v, want [0 1 4]", got)
+	}
+
+	_, err = client.GetMultiProof(context.Background(), &GetMultiProofRequest{Indices: []uint64{1, 9}})
+	wantCode(t, err, codes.InvalidArgument)
+	_, err = client.GetMultiProof(context.Background(), &GetMultiProofRequest{})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestVerifyProof(t *testing.T) {
+	tree := newTestTree(t)
+	client := newTestClient(t, tree)
+
+	proof, _ := tree.GetProof(2)
+	data, _ := proof.MarshalBinary()
+	root := tree.GetRootHash()
+	other, _ := merkletree.ParseHash("3b7546ed79e3e5a7907381b093c5a182cbf364c5dd0443dfa956c8cca271cc33")
+
+	cases := map[string]struct {
+		root []byte
+		want bool
+	}{
+		"matching root": {root[:], true},
+		"other root":    {other[:], false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp, err := client.VerifyProof(context.Background(), &VerifyProofRequest{Root: c.root, Proof: &Proof{Data: data}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.GetValid() != c.want {
+				t.Errorf("got %t, want %t", resp.GetValid(), c.want)
+			}
+		})
+	}
+
+	_, err := client.VerifyProof(context.Background(), &VerifyProofRequest{Root: root[:4], Proof: &Proof{Data: data}})
+	wantCode(t, err, codes.InvalidArgument)
+	_, err = client.VerifyProof(context.Background(), &VerifyProofRequest{Root: root[:], Proof: &Proof{Data: data[:10]}})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestServerHonorsTreeOptions(t *testing.T) {
+	tree, err := merkletree.NewMerkleTree([]string{"a", "b", "c", "d", "e"}, merkletree.WithKeccak256(), merkletree.WithRawNodeHashing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient(t, tree)
+
+	resp, err := client.GetMultiProof(context.Background(), &GetMultiProofRequest{Indices: []uint64{1, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var multi merkletree.MultiProof
+	if err := multi.UnmarshalBinary(resp.GetProof().GetData()); err != nil {
+		t.Fatal(err)
+	}
+	if !tree.Verifier().VerifyMultiProof(tree.GetRoot(), multi) {
+		t.Error("invalid multiproof")
+	}
+
+	proof, _ := tree.GetProof(3)
+	data, _ := proof.MarshalBinary()
+	root := tree.GetRootHash()
+	verified, err := client.VerifyProof(context.Background(), &VerifyProofRequest{Root: root[:], Proof: &Proof{Data: data}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verified.GetValid() {
+		t.Error("got the tree's proof rejected")
+	}
+}
+
+// A source which has not received any elements yet.
+type emptySource struct{ ProofSource }
+
+func (emptySource) LeafCount() uint64 { return 0 }
+
+func TestEmptySource(t *testing.T) {
+	client := newTestClient(t, emptySource{})
+
+	_, err := client.GetRoot(context.Background(), &GetRootRequest{})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = client.GetProof(context.Background(), &GetProofRequest{})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = client.GetMultiProof(context.Background(), &GetMultiProofRequest{Indices: []uint64{0}})
+	wantCode(t, err, codes.FailedPrecondition)
+}
@@ -0,0 +1,115 @@
+// Serves the root, proofs and stats of a Merkle tree over HTTP.
+//
+// Routes, all GET and all returning JSON:
+//
+//	/root                merkletree.RootInfo: the root with the leaf count and height it commits to
+//	/proof?index=N       proof for the element at index N
+//	/proof?element=...   proof for the lowest index holding the element
+//	/stats               merkletree.Stats of the tree
+//
+// Errors are returned as {"error": "..."}: 400 for a malformed query,
+// 404 for an index out of range or an element not in the tree,
+// 405 for methods other than GET and HEAD.
+package merklehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"merkletree"
+)
+
+// Provides the data served by the handler. *merkletree.MerkleTree implements it.
+// Implementations must be safe for concurrent use if the tree is mutated while served.
+type ProofSource interface {
+	RootInfo() merkletree.RootInfo
+	GetProof(index uint64) (merkletree.MerkleProof, error)
+	IndexOf(element string) (uint64, bool)
+	Stats() merkletree.Stats
+}
+
+type proofResponse struct {
+	Index uint64                 `json:"index"`
+	Proof merkletree.MerkleProof `json:"proof"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Creates a handler serving the routes described in the package documentation from the source.
+func NewHandler(source ProofSource) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/root", get(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, source.RootInfo())
+	}))
+	mux.HandleFunc("/proof", get(func(w http.ResponseWriter, r *http.Request) {
+		serveProof(w, r, source)
+	}))
+	mux.HandleFunc("/stats", get(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, source.Stats())
+	}))
+	return mux
+}
+
+func serveProof(w http.ResponseWriter, r *http.Request, source ProofSource) {
+	query := r.URL.Query()
+	_, byIndex := query["index"]
+	_, byElement := query["element"]
+
+	var index uint64
+	switch {
+	case byIndex == byElement:
+		writeError(w, http.StatusBadRequest, "exactly one of index and element is required")
+		return
+	case byIndex:
+		parsed, err := strconv.ParseUint(query.Get("index"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid index %q", query.Get("index")))
+			return
+		}
+		index = parsed
+	default:
+		found, ok := source.IndexOf(query.Get("element"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "element not found")
+			return
+		}
+		index = found
+	}
+
+	proof, err := source.GetProof(index)
+	switch {
+	case errors.Is(err, merkletree.ErrIndexOutOfBounds):
+		writeError(w, http.StatusNotFound, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, proofResponse{index, proof})
+	}
+}
+
+// Restricts a handler to GET and HEAD requests.
+func get(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{message})
+}
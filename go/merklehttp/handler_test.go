@@ -0,0 +1,129 @@
+package merklehttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"merkletree"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *merkletree.MerkleTree) {
+	tree, err := merkletree.NewMerkleTree([]string{"some", "test", "elements"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewHandler(tree))
+	t.Cleanup(server.Close)
+	return server, tree
+}
+
+func getJSON(t *testing.T, url string, body any) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("got content type %q, want application/json", got)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestRoot(t *testing.T) {
+	server, tree := newTestServer(t)
+
+	var body merkletree.RootInfo
+	if status := getJSON(t, server.URL+"/root", &body); status != http.StatusOK {
+		t.Fatalf("got status %d, want %d", status, http.StatusOK)
+	}
+	if want := (merkletree.RootInfo{Root: tree.GetRoot(), LeafCount: 3, Height: 2}); body != want {
+		t.Errorf("got %+v, want %+v", body, want)
+	}
+}
+
+func TestStats(t *testing.T) {
+	server, tree := newTestServer(t)
+
+	var body merkletree.Stats
+	if status := getJSON(t, server.URL+"/stats", &body); status != http.StatusOK {
+		t.Fatalf("got status %d, want %d", status, http.StatusOK)
+	}
+	if body != tree.Stats() {
+		t.Errorf("got %+v, want %+v", body, tree.Stats())
+	}
+}
+
+func TestProof(t *testing.T) {
+	server, tree := newTestServer(t)
+
+	queries := map[string]uint64{
+		"index=0":          0,
+		"index=2":          2,
+		"element=test":     1,
+		"element=elements": 2,
+	}
+	for query, index := range queries {
+		testname := fmt.Sprintf("serves proof for %s", query)
+		t.Run(testname, func(t *testing.T) {
+			var body proofResponse
+			if status := getJSON(t, server.URL+"/proof?"+query, &body); status != http.StatusOK {
+				t.Fatalf("got status %d, want %d", status, http.StatusOK)
+			}
+			if body.Index != index {
+				t.Errorf("got index %d, want %d", body.Index, index)
+			}
+			if !merkletree.VerifyProof(tree.GetRoot(), body.Proof) {
+				t.Error("invalid proof")
+			}
+		})
+	}
+}
+
+func TestProofErrors(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	queries := map[string]int{
+		"":                           http.StatusBadRequest,
+		"index=":                     http.StatusBadRequest,
+		"index=-1":                   http.StatusBadRequest,
+		"index=two":                  http.StatusBadRequest,
+		"index=99999999999999999999": http.StatusBadRequest,
+		"index=0&element=some":       http.StatusBadRequest,
+		"index=3":                    http.StatusNotFound,
+		"element=missing":            http.StatusNotFound,
+	}
+	for query, want := range queries {
+		testname := fmt.Sprintf("rejects query %q", query)
+		t.Run(testname, func(t *testing.T) {
+			var body errorResponse
+			if status := getJSON(t, server.URL+"/proof?"+query, &body); status != want {
+				t.Errorf("got status %d, want %d", status, want)
+			}
+			if body.Error == "" {
+				t.Error("got no error message")
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	resp, err := http.Post(server.URL+"/root", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+	if got := resp.Header.Get("Allow"); got != "GET, HEAD" {
+		t.Errorf("got Allow %q, want %q", got, "GET, HEAD")
+	}
+}
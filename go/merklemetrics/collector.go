@@ -0,0 +1,155 @@
+// Exposes the metrics hook of the merkletree package to Prometheus.
+//
+//	collector := merklemetrics.New(tree)
+//	prometheus.MustRegister(collector)
+//
+// The collector can also be passed to merkletree.WithMetrics directly, to instrument
+// trees from their construction or the package-level verifiers.
+package merklemetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"merkletree"
+)
+
+const namespace = "merkletree"
+
+// Provides the tree size reported by the leaf count gauge. *merkletree.MerkleTree implements it.
+type LeafCounter interface {
+	LeafCount() uint64
+}
+
+// A prometheus.Collector fed by the merkletree metrics hook.
+// It implements merkletree.MetricsSink and merkletree.LatencyMetrics, and is safe for concurrent use.
+type Collector struct {
+	leafHashes      prometheus.Counter
+	nodeHashes      prometheus.Counter
+	proofsGenerated prometheus.Counter
+	proofsVerified  *prometheus.CounterVec // by result, ok or fail
+	buildSeconds    prometheus.Histogram
+	proofSeconds    prometheus.Histogram
+	leafCount       prometheus.GaugeFunc // nil without a tree to report on
+}
+
+// Creates a collector receiving the metrics of the tree, which may be nil
+// when the collector is only passed to merkletree.WithMetrics.
+// With a tree, the collector replaces its metrics sink and reports its leaf count.
+func New(tree *merkletree.MerkleTree) *Collector {
+	c := newCollector()
+	if tree != nil {
+		tree.SetMetrics(c)
+		c.leafCount = leafCountGauge(tree)
+	}
+	return c
+}
+
+// Creates a collector reporting the leaf count of any source, such as a store
+// wrapping several trees. The collector still has to be passed to WithMetrics.
+func NewWithLeafCounter(source LeafCounter) *Collector {
+	c := newCollector()
+	c.leafCount = leafCountGauge(source)
+	return c
+}
+
+func newCollector() *Collector {
+	c := &Collector{
+		leafHashes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "leaf_hashes_total",
+			Help:      "Leaf hashes computed.",
+		}),
+		nodeHashes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "node_hashes_total",
+			Help:      "Interior node hashes computed.",
+		}),
+		proofsGenerated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "proofs_generated_total",
+			Help:      "Inclusion proofs generated.",
+		}),
+		proofsVerified: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "proofs_verified_total",
+			Help:      "Inclusion proofs verified, by result.",
+		}, []string{"result"}),
+		buildSeconds: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "build_duration_seconds",
+			Help:      "Time taken to build a tree.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}),
+		proofSeconds: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "proof_duration_seconds",
+			Help:      "Time taken to generate a proof.",
+			Buckets:   prometheus.ExponentialBuckets(0.000001, 4, 10),
+		}),
+	}
+
+	// both results are exported from the start, so rates work before the first failure
+	c.proofsVerified.WithLabelValues("ok")
+	c.proofsVerified.WithLabelValues("fail")
+	return c
+}
+
+func leafCountGauge(source LeafCounter) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "leaf_count",
+		Help:      "Elements committed to by the tree, excluding padding.",
+	}, func() float64 {
+		return float64(source.LeafCount())
+	})
+}
+
+func (c *Collector) LeafHashed(n int) {
+	c.leafHashes.Add(float64(n))
+}
+
+func (c *Collector) NodeHashed(n int) {
+	c.nodeHashes.Add(float64(n))
+}
+
+func (c *Collector) ProofGenerated(depth int) {
+	c.proofsGenerated.Inc()
+}
+
+func (c *Collector) ProofVerified(ok bool) {
+	if ok {
+		c.proofsVerified.WithLabelValues("ok").Inc()
+	} else {
+		c.proofsVerified.WithLabelValues("fail").Inc()
+	}
+}
+
+func (c *Collector) BuildDuration(d time.Duration) {
+	c.buildSeconds.Observe(d.Seconds())
+}
+
+func (c *Collector) ProofDuration(d time.Duration) {
+	c.proofSeconds.Observe(d.Seconds())
+}
+
+func (c *Collector) metrics() []prometheus.Collector {
+	metrics := []prometheus.Collector{c.leafHashes, c.nodeHashes, c.proofsGenerated, c.proofsVerified, c.buildSeconds, c.proofSeconds}
+	if c.leafCount != nil {
+		metrics = append(metrics, c.leafCount)
+	}
+	return metrics
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, metric := range c.metrics() {
+		metric.Describe(ch)
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, metric := range c.metrics() {
+		metric.Collect(ch)
+	}
+}
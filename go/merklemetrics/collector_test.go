@@ -0,0 +1,93 @@
+package merklemetrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"merkletree"
+)
+
+func TestCollectorCountsOperations(t *testing.T) {
+	tree, err := merkletree.NewMerkleTree([]string{"a", "b", "c", "d", "e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := New(tree)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	proof, _ := tree.GetProof(1)
+	tree.GetProofs([]uint64{0, 4})
+	merkletree.VerifyProof(tree.GetRoot(), proof, merkletree.WithMetrics(collector))
+	merkletree.VerifyProof(tree.GetRoot(), proof, merkletree.WithMetrics(collector))
+	merkletree.VerifyProof("00", proof, merkletree.WithMetrics(collector))
+
+	if got := testutil.ToFloat64(collector.proofsGenerated); got != 3 {
+		t.Errorf("got %v proofs generated, want 3", got)
+	}
+	if got := testutil.ToFloat64(collector.proofsVerified.WithLabelValues("ok")); got != 2 {
+		t.Errorf("got %v proofs verified, want 2", got)
+	}
+	if got := testutil.ToFloat64(collector.proofsVerified.WithLabelValues("fail")); got != 1 {
+		t.Errorf("got %v proofs rejected, want 1", got)
+	}
+	if got := testutil.ToFloat64(collector.leafCount); got != 5 {
+		t.Errorf("got leaf count %v, want 5", got)
+	}
+
+	// the tree was built before the collector existed, so only the rebuild is timed
+	if err := tree.Reset([]string{"a", "b", "c", "d", "e", "f", "g"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(collector.leafCount); got != 7 {
+		t.Errorf("got leaf count %v, want 7", got)
+	}
+	if got := testutil.ToFloat64(collector.leafHashes); got != 7 {
+		t.Errorf("got %v leaf hashes, want 7", got)
+	}
+
+	if got := sampleCount(t, registry, "merkletree_build_duration_seconds"); got != 1 {
+		t.Errorf("got %d builds timed, want 1", got)
+	}
+	if got := sampleCount(t, registry, "merkletree_proof_duration_seconds"); got != 3 {
+		t.Errorf("got %d proofs timed, want 3", got)
+	}
+}
+
+// Returns the number of observations of the named histogram.
+func sampleCount(t *testing.T, registry *prometheus.Registry, name string) uint64 {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	t.Fatalf("no histogram %s", name)
+	return 0
+}
+
+func TestCollectorWithoutTree(t *testing.T) {
+	collector := New(nil)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	tree, _ := merkletree.NewMerkleTree([]string{"a", "b", "c"}, merkletree.WithMetrics(collector))
+	tree.GetProof(0)
+
+	if got := testutil.ToFloat64(collector.proofsGenerated); got != 1 {
+		t.Errorf("got %v proofs generated, want 1", got)
+	}
+	if count, _ := testutil.GatherAndCount(registry, "merkletree_leaf_count"); count != 0 {
+		t.Errorf("got %d leaf count gauges, want 0", count)
+	}
+
+	counted := NewWithLeafCounter(tree)
+	if got := testutil.ToFloat64(counted.leafCount); got != 3 {
+		t.Errorf("got leaf count %v, want 3", got)
+	}
+}
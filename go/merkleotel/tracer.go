@@ -0,0 +1,84 @@
+// Adapts an OpenTelemetry tracer to the tracing hook of the merkletree package.
+//
+//	tracer := merkleotel.New(otel.Tracer("merkletree"))
+//	tree, err := merkletree.NewMerkleTree(elements, merkletree.WithTracer(tracer))
+//
+// The spans the tree starts become OpenTelemetry spans, named as the merkletree package
+// names them and carrying its attributes, and nest under any span of the context given.
+package merkleotel
+
+import (
+	"context"
+	"log/slog"
+	"math"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"merkletree"
+)
+
+// A merkletree.Tracer starting its spans with an OpenTelemetry tracer.
+// It is safe for concurrent use when the tracer it wraps is.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// Creates a tracer starting the merkletree package's spans with tracer.
+func New(tracer trace.Tracer) *Tracer {
+	return &Tracer{tracer: tracer}
+}
+
+// Starts an OpenTelemetry span under ctx with the attributes converted, returning the
+// context carrying it.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, merkletree.Span) {
+	ctx, s := t.tracer.Start(ctx, name, trace.WithAttributes(attributes(attrs)...))
+	return ctx, span{s}
+}
+
+// A merkletree.Span wrapping the OpenTelemetry span it ends.
+type span struct {
+	span trace.Span
+}
+
+func (s span) SetAttributes(attrs ...slog.Attr) {
+	s.span.SetAttributes(attributes(attrs)...)
+}
+
+// Records err on the span and sets its status to error, as OpenTelemetry expects of a
+// failed operation.
+func (s span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s span) End() {
+	s.span.End()
+}
+
+// Converts each attribute to its OpenTelemetry counterpart. Unsigned integers too large
+// for an int64 and kinds OpenTelemetry has no type for are kept as strings.
+func attributes(attrs []slog.Attr) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, len(attrs))
+	for i, a := range attrs {
+		value := a.Value.Resolve()
+		switch value.Kind() {
+		case slog.KindBool:
+			kvs[i] = attribute.Bool(a.Key, value.Bool())
+		case slog.KindInt64:
+			kvs[i] = attribute.Int64(a.Key, value.Int64())
+		case slog.KindUint64:
+			if n := value.Uint64(); n <= math.MaxInt64 {
+				kvs[i] = attribute.Int64(a.Key, int64(n))
+				continue
+			}
+			kvs[i] = attribute.String(a.Key, value.String())
+		case slog.KindFloat64:
+			kvs[i] = attribute.Float64(a.Key, value.Float64())
+		default:
+			kvs[i] = attribute.String(a.Key, value.String())
+		}
+	}
+	return kvs
+}
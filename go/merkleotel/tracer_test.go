@@ -0,0 +1,91 @@
+package merkleotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"merkletree"
+)
+
+func newTracer() (*Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return New(provider.Tracer("merkleotel")), exporter
+}
+
+func attrs(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestTracerExportsSpans(t *testing.T) {
+	tracer, exporter := newTracer()
+	tree, err := merkletree.NewMerkleTree([]string{"a", "b", "c", "d", "e"}, merkletree.WithTracer(tracer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, _ := tree.GetProof(3)
+	merkletree.VerifyProof(tree.GetRoot(), proof, merkletree.WithTracer(tracer))
+	merkletree.VerifyProof("root", proof, merkletree.WithTracer(tracer))
+
+	spans := exporter.GetSpans()
+	names := []string{"merkletree.NewMerkleTree", "merkletree.GetProof", "merkletree.VerifyProof", "merkletree.VerifyProof"}
+	if len(spans) != len(names) {
+		t.Fatalf("got %d spans, want %d", len(spans), len(names))
+	}
+	for i, span := range spans {
+		if span.Name != names[i] {
+			t.Errorf("span %d: got %s, want %s", i, span.Name, names[i])
+		}
+	}
+
+	built := attrs(spans[0])
+	if built["merkletree.leaf_count"].AsInt64() != 5 || built["merkletree.depth"].AsInt64() != 3 {
+		t.Errorf("got leaf count %v and depth %v, want 5 and 3", built["merkletree.leaf_count"].Emit(), built["merkletree.depth"].Emit())
+	}
+	if got := attrs(spans[1])["merkletree.index"]; got.Type() != attribute.INT64 || got.AsInt64() != 3 {
+		t.Errorf("got index %v, want 3", got.Emit())
+	}
+	if got := attrs(spans[2])["merkletree.result"]; got.Type() != attribute.BOOL || !got.AsBool() {
+		t.Errorf("got result %v, want true", got.Emit())
+	}
+
+	failed := spans[3]
+	if got := attrs(failed)["merkletree.result"]; got.AsBool() {
+		t.Errorf("got result %v, want false", got.Emit())
+	}
+	if _, ok := attrs(failed)["merkletree.reason"]; !ok || failed.Status.Code != codes.Error || len(failed.Events) != 1 {
+		t.Errorf("got status %v with %d events, want the error recorded", failed.Status, len(failed.Events))
+	}
+}
+
+func TestTracerNestsUnderContext(t *testing.T) {
+	tracer, exporter := newTracer()
+	ctx, parent := tracer.tracer.Start(context.Background(), "request")
+	_, err := merkletree.NewMerkleTreeFromSource(ctx, merkletree.SliceSource(nil), merkletree.WithTracer(tracer))
+	parent.End()
+	if !errors.Is(err, merkletree.ErrEmptyTree) {
+		t.Fatalf("got %v, want %v", err, merkletree.ErrEmptyTree)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want the build and the request", len(spans))
+	}
+	build := spans[0]
+	if build.Name != "merkletree.NewMerkleTreeFromSource" || build.Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Errorf("got span %s under %v, want it under the request's", build.Name, build.Parent.SpanID())
+	}
+	if build.Status.Code != codes.Error {
+		t.Errorf("got status %v, want the error recorded", build.Status)
+	}
+}
@@ -1,21 +1,187 @@
 package merkletree
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
 )
 
-// Verifies a Merkle proof against a known root.
-func VerifyProof(root string, proof MerkleProof) bool {
-	// TODO
-	return true
+var (
+	ErrEmptyTree        = errors.New("merkletree: at least one element is required")
+	ErrIndexOutOfBounds = errors.New("merkletree: index out of bounds")
+	ErrCapacityExceeded = errors.New("merkletree: elements exceed the fixed depth")
+)
+
+// Verifies a Merkle proof against a known root. A proof without siblings, as from a
+// single element tree, verifies exactly when its element hash is the root. The depth
+// of the proof is not checked against anything, so a proof omitting upper levels
+// verifies against the inner node it reaches; VerifyProofAtDepth rules that out.
+func VerifyProof(root string, proof MerkleProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyProof(root, proof)
+}
+
+// Verifies a Merkle proof against a known root, returning why it fails: an *InvalidDigestError
+// for a root or proof digest that is not a valid hash, ErrMalformedProof for a proof of
+// inconsistent shape, ErrDomainMismatch for a proof under another application tag,
+// or ErrInvalidProof for a well-formed proof of another root.
+// Digests may use either case and an optional 0x prefix.
+func VerifyProofWithReason(root string, proof MerkleProof, opts ...Option) error {
+	return newVerifier(opts).VerifyProofWithReason(root, proof)
+}
+
+// Verifies a Merkle proof against a known, typed root.
+func VerifyProofHash(root Hash, proof MerkleProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyProofHash(root, proof)
+}
+
+func verifyProofHash(cfg config, root Hash, proof MerkleProof) (err error) {
+	if cfg.tracer != nil {
+		_, span := cfg.tracer.Start(context.Background(), spanVerifyProof, slog.Int(attrDepth, len(proof.siblings)))
+		defer func() { endVerifySpan(span, err) }()
+	}
+
+	var derived string
+	proof, derived, err = cfg.deriveRoot(proof)
+	if err == nil && derived != root.String() {
+		err = ErrInvalidProof
+	}
+
+	if cfg.metrics != nil {
+		cfg.metrics.NodeHashed(len(proof.siblings))
+		cfg.metrics.ProofVerified(err == nil)
+	}
+	switch {
+	case errors.Is(err, ErrInvalidProof):
+		cfg.logVerifyFailure(root.String(), proof, nil)
+	case err != nil:
+		cfg.logVerifyFailure(root.String(), proof, err)
+	}
+
+	return err
+}
+
+// Returns the root the proof produces, without comparing it to anything. With options,
+// the proof folds exactly as VerifyProof(root, proof, opts...) folds it, so the root
+// differs from that of the tree only when the proof or the options do, and proofs of
+// another application tag or algorithm fail as VerifyProofWithReason reports them.
+// Without options, the proof alone is folded: under its tag, with the algorithm it names
+// when that hashes bytes and with SHA-256 for proofs naming none. Malformed proofs, of
+// inconsistent shape or with invalid digests, produce an error either way.
+func DeriveRoot(proof MerkleProof, opts ...Option) (string, error) {
+	return proofVerifier(proof, opts).DeriveRoot(proof)
+}
+
+// Normalizes the proof and folds it under cfg once its tag and algorithm are checked: the
+// fold VerifyProof compares and DeriveRoot returns. Returns the normalized proof with it.
+func (cfg config) deriveRoot(proof MerkleProof) (MerkleProof, string, error) {
+	cfg, proof, err := cfg.forProof(proof)
+	if err != nil {
+		return proof, "", err
+	}
+	return proof, cfg.foldProof(proof), nil
+}
+
+// Returns the proof normalized and the configuration it folds under, failing for a proof
+// that is malformed or names another tag or algorithm than cfg.
+func (cfg config) forProof(proof MerkleProof) (config, MerkleProof, error) {
+	proof, err := proof.normalized()
+	if err != nil {
+		return cfg, proof, err
+	}
+	if err := cfg.checkTag(proof.tag); err != nil {
+		return cfg, proof, err
+	}
+	cfg, err = cfg.forAlgorithm(proof.algorithm)
+	return cfg, proof, err
+}
+
+// Returns the root the proof produces, as DeriveRoot does, along with every node the fold
+// computed on the way there. The trace is ordered from the leaf upwards: trace[i] is the
+// node after folding sibling i, the one at level i+1 of the tree, so the root is its last
+// entry and a proof of depth 0 has none. Callers can keep the entries as trusted nodes for
+// VerifyProofPartial, or compare them with a prover's claimed intermediates, where the
+// first entry that differs locates the corrupted sibling. Options are taken as DeriveRoot
+// takes them, so the trace matches the nodes of a tree built with the same options.
+func DeriveRootWithTrace(proof MerkleProof, opts ...Option) (root string, trace []string, err error) {
+	return proofVerifier(proof, opts).DeriveRootWithTrace(proof)
+}
+
+// Returns the root the proof produces under the verifier's options along with every node
+// the fold computed, as DeriveRootWithTrace does.
+func (v *Verifier) DeriveRootWithTrace(proof MerkleProof) (root string, trace []string, err error) {
+	cfg, proof, err := v.cfg.forProof(proof)
+	if err != nil {
+		return "", nil, err
+	}
+
+	trace = make([]string, len(proof.siblings))
+	current := proof.hElement
+	for i, sibling := range proof.siblings {
+		if proof.directions[i] {
+			current = cfg.hashNode(sibling, current)
+		} else {
+			current = cfg.hashNode(current, sibling)
+		}
+		trace[i] = current
+	}
+	return current, trace, nil
+}
+
+// Checks the proof's shape and digests, returning it with every digest in canonical form.
+// The siblings are only copied when one of them is not canonical already.
+func (p MerkleProof) normalized() (MerkleProof, error) {
+	if err := p.validateShape(); err != nil {
+		return p, err
+	}
+
+	element, err := canonicalDigest(p.hElement)
+	if err != nil {
+		return p, &InvalidDigestError{Field: "element", Err: err}
+	}
+	p.hElement = element
+
+	copied := false
+	for i, sibling := range p.siblings {
+		canonical, err := canonicalDigest(sibling)
+		if err != nil {
+			return p, &InvalidDigestError{Field: "sibling", Index: i, Err: err}
+		}
+		if canonical != sibling {
+			if !copied {
+				p.siblings = append([]string(nil), p.siblings...)
+				copied = true
+			}
+			p.siblings[i] = canonical
+		}
+	}
+
+	return p, nil
+}
+
+// Folds the proof's siblings into its element hash, returning the resulting root, through
+// the fold of VerifyProofInto. Expects a normalized proof.
+func (cfg config) foldProof(proof MerkleProof) string {
+	digests := make([]byte, digestSize*(len(proof.siblings)+1))
+	siblings := make([][]byte, len(proof.siblings))
+	hex.Decode(digests, []byte(proof.hElement))
+	for i, sibling := range proof.siblings {
+		siblings[i] = digests[digestSize*(i+1) : digestSize*(i+2)]
+		hex.Decode(siblings[i], []byte(sibling))
+	}
+
+	root, _ := cfg.foldDigests(nil, digests[:digestSize], siblings, proof.directions)
+	return root.String()
 }
 
 // Hash function to be used for the construction of the merkle tree
 func hashLeaf(leaf string) string {
-	h := sha256.New()
-	h.Write([]byte(leaf))
-	return fmt.Sprintf("%x", h.Sum(nil))
+	return leafDigest(leaf).String()
 }
 
 // Hash function to be used for the construction of the merkle tree
@@ -23,30 +189,294 @@ func hashNode(a string, b string) string {
 	h := sha256.New()
 	h.Write([]byte(a))
 	h.Write([]byte(b))
-	return fmt.Sprintf("%x", h.Sum(nil))
+	return hex.EncodeToString(h.Sum(nil))
 }
 
+// A MerkleTree is safe for concurrent use. Mutations take it exclusively, so every
+// read, including a whole proof, sees the tree entirely before or after each mutation.
 type MerkleTree struct {
-	// TODO
+	mu       sync.RWMutex // held for writing by mutations and for reading by everything else
+	cfg      config
+	elements []string            // original elements, without padding
+	indices  map[string][]uint64 // ascending indices holding each element
+	data     [][]byte            // data committed with each leaf, see leafdata.go; nil when no leaf has any
+	keys     map[string]uint64   // index of each key, for trees built from a map
+	nodes    []Hash              // stored node hashes of every level, from the leaves up to the root, see layout.go
+	count    uint64              // leaves stored, equal to the element count when elements are known
+	offsets  []uint64            // start of each level within nodes, followed by the total
+	zero     []Hash              // padding hash per level, nil until first needed
+	epoch    uint64              // number of mutations since construction
+	dirty    []uint64            // leaves updated since their ancestors were last computed, see lazy.go
+	workers  int                 // goroutines which hashed the last build at once, zero when sequential, see parallel.go
+
+	witnesses   map[uint64]MerkleProof // maintained proof of each watched index, see witness.go
+	subscribers subscribers            // notified of root changes, see subscribe.go
+	bloom       *bloomFilter           // filter over the leaf hashes, see bloom.go; nil without WithBloomFilter
+	proofs      proofCache             // proofs served at the current epoch, see proofcache.go
+	shared      bool                   // state is held by a FrozenTree too, so copied before mutating, see freeze.go
 }
 
 type MerkleProof struct {
 	hElement   string   // hash of element for which we want to prove inclusion
 	siblings   []string // path of siblings from the element up to the root
 	directions []bool   // signal if the sibling at the same index is on the left or right
+	epoch      uint64   // epoch of the tree when the proof was generated
+	tag        string   // application tag of the tree, see WithApplicationTag
+
+	algorithm AlgorithmID // algorithm the siblings were hashed with, see algorithm.go
+	data      []byte      // data committed with the element, see leafdata.go
 }
 
 // Creates a merkle tree from a list of elements.
 // The tree should have the minimum height needed to contain all elements.
 // Empty slots should be filled with an empty string.
-func NewMerkleTree(elements []string) (*MerkleTree, error) {
-	// TODO
-	return &MerkleTree{}, nil
+// The elements are copied, as are the slices of every accessor and proof returned,
+// so neither the caller's slice nor anything handed out aliases the tree.
+func NewMerkleTree(elements []string, opts ...Option) (*MerkleTree, error) {
+	cfg := newConfig(opts)
+	if cfg.tracer == nil {
+		return newMerkleTree(elements, cfg)
+	}
+
+	_, span := cfg.tracer.Start(context.Background(), spanNewMerkleTree)
+	t, err := newMerkleTree(elements, cfg)
+	t.endSpan(span, err)
+	return t, err
+}
+
+// Builds the tree NewMerkleTree does, under cfg and without a span of its own.
+func newMerkleTree(elements []string, cfg config) (*MerkleTree, error) {
+	if len(elements) == 0 {
+		return nil, ErrEmptyTree
+	}
+
+	t := &MerkleTree{cfg: cfg}
+	if err := t.build(elements); err != nil {
+		return nil, err
+	}
+
+	return t, nil
 }
 
+// Replaces every element of the tree and recomputes it, leaving it as NewMerkleTree
+// would with the same options, except that the epoch advances as for any mutation.
+// Storage from the previous build is reused where it is large enough, so rebuilding
+// a similarly sized tree allocates little. On error the tree is left unchanged.
+func (t *MerkleTree) Reset(elements []string) error {
+	return t.mutate(func(rec *journalRecord) error {
+		if rec != nil {
+			*rec = journalRecord{op: journalReset, values: elements}
+		}
+		return t.reset(elements)
+	})
+}
+
+func (t *MerkleTree) reset(elements []string) error {
+	if err := t.build(elements); err != nil {
+		return err
+	}
+
+	t.keys = nil
+	t.data = nil
+	t.epoch++
+	return nil
+}
+
+// Computes the tree over the elements, reusing any storage already held.
+func (t *MerkleTree) build(elements []string) error {
+	var start time.Time
+	if t.cfg.timed() {
+		start = time.Now()
+	}
+
+	height, leaves, err := t.prepare(elements)
+	if err != nil {
+		return err
+	}
+	t.hashLevels(height, leaves)
+
+	if t.cfg.timed() {
+		t.reportBuild(time.Since(start))
+	}
+	return nil
+}
+
+// Checks and indexes the elements of a build and stores them, returning the height of
+// the tree and, under set semantics, the leaf hashes already computed to order them.
+// On error the tree is left unchanged.
+func (t *MerkleTree) prepare(elements []string) (height int, leaves []Hash, err error) {
+	if t.cfg.setSemantics {
+		elements, leaves = t.cfg.setOrder(elements)
+	}
+
+	height, err = t.cfg.checkedHeight(uint64(len(elements)))
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := t.cfg.checkElements(elements); err != nil {
+		return 0, nil, err
+	}
+
+	if err := t.indexElements(elements); err != nil {
+		if len(t.elements) > 0 {
+			t.indexElements(t.elements)
+		}
+		return 0, nil, err
+	}
+	t.elements = append(t.elements[:0], elements...)
+	return height, leaves, nil
+}
+
+// Hashes every level of a tree of the given height over the elements,
+// reusing the stored node array where it is large enough.
+// Leaf hashes already computed by the caller can be passed as leaves, or nil to hash them here;
+// a tree without elements is built over the leaves alone.
+func (t *MerkleTree) hashLevels(height int, leaves []Hash) {
+	t.layoutLevels(height, leaves)
+
+	reduced := t.cfg.pipelineChunkHeight(t.count, height)
+	t.workers = 0
+	switch {
+	case reduced > 0:
+		t.hashPipelined(reduced, leaves)
+	case leaves == nil:
+		t.hashLeaves()
+	default:
+		copy(t.level(0), leaves)
+		if t.cfg.metrics != nil {
+			t.cfg.metrics.LeafHashed(len(leaves))
+		}
+	}
+	for level := reduced + 1; level <= height; level++ {
+		t.hashParents(level)
+	}
+	t.buildBloom()
+}
+
+// Sizes the levels of a tree of the given height over its elements, or over the leaves
+// when not nil, leaving every node to be hashed.
+func (t *MerkleTree) layoutLevels(height int, leaves []Hash) {
+	if len(t.zero) != height+1 {
+		t.zero = nil
+	}
+	t.count = uint64(len(t.elements))
+	if leaves != nil {
+		t.count = uint64(len(leaves))
+	}
+	t.offsets = levelOffsets(t.count, height, t.offsets[:0])
+	t.nodes = resizeHashes(t.nodes, int(t.offsets[height+1]))
+	t.dirty = t.dirty[:0]
+}
+
+// Returns s resized to n hashes, reusing its backing array when it is large enough.
+func resizeHashes(s []Hash, n int) []Hash {
+	if cap(s) >= n {
+		return s[:n]
+	}
+	return make([]Hash, n)
+}
+
+// Hashes the elements into the leaf level.
+// Only occupied slots are stored; the padding beyond them is implied, see node.
+func (t *MerkleTree) hashLeaves() {
+	leaves := t.level(0)
+	cache := t.cfg.newLeafCache()
+
+	for i, element := range t.elements {
+		leaves[i] = cache.digest(t.cfg, element)
+	}
+
+	cache.flush(t.cfg.metrics, len(leaves))
+}
+
+// Hashes each pair of nodes in the level below into the given level.
+// A trailing node without a stored sibling is paired with padding.
+func (t *MerkleTree) hashParents(level int) {
+	children, parents := t.level(level-1), t.level(level)
+
+	for i := range parents {
+		parents[i] = t.cfg.nodeDigest(children[2*i], t.node(level-1, uint64(2*i+1)))
+	}
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.NodeHashed(len(parents))
+	}
+}
+
+// Returns the padding hash for each level of the tree, computing them on first use.
+// Builds reach this whenever the tree holds padding, so readers never assign t.zero.
+func (t *MerkleTree) zeroHashes() []Hash {
+	if t.zero == nil {
+		t.zero = t.cfg.zeroHashes(t.height())
+		if t.cfg.metrics != nil {
+			t.cfg.metrics.LeafHashed(1)
+			t.cfg.metrics.NodeHashed(t.height())
+		}
+	}
+	return t.zero
+}
+
+// Returns the root as hex, or the empty string, which verifies nothing, for a tree no
+// constructor built.
 func (t *MerkleTree) GetRoot() string {
-	// TODO
-	return ""
+	if t.rlockBuilt() != nil {
+		return ""
+	}
+	defer t.mu.RUnlock()
+	return t.rootHash().String()
+}
+
+func (t *MerkleTree) GetRootHash() Hash {
+	if t.rlockBuilt() != nil {
+		return Hash{}
+	}
+	defer t.mu.RUnlock()
+	return t.rootHash()
+}
+
+// Returns the number of levels between the leaves and the root.
+func (t *MerkleTree) Height() int {
+	if t.rlockBuiltAsIs() != nil {
+		return 0
+	}
+	defer t.mu.RUnlock()
+	return t.height()
+}
+
+// Returns the number of elements committed to, excluding padding.
+func (t *MerkleTree) LeafCount() uint64 {
+	if t.rlockBuiltAsIs() != nil {
+		return 0
+	}
+	defer t.mu.RUnlock()
+	return t.leafCount()
+}
+
+// Returns the number of leaf slots, including padding.
+func (t *MerkleTree) PaddedLeafCount() uint64 {
+	if t.rlockBuiltAsIs() != nil {
+		return 0
+	}
+	defer t.mu.RUnlock()
+	return t.paddedLeafCount()
+}
+
+// The unexported accessors below expect the caller to hold t.mu.
+
+func (t *MerkleTree) rootHash() Hash {
+	return t.nodes[t.offsets[t.height()]]
+}
+
+func (t *MerkleTree) height() int {
+	return len(t.offsets) - 2
+}
+
+func (t *MerkleTree) leafCount() uint64 {
+	return t.count
+}
+
+func (t *MerkleTree) paddedLeafCount() uint64 {
+	return 1 << t.height()
 }
 
 // Generates a Merkle proof of the inclusion of the element at the given index.
@@ -65,9 +495,63 @@ func (t *MerkleTree) GetRoot() string {
 // hElement   = h
 // siblings   = [d3-3, d2-0, d1-1]
 // directions = [false, true, false]
-func (t *MerkleTree) GetProof(index uint64) (MerkleProof, error) {
-	// TODO
-	return MerkleProof{}, nil
+func (t *MerkleTree) GetProof(index uint64) (proof MerkleProof, err error) {
+	if err := t.rlockBuilt(); err != nil {
+		return MerkleProof{}, err
+	}
+	defer t.mu.RUnlock()
+	if t.cfg.tracer != nil {
+		_, span := t.cfg.tracer.Start(context.Background(), spanGetProof, slog.Uint64(attrIndex, index))
+		defer func() { t.endSpan(span, err) }()
+	}
+	return t.getProof(index)
+}
+
+func (t *MerkleTree) getProof(index uint64) (MerkleProof, error) {
+	if index >= t.leafCount() {
+		return MerkleProof{}, t.outOfBounds(index)
+	}
+
+	return t.cachedProof(index), nil
+}
+
+// Builds the proof for any leaf slot, including padding.
+func (t *MerkleTree) proofAt(index uint64) MerkleProof {
+	var start time.Time
+	if t.cfg.timed() {
+		start = time.Now()
+	}
+	leaf := index
+
+	proof := MerkleProof{
+		hElement:   t.node(0, index).String(),
+		siblings:   make([]string, 0, t.height()),
+		directions: make([]bool, 0, t.height()),
+		epoch:      t.epoch,
+		tag:        t.cfg.tag,
+		algorithm:  t.cfg.algorithm(),
+		data:       slices.Clone(t.leafData(index)),
+	}
+
+	for level := 0; level < t.height(); level++ {
+		siblingIsLeft := index%2 == 1
+		proof.siblings = append(proof.siblings, t.node(level, index^1).String())
+		proof.directions = append(proof.directions, siblingIsLeft)
+		index /= 2
+	}
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.ProofGenerated(len(proof.siblings))
+	}
+	if t.cfg.timed() {
+		t.reportProofs(leaf, 1, len(proof.siblings), time.Since(start))
+	}
+
+	return proof
+}
+
+func (t *MerkleTree) outOfBounds(index uint64) error {
+	return fmt.Errorf("%w: index %d, element count %d", ErrIndexOutOfBounds, index, t.leafCount())
 }
 
 // ** BONUS (optional - easy) **
@@ -75,8 +559,74 @@ func (t *MerkleTree) GetProof(index uint64) (MerkleProof, error) {
 // For simplicity, the index must be within the bounds of the original vector size.
 // If it is not, return an error.
 func (t *MerkleTree) UpdateElement(index uint64, element string) error {
-	// TODO
-	return nil
+	_, err := t.UpdateElementSwap(index, element)
+	return err
+}
+
+// Updates the element at index as UpdateElement does, returning the element it replaced.
+// Only slots holding elements can be updated, never padding, so on success the previous
+// element is always one the tree was built or updated with. On error it is empty.
+// Under WithPaddedSlotPolicy(PaddedSlotAllow) the slot at LeafCount() can be updated
+// too, as an append; its previous element is then empty.
+func (t *MerkleTree) UpdateElementSwap(index uint64, element string) (string, error) {
+	var previous string
+	err := t.mutate(func(rec *journalRecord) (err error) {
+		previous, err = t.updateElementSwap(index, element)
+		if rec != nil {
+			*rec = journalRecord{op: journalUpdate, indices: []uint64{index}, values: []string{previous, element}}
+		}
+		return err
+	})
+	return previous, err
+}
+
+func (t *MerkleTree) updateElementSwap(index uint64, element string) (string, error) {
+	if t.cfg.paddedSlots == PaddedSlotAllow && index == t.leafCount() && index < t.paddedLeafCount() {
+		if t.cfg.setSemantics {
+			return "", ErrSetOrder
+		}
+		return "", t.append(element)
+	}
+	if index >= t.leafCount() {
+		return "", t.outOfBounds(index)
+	}
+	if t.elements == nil {
+		return "", ErrElementsUnknown
+	}
+	if t.cfg.setSemantics {
+		return "", ErrSetOrder
+	}
+	if err := t.cfg.checkElement(element); err != nil {
+		return "", err
+	}
+	if err := t.checkDuplicate(index, element); err != nil {
+		return "", err
+	}
+
+	previous := t.elements[index]
+	t.reindex(index, previous, element)
+	t.elements[index] = element
+	t.nodes[t.nodeIndex(0, index)] = t.elementDigest(index, element)
+	t.bloomAdd(index)
+	t.epoch++
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.LeafHashed(1)
+	}
+	if t.cfg.lazyRecompute {
+		t.markDirty(index)
+		return previous, nil
+	}
+
+	for level := 1; level <= t.height(); level++ {
+		index /= 2
+		t.nodes[t.nodeIndex(level, index)] = t.cfg.nodeDigest(t.node(level-1, 2*index), t.node(level-1, 2*index+1))
+	}
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.NodeHashed(t.height())
+	}
+
+	return previous, nil
 }
 
 // ** BONUS (optional - hard) **
@@ -84,12 +634,13 @@ func (t *MerkleTree) UpdateElement(index uint64, element string) error {
 // starting at startIndex (inclusive) and ending at endIndex (exclusive).
 // If the indexes are out of bounds or startIndex >= endIndex, an error is returned.
 //
-// Note: modify the method signature to return your proof type.
-// Implement a separate VerifyAggregatedProof for this type.
-//
-// The aggregated proof size should generally be smaller than
-// that of the naive approach (calling GetProof for every index).
-func (t *MerkleTree) GetAggregatedProof(startIndex uint64, endIndex uint64) error {
-	// TODO
-	return nil
+// The proof is verified with VerifyAggregatedProof, and its size is generally
+// smaller than that of the naive approach (calling GetProof for every index):
+// siblings derivable from the proven leaves are left out.
+func (t *MerkleTree) GetAggregatedProof(startIndex uint64, endIndex uint64) (AggregatedProof, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return AggregatedProof{}, err
+	}
+	defer t.mu.RUnlock()
+	return t.getAggregatedProof([]Range{{startIndex, endIndex}})
 }
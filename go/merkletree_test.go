@@ -1,7 +1,13 @@
 package merkletree
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -46,3 +52,408 @@ func TestProof(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyProofWithReason(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	root := mt.GetRoot()
+	proof, _ := mt.GetProof(3)
+
+	withSibling := func(level int, sibling string) MerkleProof {
+		p := proof
+		p.siblings = append([]string(nil), proof.siblings...)
+		p.siblings[level] = sibling
+		return p
+	}
+	withElement := proof
+	withElement.hElement = "0x" + proof.hElement[:10]
+
+	cases := []struct {
+		name  string
+		root  string
+		proof MerkleProof
+		field string
+		index int
+	}{
+		{"truncated root", root[:63], proof, "root", 0},
+		{"non-hex root", "z" + root[1:], proof, "root", 0},
+		{"truncated element", root, withElement, "element", 0},
+		{"short sibling", root, withSibling(1, "abc"), "sibling", 1},
+		{"unicode sibling", root, withSibling(2, proof.siblings[2][:62]+"é"), "sibling", 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := VerifyProofWithReason(c.root, c.proof)
+			var invalid *InvalidDigestError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("got %v, want an InvalidDigestError", err)
+			}
+			if invalid.Field != c.field || invalid.Index != c.index {
+				t.Errorf("got %s %d, want %s %d", invalid.Field, invalid.Index, c.field, c.index)
+			}
+			if !errors.Is(err, ErrInvalidHash) {
+				t.Errorf("got %v, want %v", err, ErrInvalidHash)
+			}
+		})
+	}
+
+	t.Run("wrong root", func(t *testing.T) {
+		if err := VerifyProofWithReason(hashLeaf("other"), proof); !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("got %v, want %v", err, ErrInvalidProof)
+		}
+	})
+
+	t.Run("normalizes case and prefix", func(t *testing.T) {
+		upper := withSibling(0, "0X"+strings.ToUpper(proof.siblings[0]))
+		upper.hElement = strings.ToUpper(proof.hElement)
+		if err := VerifyProofWithReason("0x"+strings.ToUpper(root), upper); err != nil {
+			t.Error(err)
+		}
+		if upper.siblings[0] == proof.siblings[0] {
+			t.Error("verification modified the proof")
+		}
+	})
+}
+
+func FuzzVerifyProofWithReason(f *testing.F) {
+	mt, _ := NewMerkleTree(testElements(3))
+	proof, _ := mt.GetProof(1)
+	f.Add(mt.GetRoot(), proof.hElement, proof.siblings[0], proof.siblings[1])
+	f.Add("0x"+mt.GetRoot(), strings.ToUpper(proof.hElement), "0x", "\xff\xfe")
+	f.Add("", "é", "0x0x", strings.Repeat("\u00e9", 32))
+
+	f.Fuzz(func(t *testing.T, root string, element string, first string, second string) {
+		p := MerkleProof{hElement: element, siblings: []string{first, second}, directions: proof.directions}
+		err := VerifyProofWithReason(root, p)
+
+		var invalid *InvalidDigestError
+		if err != nil && !errors.As(err, &invalid) && !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("unexpected error %v", err)
+		}
+		if (err == nil) != VerifyProof(root, p) {
+			t.Errorf("VerifyProof disagrees with %v", err)
+		}
+	})
+}
+
+func TestUpdateElement(t *testing.T) {
+	elements := []string{"some", "test", "elements"}
+	mt, err := NewMerkleTree(elements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mt.UpdateElement(1, "updated"); err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := NewMerkleTree([]string{"some", "updated", "elements"})
+	if mt.GetRoot() != expected.GetRoot() {
+		t.Errorf("got %s, want %s", mt.GetRoot(), expected.GetRoot())
+	}
+	if elements[1] != "test" {
+		t.Errorf("caller's slice was modified: got %s", elements[1])
+	}
+
+	if err := mt.UpdateElement(uint64(len(elements)), "oob"); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+}
+
+func TestUpdateElementSwap(t *testing.T) {
+	mt, _ := NewMerkleTree([]string{"some", "test", "elements", "test"}, WithLazyRecompute())
+
+	for i, step := range []struct {
+		index    uint64
+		element  string
+		previous string
+	}{
+		{1, "updated", "test"},
+		{1, "again", "updated"},
+		{3, "some", "test"},
+	} {
+		testname := fmt.Sprintf("swap %d returns the replaced element", i)
+		t.Run(testname, func(t *testing.T) {
+			previous, err := mt.UpdateElementSwap(step.index, step.element)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if previous != step.previous {
+				t.Errorf("got %q, want %q", previous, step.previous)
+			}
+			if got := mt.AllIndices(step.element); !slices.Contains(got, step.index) {
+				t.Errorf("got indices %v for %q, want %d among them", got, step.element, step.index)
+			}
+			if got := mt.AllIndices(previous); slices.Contains(got, step.index) {
+				t.Errorf("got indices %v for %q, want %d removed", got, previous, step.index)
+			}
+		})
+	}
+
+	expected, _ := NewMerkleTree([]string{"some", "again", "elements", "some"})
+	if mt.GetRoot() != expected.GetRoot() {
+		t.Errorf("got %s, want %s", mt.GetRoot(), expected.GetRoot())
+	}
+	if _, ok := mt.IndexOf("test"); ok {
+		t.Errorf("got indices %v for a replaced element", mt.AllIndices("test"))
+	}
+
+	strict, _ := NewMerkleTree([]string{"a", "b"}, WithRejectDuplicates())
+	if previous, err := strict.UpdateElementSwap(1, "a"); !errors.Is(err, ErrDuplicateLeaf) || previous != "" {
+		t.Errorf("got %q, %v, want %v", previous, err, ErrDuplicateLeaf)
+	}
+	if previous, err := strict.UpdateElementSwap(2, "c"); !errors.Is(err, ErrIndexOutOfBounds) || previous != "" {
+		t.Errorf("got %q, %v, want %v", previous, err, ErrIndexOutOfBounds)
+	}
+}
+
+func TestSingleElement(t *testing.T) {
+	mt, err := NewMerkleTree([]string{"only"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt.GetRoot() != hashLeaf("only") {
+		t.Errorf("got %s, want %s", mt.GetRoot(), hashLeaf("only"))
+	}
+	proof, err := mt.GetProof(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyProof(mt.GetRoot(), proof) {
+		t.Error("invalid proof")
+	}
+}
+
+func TestEmptyElements(t *testing.T) {
+	if _, err := NewMerkleTree(nil); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+}
+
+func TestReset(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6))
+
+	for _, size := range []int{6, 13, 2, 1, 16, 5} {
+		testname := fmt.Sprintf("resets to %d elements", size)
+		t.Run(testname, func(t *testing.T) {
+			elements := testElements(size)
+			elements[0] = "repeated"
+			elements[len(elements)-1] = "repeated"
+
+			if err := mt.Reset(elements); err != nil {
+				t.Fatal(err)
+			}
+			fresh, _ := NewMerkleTree(elements)
+
+			if mt.GetRoot() != fresh.GetRoot() {
+				t.Errorf("got %s, want %s", mt.GetRoot(), fresh.GetRoot())
+			}
+			if !reflect.DeepEqual(mt.nodes, fresh.nodes) || !reflect.DeepEqual(mt.offsets, fresh.offsets) || !reflect.DeepEqual(mt.elements, fresh.elements) {
+				t.Error("stored nodes differ from a fresh tree")
+			}
+			if !reflect.DeepEqual(mt.indices, fresh.indices) {
+				t.Errorf("got indices %v, want %v", mt.indices, fresh.indices)
+			}
+			// Reset is a mutation, so only the epoch tells the tree from a fresh one
+			want := fresh.Stats()
+			want.Epoch = mt.Epoch()
+			if mt.Stats() != want || want.Epoch == 0 {
+				t.Errorf("got %+v, want %+v", mt.Stats(), want)
+			}
+			for i := range elements {
+				got, _ := mt.GetProof(uint64(i))
+				want, _ := fresh.GetProof(uint64(i))
+				if !reflect.DeepEqual(got.siblings, want.siblings) {
+					t.Errorf("got %v, want %v", got.siblings, want.siblings)
+				}
+			}
+
+			elements[1%size] = "changed"
+			if mt.elements[1%size] == "changed" {
+				t.Error("tree shares the caller's slice")
+			}
+		})
+	}
+
+	if mt.Epoch() != 6 {
+		t.Errorf("got epoch %d, want 6", mt.Epoch())
+	}
+}
+
+func TestResetFailureLeavesTree(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5), WithRejectDuplicates())
+	root := mt.GetRoot()
+
+	if err := mt.Reset([]string{"a", "b", "a"}); !errors.Is(err, ErrDuplicateLeaf) {
+		t.Errorf("got %v, want %v", err, ErrDuplicateLeaf)
+	}
+	if err := mt.Reset(nil); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+
+	fresh, _ := NewMerkleTree(testElements(5), WithRejectDuplicates())
+	if mt.GetRoot() != root || mt.Epoch() != 0 {
+		t.Errorf("got root %s at epoch %d, want %s at 0", mt.GetRoot(), mt.Epoch(), root)
+	}
+	if !reflect.DeepEqual(mt.indices, fresh.indices) {
+		t.Errorf("got indices %v, want %v", mt.indices, fresh.indices)
+	}
+}
+
+func TestNoAliasing(t *testing.T) {
+	constructors := []struct {
+		name  string
+		build func(elements []string) (*MerkleTree, error)
+	}{
+		{"NewMerkleTree", func(elements []string) (*MerkleTree, error) { return NewMerkleTree(elements) }},
+		{"Builder", NewBuilder(8).Build},
+		{"Reset", func(elements []string) (*MerkleTree, error) {
+			mt, _ := NewMerkleTree([]string{"x"})
+			return mt, mt.Reset(elements)
+		}},
+		{"BuildAsync", func(elements []string) (*MerkleTree, error) {
+			h := BuildAsync(context.Background(), elements)
+			<-h.Done()
+			return h.Result()
+		}},
+	}
+
+	for _, c := range constructors {
+		t.Run(c.name, func(t *testing.T) {
+			elements := testElements(7)
+			mt, err := c.build(elements)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := NewMerkleTree(testElements(7))
+			wantProof, _ := want.GetProof(3)
+
+			elements[3] = "mutated"
+			mt.OriginalElements()[2] = "mutated"
+			mt.AllIndices("element-1")[0] = 5
+			proof, _ := mt.GetProof(3)
+			proof.siblings[0] = proof.hElement
+			batch, _ := mt.GetProofs([]uint64{3, 4})
+			batch[3] = MerkleProof{hElement: batch[3].hElement, siblings: append(batch[3].siblings, "extra"), directions: batch[3].directions}
+			raw, _ := proof.Bytes()
+			raw.Siblings[1] = Hash{}
+
+			if mt.GetRoot() != want.GetRoot() {
+				t.Errorf("got root %s, want %s", mt.GetRoot(), want.GetRoot())
+			}
+			if !slices.Equal(mt.OriginalElements(), testElements(7)) {
+				t.Errorf("got elements %v, want %v", mt.OriginalElements(), testElements(7))
+			}
+			if index, ok := mt.IndexOf("element-3"); !ok || index != 3 {
+				t.Errorf("got index %d, %v, want 3", index, ok)
+			}
+			if _, ok := mt.IndexOf("mutated"); ok {
+				t.Error("found the mutated element")
+			}
+			if got := mt.AllIndices("element-1"); !slices.Equal(got, []uint64{1}) {
+				t.Errorf("got indices %v, want [1]", got)
+			}
+			if again, _ := mt.GetProof(3); again.hElement != wantProof.hElement || !slices.Equal(again.siblings, wantProof.siblings) {
+				t.Errorf("got %+v, want %+v", again, wantProof)
+			}
+			if neighbour, _ := mt.GetProofs([]uint64{3, 4}); !VerifyProof(mt.GetRoot(), neighbour[4]) {
+				t.Error("proof batched after an extended one rejected")
+			}
+			if !VerifyProof(mt.GetRoot(), batch[4]) {
+				t.Error("appending to one batched proof corrupted the next")
+			}
+		})
+	}
+}
+
+func TestBuildAllocations(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"tagged", []Option{WithApplicationTag("app")}},
+		{"keccak", []Option{WithKeccak256()}},
+		{"rfc6962", []Option{WithRFC6962Hashing()}},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			elements := testElements(2000)
+			// nodes and the element index take a few large arrays, never one per element
+			allocs := testing.AllocsPerRun(5, func() {
+				if _, err := NewMerkleTree(elements, c.opts...); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if allocs > 50 {
+				t.Errorf("got %v allocations building %d elements, want at most 50", allocs, len(elements))
+			}
+		})
+	}
+}
+
+func BenchmarkNewMerkleTree(b *testing.B) {
+	elements := testElements(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := NewMerkleTree(elements); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Reports the heap objects a built tree keeps alive, and the GC pause time per build.
+func BenchmarkNewMerkleTreeGC(b *testing.B) {
+	elements := testElements(1 << 20)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	var mt *MerkleTree
+	for i := 0; i < b.N; i++ {
+		var err error
+		if mt, err = NewMerkleTree(elements); err != nil {
+			b.Fatal(err)
+		}
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	b.StopTimer()
+
+	b.ReportMetric(float64(after.HeapObjects)-float64(before.HeapObjects), "live-objects")
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+	runtime.KeepAlive(mt)
+}
+
+func BenchmarkReset(b *testing.B) {
+	elements := testElements(10000)
+	mt, _ := NewMerkleTree(elements)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	// alternating with a rotation keeps the size and the set of elements while moving every leaf
+	rotated := append(append([]string(nil), elements[1:]...), elements[0])
+	inputs := [][]string{rotated, elements}
+
+	for i := 0; i < b.N; i++ {
+		if err := mt.Reset(inputs[i%2]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetProof(b *testing.B) {
+	mt, _ := NewMerkleTree(testElements(1 << 16))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := mt.GetProof(uint64(i*7919) % mt.LeafCount()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
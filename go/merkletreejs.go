@@ -0,0 +1,107 @@
+package merkletree
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// A step of a merkletreejs proof: the sibling and which side of the path it joins from.
+type merkleTreeJSStep struct {
+	Position string          `json:"position"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Encodes the proof as merkletreejs's getProof returns it, with 0x-prefixed hex data:
+//
+//	[{"position": "left" | "right", "data": "0x..."}, ...]
+//
+// The JSON carries neither the element hash nor the tag, which merkletreejs passes to verify separately.
+//
+// merkletreejs hashes nodes over the raw bytes of their children, so its roots are those of
+// trees built with WithRawNodeHashing, plus WithSortedPairs for its sortPairs option.
+// Its hashLeaves option matches this package, which always hashes elements; without it the
+// leaves are taken as given. Either way it promotes an odd node to the next level rather
+// than padding, so a tree's root only matches the library's for power-of-two sizes, while
+// its proofs verify here under the matching options whatever the size of their tree.
+func ExportMerkleTreeJSProof(proof MerkleProof) ([]byte, error) {
+	proof, err := proof.normalized()
+	if err != nil {
+		return nil, err
+	}
+
+	steps := make([]merkleTreeJSStep, len(proof.siblings))
+	for i, sibling := range proof.siblings {
+		steps[i].Position = "right"
+		if proof.directions[i] {
+			steps[i].Position = "left"
+		}
+		steps[i].Data, _ = json.Marshal("0x" + sibling)
+	}
+	return json.Marshal(steps)
+}
+
+// Decodes a proof in the form of ExportMerkleTreeJSProof for the leaf with the given hash,
+// which merkletreejs keeps apart from the proof. Data may also be a serialized Node.js Buffer,
+// {"type": "Buffer", "data": [...]}, as JSON.stringify writes the library's own proofs.
+// See ExportMerkleTreeJSProof for the options to verify it under.
+func ImportMerkleTreeJSProof(raw []byte, leaf string) (MerkleProof, error) {
+	element, err := ParseHash(leaf)
+	if err != nil {
+		return MerkleProof{}, fmt.Errorf("%w: %w", ErrMalformedProof, &InvalidDigestError{Field: "element", Err: err})
+	}
+
+	var steps []merkleTreeJSStep
+	if err := json.Unmarshal(raw, &steps); err != nil {
+		return MerkleProof{}, fmt.Errorf("%w: %v", ErrMalformedProof, err)
+	}
+	if len(steps) > maxProofDepth {
+		return MerkleProof{}, fmt.Errorf("%w: %d steps", ErrMalformedProof, len(steps))
+	}
+
+	proof := MerkleProof{
+		hElement:   element.String(),
+		siblings:   make([]string, len(steps)),
+		directions: make([]bool, len(steps)),
+	}
+	for i, step := range steps {
+		switch step.Position {
+		case "left":
+			proof.directions[i] = true
+		case "right":
+		default:
+			return MerkleProof{}, fmt.Errorf("%w: step %d has position %q", ErrMalformedProof, i, step.Position)
+		}
+
+		sibling, err := parseMerkleTreeJSData(step.Data)
+		if err != nil {
+			return MerkleProof{}, fmt.Errorf("%w: %w", ErrMalformedProof, &InvalidDigestError{Field: "sibling", Index: i, Err: err})
+		}
+		proof.siblings[i] = sibling.String()
+	}
+	return proof, nil
+}
+
+// Parses step data, either a hex string or a serialized Buffer.
+func parseMerkleTreeJSData(data json.RawMessage) (Hash, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return ParseHash(s)
+	}
+
+	var buffer struct {
+		Type string `json:"type"`
+		Data []int  `json:"data"`
+	}
+	if err := json.Unmarshal(data, &buffer); err != nil || buffer.Type != "Buffer" {
+		return Hash{}, fmt.Errorf("%w: data is neither a hex string nor a Buffer", ErrInvalidHash)
+	}
+	raw := make([]byte, len(buffer.Data))
+	for i, b := range buffer.Data {
+		if b < 0 || b > 0xff {
+			return Hash{}, fmt.Errorf("%w: Buffer holds %d", ErrInvalidHash, b)
+		}
+		raw[i] = byte(b)
+	}
+	return ParseHash(hex.EncodeToString(raw))
+}
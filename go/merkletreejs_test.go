@@ -0,0 +1,128 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+type merkleTreeJSFixture struct {
+	Options struct {
+		SortPairs bool `json:"sortPairs"`
+	} `json:"options"`
+	Leaves []string `json:"leaves"`
+	Root   string   `json:"root"`
+	Proofs []struct {
+		Leaf  string          `json:"leaf"`
+		Proof json.RawMessage `json:"proof"`
+	} `json:"proofs"`
+}
+
+func loadMerkleTreeJSFixtures(t *testing.T) []merkleTreeJSFixture {
+	data, err := os.ReadFile("testdata/merkletreejs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Fixtures []merkleTreeJSFixture `json:"fixtures"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	return file.Fixtures
+}
+
+func (f merkleTreeJSFixture) opts() []Option {
+	opts := []Option{WithRawNodeHashing()}
+	if f.Options.SortPairs {
+		opts = append(opts, WithSortedPairs())
+	}
+	return opts
+}
+
+func TestImportMerkleTreeJSProof(t *testing.T) {
+	for _, fixture := range loadMerkleTreeJSFixtures(t) {
+		for i, p := range fixture.Proofs {
+			testname := fmt.Sprintf("%d leaves sortPairs %v leaf %d", len(fixture.Leaves), fixture.Options.SortPairs, i)
+			t.Run(testname, func(t *testing.T) {
+				proof, err := ImportMerkleTreeJSProof(p.Proof, p.Leaf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := VerifyProofWithReason(fixture.Root, proof, fixture.opts()...); err != nil {
+					t.Errorf("got %v, want nil", err)
+				}
+				if VerifyProof(fixture.Root, proof) {
+					t.Error("verified under the default hashing")
+				}
+
+				exported, err := ExportMerkleTreeJSProof(proof)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got, want any
+				json.Unmarshal(exported, &got)
+				json.Unmarshal(p.Proof, &want)
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("got %s, want %s", exported, p.Proof)
+				}
+			})
+		}
+	}
+}
+
+func TestMerkleTreeJSRoots(t *testing.T) {
+	for _, fixture := range loadMerkleTreeJSFixtures(t) {
+		if len(fixture.Leaves)&(len(fixture.Leaves)-1) != 0 {
+			continue // merkletreejs promotes odd nodes where this package pads
+		}
+		mt, _ := NewMerkleTree(fixture.Leaves, fixture.opts()...)
+		if "0x"+mt.GetRoot() != fixture.Root {
+			t.Errorf("got 0x%s, want %s", mt.GetRoot(), fixture.Root)
+		}
+	}
+}
+
+func TestImportMerkleTreeJSProofBuffers(t *testing.T) {
+	fixture := loadMerkleTreeJSFixtures(t)[0]
+	var steps []struct {
+		Position string `json:"position"`
+		Data     string `json:"data"`
+	}
+	json.Unmarshal(fixture.Proofs[0].Proof, &steps)
+
+	type buffer struct {
+		Type string `json:"type"`
+		Data []int  `json:"data"`
+	}
+	type bufferStep struct {
+		Position string `json:"position"`
+		Data     buffer `json:"data"`
+	}
+	var buffers []bufferStep
+	for _, step := range steps {
+		h, _ := ParseHash(step.Data)
+		data := make([]int, len(h))
+		for i, b := range h {
+			data[i] = int(b)
+		}
+		buffers = append(buffers, bufferStep{step.Position, buffer{"Buffer", data}})
+	}
+	raw, _ := json.Marshal(buffers)
+
+	proof, err := ImportMerkleTreeJSProof(raw, fixture.Proofs[0].Leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyProof(fixture.Root, proof, fixture.opts()...) {
+		t.Error("failed to verify")
+	}
+
+	for _, bad := range []string{`{}`, `[{"position":"up","data":"0x00"}]`, `[{"position":"left","data":"0x00"}]`, `[{"position":"left","data":{"type":"Buffer","data":[256]}}]`} {
+		if _, err := ImportMerkleTreeJSProof([]byte(bad), fixture.Proofs[0].Leaf); !errors.Is(err, ErrMalformedProof) {
+			t.Errorf("%s: got %v, want %v", bad, err, ErrMalformedProof)
+		}
+	}
+}
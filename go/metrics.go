@@ -0,0 +1,108 @@
+package merkletree
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Receives counts of the work performed by tree operations.
+// Calls are batched per operation (e.g. one LeafHashed call for a whole build),
+// so implementations should only need to bump counters.
+type MetricsSink interface {
+	LeafHashed(n int)         // n leaf hashes were computed
+	NodeHashed(n int)         // n interior node hashes were computed
+	ProofGenerated(depth int) // a proof with the given number of siblings was generated
+	ProofVerified(ok bool)    // a proof was verified, successfully or not
+}
+
+// Optionally implemented by a MetricsSink to also receive how long operations took.
+// Timing is only measured when the sink implements this interface.
+type LatencyMetrics interface {
+	BuildDuration(d time.Duration) // a tree was built, by NewMerkleTree, Reset or Builder.Build
+	ProofDuration(d time.Duration) // a proof was generated; batches report their average per proof
+}
+
+// Returns the sink's latency hooks, or nil when it does not take them.
+func (cfg config) latency() LatencyMetrics {
+	latency, _ := cfg.metrics.(LatencyMetrics)
+	return latency
+}
+
+// Replaces the sink receiving the tree's metrics, e.g. to instrument a tree built
+// before its sink existed. A nil sink turns instrumentation off.
+// The sink must be safe for concurrent use if the tree is.
+func (t *MerkleTree) SetMetrics(m MetricsSink) {
+	if t.lockBuilt() != nil {
+		return
+	}
+	defer t.mu.Unlock()
+	t.cfg.metrics = m
+}
+
+// A MetricsSink which keeps running totals, safe for concurrent use.
+type CountingMetrics struct {
+	LeafHashes      atomic.Uint64
+	NodeHashes      atomic.Uint64
+	ProofsGenerated atomic.Uint64
+	ProofsVerified  atomic.Uint64
+	ProofsRejected  atomic.Uint64
+	LeafCacheHits   atomic.Uint64
+	NodeReads       atomic.Uint64
+
+	ProofCacheHits   atomic.Uint64
+	ProofCacheMisses atomic.Uint64
+}
+
+func (m *CountingMetrics) LeafHashed(n int) {
+	m.LeafHashes.Add(uint64(n))
+}
+
+func (m *CountingMetrics) NodeHashed(n int) {
+	m.NodeHashes.Add(uint64(n))
+}
+
+func (m *CountingMetrics) ProofGenerated(depth int) {
+	m.ProofsGenerated.Add(1)
+}
+
+func (m *CountingMetrics) ProofVerified(ok bool) {
+	if ok {
+		m.ProofsVerified.Add(1)
+	} else {
+		m.ProofsRejected.Add(1)
+	}
+}
+
+func (m *CountingMetrics) LeafCacheHit(n int) {
+	m.LeafCacheHits.Add(uint64(n))
+}
+
+func (m *CountingMetrics) NodeRead(n int) {
+	m.NodeReads.Add(uint64(n))
+}
+
+func (m *CountingMetrics) ProofCacheHit() {
+	m.ProofCacheHits.Add(1)
+}
+
+func (m *CountingMetrics) ProofCacheMiss() {
+	m.ProofCacheMisses.Add(1)
+}
+
+// Returns the total number of hashes, leaf and node, counted so far.
+func (m *CountingMetrics) Hashes() uint64 {
+	return m.LeafHashes.Load() + m.NodeHashes.Load()
+}
+
+// Zeroes every counter.
+func (m *CountingMetrics) Reset() {
+	m.LeafHashes.Store(0)
+	m.NodeHashes.Store(0)
+	m.ProofsGenerated.Store(0)
+	m.ProofsVerified.Store(0)
+	m.ProofsRejected.Store(0)
+	m.LeafCacheHits.Store(0)
+	m.NodeReads.Store(0)
+	m.ProofCacheHits.Store(0)
+	m.ProofCacheMisses.Store(0)
+}
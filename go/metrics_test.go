@@ -0,0 +1,168 @@
+package merkletree
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMetricsBuild(t *testing.T) {
+	for _, size := range []int{1, 2, 3, 4, 5, 8} {
+		testname := fmt.Sprintf("counts hashes for %d elements", size)
+		t.Run(testname, func(t *testing.T) {
+			var m CountingMetrics
+			_, err := NewMerkleTree(make([]string, size), WithMetrics(&m))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// each level stores only the nodes covering elements, and padding
+			// hashes are computed once per level as a ladder
+			height := treeHeight(uint64(size))
+			wantLeaves, wantNodes := uint64(size), uint64(0)
+			for stored := uint64(size); stored > 1; {
+				stored = (stored + 1) / 2
+				wantNodes += stored
+			}
+			if size < 1<<height {
+				wantLeaves++
+				wantNodes += uint64(height)
+			}
+			if got := m.LeafHashes.Load(); got != wantLeaves {
+				t.Errorf("got %d leaf hashes, want %d", got, wantLeaves)
+			}
+			if got := m.NodeHashes.Load(); got != wantNodes {
+				t.Errorf("got %d node hashes, want %d", got, wantNodes)
+			}
+		})
+	}
+}
+
+func TestMetricsUpdateElement(t *testing.T) {
+	elements := []string{"some", "more", "valid", "test", "elements"}
+	var m CountingMetrics
+	mt, err := NewMerkleTree(elements, WithMetrics(&m))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Reset()
+	if err := mt.UpdateElement(3, "updated"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := m.Hashes(), uint64(mt.Height()+1); got != want {
+		t.Errorf("got %d hashes, want %d", got, want)
+	}
+	if got := m.LeafHashes.Load(); got != 1 {
+		t.Errorf("got %d leaf hashes, want 1", got)
+	}
+}
+
+func TestMetricsProofs(t *testing.T) {
+	elements := []string{"some", "test", "elements"}
+	var m CountingMetrics
+	mt, err := NewMerkleTree(elements, WithMetrics(&m))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := mt.GetProof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.ProofsGenerated.Load(); got != 1 {
+		t.Errorf("got %d proofs generated, want 1", got)
+	}
+
+	m.Reset()
+	VerifyProof(mt.GetRoot(), proof, WithMetrics(&m))
+	VerifyProof(hashLeaf("other"), proof, WithMetrics(&m))
+
+	if got := m.ProofsVerified.Load(); got != 1 {
+		t.Errorf("got %d proofs verified, want 1", got)
+	}
+	if got := m.ProofsRejected.Load(); got != 1 {
+		t.Errorf("got %d proofs rejected, want 1", got)
+	}
+	if got, want := m.NodeHashes.Load(), uint64(2*len(proof.siblings)); got != want {
+		t.Errorf("got %d node hashes, want %d", got, want)
+	}
+}
+
+func TestMetricsAbsentByDefault(t *testing.T) {
+	mt, err := NewMerkleTree([]string{"some", "test", "elements"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt.cfg.metrics != nil {
+		t.Error("expected no metrics sink without WithMetrics")
+	}
+}
+
+// Records the latencies reported to it, on top of the usual counts.
+type latencyRecorder struct {
+	CountingMetrics
+	builds, proofs []time.Duration
+}
+
+func (r *latencyRecorder) BuildDuration(d time.Duration) { r.builds = append(r.builds, d) }
+func (r *latencyRecorder) ProofDuration(d time.Duration) { r.proofs = append(r.proofs, d) }
+
+func TestLatencyMetrics(t *testing.T) {
+	var r latencyRecorder
+	mt, _ := NewMerkleTree(testElements(9), WithMetrics(&r))
+	mt.GetProof(3)
+	mt.GetProofs([]uint64{1, 2, 8})
+	mt.Reset(testElements(4))
+	NewBuilder(4, WithMetrics(&r)).Build(testElements(4))
+
+	if len(r.builds) != 3 || len(r.proofs) != 4 {
+		t.Errorf("got %d builds and %d proofs, want 3 and 4", len(r.builds), len(r.proofs))
+	}
+	for _, d := range append(r.builds, r.proofs...) {
+		if d < 0 {
+			t.Errorf("got duration %v, want a non-negative one", d)
+		}
+	}
+
+	// failed builds are not timed
+	mt.Reset(nil)
+	if len(r.builds) != 3 {
+		t.Errorf("got %d builds, want 3", len(r.builds))
+	}
+}
+
+func TestSetMetrics(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(4))
+
+	var m CountingMetrics
+	mt.SetMetrics(&m)
+	mt.GetProof(0)
+	if got := m.ProofsGenerated.Load(); got != 1 {
+		t.Errorf("got %d proofs, want 1", got)
+	}
+
+	mt.SetMetrics(nil)
+	mt.GetProof(0)
+	if got := m.ProofsGenerated.Load(); got != 1 {
+		t.Errorf("got %d proofs, want 1", got)
+	}
+}
+
+func TestCountingMetricsResetZeroesEveryCounter(t *testing.T) {
+	var m CountingMetrics
+	fields := reflect.ValueOf(&m).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		fields.Field(i).Addr().Interface().(*atomic.Uint64).Store(uint64(i + 1))
+	}
+
+	m.Reset()
+	for i := 0; i < fields.NumField(); i++ {
+		if got := fields.Field(i).Addr().Interface().(*atomic.Uint64).Load(); got != 0 {
+			t.Errorf("%s: got %d, want 0", fields.Type().Field(i).Name, got)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownMode  = errors.New("merkletree: unknown mode")
+	ErrModeConflict = errors.New("merkletree: options conflict with mode")
+	ErrElementSize  = errors.New("merkletree: element does not fit a leaf")
+	ErrNoPadding    = errors.New("merkletree: mode pairs lone nodes with themselves rather than padding")
+)
+
+// A preset of the hashing options matching the trees of another ecosystem.
+type Mode uint8
+
+const (
+	// Hashes as this package always has, over the hex encodings of child digests.
+	ModeDefault Mode = iota
+
+	// Hashes leaves and nodes as Certificate Transparency logs do, see WithRFC6962Hashing.
+	// Roots match the logs' only for power-of-two sizes, as the tree pads rather than
+	// splitting at the largest power of two; ParseCTInclusionProof reads proofs for any size.
+	ModeRFC6962
+
+	// Hashes as Bitcoin block headers commit to transactions: leaves and nodes are double
+	// SHA-256 over raw bytes, and a node without a sibling is paired with itself.
+	// Digests are in internal byte order, the reverse of how txids and roots are displayed.
+	// As in Bitcoin, elements ending in a repeat of the last one can share a root without it.
+	ModeBitcoin
+
+	// Hashes as OpenZeppelin's StandardMerkleTree does, see StandardMerkleTreeOptions.
+	ModeOpenZeppelin
+
+	// Hashes as SSZ merkleization does: elements are 32-byte chunks taken as their own
+	// leaves, right-padded with zeros when shorter, nodes are SHA-256 over raw bytes,
+	// and padding is the zero chunk. Elements longer than a chunk fail with ErrElementSize.
+	ModeSSZ
+)
+
+var modeNames = [...]string{
+	ModeDefault:      "default",
+	ModeRFC6962:      "rfc6962",
+	ModeBitcoin:      "bitcoin",
+	ModeOpenZeppelin: "openzeppelin",
+	ModeSSZ:          "ssz",
+}
+
+// The hashing each mode sets, replacing every option it covers.
+var modePresets = [...]hashing{
+	ModeDefault: {},
+	ModeRFC6962: {rfc6962: true},
+	ModeBitcoin: {
+		rawNodeHashing:    true,
+		doubleHashLeaves:  true,
+		doubleHashNodes:   true,
+		duplicateOddNodes: true,
+	},
+	ModeOpenZeppelin: {
+		rawNodeHashing:   true,
+		sortedPairs:      true,
+		leafHash:         HashKeccak256,
+		nodeHash:         HashKeccak256,
+		doubleHashLeaves: true,
+	},
+	ModeSSZ: {rawNodeHashing: true, chunkLeaves: true},
+}
+
+// Returns the name of the mode, as it appears in JSON.
+func (m Mode) String() string {
+	if int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+	return fmt.Sprintf("Mode(%d)", uint8(m))
+}
+
+func (m Mode) valid() bool {
+	return int(m) < len(modePresets)
+}
+
+func (m Mode) MarshalText() ([]byte, error) {
+	if !m.valid() {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownMode, uint8(m))
+	}
+	return []byte(modeNames[m]), nil
+}
+
+func (m *Mode) UnmarshalText(text []byte) error {
+	for i, name := range modeNames {
+		if string(text) == name {
+			*m = Mode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownMode, text)
+}
+
+// Sets every hashing option at once to the preset of the mode, replacing any given before.
+// Options given after it that change its hashing, such as WithKeccak256 after
+// WithMode(ModeBitcoin), make constructors fail with ErrModeConflict.
+// The mode is recorded in the tree's Scheme and so in its Commitment.
+func WithMode(m Mode) Option {
+	return func(cfg *config) {
+		cfg.mode = m
+		cfg.presetMode = true
+		if m.valid() {
+			cfg.hashing = modePresets[m]
+		}
+	}
+}
+
+// Fails when the mode is unknown or later options changed the hashing it set, for an
+// empty HMAC key and for a hash backend the other options cannot apply to, every
+// construction and verifier checking its options here.
+func (cfg config) checkMode() error {
+	if cfg.keyed && cfg.hmacKey == "" {
+		return ErrEmptyHMACKey
+	}
+	if err := cfg.checkHashFunctions(); err != nil {
+		return err
+	}
+	if cfg.presetMode {
+		if !cfg.mode.valid() {
+			return fmt.Errorf("%w: %d", ErrUnknownMode, uint8(cfg.mode))
+		}
+		if cfg.hashing != modePresets[cfg.mode] {
+			return fmt.Errorf("%w: options after WithMode(%v) change its hashing", ErrModeConflict, cfg.mode)
+		}
+	}
+	return cfg.checkBackend()
+}
+
+// Fails for an element the configured hashing cannot take as a leaf.
+func (cfg config) checkElement(element string) error {
+	if cfg.chunkLeaves && len(element) > digestSize {
+		return fmt.Errorf("%w: %d bytes, at most %d in mode %v", ErrElementSize, len(element), digestSize, cfg.mode)
+	}
+	if cfg.backend != nil {
+		return cfg.backend.checkElement(element)
+	}
+	return nil
+}
+
+func (cfg config) checkElements(elements []string) error {
+	for i, element := range elements {
+		if cfg.chunkLeaves && len(element) > digestSize {
+			return fmt.Errorf("%w: element %d has %d bytes, at most %d in mode %v", ErrElementSize, i, len(element), digestSize, cfg.mode)
+		}
+		if cfg.backend != nil {
+			if err := cfg.backend.checkElement(element); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
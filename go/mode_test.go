@@ -0,0 +1,267 @@
+package merkletree
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// The raw coinbase transaction of the Bitcoin genesis block, the only one in the block,
+// and the block's merkle root as explorers display it, in reversed byte order.
+const (
+	bitcoinGenesisTx   = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+	bitcoinGenesisRoot = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+)
+
+// The txids of Bitcoin block 100000 and its merkle root, as explorers display them.
+var (
+	bitcoinBlock100000Txids = []string{
+		"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+		"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+		"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+		"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+	}
+	bitcoinBlock100000Root = "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766"
+)
+
+// The roots of SSZ merkleization over 2, 4 and 8 zero chunks, as in the zero hashes
+// of the Ethereum deposit contract.
+var sszZeroRoots = []string{
+	"f5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a92759fb4b",
+	"db56114e00fdd4c1f85c892bf35ac9a89289aaecb1ebd0a96cde606a748b5d71",
+	"c78009fdf07fc56a11f122370658a353aaa542ed63e44c4bc15ff4cd105ab33c",
+}
+
+// Returns the hex digest with its bytes reversed, converting between Bitcoin's
+// display order and the internal order digests are hashed in.
+func reversedHex(s string) string {
+	b, _ := hex.DecodeString(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return hex.EncodeToString(b)
+}
+
+func TestModeGoldenRoots(t *testing.T) {
+	genesis, _ := hex.DecodeString(bitcoinGenesisTx)
+	types, _ := parseABITypes([]string{"address", "uint256"})
+	encoded := make([]string, 2)
+	for i, value := range [][]string{
+		{"0x1111111111111111111111111111111111111111", "5000000000000000000"},
+		{"0x2222222222222222222222222222222222222222", "2500000000000000000"},
+	} {
+		leaf, err := abiEncode(types, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded[i] = string(leaf)
+	}
+
+	cases := []struct {
+		name     string
+		mode     Mode
+		elements []string
+		want     string
+	}{
+		{"default", ModeDefault, []string{"a", "b", "c", "d"}, "58c89d709329eb37285837b042ab6ff72c7c8f74de0446b091b6a0131c102cfd"},
+		{"rfc6962", ModeRFC6962, ctLeaves, ctRoots[7]},
+		{"rfc6962 pair", ModeRFC6962, ctLeaves[:2], ctRoots[1]},
+		{"bitcoin genesis", ModeBitcoin, []string{string(genesis)}, reversedHex(bitcoinGenesisRoot)},
+		{"openzeppelin readme", ModeOpenZeppelin, encoded, standardReadmeRoot},
+		{"ssz 8 zero chunks", ModeSSZ, make([]string, 8), sszZeroRoots[2]},
+		{"ssz 5 zero chunks", ModeSSZ, make([]string, 5), sszZeroRoots[2]},
+		{"ssz 2 short zero chunks", ModeSSZ, []string{"\x00", "\x00\x00"}, sszZeroRoots[0]},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mt, err := NewMerkleTree(c.elements, WithMode(c.mode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := mt.GetRoot(); got != c.want {
+				t.Errorf("got %s, want %s", got, c.want)
+			}
+			if got, _ := ComputeRoot(c.elements, WithMode(c.mode)); got != c.want {
+				t.Errorf("ComputeRoot: got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestModeBitcoinNodes(t *testing.T) {
+	cfg := newConfig([]Option{WithMode(ModeBitcoin)})
+	level := make([]Hash, len(bitcoinBlock100000Txids))
+	for i, txid := range bitcoinBlock100000Txids {
+		level[i], _ = ParseHash(reversedHex(txid))
+	}
+	root := cfg.nodeDigest(cfg.nodeDigest(level[0], level[1]), cfg.nodeDigest(level[2], level[3]))
+	if got := reversedHex(root.String()); got != bitcoinBlock100000Root {
+		t.Errorf("got %s, want %s", got, bitcoinBlock100000Root)
+	}
+}
+
+func TestModeBitcoinDuplicatesOddNodes(t *testing.T) {
+	cfg := newConfig([]Option{WithMode(ModeBitcoin)})
+	for n := 1; n <= 9; n++ {
+		testname := fmt.Sprintf("%d elements", n)
+		t.Run(testname, func(t *testing.T) {
+			elements := testElements(n)
+			level := make([]Hash, n)
+			for i, element := range elements {
+				level[i] = cfg.leafDigest(element)
+			}
+			for len(level) > 1 {
+				if len(level)%2 == 1 {
+					level = append(level, level[len(level)-1])
+				}
+				parents := make([]Hash, len(level)/2)
+				for i := range parents {
+					parents[i] = cfg.nodeDigest(level[2*i], level[2*i+1])
+				}
+				level = parents
+			}
+			want := level[0].String()
+
+			mt, _ := NewMerkleTree(elements, WithMode(ModeBitcoin))
+			if got := mt.GetRoot(); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+			if got, _ := ComputeRoot(elements, WithMode(ModeBitcoin)); got != want {
+				t.Errorf("ComputeRoot: got %s, want %s", got, want)
+			}
+
+			for i := range elements {
+				proof, _ := mt.GetProof(uint64(i))
+				if !VerifyProof(want, proof, WithMode(ModeBitcoin)) {
+					t.Errorf("proof of element %d failed to verify", i)
+				}
+			}
+			last, _ := mt.GetLastLeafProof()
+			if !VerifyLastLeafProof(want, uint64(n), last, WithMode(ModeBitcoin)) {
+				t.Error("last leaf proof failed to verify")
+			}
+			prefix, _ := mt.GetPrefixProof(uint64(n+1) / 2)
+			if !VerifyPrefixProof(want, uint64(n+1)/2, prefix.PrefixRoot(), prefix, WithMode(ModeBitcoin)) {
+				t.Error("prefix proof failed to verify")
+			}
+			if half, _ := NewMerkleTree(elements[:(n+1)/2], WithMode(ModeBitcoin)); prefix.PrefixRoot() != half.GetRoot() {
+				t.Errorf("prefix root: got %s, want %s", prefix.PrefixRoot(), half.GetRoot())
+			}
+		})
+	}
+
+	mt, _ := NewMerkleTree(testElements(3), WithMode(ModeBitcoin))
+	if _, err := mt.ProveEmptySlot(3); !errors.Is(err, ErrNoPadding) {
+		t.Errorf("got %v, want %v", err, ErrNoPadding)
+	}
+	appended, _ := NewMerkleTree(testElements(2), WithMode(ModeBitcoin))
+	appended.Append("element-2")
+	if appended.GetRoot() != mt.GetRoot() {
+		t.Errorf("after append: got %s, want %s", appended.GetRoot(), mt.GetRoot())
+	}
+}
+
+func TestModeConflicts(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		want error
+	}{
+		{"option after mode", []Option{WithMode(ModeBitcoin), WithKeccak256()}, ErrModeConflict},
+		{"tag after mode", []Option{WithMode(ModeOpenZeppelin), WithApplicationTag("app")}, ErrModeConflict},
+		{"padding after mode", []Option{WithMode(ModeSSZ), WithEmptyLeaf([]byte("x"))}, ErrModeConflict},
+		{"option after default mode", []Option{WithMode(ModeDefault), WithSortedPairs()}, ErrModeConflict},
+		{"unknown mode", []Option{WithMode(Mode(42))}, ErrUnknownMode},
+		{"option before mode", []Option{WithKeccak256(), WithMode(ModeBitcoin)}, nil},
+		{"option repeating mode", []Option{WithMode(ModeOpenZeppelin), WithSortedPairs()}, nil},
+		{"unrelated option", []Option{WithMode(ModeSSZ), WithFixedDepth(5)}, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := NewMerkleTree(testElements(3), c.opts...); !errors.Is(err, c.want) {
+				t.Errorf("NewMerkleTree: got %v, want %v", err, c.want)
+			}
+			if _, err := ComputeRoot(testElements(3), c.opts...); !errors.Is(err, c.want) {
+				t.Errorf("ComputeRoot: got %v, want %v", err, c.want)
+			}
+		})
+	}
+
+	before, _ := NewMerkleTree(testElements(3), WithKeccak256(), WithMode(ModeBitcoin))
+	preset, _ := NewMerkleTree(testElements(3), WithMode(ModeBitcoin))
+	if before.GetRoot() != preset.GetRoot() {
+		t.Errorf("got %s, want %s", before.GetRoot(), preset.GetRoot())
+	}
+}
+
+func TestModeSSZElementSize(t *testing.T) {
+	long := string(make([]byte, 33))
+	if _, err := NewMerkleTree([]string{"a", long}, WithMode(ModeSSZ)); !errors.Is(err, ErrElementSize) {
+		t.Errorf("got %v, want %v", err, ErrElementSize)
+	}
+
+	mt, _ := NewMerkleTree([]string{"a", "b"}, WithMode(ModeSSZ))
+	if err := mt.UpdateElement(0, long); !errors.Is(err, ErrElementSize) {
+		t.Errorf("update: got %v, want %v", err, ErrElementSize)
+	}
+	if err := mt.Append(long); !errors.Is(err, ErrElementSize) {
+		t.Errorf("append: got %v, want %v", err, ErrElementSize)
+	}
+	if mt.LeafCount() != 2 {
+		t.Errorf("got %d elements, want 2", mt.LeafCount())
+	}
+}
+
+func TestModeRecordedInScheme(t *testing.T) {
+	for mode := ModeDefault; mode <= ModeSSZ; mode++ {
+		t.Run(mode.String(), func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(5), WithMode(mode))
+			scheme := mt.Scheme()
+			if scheme.Mode != mode {
+				t.Errorf("got %v, want %v", scheme.Mode, mode)
+			}
+
+			data, _ := json.Marshal(mt.Commitment())
+			var commitment Commitment
+			if err := json.Unmarshal(data, &commitment); err != nil {
+				t.Fatal(err)
+			}
+			if commitment.Scheme.Mode != mode {
+				t.Errorf("JSON: got %v, want %v", commitment.Scheme.Mode, mode)
+			}
+
+			r := byteReader{data: appendScheme(nil, scheme)}
+			decoded := r.scheme()
+			if r.err != nil || decoded.Mode != mode {
+				t.Errorf("binary: got %v (%v), want %v", decoded.Mode, r.err, mode)
+			}
+
+			rebuilt, err := NewMerkleTree(testElements(5), WithScheme(decoded))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rebuilt.GetRoot() != mt.GetRoot() {
+				t.Errorf("got %s, want %s", rebuilt.GetRoot(), mt.GetRoot())
+			}
+
+			layout, nodes := mt.ExportNodes()
+			if _, err := ImportNodes(layout, nodes, WithMode(mode)); err != nil {
+				t.Errorf("import: %v", err)
+			}
+		})
+	}
+
+	mt, _ := NewMerkleTree(testElements(5), WithMode(ModeBitcoin))
+	data, _ := json.Marshal(mt.Scheme())
+	if want := `{"mode":"bitcoin","rawNodes":true,"doubleHash":true}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	layout, nodes := mt.ExportNodes()
+	if _, err := ImportNodes(layout, nodes); !errors.Is(err, ErrModeConflict) {
+		t.Errorf("got %v, want %v", err, ErrModeConflict)
+	}
+}
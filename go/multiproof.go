@@ -0,0 +1,308 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+)
+
+var ErrInconsistentProofs = errors.New("merkletree: proofs are inconsistent")
+
+// Hashes VerifyMultiProofCtx computes between checks of its context.
+const multiProofCheckInterval = 1 << 8
+
+// Proves the inclusion of several elements of the same tree at once.
+// Siblings which can be derived from the proven leaves themselves are left out.
+type MultiProof struct {
+	depth    int      // levels between the leaves and the root
+	indices  []uint64 // ascending leaf indices being proven
+	leaves   []string // hash of the element at each index
+	siblings []string // nodes not derivable from the leaves, ordered by level then index
+	tag      string   // application tag shared by the combined proofs
+
+	algorithm AlgorithmID // algorithm the combined proofs were hashed with
+}
+
+// Returns the proven leaf indices, ascending.
+func (p MultiProof) Indices() []uint64 {
+	return append([]uint64(nil), p.indices...)
+}
+
+// Returns the algorithm the multiproof's nodes were hashed with, AlgorithmUnspecified for
+// one combined from proofs that do not name one.
+func (p MultiProof) Algorithm() AlgorithmID {
+	return p.algorithm
+}
+
+// Combines individual proofs against the same root into a single MultiProof.
+// Fails with ErrInconsistentProofs if the proofs differ in depth, root, tag or algorithm,
+// or disagree about any node. The application tag and the algorithm are taken from the
+// proofs, which the multiproof records; proofs from a tree built with other hashing
+// options, such as WithRawNodeHashing or WithSortedPairs, need the same options, as its
+// verifiers do; the tree's GetMultiProof needs none.
+func CombineProofs(proofs []MerkleProof, opts ...Option) (MultiProof, error) {
+	if len(proofs) == 0 {
+		return MultiProof{}, fmt.Errorf("%w: no proofs given", ErrInconsistentProofs)
+	}
+
+	cfg := newConfig(opts)
+	cfg.setTag(proofs[0].tag)
+	cfg.detectAlgorithm = true // the proofs name the algorithm to fold with
+	cfg, err := cfg.forAlgorithm(proofs[0].algorithm)
+	if err != nil {
+		return MultiProof{}, err
+	}
+	return cfg.combineProofs(proofs)
+}
+
+// Generates a single MultiProof of the elements at indices, each once whatever its
+// repetitions, as CombineProofs would combine their proofs under the tree's options.
+// Fails with ErrIndexOutOfBounds for an index outside the tree and with
+// ErrInconsistentProofs for no indices.
+func (t *MerkleTree) GetMultiProof(indices []uint64) (MultiProof, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return MultiProof{}, err
+	}
+	defer t.mu.RUnlock()
+
+	unique := make([]uint64, 0, len(indices))
+	for _, index := range indices {
+		if index >= t.leafCount() {
+			return MultiProof{}, t.outOfBounds(index)
+		}
+		unique = append(unique, index)
+	}
+	slices.Sort(unique)
+	return t.cfg.combineProofs(t.proofsFor(slices.Compact(unique)))
+}
+
+// Combines the proofs as CombineProofs does, cfg hashing under their tag and algorithm.
+func (cfg config) combineProofs(proofs []MerkleProof) (MultiProof, error) {
+	if len(proofs) == 0 {
+		return MultiProof{}, fmt.Errorf("%w: no proofs given", ErrInconsistentProofs)
+	}
+
+	depth := len(proofs[0].siblings)
+	algorithm := proofs[0].algorithm
+	root := ""
+	proven := make(map[uint64]bool)             // leaf indices covered by the proofs
+	known := make([]map[uint64]string, depth+1) // node hashes revealed by any proof, per level
+	for level := range known {
+		known[level] = make(map[uint64]string)
+	}
+
+	record := func(level int, position uint64, hash string) error {
+		if existing, ok := known[level][position]; ok && existing != hash {
+			return fmt.Errorf("%w: node %d at level %d differs", ErrInconsistentProofs, position, level)
+		}
+		known[level][position] = hash
+		return nil
+	}
+
+	for i, proof := range proofs {
+		if err := proof.validateShape(); err != nil {
+			return MultiProof{}, err
+		}
+		if len(proof.siblings) != depth {
+			return MultiProof{}, fmt.Errorf("%w: proof %d has depth %d, want %d", ErrInconsistentProofs, i, len(proof.siblings), depth)
+		}
+		if proof.tag != cfg.tag {
+			return MultiProof{}, fmt.Errorf("%w: proof %d has tag %q, want %q", ErrInconsistentProofs, i, proof.tag, cfg.tag)
+		}
+		if proof.algorithm != algorithm {
+			return MultiProof{}, fmt.Errorf("%w: proof %d has algorithm %v, want %v", ErrInconsistentProofs, i, proof.algorithm, algorithm)
+		}
+
+		position := proofIndex(proof)
+		proven[position] = true
+		if err := record(0, position, proof.hElement); err != nil {
+			return MultiProof{}, err
+		}
+
+		current := proof.hElement
+		for level, sibling := range proof.siblings {
+			if err := record(level, position, current); err != nil {
+				return MultiProof{}, err
+			}
+			if err := record(level, position^1, sibling); err != nil {
+				return MultiProof{}, err
+			}
+			if proof.directions[level] {
+				current = cfg.hashNode(sibling, current)
+			} else {
+				current = cfg.hashNode(current, sibling)
+			}
+			position /= 2
+		}
+
+		if i == 0 {
+			root = current
+		} else if current != root {
+			return MultiProof{}, fmt.Errorf("%w: proof %d derives a different root", ErrInconsistentProofs, i)
+		}
+	}
+
+	multi := MultiProof{depth: depth, tag: cfg.tag, algorithm: algorithm}
+	for index := range proven {
+		multi.indices = append(multi.indices, index)
+	}
+	sort.Slice(multi.indices, func(i, j int) bool { return multi.indices[i] < multi.indices[j] })
+	for _, index := range multi.indices {
+		multi.leaves = append(multi.leaves, known[0][index])
+	}
+
+	positions := multi.indices
+	for level := 0; level < depth; level++ {
+		var parents []uint64
+		for i := 0; i < len(positions); i++ {
+			position := positions[i]
+			if position%2 == 0 && i+1 < len(positions) && positions[i+1] == position+1 {
+				i++
+			} else {
+				multi.siblings = append(multi.siblings, known[level][position^1])
+			}
+			parents = append(parents, position/2)
+		}
+		positions = parents
+	}
+
+	return multi, nil
+}
+
+// Verifies that every leaf of the multiproof is included under root.
+// A multiproof from a tree with another application tag never verifies, nor one naming
+// another algorithm than the options hash with, unless WithAlgorithmAutoDetect is given.
+func VerifyMultiProof(root string, proof MultiProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyMultiProof(root, proof)
+}
+
+// Verifies the multiproof as VerifyMultiProof does, checking ctx every few hundred hashes
+// so that verifying a very large proof stops soon after ctx is done. Returns nil for a
+// proof that verifies, ErrInvalidProof for one that does not, ErrAlgorithmMismatch for
+// one naming another algorithm and an InvalidDigestError for a digest that does not
+// parse, or once ctx is done, context.Cause(ctx), ctx.Err() unless a cause was given,
+// telling a deadline apart from a proof that failed.
+func VerifyMultiProofCtx(ctx context.Context, root string, proof MultiProof, opts ...Option) error {
+	return newVerifier(opts).VerifyMultiProofCtx(ctx, root, proof)
+}
+
+// Verifies that every leaf of the multiproof is included under root, as VerifyMultiProof does.
+func (v *Verifier) VerifyMultiProof(root string, proof MultiProof) bool {
+	return v.VerifyMultiProofCtx(context.Background(), root, proof) == nil
+}
+
+// Verifies the multiproof under ctx, as VerifyMultiProofCtx does.
+func (v *Verifier) VerifyMultiProofCtx(ctx context.Context, root string, proof MultiProof) (err error) {
+	if v.cfg.tracer != nil {
+		_, span := v.cfg.tracer.Start(ctx, spanVerifyMulti,
+			slog.Int(attrDepth, proof.depth), slog.Int(attrIndexCount, len(proof.indices)))
+		defer func() { endVerifySpan(span, err) }()
+	}
+	_, err = v.cfg.verifyMultiProof(ctx, root, proof)
+	return err
+}
+
+// Verifies the multiproof under cfg, failing with ErrInvalidProof, ErrAlgorithmMismatch,
+// an InvalidDigestError for a root, leaf or sibling that does not parse, or the cause of
+// ctx. Returns the leaves parsed, in the order of the proof's indices.
+func (cfg config) verifyMultiProof(ctx context.Context, root string, proof MultiProof) ([]Hash, error) {
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	want, err := parseRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	if proof.tag != cfg.tag {
+		return nil, ErrInvalidProof
+	}
+	if cfg, err = cfg.forAlgorithm(proof.algorithm); err != nil {
+		return nil, err
+	}
+	if len(proof.indices) == 0 || len(proof.indices) != len(proof.leaves) || proof.depth > maxProofDepth {
+		return nil, ErrInvalidProof
+	}
+	for i, index := range proof.indices {
+		if (proof.depth < 64 && index>>proof.depth != 0) || (i > 0 && index <= proof.indices[i-1]) {
+			return nil, ErrInvalidProof
+		}
+	}
+
+	leaves, err := parseDigests("leaf", proof.leaves)
+	if err != nil {
+		return nil, err
+	}
+	siblings, err := parseDigests("sibling", proof.siblings)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := proof.indices
+	hashes := leaves
+	hashed := 0
+
+	for level := 0; level < proof.depth; level++ {
+		var parentPositions []uint64
+		var parentHashes []Hash
+
+		for i := 0; i < len(positions); i++ {
+			if hashed++; hashed%multiProofCheckInterval == 0 && ctx.Err() != nil {
+				return nil, context.Cause(ctx)
+			}
+			position, hash := positions[i], hashes[i]
+
+			var parent Hash
+			switch {
+			case position%2 == 0 && i+1 < len(positions) && positions[i+1] == position+1:
+				parent = cfg.nodeDigest(hash, hashes[i+1])
+				i++
+			case len(siblings) == 0:
+				return nil, ErrInvalidProof
+			case position%2 == 0:
+				parent = cfg.nodeDigest(hash, siblings[0])
+				siblings = siblings[1:]
+			default:
+				parent = cfg.nodeDigest(siblings[0], hash)
+				siblings = siblings[1:]
+			}
+
+			parentPositions = append(parentPositions, position/2)
+			parentHashes = append(parentHashes, parent)
+		}
+
+		positions, hashes = parentPositions, parentHashes
+	}
+
+	if len(siblings) != 0 || len(hashes) != 1 || hashes[0] != want {
+		return nil, ErrInvalidProof
+	}
+	return leaves, nil
+}
+
+// Parses the digests of a multiproof, failing with an InvalidDigestError naming the field
+// and the position of the first that does not parse.
+func parseDigests(field string, digests []string) ([]Hash, error) {
+	parsed := make([]Hash, len(digests))
+	for i, digest := range digests {
+		h, err := ParseHash(digest)
+		if err != nil {
+			return nil, &InvalidDigestError{Field: field, Index: i, Err: err}
+		}
+		parsed[i] = h
+	}
+	return parsed, nil
+}
+
+// Returns the leaf index a proof's directions describe.
+func proofIndex(proof MerkleProof) uint64 {
+	var index uint64
+	for level, siblingIsLeft := range proof.directions {
+		if siblingIsLeft {
+			index |= 1 << level
+		}
+	}
+	return index
+}
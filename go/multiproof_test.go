@@ -0,0 +1,246 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCombineProofs(t *testing.T) {
+	cases := []struct {
+		size    int
+		indices []uint64
+	}{
+		{1, []uint64{0}},
+		{2, []uint64{0, 1}},
+		{8, []uint64{2, 3}},
+		{8, []uint64{0, 7}},
+		{13, []uint64{12, 0, 5, 6, 5}},
+		{32, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 30}},
+	}
+
+	for _, c := range cases {
+		testname := fmt.Sprintf("combines %v of %d elements", c.indices, c.size)
+		t.Run(testname, func(t *testing.T) {
+			mt, err := NewMerkleTree(testElements(c.size))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var proofs []MerkleProof
+			naive := 0
+			for _, index := range c.indices {
+				proof, err := mt.GetProof(index)
+				if err != nil {
+					t.Fatal(err)
+				}
+				proofs = append(proofs, proof)
+				naive += len(proof.siblings)
+			}
+
+			multi, err := CombineProofs(proofs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyMultiProof(mt.GetRoot(), multi) {
+				t.Error("invalid multiproof")
+			}
+			if VerifyMultiProof(hashLeaf("other"), multi) {
+				t.Error("verified against the wrong root")
+			}
+			if len(multi.siblings) > naive {
+				t.Errorf("got %d siblings, more than the %d of the individual proofs", len(multi.siblings), naive)
+			}
+		})
+	}
+}
+
+func TestCombineProofsDedupesDerivableSiblings(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(8))
+	left, _ := mt.GetProof(2)
+	right, _ := mt.GetProof(3)
+
+	multi, err := CombineProofs([]MerkleProof{left, right})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// leaves 2 and 3 derive their parent, so only the two upper siblings remain
+	if len(multi.siblings) != 2 {
+		t.Errorf("got %d siblings, want 2", len(multi.siblings))
+	}
+}
+
+func TestGetMultiProof(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(13), WithRawNodeHashing(), WithApplicationTag("app"))
+	multi, err := mt.GetMultiProof([]uint64{12, 0, 5, 6, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := multi.Indices(), []uint64{0, 5, 6, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if !mt.Verifier().VerifyMultiProof(mt.GetRoot(), multi) {
+		t.Error("invalid multiproof")
+	}
+
+	if _, err := mt.GetMultiProof([]uint64{1, 13}); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	if _, err := mt.GetMultiProof(nil); !errors.Is(err, ErrInconsistentProofs) {
+		t.Errorf("got %v, want %v", err, ErrInconsistentProofs)
+	}
+}
+
+func TestCombineProofsRejectsInconsistent(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(8))
+	other, _ := NewMerkleTree(testElements(7))
+	small, _ := NewMerkleTree(testElements(4))
+
+	a, _ := mt.GetProof(1)
+	b, _ := other.GetProof(5)
+	c, _ := small.GetProof(1)
+
+	if _, err := CombineProofs([]MerkleProof{a, b}); !errors.Is(err, ErrInconsistentProofs) {
+		t.Errorf("different roots: got %v, want %v", err, ErrInconsistentProofs)
+	}
+	if _, err := CombineProofs([]MerkleProof{a, c}); !errors.Is(err, ErrInconsistentProofs) {
+		t.Errorf("different depths: got %v, want %v", err, ErrInconsistentProofs)
+	}
+	mixed, _ := mt.GetProof(5)
+	mixed.algorithm = AlgorithmKeccak256
+	if _, err := CombineProofs([]MerkleProof{a, mixed}); !errors.Is(err, ErrInconsistentProofs) {
+		t.Errorf("different algorithms: got %v, want %v", err, ErrInconsistentProofs)
+	}
+	if _, err := CombineProofs(nil); !errors.Is(err, ErrInconsistentProofs) {
+		t.Errorf("no proofs: got %v, want %v", err, ErrInconsistentProofs)
+	}
+}
+
+func TestVerifyMultiProofRejectsTampering(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(16))
+	var proofs []MerkleProof
+	for _, index := range []uint64{3, 9} {
+		proof, _ := mt.GetProof(index)
+		proofs = append(proofs, proof)
+	}
+	multi, _ := CombineProofs(proofs)
+
+	extra := multi
+	extra.siblings = append(append([]string(nil), multi.siblings...), hashLeaf("extra"))
+	if VerifyMultiProof(mt.GetRoot(), extra) {
+		t.Error("verified with a surplus sibling")
+	}
+
+	short := multi
+	short.siblings = multi.siblings[1:]
+	if VerifyMultiProof(mt.GetRoot(), short) {
+		t.Error("verified with a missing sibling")
+	}
+
+	moved := multi
+	moved.indices = []uint64{3, 8}
+	if VerifyMultiProof(mt.GetRoot(), moved) {
+		t.Error("verified with the wrong index")
+	}
+
+	garbled := multi
+	garbled.siblings = append([]string(nil), multi.siblings...)
+	garbled.siblings[1] = "not a digest"
+	var invalid *InvalidDigestError
+	if err := VerifyMultiProofCtx(context.Background(), mt.GetRoot(), garbled); !errors.As(err, &invalid) || invalid.Field != "sibling" || invalid.Index != 1 {
+		t.Errorf("got %v, want an InvalidDigestError for sibling 1", err)
+	}
+
+	prefixed := multi
+	prefixed.leaves = []string{"0x" + strings.ToUpper(multi.leaves[0]), multi.leaves[1]}
+	if !VerifyMultiProof(mt.GetRoot(), prefixed) {
+		t.Error("got a leaf in another form of the same digest rejected")
+	}
+}
+
+// Hashes as the default options do, taking delay over every node, to stand in for a
+// proof too large to verify before a deadline. Nodes hashed are counted in calls.
+type slowBackend struct {
+	delay time.Duration
+	calls *atomic.Int64
+}
+
+func (slowBackend) name() string                      { return "slow" }
+func (slowBackend) algorithm() AlgorithmID            { return AlgorithmSHA256 }
+func (slowBackend) check() error                      { return nil }
+func (slowBackend) checkElement(element string) error { return nil }
+func (slowBackend) leaf(element string) Hash          { return leafDigest(element) }
+
+func (b slowBackend) node(left Hash, right Hash) Hash {
+	b.calls.Add(1)
+	// spun rather than slept, as sleeps this short take far longer
+	for start := time.Now(); time.Since(start) < b.delay; {
+	}
+	return nodeDigest(left, right)
+}
+
+// Returns the option hashing with a slowBackend of the delay, counting nodes in calls.
+func withSlowBackend(delay time.Duration, calls *atomic.Int64) Option {
+	return func(cfg *config) { cfg.backend = slowBackend{delay: delay, calls: calls} }
+}
+
+// Returns the multiproof of every third leaf of a tree of n elements.
+func sparseMultiProof(t *testing.T, n int) (*MerkleTree, MultiProof) {
+	t.Helper()
+	mt, _ := NewMerkleTree(testElements(n))
+	var indices []uint64
+	for i := uint64(0); i < uint64(n); i += 3 {
+		indices = append(indices, i)
+	}
+	proofs, _ := mt.GetProofs(indices)
+	list := make([]MerkleProof, 0, len(proofs))
+	for _, index := range indices {
+		list = append(list, proofs[index])
+	}
+	multi, err := CombineProofs(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mt, multi
+}
+
+func TestVerifyMultiProofCtx(t *testing.T) {
+	mt, multi := sparseMultiProof(t, 1<<12)
+	if err := VerifyMultiProofCtx(context.Background(), mt.GetRoot(), multi); err != nil {
+		t.Errorf("got %v, want the proof verified", err)
+	}
+	if err := VerifyMultiProofCtx(context.Background(), hashLeaf("other"), multi); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v", err, ErrInvalidProof)
+	}
+
+	var full, calls atomic.Int64
+	if err := VerifyMultiProofCtx(context.Background(), mt.GetRoot(), multi, withSlowBackend(0, &full)); err != nil {
+		t.Fatal(err)
+	}
+	slow := withSlowBackend(50*time.Microsecond, &calls)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := VerifyMultiProofCtx(ctx, mt.GetRoot(), multi, slow)
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v rather than an invalid proof", err, context.DeadlineExceeded)
+	}
+	// the whole proof hashes about 4000 nodes, 200ms at the delay
+	if calls.Load() >= full.Load() {
+		t.Errorf("got %d nodes hashed, want verification stopping before the %d of the whole proof", calls.Load(), full.Load())
+	}
+
+	stop := errors.New("client went away")
+	cancelled, cancelCause := context.WithCancelCause(context.Background())
+	cancelCause(stop)
+	if err := VerifyMultiProofCtx(cancelled, mt.GetRoot(), multi, slow); !errors.Is(err, stop) {
+		t.Errorf("got %v, want %v", err, stop)
+	}
+	if !VerifyMultiProof(mt.GetRoot(), multi) {
+		t.Error("got the proof rejected without a context")
+	}
+}
@@ -0,0 +1,328 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+var (
+	ErrNamespaceSize       = errors.New("merkletree: namespace has the wrong size")
+	ErrNamespaceOrder      = errors.New("merkletree: leaves are not sorted by namespace")
+	ErrIncompleteNamespace = errors.New("merkletree: proof omits leaves of the namespace")
+)
+
+// Data filed under a fixed-size namespace ID, a leaf of a NamespacedTree.
+type NamespacedLeaf struct {
+	Namespace []byte
+	Data      string
+}
+
+// A node of a NamespacedTree: its digest with the lowest and highest namespaces beneath it.
+type NamespacedHash struct {
+	Min    []byte
+	Max    []byte
+	Digest Hash
+}
+
+// Formats the node as hex Min | Max | Digest.
+func (h NamespacedHash) String() string {
+	return hex.EncodeToString(h.Min) + hex.EncodeToString(h.Max) + h.Digest.String()
+}
+
+// Reports whether both nodes have the same namespace range and digest.
+func (h NamespacedHash) Equal(other NamespacedHash) bool {
+	return h.Digest == other.Digest && bytes.Equal(h.Min, other.Min) && bytes.Equal(h.Max, other.Max)
+}
+
+func (h NamespacedHash) clone() NamespacedHash {
+	return NamespacedHash{Min: bytes.Clone(h.Min), Max: bytes.Clone(h.Max), Digest: h.Digest}
+}
+
+// A Merkle tree over leaves sorted by namespace, after Celestia's namespaced Merkle trees.
+// Every node commits to the range of namespaces beneath it, so a NamespaceProof shows
+// that it returns all the leaves of a namespace, or that the tree holds none.
+//
+// A leaf hashes as the element namespace | data would. A node hashes
+//
+//	left.Min | left.Max | left.Digest | right.Min | right.Max | right.Digest
+//
+// with any application tag digest in front. Rather than padding, which would need a
+// namespace of its own, a subtree splits at the largest power of two below its size as in RFC 6962.
+// The tree is never modified once built, so it is safe for concurrent use.
+type NamespacedTree struct {
+	cfg    config
+	size   int // bytes in every namespace ID
+	leaves []NamespacedLeaf
+	nodes  map[leafSpan]NamespacedHash // every subtree, by the leaves it covers
+}
+
+// The leaves lo to hi, exclusive, covered by a subtree.
+type leafSpan struct {
+	lo, hi uint64
+}
+
+// Builds a namespaced tree over the leaves, whose namespaces must all be namespaceSize bytes
+// and in ascending byte order. Leaves may share a namespace.
+func NewNamespacedTree(namespaceSize int, leaves []NamespacedLeaf, opts ...Option) (*NamespacedTree, error) {
+	if len(leaves) == 0 {
+		return nil, ErrEmptyTree
+	}
+	if namespaceSize <= 0 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrNamespaceSize, namespaceSize)
+	}
+
+	t := &NamespacedTree{
+		cfg:    newConfig(opts),
+		size:   namespaceSize,
+		leaves: make([]NamespacedLeaf, len(leaves)),
+		nodes:  make(map[leafSpan]NamespacedHash, 2*len(leaves)),
+	}
+	if err := t.cfg.checkMode(); err != nil {
+		return nil, err
+	}
+	for i, leaf := range leaves {
+		if len(leaf.Namespace) != namespaceSize {
+			return nil, fmt.Errorf("%w: leaf %d has %d bytes, want %d", ErrNamespaceSize, i, len(leaf.Namespace), namespaceSize)
+		}
+		if i > 0 && bytes.Compare(leaves[i-1].Namespace, leaf.Namespace) > 0 {
+			return nil, fmt.Errorf("%w: leaf %d precedes leaf %d", ErrNamespaceOrder, i, i-1)
+		}
+		t.leaves[i] = NamespacedLeaf{Namespace: bytes.Clone(leaf.Namespace), Data: leaf.Data}
+	}
+
+	t.build(0, uint64(len(leaves)))
+	return t, nil
+}
+
+func (t *NamespacedTree) build(lo, hi uint64) NamespacedHash {
+	var node NamespacedHash
+	if hi-lo == 1 {
+		node = t.cfg.namespacedLeaf(t.leaves[lo].Namespace, t.leaves[lo].Data)
+	} else {
+		mid := lo + splitPoint(hi-lo)
+		node = t.cfg.namespacedNode(t.build(lo, mid), t.build(mid, hi))
+	}
+	t.nodes[leafSpan{lo, hi}] = node
+	return node
+}
+
+// Returns the root, covering the namespaces of the first and last leaves.
+func (t *NamespacedTree) Root() NamespacedHash {
+	return t.nodes[leafSpan{0, t.LeafCount()}].clone()
+}
+
+// Returns the number of leaves.
+func (t *NamespacedTree) LeafCount() uint64 {
+	return uint64(len(t.leaves))
+}
+
+// Returns the number of bytes in every namespace ID of the tree.
+func (t *NamespacedTree) NamespaceSize() int {
+	return t.size
+}
+
+// Returns the data of every leaf in the namespace, in leaf order, with the proof that there are no others.
+// For a namespace the tree does not hold the data is empty and the proof shows its absence.
+func (t *NamespacedTree) GetNamespaceProof(namespace []byte) ([]string, NamespaceProof, error) {
+	if len(namespace) != t.size {
+		return nil, NamespaceProof{}, fmt.Errorf("%w: %d bytes, want %d", ErrNamespaceSize, len(namespace), t.size)
+	}
+
+	n := len(t.leaves)
+	start := sort.Search(n, func(i int) bool { return bytes.Compare(t.leaves[i].Namespace, namespace) >= 0 })
+	end := sort.Search(n, func(i int) bool { return bytes.Compare(t.leaves[i].Namespace, namespace) > 0 })
+	proof := NamespaceProof{Start: uint64(start), End: uint64(end), LeafCount: uint64(n)}
+
+	covered := proof.End
+	if start == end {
+		if start == 0 || start == n {
+			// outside the root's range, which shows the absence on its own
+			return nil, NamespaceProof{LeafCount: uint64(n)}, nil
+		}
+		boundary := t.nodes[leafSpan{proof.Start, proof.Start + 1}].clone()
+		proof.Boundary = &boundary
+		covered++
+	}
+
+	proof.Siblings = t.rangeSiblings(0, proof.LeafCount, proof.Start, covered, nil)
+	data := make([]string, 0, end-start)
+	for _, leaf := range t.leaves[start:end] {
+		data = append(data, leaf.Data)
+	}
+	return data, proof, nil
+}
+
+// Appends the subtrees under lo to hi which cover no leaf from start to end, left to right.
+func (t *NamespacedTree) rangeSiblings(lo, hi, start, end uint64, siblings []NamespacedHash) []NamespacedHash {
+	switch {
+	case hi <= start || lo >= end:
+		return append(siblings, t.nodes[leafSpan{lo, hi}].clone())
+	case start <= lo && hi <= end:
+		return siblings
+	}
+	mid := lo + splitPoint(hi-lo)
+	siblings = t.rangeSiblings(lo, mid, start, end, siblings)
+	return t.rangeSiblings(mid, hi, start, end, siblings)
+}
+
+// Proves that the leaves from Start to End, exclusive, are all those of a NamespacedTree
+// in some namespace, by the nodes covering every other leaf: those on the left must end
+// below the namespace and those on the right start above it.
+//
+// A namespace the tree does not hold has Start equal to End. Boundary is then the leaf
+// at Start, the first above the namespace, with the nodes covering the other leaves.
+// When the namespace lies outside the range of the root, the range is empty and there
+// is neither a boundary nor any siblings.
+type NamespaceProof struct {
+	Start     uint64
+	End       uint64
+	LeafCount uint64           // leaves in the tree, which fixes its shape
+	Siblings  []NamespacedHash // left to right
+	Boundary  *NamespacedHash
+}
+
+// Verifies that data is all the data in the namespace of the tree with the given root,
+// in leaf order, returning nil or why not: ErrNamespaceSize for a namespace of another
+// size than the root's, ErrMalformedProof for a proof of inconsistent shape,
+// ErrIncompleteNamespace or ErrNamespaceOrder when the proof leaves room for other leaves
+// in the namespace, or ErrInvalidProof for a well-formed proof of another root.
+// An empty data proves the tree holds no leaf in the namespace.
+func VerifyNamespaceProof(root NamespacedHash, namespace []byte, data []string, proof NamespaceProof, opts ...Option) error {
+	size := len(root.Min)
+	if len(root.Max) != size || size == 0 {
+		return fmt.Errorf("%w: root namespaces have %d and %d bytes", ErrMalformedProof, len(root.Min), len(root.Max))
+	}
+	if len(namespace) != size {
+		return fmt.Errorf("%w: %d bytes, want %d", ErrNamespaceSize, len(namespace), size)
+	}
+
+	if proof.Start == proof.End && proof.Boundary == nil {
+		if len(data) > 0 || len(proof.Siblings) > 0 {
+			return fmt.Errorf("%w: absence outside the root's range carries leaves", ErrMalformedProof)
+		}
+		if bytes.Compare(namespace, root.Min) < 0 || bytes.Compare(namespace, root.Max) > 0 {
+			return nil
+		}
+		return fmt.Errorf("%w: namespace lies within the root's range", ErrIncompleteNamespace)
+	}
+
+	if proof.Start > proof.End || uint64(len(data)) != proof.End-proof.Start {
+		return fmt.Errorf("%w: %d leaves for the range %d to %d", ErrMalformedProof, len(data), proof.Start, proof.End)
+	}
+
+	cfg := newConfig(opts)
+	v := namespaceVerifier{cfg: cfg, namespace: namespace, start: proof.Start, end: proof.End, siblings: proof.Siblings}
+	for _, d := range data {
+		v.leaves = append(v.leaves, cfg.namespacedLeaf(namespace, d))
+	}
+	if proof.Boundary != nil {
+		if proof.Start != proof.End {
+			return fmt.Errorf("%w: boundary leaf with a non-empty range", ErrMalformedProof)
+		}
+		if !v.wellFormed(*proof.Boundary) {
+			return fmt.Errorf("%w: boundary leaf", ErrMalformedProof)
+		}
+		if bytes.Compare(proof.Boundary.Min, namespace) <= 0 {
+			return fmt.Errorf("%w: boundary leaf does not follow the namespace", ErrIncompleteNamespace)
+		}
+		v.leaves = append(v.leaves, *proof.Boundary)
+		v.end++
+	}
+	if v.end > proof.LeafCount {
+		return fmt.Errorf("%w: range ends at %d of %d leaves", ErrMalformedProof, v.end, proof.LeafCount)
+	}
+
+	derived, err := v.node(0, proof.LeafCount)
+	if err != nil {
+		return err
+	}
+	if len(v.siblings) > 0 {
+		return fmt.Errorf("%w: %d siblings left over", ErrMalformedProof, len(v.siblings))
+	}
+	if !derived.Equal(root) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// Rebuilds the root of a namespace proof, checking the namespace ranges on the way.
+type namespaceVerifier struct {
+	cfg        config
+	namespace  []byte
+	start, end uint64           // leaves the verifier holds
+	leaves     []NamespacedHash // from start to end
+	siblings   []NamespacedHash // not yet consumed, left to right
+}
+
+func (v *namespaceVerifier) node(lo, hi uint64) (NamespacedHash, error) {
+	if hi <= v.start || lo >= v.end {
+		if len(v.siblings) == 0 {
+			return NamespacedHash{}, fmt.Errorf("%w: too few siblings", ErrMalformedProof)
+		}
+		sibling := v.siblings[0]
+		v.siblings = v.siblings[1:]
+		if !v.wellFormed(sibling) {
+			return NamespacedHash{}, fmt.Errorf("%w: sibling covering leaves %d to %d", ErrMalformedProof, lo, hi)
+		}
+		if hi <= v.start && bytes.Compare(sibling.Max, v.namespace) >= 0 {
+			return NamespacedHash{}, fmt.Errorf("%w: leaves %d to %d reach the namespace", ErrIncompleteNamespace, lo, hi)
+		}
+		if lo >= v.end && bytes.Compare(sibling.Min, v.namespace) <= 0 {
+			return NamespacedHash{}, fmt.Errorf("%w: leaves %d to %d reach the namespace", ErrIncompleteNamespace, lo, hi)
+		}
+		return sibling, nil
+	}
+	if hi-lo == 1 {
+		return v.leaves[lo-v.start], nil
+	}
+
+	mid := lo + splitPoint(hi-lo)
+	left, err := v.node(lo, mid)
+	if err != nil {
+		return NamespacedHash{}, err
+	}
+	right, err := v.node(mid, hi)
+	if err != nil {
+		return NamespacedHash{}, err
+	}
+	if bytes.Compare(left.Max, right.Min) > 0 {
+		return NamespacedHash{}, fmt.Errorf("%w: leaves %d to %d", ErrNamespaceOrder, lo, hi)
+	}
+	return v.cfg.namespacedNode(left, right), nil
+}
+
+// Reports whether a node from the proof has namespaces of the right size, in order.
+func (v *namespaceVerifier) wellFormed(node NamespacedHash) bool {
+	return len(node.Min) == len(v.namespace) && len(node.Max) == len(v.namespace) && bytes.Compare(node.Min, node.Max) <= 0
+}
+
+func (cfg config) namespacedLeaf(namespace []byte, data string) NamespacedHash {
+	return NamespacedHash{Min: namespace, Max: namespace, Digest: cfg.leafDigest(string(namespace) + data)}
+}
+
+// Hashes two adjacent nodes into their parent, which spans from the left's Min to the right's Max.
+func (cfg config) namespacedNode(left NamespacedHash, right NamespacedHash) NamespacedHash {
+	h := sha256.New()
+	if cfg.tag != "" {
+		h.Write(cfg.tagDigest[:])
+	}
+	for _, child := range []NamespacedHash{left, right} {
+		h.Write(child.Min)
+		h.Write(child.Max)
+		h.Write(child.Digest[:])
+	}
+
+	parent := NamespacedHash{Min: left.Min, Max: right.Max}
+	h.Sum(parent.Digest[:0])
+	return parent
+}
+
+// Returns the size of the left subtree over n > 1 leaves, the largest power of two below n.
+func splitPoint(n uint64) uint64 {
+	return 1 << (bits.Len64(n-1) - 1)
+}
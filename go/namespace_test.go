@@ -0,0 +1,131 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Returns leaves in namespaces 2, 4, 6, ... with leaf i in namespace 2*(i/2+1),
+// so that every namespace holds two leaves apart from possibly the last.
+func testNamespacedLeaves(n int) []NamespacedLeaf {
+	leaves := make([]NamespacedLeaf, n)
+	for i := range leaves {
+		leaves[i] = NamespacedLeaf{Namespace: []byte{0, byte(2 * (i/2 + 1))}, Data: fmt.Sprintf("element-%d", i)}
+	}
+	return leaves
+}
+
+func TestNamespaceProofs(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		leaves := testNamespacedLeaves(n)
+		nt, err := NewNamespacedTree(2, leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for ns := 0; ns <= n+3; ns++ {
+			testname := fmt.Sprintf("%d leaves, namespace %d", n, ns)
+			t.Run(testname, func(t *testing.T) {
+				namespace := []byte{0, byte(ns)}
+				var want []string
+				for _, leaf := range leaves {
+					if slices.Equal(leaf.Namespace, namespace) {
+						want = append(want, leaf.Data)
+					}
+				}
+
+				data, proof, err := nt.GetNamespaceProof(namespace)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(data, want) {
+					t.Errorf("got %v, want %v", data, want)
+				}
+				if err := VerifyNamespaceProof(nt.Root(), namespace, data, proof); err != nil {
+					t.Errorf("got %v, want nil", err)
+				}
+			})
+		}
+	}
+}
+
+func TestNamespaceProofRejectsOmissions(t *testing.T) {
+	nt, _ := NewNamespacedTree(2, testNamespacedLeaves(9))
+	root := nt.Root()
+	namespace := []byte{0, 4}
+	data, proof, _ := nt.GetNamespaceProof(namespace)
+
+	// dropping the last leaf of the namespace and claiming a shorter range
+	short := proof
+	short.End--
+	if err := VerifyNamespaceProof(root, namespace, data[:1], short); err == nil {
+		t.Error("verified with a leaf omitted")
+	}
+
+	// a proof for one namespace does not prove another
+	if err := VerifyNamespaceProof(root, []byte{0, 6}, data, proof); err == nil {
+		t.Error("verified under another namespace")
+	}
+
+	// claiming the namespace is absent
+	absent := NamespaceProof{LeafCount: proof.LeafCount}
+	if err := VerifyNamespaceProof(root, namespace, nil, absent); !errors.Is(err, ErrIncompleteNamespace) {
+		t.Errorf("got %v, want %v", err, ErrIncompleteNamespace)
+	}
+
+	tampered := proof
+	tampered.Siblings = slices.Clone(proof.Siblings)
+	tampered.Siblings[0].Digest[0] ^= 1
+	if err := VerifyNamespaceProof(root, namespace, data, tampered); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, want %v", err, ErrInvalidProof)
+	}
+
+	if err := VerifyNamespaceProof(root, []byte{4}, data, proof); !errors.Is(err, ErrNamespaceSize) {
+		t.Errorf("got %v, want %v", err, ErrNamespaceSize)
+	}
+}
+
+func TestNamespaceAbsenceProof(t *testing.T) {
+	nt, _ := NewNamespacedTree(2, testNamespacedLeaves(9))
+	root := nt.Root()
+	namespace := []byte{0, 5}
+
+	data, proof, _ := nt.GetNamespaceProof(namespace)
+	if len(data) != 0 || proof.Boundary == nil {
+		t.Fatalf("got %d leaves and boundary %v, want an absence proof", len(data), proof.Boundary)
+	}
+	if !slices.Equal(proof.Boundary.Min, []byte{0, 6}) {
+		t.Errorf("got boundary namespace %x, want 0006", proof.Boundary.Min)
+	}
+
+	// the boundary proves nothing for its own namespace
+	if err := VerifyNamespaceProof(root, []byte{0, 6}, nil, proof); !errors.Is(err, ErrIncompleteNamespace) {
+		t.Errorf("got %v, want %v", err, ErrIncompleteNamespace)
+	}
+	// nor for a namespace past the leaves before it
+	if err := VerifyNamespaceProof(root, []byte{0, 3}, nil, proof); !errors.Is(err, ErrIncompleteNamespace) {
+		t.Errorf("got %v, want %v", err, ErrIncompleteNamespace)
+	}
+}
+
+func TestNamespacedTreeRejectsBadLeaves(t *testing.T) {
+	leaves := testNamespacedLeaves(4)
+	leaves[1], leaves[2] = leaves[2], leaves[1]
+	if _, err := NewNamespacedTree(2, leaves); !errors.Is(err, ErrNamespaceOrder) {
+		t.Errorf("got %v, want %v", err, ErrNamespaceOrder)
+	}
+	if _, err := NewNamespacedTree(3, testNamespacedLeaves(4)); !errors.Is(err, ErrNamespaceSize) {
+		t.Errorf("got %v, want %v", err, ErrNamespaceSize)
+	}
+	if _, err := NewNamespacedTree(2, nil); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+
+	tagged, _ := NewNamespacedTree(2, testNamespacedLeaves(4), WithApplicationTag("ledger"))
+	untagged, _ := NewNamespacedTree(2, testNamespacedLeaves(4))
+	if tagged.Root().Equal(untagged.Root()) {
+		t.Error("the application tag did not change the root")
+	}
+}
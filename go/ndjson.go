@@ -0,0 +1,241 @@
+package merkletree
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+var (
+	ErrMalformedDump = errors.New("merkletree: malformed NDJSON dump")
+	ErrDumpMismatch  = errors.New("merkletree: NDJSON dump disagrees with its header")
+)
+
+// Opens an NDJSON dump: the options shaping the tree that a Scheme does not carry, and
+// what the records that follow must rebuild.
+type ndjsonHeader struct {
+	Scheme       Scheme `json:"scheme"`
+	FixedDepth   int    `json:"fixedDepth,omitempty"`
+	SetSemantics bool   `json:"setSemantics,omitempty"`
+	Height       int    `json:"height"`
+	Count        uint64 `json:"count"`
+	Root         string `json:"root"`
+}
+
+// One leaf of an NDJSON dump, carrying its element or, for imported trees, its leaf hash.
+type ndjsonRecord struct {
+	Index    *uint64 `json:"index"`
+	Element  *string `json:"element"`
+	LeafHash *string `json:"leafHash"`
+	Data     []byte  `json:"data"` // committed with the element, see SetLeafData
+}
+
+// Writes the tree to w as newline-delimited JSON, for RestoreNDJSON to rebuild: a header
+// line carrying the scheme, the count, the height and the root, then one line per leaf
+// in index order, {"index": ..., "element": ...}, or {"index": ..., "leafHash": ...} for
+// imported trees, which hold no elements. Leaves committed with data, see SetLeafData,
+// carry it base64 encoded as "data". Lines are written through a buffer as they
+// are produced, so the dump takes no memory beyond the tree's, however large.
+// The tree is read locked throughout. Elements must be valid UTF-8, failing with
+// ErrClaimElement before anything is written otherwise.
+// HMAC keys are never written, as they are never part of a Scheme.
+func (t *MerkleTree) DumpNDJSON(w io.Writer) error {
+	if err := t.rlockBuilt(); err != nil {
+		return err
+	}
+	defer t.mu.RUnlock()
+
+	for i, element := range t.elements {
+		if !utf8.ValidString(element) {
+			return fmt.Errorf("%w: index %d", ErrClaimElement, i)
+		}
+	}
+	header, err := json.Marshal(ndjsonHeader{
+		Scheme:       t.cfg.scheme(),
+		FixedDepth:   t.cfg.fixedDepth,
+		SetSemantics: t.cfg.setSemantics,
+		Height:       t.height(),
+		Count:        t.leafCount(),
+		Root:         t.rootHash().String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	out.Write(header)
+	out.WriteByte('\n')
+	var buf []byte
+	for index := uint64(0); index < t.leafCount(); index++ {
+		buf = append(buf[:0], `{"index":`...)
+		buf = strconv.AppendUint(buf, index, 10)
+		if t.elements != nil {
+			buf = append(buf, `,"element":`...)
+			buf = appendJSONString(buf, t.elements[index])
+			if data := t.leafData(index); len(data) > 0 {
+				buf = append(buf, `,"data":"`...)
+				buf = append(buf, base64.StdEncoding.EncodeToString(data)...)
+				buf = append(buf, '"')
+			}
+		} else {
+			buf = append(buf, `,"leafHash":"`...)
+			buf = append(buf, t.node(0, index).String()...)
+			buf = append(buf, '"')
+		}
+		buf = append(buf, "}\n"...)
+		if _, err := out.Write(buf); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// Rebuilds a tree from a dump written by DumpNDJSON, reading one line at a time.
+// Elements are appended as they are read, as NewMerkleTreeFromChannel does, and leaf
+// hashes gathered and hashed once all are read, as is the data of a dump carrying any,
+// so memory is that of the tree itself and one line. The rebuilt root must match the header's, failing with ErrDumpMismatch
+// otherwise, as for a stream holding more or fewer records than the header counts.
+// A stream cut short, within a line or before the last record, fails with an error
+// wrapping io.ErrUnexpectedEOF; lines that do not decode, or out of index order, fail
+// with ErrMalformedDump.
+// The tree hashes under the header's scheme, then opts, for options a dump does not
+// carry, such as WithHMACKey or WithMetrics. Trees restored from leaf hashes hold no
+// elements, as imported trees do.
+func RestoreNDJSON(r io.Reader, opts ...Option) (*MerkleTree, error) {
+	in := bufio.NewReader(r)
+	line, err := readNDJSONLine(in)
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: no header: %w", ErrMalformedDump, io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var header ndjsonHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformedDump, err)
+	}
+	root, err := ParseHash(header.Root)
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformedDump, err)
+	}
+	if header.Count == 0 {
+		return nil, fmt.Errorf("%w: header counts no records", ErrMalformedDump)
+	}
+
+	opts = append([]Option{WithScheme(header.Scheme)}, opts...)
+	if header.FixedDepth > 0 {
+		opts = append(opts, WithFixedDepth(header.FixedDepth))
+	}
+	if header.SetSemantics {
+		opts = append(opts, WithSetSemantics())
+	}
+	cfg := newConfig(opts)
+
+	var t *MerkleTree
+	var pending []string // elements of a WithSetSemantics tree, rebuilt once all are read
+	var leaves []Hash    // leaf hashes of a tree dumped without its elements
+	var data [][]byte    // data of each leaf, committed once all are read
+	withData := false
+	var count uint64
+	for ; ; count++ {
+		line, err := readNDJSONLine(in)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if count == header.Count {
+			return nil, fmt.Errorf("%w: header counts %d records, stream holds more", ErrDumpMismatch, header.Count)
+		}
+
+		var rec ndjsonRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrMalformedDump, count, err)
+		}
+		switch {
+		case rec.Index == nil || *rec.Index != count:
+			return nil, fmt.Errorf("%w: record %d is not of index %d", ErrMalformedDump, count, count)
+		case (rec.Element == nil) == (rec.LeafHash == nil):
+			return nil, fmt.Errorf("%w: record %d needs either an element or a leaf hash", ErrMalformedDump, count)
+		case count > 0 && (rec.LeafHash != nil) != (leaves != nil):
+			return nil, fmt.Errorf("%w: record %d mixes elements and leaf hashes", ErrMalformedDump, count)
+		case rec.LeafHash != nil && rec.Data != nil:
+			return nil, fmt.Errorf("%w: record %d has data without an element", ErrMalformedDump, count)
+		}
+		data = append(data, rec.Data)
+		withData = withData || len(rec.Data) > 0
+
+		switch {
+		case rec.LeafHash != nil:
+			leaf, err := ParseHash(*rec.LeafHash)
+			if err != nil {
+				return nil, fmt.Errorf("%w: record %d: %v", ErrMalformedDump, count, err)
+			}
+			leaves = append(leaves, leaf)
+		case cfg.setSemantics:
+			pending = append(pending, *rec.Element)
+		case t == nil:
+			if t, err = NewMerkleTree([]string{*rec.Element}, opts...); err != nil {
+				return nil, err
+			}
+		default:
+			if err := t.append(*rec.Element); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if count < header.Count {
+		return nil, fmt.Errorf("%w: header counts %d records, stream ended after %d: %w", ErrDumpMismatch, header.Count, count, io.ErrUnexpectedEOF)
+	}
+
+	switch {
+	case leaves != nil:
+		t = &MerkleTree{cfg: cfg}
+		height, err := cfg.checkedHeight(count)
+		if err != nil {
+			return nil, err
+		}
+		t.hashLevels(height, leaves)
+	case cfg.setSemantics && withData:
+		return nil, ErrSetOrder
+	case cfg.setSemantics:
+		if t, err = NewMerkleTree(pending, opts...); err != nil {
+			return nil, err
+		}
+	case withData:
+		if err := t.buildWithData(slices.Clone(t.elements), data); err != nil {
+			return nil, err
+		}
+	}
+	t.recomputeDirty()
+	if header.Height > t.height() {
+		t.grow(header.Height, t.reserved())
+	}
+	t.epoch = 0
+	if t.height() != header.Height || t.rootHash() != root {
+		return nil, fmt.Errorf("%w: rebuilt root %s at height %d, header has %s at height %d", ErrDumpMismatch, t.rootHash(), t.height(), root, header.Height)
+	}
+	return t, nil
+}
+
+// Reads one line of a dump without its newline, failing with io.EOF at the end of the
+// stream and with ErrMalformedDump wrapping io.ErrUnexpectedEOF for a line cut short.
+func readNDJSONLine(in *bufio.Reader) ([]byte, error) {
+	line, err := in.ReadBytes('\n')
+	switch {
+	case err == io.EOF && len(line) == 0:
+		return nil, io.EOF
+	case err == io.EOF:
+		return nil, fmt.Errorf("%w: last line cut short: %w", ErrMalformedDump, io.ErrUnexpectedEOF)
+	case err != nil:
+		return nil, err
+	}
+	return line[:len(line)-1], nil
+}
@@ -0,0 +1,134 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONRoundTrip(t *testing.T) {
+	options := map[string][]Option{
+		"default":       nil,
+		"keccak":        {WithKeccak256(), WithApplicationTag("airdrop")},
+		"bitcoin":       {WithMode(ModeBitcoin)},
+		"fixed depth":   {WithFixedDepth(10)},
+		"set semantics": {WithSetSemantics()},
+	}
+	for name, opts := range options {
+		t.Run(name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(37), opts...)
+			var buf bytes.Buffer
+			if err := mt.DumpNDJSON(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if lines := strings.Count(buf.String(), "\n"); lines != 38 {
+				t.Errorf("got %d lines, want a header and 37 records", lines)
+			}
+
+			restored, err := RestoreNDJSON(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored.GetRoot() != mt.GetRoot() || restored.Height() != mt.Height() {
+				t.Errorf("got root %s at height %d, want %s at height %d", restored.GetRoot(), restored.Height(), mt.GetRoot(), mt.Height())
+			}
+			if !reflect.DeepEqual(restored.Scheme(), mt.Scheme()) {
+				t.Errorf("got scheme %+v, want %+v", restored.Scheme(), mt.Scheme())
+			}
+			if err := restored.Append("more"); err != nil {
+				t.Errorf("got %v appending to the restored tree", err)
+			}
+		})
+	}
+}
+
+func TestNDJSONExtendedAndImported(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	mt.ExtendCapacity(64)
+	var buf bytes.Buffer
+	mt.DumpNDJSON(&buf)
+	restored, err := RestoreNDJSON(&buf)
+	if err != nil || restored.GetRoot() != mt.GetRoot() {
+		t.Errorf("got %v, extended tree not restored at its height", err)
+	}
+
+	// trees without elements dump their leaf hashes, and restore without elements too
+	imported, _ := ImportNodes(mt.ExportNodes())
+	buf.Reset()
+	if err := imported.DumpNDJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"leafHash"`) {
+		t.Fatalf("got %q, want leaf hash records", buf.String())
+	}
+	restored, err = RestoreNDJSON(&buf)
+	if err != nil || restored.GetRoot() != mt.GetRoot() {
+		t.Fatalf("got %v, imported tree not restored", err)
+	}
+	proof, _ := restored.GetProof(3)
+	if !VerifyProof(mt.GetRoot(), proof) {
+		t.Errorf("proof of the restored tree rejected")
+	}
+	if err := restored.Append("more"); !errors.Is(err, ErrElementsUnknown) {
+		t.Errorf("got %v, want %v", err, ErrElementsUnknown)
+	}
+}
+
+func TestNDJSONTruncated(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(20))
+	var buf bytes.Buffer
+	mt.DumpNDJSON(&buf)
+	dump := buf.String()
+
+	cases := map[string]string{
+		"empty":           "",
+		"within header":   dump[:10],
+		"within record":   dump[:len(dump)-5],
+		"between records": dump[:strings.LastIndex(dump[:len(dump)-1], "\n")+1],
+	}
+	for name, cut := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := RestoreNDJSON(strings.NewReader(cut)); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("got %v, want %v", err, io.ErrUnexpectedEOF)
+			}
+		})
+	}
+}
+
+func TestNDJSONMismatch(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(20))
+	var buf bytes.Buffer
+	mt.DumpNDJSON(&buf)
+	lines := strings.SplitAfter(buf.String(), "\n")
+	header, records := lines[0], lines[1:len(lines)-1]
+
+	cases := []struct {
+		name string
+		dump string
+		want error
+	}{
+		{"fewer records than counted", strings.Replace(header, `"count":20`, `"count":21`, 1) + strings.Join(records, ""), ErrDumpMismatch},
+		{"more records than counted", strings.Replace(header, `"count":20`, `"count":19`, 1) + strings.Join(records, ""), ErrDumpMismatch},
+		{"changed element", header + strings.Join(records[:19], "") + `{"index":19,"element":"other"}` + "\n", ErrDumpMismatch},
+		{"other root", strings.Replace(header, mt.GetRoot(), strings.Repeat("0", 64), 1) + strings.Join(records, ""), ErrDumpMismatch},
+		{"records out of order", header + records[1] + records[0] + strings.Join(records[2:], ""), ErrMalformedDump},
+		{"mixed records", header + `{"index":0,"leafHash":"` + strings.Repeat("0", 64) + `"}` + "\n" + strings.Join(records[1:], ""), ErrMalformedDump},
+		{"neither element nor leaf hash", header + `{"index":0}` + "\n" + strings.Join(records[1:], ""), ErrMalformedDump},
+		{"bad header", "{}\n" + strings.Join(records, ""), ErrMalformedDump},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := RestoreNDJSON(strings.NewReader(c.dump)); !errors.Is(err, c.want) {
+				t.Errorf("got %v, want %v", err, c.want)
+			}
+		})
+	}
+
+	invalid, _ := NewMerkleTree([]string{"a", "\xff"})
+	if err := invalid.DumpNDJSON(io.Discard); !errors.Is(err, ErrClaimElement) {
+		t.Errorf("got %v, want %v", err, ErrClaimElement)
+	}
+}
@@ -0,0 +1,122 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrElementsUnknown = errors.New("merkletree: tree was imported without its elements")
+	ErrMalformedNodes  = errors.New("merkletree: malformed node data")
+)
+
+// Describes the node array returned by ExportNodes.
+// Level l occupies digests LevelOffsets[l] up to LevelOffsets[l+1], holding the
+// ceil(LeafCount / 2^l) nodes covering elements, starting with the leftmost.
+// The last offset is the total number of digests; the root is the final digest.
+type NodeLayout struct {
+	DigestSize   int      `json:"digestSize"`
+	LeafCount    uint64   `json:"leafCount"`
+	LevelOffsets []uint64 `json:"levelOffsets"`
+	Tag          string   `json:"tag,omitempty"`  // application tag the nodes were hashed under
+	Mode         Mode     `json:"mode,omitempty"` // preset the nodes were hashed under, see WithMode
+
+	Algorithm AlgorithmID `json:"algorithm,omitempty"` // algorithm the nodes were hashed with
+	Bloom     []byte      `json:"bloom,omitempty"`     // filter of WithBloomFilter over the leaves, nil without one
+}
+
+// Returns the stored node digests, concatenated in storage order, and their layout.
+// Padding nodes are not stored; they follow from the empty leaf the tree was built with.
+func (t *MerkleTree) ExportNodes() (NodeLayout, []byte) {
+	if t.rlockBuilt() != nil {
+		return NodeLayout{}, nil
+	}
+	defer t.mu.RUnlock()
+
+	// room reserved for appends is left out, so the export is laid out for the leaf count
+	layout := NodeLayout{
+		DigestSize:   digestSize,
+		LeafCount:    t.leafCount(),
+		LevelOffsets: levelOffsets(t.leafCount(), t.height(), nil),
+		Tag:          t.cfg.tag,
+		Mode:         t.cfg.mode,
+		Algorithm:    t.cfg.algorithm(),
+		Bloom:        t.bloomBytes(),
+	}
+
+	data := make([]byte, 0, layout.LevelOffsets[t.height()+1]*digestSize)
+	for level := 0; level <= t.height(); level++ {
+		for _, node := range t.level(level) {
+			data = append(data, node[:]...)
+		}
+	}
+
+	return layout, data
+}
+
+// Creates a tree from nodes exported by ExportNodes, given the options it was built with.
+// The root is checked against a recomputation from the level below it; lower levels
+// are taken as given. The elements are not part of the export, so proofs and roots
+// are available but element lookups find nothing and UpdateElement fails with
+// ErrElementsUnknown. Reset replaces the nodes with a full tree as usual.
+// Nodes hashed with another algorithm than the options' fail with ErrAlgorithmMismatch,
+// unless WithAlgorithmAutoDetect is given, and unregistered ones with ErrUnknownAlgorithm.
+// A bloom filter in the layout is restored as exported; without one, WithBloomFilter
+// fills a new filter from the leaves.
+func ImportNodes(layout NodeLayout, data []byte, opts ...Option) (*MerkleTree, error) {
+	if layout.DigestSize != digestSize {
+		return nil, fmt.Errorf("%w: digest size %d, want %d", ErrMalformedNodes, layout.DigestSize, digestSize)
+	}
+
+	t := &MerkleTree{cfg: newConfig(opts)}
+	if err := t.cfg.checkMode(); err != nil {
+		return nil, err
+	}
+	if layout.Tag != t.cfg.tag {
+		return nil, fmt.Errorf("%w: nodes tagged %q, options tag %q", ErrDomainMismatch, layout.Tag, t.cfg.tag)
+	}
+	if layout.Mode != t.cfg.mode {
+		return nil, fmt.Errorf("%w: nodes hashed in mode %v, options mode %v", ErrModeConflict, layout.Mode, t.cfg.mode)
+	}
+	cfg, err := t.cfg.forAlgorithm(layout.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	t.cfg = cfg
+
+	height := len(layout.LevelOffsets) - 2
+	if height < 0 || layout.LeafCount == 0 {
+		return nil, fmt.Errorf("%w: no levels or no leaves", ErrMalformedNodes)
+	}
+	if minimum := treeHeight(layout.LeafCount); height < minimum || height > 63 {
+		return nil, fmt.Errorf("%w: %d leaves need depth %d to 63, layout has %d", ErrMalformedNodes, layout.LeafCount, minimum, height)
+	}
+
+	t.count = layout.LeafCount
+	t.offsets = levelOffsets(layout.LeafCount, height, nil)
+	for level, offset := range t.offsets {
+		if layout.LevelOffsets[level] != offset {
+			return nil, fmt.Errorf("%w: level %d starts at %d, want %d", ErrMalformedNodes, level, layout.LevelOffsets[level], offset)
+		}
+	}
+	// compared by node count, as the byte count a crafted layout implies may overflow
+	if nodes := uint64(len(data)) / digestSize; nodes != t.offsets[height+1] || len(data)%digestSize != 0 {
+		return nil, fmt.Errorf("%w: %d bytes, want %d nodes of %d", ErrMalformedNodes, len(data), t.offsets[height+1], digestSize)
+	}
+
+	t.nodes = make([]Hash, t.offsets[height+1])
+	for i := range t.nodes {
+		copy(t.nodes[i][:], data[i*digestSize:])
+	}
+
+	if height > 0 {
+		if want := t.cfg.nodeDigest(t.node(height-1, 0), t.node(height-1, 1)); t.rootHash() != want {
+			return nil, fmt.Errorf("%w: root %s, level below hashes to %s", ErrMalformedNodes, t.rootHash(), want)
+		}
+	}
+	if err := t.importBloom(layout.Bloom); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
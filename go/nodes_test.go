@@ -0,0 +1,123 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExportImportNodes(t *testing.T) {
+	optionSets := map[string][]Option{
+		"default":     nil,
+		"empty leaf":  {WithEmptyLeaf([]byte("nothing"))},
+		"fixed depth": {WithFixedDepth(10)},
+	}
+
+	for name, opts := range optionSets {
+		for _, size := range []int{1, 2, 5, 8, 13} {
+			testname := fmt.Sprintf("%s with %d elements", name, size)
+			t.Run(testname, func(t *testing.T) {
+				mt, _ := NewMerkleTree(testElements(size), opts...)
+				layout, data := mt.ExportNodes()
+
+				if layout.DigestSize != digestSize || layout.LeafCount != uint64(size) {
+					t.Errorf("got %+v, want digest size %d and %d leaves", layout, digestSize, size)
+				}
+				if want := int(layout.LevelOffsets[len(layout.LevelOffsets)-1]) * digestSize; len(data) != want {
+					t.Errorf("got %d bytes, want %d", len(data), want)
+				}
+
+				imported, err := ImportNodes(layout, data, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if imported.GetRoot() != mt.GetRoot() || imported.Stats() != mt.Stats() {
+					t.Errorf("got root %s, want %s", imported.GetRoot(), mt.GetRoot())
+				}
+				for i := uint64(0); i < uint64(size); i++ {
+					got, _ := imported.GetProof(i)
+					want, _ := mt.GetProof(i)
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("got %+v, want %+v", got, want)
+					}
+				}
+
+				// the export is a copy
+				data[0] ^= 1
+				if imported.node(0, 0) != mt.node(0, 0) {
+					t.Error("imported tree shares the exported data")
+				}
+			})
+		}
+	}
+}
+
+func TestImportNodesWithoutElements(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	imported, _ := ImportNodes(mt.ExportNodes())
+
+	if _, ok := imported.IndexOf("element-0"); ok {
+		t.Error("found an element in an imported tree")
+	}
+	if err := imported.UpdateElement(0, "updated"); !errors.Is(err, ErrElementsUnknown) {
+		t.Errorf("got %v, want %v", err, ErrElementsUnknown)
+	}
+
+	if err := imported.Reset(testElements(3)); err != nil {
+		t.Fatal(err)
+	}
+	fresh, _ := NewMerkleTree(testElements(3))
+	if imported.GetRoot() != fresh.GetRoot() {
+		t.Errorf("got %s, want %s", imported.GetRoot(), fresh.GetRoot())
+	}
+}
+
+func TestImportNodesRejectsCorruption(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	layout, data := mt.ExportNodes()
+	rootAt := len(data) - digestSize
+	belowRoot := rootAt - digestSize
+
+	corrupt := func(at int) []byte {
+		changed := append([]byte(nil), data...)
+		changed[at] ^= 0xff
+		return changed
+	}
+	withOffsets := func(offsets ...uint64) NodeLayout {
+		changed := layout
+		changed.LevelOffsets = offsets
+		return changed
+	}
+
+	// nodes whose byte count wraps around to 1888, as a size check multiplying would accept
+	const huge = 288230376151711745
+	overflowing := NodeLayout{DigestSize: digestSize, LeafCount: huge, LevelOffsets: levelOffsets(huge, treeHeight(huge), nil)}
+
+	cases := map[string]struct {
+		layout NodeLayout
+		data   []byte
+	}{
+		"root":           {layout, corrupt(rootAt)},
+		"level below":    {layout, corrupt(belowRoot)},
+		"truncated":      {layout, data[:len(data)-1]},
+		"digest size":    {NodeLayout{DigestSize: 20, LeafCount: 5, LevelOffsets: layout.LevelOffsets}, data},
+		"leaf count":     {NodeLayout{DigestSize: digestSize, LeafCount: 6, LevelOffsets: layout.LevelOffsets}, data},
+		"no leaves":      {NodeLayout{DigestSize: digestSize, LevelOffsets: []uint64{0, 0}}, nil},
+		"offsets":        {withOffsets(0, 5, 8, 9, 10), data},
+		"too few levels": {withOffsets(0, 5, 8), data[:8*digestSize]},
+		"overflowing":    {overflowing, make([]byte, 1888)},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ImportNodes(c.layout, c.data); !errors.Is(err, ErrMalformedNodes) {
+				t.Errorf("got %v, want %v", err, ErrMalformedNodes)
+			}
+		})
+	}
+
+	// the spot check only covers the top level
+	if _, err := ImportNodes(layout, corrupt(0)); err != nil {
+		t.Errorf("got %v, want nil for a corrupted leaf", err)
+	}
+}
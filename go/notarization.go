@@ -0,0 +1,50 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+)
+
+// Layout of a notarization leaf, NotarizationLeafSize bytes in total:
+//
+//	timestamp (8 bytes, big-endian signed nanoseconds since the Unix epoch) | sha256(payload) (32 bytes)
+const (
+	notarizationTimestampSize = 8
+	notarizationDigestSize    = sha256.Size
+	NotarizationLeafSize      = notarizationTimestampSize + notarizationDigestSize
+)
+
+// Returns the canonical leaf recording that payload existed at time ts.
+// The payload itself is not part of the leaf, only its sha256 digest. The timestamp is
+// taken at nanosecond precision, so it must lie between the years 1678 and 2262.
+func NotarizationLeaf(ts time.Time, payload []byte) string {
+	out := make([]byte, 0, NotarizationLeafSize)
+	out = binary.BigEndian.AppendUint64(out, uint64(ts.UnixNano()))
+	digest := sha256.Sum256(payload)
+	out = append(out, digest[:]...)
+	return string(out)
+}
+
+// Appends NotarizationLeaf(ts, payload) to the tree, returning its index.
+func (t *MerkleTree) AppendNotarized(ts time.Time, payload []byte) (uint64, error) {
+	var index uint64
+	err := t.mutate(func(rec *journalRecord) error {
+		index = t.count
+		element := NotarizationLeaf(ts, payload)
+		if rec != nil {
+			*rec = journalRecord{op: journalAppend, values: []string{element}}
+		}
+		return t.append(element)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// Verifies that the proof commits to payload at time ts under root.
+func VerifyNotarization(root string, ts time.Time, payload []byte, proof MerkleProof, opts ...Option) bool {
+	element, err := canonicalDigest(proof.hElement)
+	return err == nil && element == newConfig(opts).hashLeaf(NotarizationLeaf(ts, payload)) && VerifyProof(root, proof, opts...)
+}
@@ -0,0 +1,84 @@
+package merkletree
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// Computed independently of this package, for verifiers in other languages to match.
+var notarizationVectors = []struct {
+	ts       time.Time
+	payload  []byte
+	leaf     string // hex of the leaf bytes
+	leafHash string
+}{
+	{
+		time.Unix(0, 0),
+		[]byte{},
+		"0000000000000000e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"9a0be4ec109b7ca51504ebd60835e9599f33a732c47c5450301784f5c28edd63",
+	},
+	{
+		time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		[]byte("hello"),
+		"17a668b7300132062cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		"58b4235870d03ded7c71f844b4a8d2dc7628a2a6abe3a4587c15d120e192ef67",
+	},
+	{
+		time.Unix(-1, 0),
+		[]byte{0x00, 0xff},
+		"ffffffffc465360006eb7d6a69ee19e5fbdf749018d3d2abfa04bcbd1365db312eb86dc7169389b8",
+		"b50390e28eeb79920e7949f5072b8b7c2e29b5bd294674d7fbcfc37a87a85b46",
+	},
+}
+
+const notarizationVectorRoot = "0d68269de442f94400f90cff3d388fb01587a65e842be2f14c47041d109cbdcc"
+
+func TestNotarizationLeafVectors(t *testing.T) {
+	for _, v := range notarizationVectors {
+		leaf := NotarizationLeaf(v.ts, v.payload)
+		if len(leaf) != NotarizationLeafSize {
+			t.Errorf("got %d bytes, want %d", len(leaf), NotarizationLeafSize)
+		}
+		if got := hex.EncodeToString([]byte(leaf)); got != v.leaf {
+			t.Errorf("got %s, want %s", got, v.leaf)
+		}
+		if got := hashLeaf(leaf); got != v.leafHash {
+			t.Errorf("got %s, want %s", got, v.leafHash)
+		}
+	}
+
+	// the instant, not the location, determines the leaf
+	local := notarizationVectors[1].ts.In(time.FixedZone("UTC+5", 5*60*60))
+	if NotarizationLeaf(local, []byte("hello")) != NotarizationLeaf(notarizationVectors[1].ts, []byte("hello")) {
+		t.Error("time zone changed the leaf")
+	}
+}
+
+func TestAppendNotarized(t *testing.T) {
+	mt, _ := NewMerkleTree([]string{NotarizationLeaf(notarizationVectors[0].ts, notarizationVectors[0].payload)})
+
+	for i, v := range notarizationVectors[1:] {
+		index, err := mt.AppendNotarized(v.ts, v.payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index != uint64(i+1) {
+			t.Errorf("got index %d, want %d", index, i+1)
+		}
+	}
+	if mt.GetRoot() != notarizationVectorRoot {
+		t.Errorf("got %s, want %s", mt.GetRoot(), notarizationVectorRoot)
+	}
+
+	for i, v := range notarizationVectors {
+		proof, _ := mt.GetProof(uint64(i))
+		if !VerifyNotarization(mt.GetRoot(), v.ts, v.payload, proof) {
+			t.Errorf("invalid notarization %d", i)
+		}
+		if VerifyNotarization(mt.GetRoot(), v.ts.Add(time.Nanosecond), v.payload, proof) {
+			t.Errorf("notarization %d verified at another time", i)
+		}
+	}
+}
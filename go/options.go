@@ -0,0 +1,110 @@
+package merkletree
+
+import (
+	"io"
+	"log/slog"
+)
+
+// Configures optional behaviour of a tree or of the package-level verifiers.
+type Option func(*config)
+
+type config struct {
+	metrics          MetricsSink      // receives hash and proof counts; nil when not instrumented
+	rejectDuplicates bool             // fail rather than commit to an element at two indices
+	fixedDepth       int              // height of the tree regardless of element count; 0 for the minimum height
+	leafCacheSize    int              // most leaf hashes memoized while building; 0 for no cache
+	logger           *slog.Logger     // receives debug records of builds, proofs and failed verifications; nil for none
+	logLeafValues    bool             // include element contents in debug records
+	lazyRecompute    bool             // defer recomputing ancestors of updated leaves until the next read
+	setSemantics     bool             // sort leaves by hash and drop duplicates, see set.go
+	mode             Mode             // preset set by WithMode, when presetMode is set
+	presetMode       bool             // WithMode was given, so hashing must stay as the preset sets it
+	subscriberErrors func(error)      // receives panics of root subscribers; nil to log them
+	journal          io.Writer        // receives a record of every mutation, see journal.go
+	parallelism      int              // goroutines hashing a build at once, see parallel.go; 1 or less for none
+	paddedSlots      PaddedSlotPolicy // whether UpdateElement may write the first padded slot
+	hmacKey          string           // secret keying every hash, see hmac.go; never part of a Scheme
+	keyed            bool             // WithHMACKey was given, so hmacKey must not be empty
+	bloomBitsPerLeaf float64          // size of the membership filter, see bloom.go; 0 for none
+	proofCacheSize   int              // most proofs cached between mutations, see proofcache.go; 0 for no cache
+	tracer           Tracer           // starts spans around builds, proofs and verifications, see trace.go; nil for none
+
+	detectAlgorithm bool // verify proofs naming another algorithm under it, see algorithm.go
+	hashing
+}
+
+// The options deciding which digests a tree computes, as a Scheme describes them.
+type hashing struct {
+	tag               string // application tag prefixed to every hash, see domain.go
+	tagDigest         Hash   // fixed-length encoding of tag, zero without one
+	emptyLeaf         string // element assumed for every padding slot
+	rfc6962           bool   // hash leaves and nodes as RFC 6962 does, see rfc6962.go
+	rawNodeHashing    bool   // hash nodes over the raw bytes of their children rather than their hex
+	sortedPairs       bool   // order children by value before hashing them, ignoring proof directions
+	doubleHashLeaves  bool   // hash every leaf digest once more
+	doubleHashNodes   bool   // hash every node digest once more, only set by ModeBitcoin
+	duplicateOddNodes bool   // pair a node without a sibling with itself rather than padding, only set by ModeBitcoin
+	chunkLeaves       bool   // take elements as their own leaf digests, only set by ModeSSZ
+
+	leafHash HashFunction // hash function of leaves and padding, see hasher.go
+	nodeHash HashFunction // hash function of interior nodes
+	backend  hashBackend  // computes every digest in place of the options above, as WithPoseidon sets; nil for none
+}
+
+func newConfig(opts []Option) config {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// Reports hashing and proof activity to the given sink.
+// Without this option no instrumentation calls are made at all.
+func WithMetrics(m MetricsSink) Option {
+	return func(cfg *config) {
+		cfg.metrics = m
+	}
+}
+
+// Sets the element assumed for every padding slot, which defaults to the empty string.
+// Verifiers of padding-aware proofs need the same option.
+func WithEmptyLeaf(value []byte) Option {
+	return func(cfg *config) {
+		cfg.emptyLeaf = string(value)
+	}
+}
+
+// Fixes the height of the tree rather than using the minimum needed for its elements.
+// Padding subtrees are never materialised, so deep trees over few elements stay cheap.
+// Depths above 63 are not supported.
+func WithFixedDepth(depth int) Option {
+	return func(cfg *config) {
+		cfg.fixedDepth = depth
+	}
+}
+
+// Hashes a node over the raw bytes of its children, sha256(left | right), rather than over
+// their hex encodings, as merkletreejs and most other libraries do.
+func WithRawNodeHashing() Option {
+	return func(cfg *config) {
+		cfg.rawNodeHashing = true
+	}
+}
+
+// Orders the children of every node by byte value before hashing them, so a proof verifies
+// whatever its directions say. Libraries such as merkletreejs with sortPairs do this to
+// let verifiers skip the directions altogether.
+func WithSortedPairs() Option {
+	return func(cfg *config) {
+		cfg.sortedPairs = true
+	}
+}
+
+// Hashes every leaf digest once more, so a leaf is H(H(element)), as OpenZeppelin's
+// StandardMerkleTree does to keep leaves apart from the 64-byte inputs of nodes.
+func WithDoubleHashedLeaves() Option {
+	return func(cfg *config) {
+		cfg.doubleHashLeaves = true
+	}
+}
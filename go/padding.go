@@ -0,0 +1,171 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrSlotOccupied = errors.New("merkletree: slot holds a committed element")
+
+// Decides whether UpdateElement may write the padded slot just past the elements.
+type PaddedSlotPolicy uint8
+
+const (
+	PaddedSlotReject PaddedSlotPolicy = iota // only slots holding elements can be updated, the default
+	PaddedSlotAllow                          // the slot at LeafCount() can be updated, appending to it
+)
+
+// Sets whether UpdateElement may activate the first padded slot. Under PaddedSlotAllow
+// an update at index LeafCount() commits the element there as Append would, without
+// growing the tree, so IsPadded turns false for it and LeafCount grows by one. Other
+// padded slots, which would leave a gap, still fail with ErrIndexOutOfBounds, as does
+// the slot past a full tree. Trees with WithSetSemantics fail with ErrSetOrder instead.
+func WithPaddedSlotPolicy(policy PaddedSlotPolicy) Option {
+	return func(cfg *config) {
+		cfg.paddedSlots = policy
+	}
+}
+
+// Returns the hash of a fully padded subtree at each level under the given options,
+// from a single padding leaf (0) up to a subtree of the given depth:
+// zero[0] = hashLeaf(empty leaf), zero[i] = hashNode(zero[i-1], zero[i-1]).
+func ZeroHashes(depth int, opts ...Option) ([]string, error) {
+	if depth < 0 || depth > maxProofDepth {
+		return nil, fmt.Errorf("%w: depth %d, want 0 to %d", ErrIndexOutOfBounds, depth, maxProofDepth)
+	}
+	return newConfig(opts).paddingHashes(depth), nil
+}
+
+func (cfg config) zeroHashes(height int) []Hash {
+	ladder := make([]Hash, height+1)
+	ladder[0] = cfg.leafDigest(cfg.emptyLeaf)
+	for i := 1; i <= height; i++ {
+		ladder[i] = cfg.nodeDigest(ladder[i-1], ladder[i-1])
+	}
+	return ladder
+}
+
+// Returns the sibling of a node with nothing committed to its right: the padding
+// subtree of its level, or the node itself under ModeBitcoin.
+func (cfg config) edgeSibling(node Hash, padding Hash) Hash {
+	if cfg.duplicateOddNodes {
+		return node
+	}
+	return padding
+}
+
+// Returns the padding ladder under the configured options, hex encoded.
+func (cfg config) paddingHashes(height int) []string {
+	ladder := make([]string, height+1)
+	for i, zero := range cfg.zeroHashes(height) {
+		ladder[i] = zero.String()
+	}
+	return ladder
+}
+
+// Returns the height of a tree over leafCount elements under the configured options.
+func (cfg config) height(leafCount uint64) int {
+	if cfg.fixedDepth > 0 {
+		return cfg.fixedDepth
+	}
+	return treeHeight(leafCount)
+}
+
+// Returns the height of a tree over leafCount elements, failing when there are no
+// elements or when a fixed depth cannot hold them.
+// It also fails with the checkMode error, as every construction passes through here.
+func (cfg config) checkedHeight(leafCount uint64) (int, error) {
+	if err := cfg.checkMode(); err != nil {
+		return 0, err
+	}
+	if leafCount == 0 {
+		return 0, ErrEmptyTree
+	}
+	height := cfg.height(leafCount)
+	if minimum := treeHeight(leafCount); height < minimum || height > 63 {
+		return 0, fmt.Errorf("%w: %d elements need depth %d to 63, fixed at %d", ErrCapacityExceeded, leafCount, minimum, height)
+	}
+	return height, nil
+}
+
+// Reports whether the proof's directions are exactly those of the leaf at index
+// in a tree of the given height.
+func directionsMatchIndex(directions []bool, index uint64, height int) bool {
+	if len(directions) != height || (height < 64 && index>>height != 0) {
+		return false
+	}
+	for level, siblingIsLeft := range directions {
+		if siblingIsLeft != (index>>level&1 == 1) {
+			return false
+		}
+	}
+	return true
+}
+
+// Generates a proof that the padded slot at index holds the padding value, i.e. that
+// nothing was committed there. Only indices in [LeafCount(), PaddedLeafCount()) are accepted.
+// Trees in ModeBitcoin have no padding slots and fail with ErrNoPadding.
+func (t *MerkleTree) ProveEmptySlot(index uint64) (MerkleProof, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return MerkleProof{}, err
+	}
+	defer t.mu.RUnlock()
+
+	if t.cfg.duplicateOddNodes {
+		return MerkleProof{}, ErrNoPadding
+	}
+	if index < t.leafCount() {
+		return MerkleProof{}, fmt.Errorf("%w: index %d, element count %d", ErrSlotOccupied, index, t.leafCount())
+	}
+	if index >= t.paddedLeafCount() {
+		return MerkleProof{}, fmt.Errorf("%w: index %d, padded leaf count %d", ErrIndexOutOfBounds, index, t.paddedLeafCount())
+	}
+
+	return t.proofAt(index), nil
+}
+
+// Reports whether the slot at index is padding, lying beyond the committed elements,
+// rather than holding one. Appending an element turns the first padded slot into a
+// committed one. Under ModeBitcoin the slots beyond the elements are reported as
+// padded, though they repeat the last element rather than hold the padding value.
+// Fails with ErrIndexOutOfBounds from PaddedLeafCount() on.
+func (t *MerkleTree) IsPadded(index uint64) (bool, error) {
+	if err := t.rlockBuiltAsIs(); err != nil {
+		return false, err
+	}
+	defer t.mu.RUnlock()
+
+	if index >= t.paddedLeafCount() {
+		return false, fmt.Errorf("%w: index %d, padded leaf count %d", ErrIndexOutOfBounds, index, t.paddedLeafCount())
+	}
+	return index >= t.leafCount(), nil
+}
+
+// Returns a copy of the committed elements in index order, without the padding slots.
+// Trees restored with ImportNodes hold no elements and return nil.
+func (t *MerkleTree) OriginalElements() []string {
+	if t.rlockBuiltAsIs() != nil {
+		return nil
+	}
+	defer t.mu.RUnlock()
+
+	if t.elements == nil {
+		return nil
+	}
+	return append([]string(nil), t.elements...)
+}
+
+// Verifies that the proof shows the slot at index holds the padding value under root.
+func VerifyEmptySlot(root string, index uint64, proof MerkleProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyEmptySlot(root, index, proof)
+}
+
+// Verifies that the proof shows the slot at index holds the padding value under root,
+// as VerifyEmptySlot does.
+func (v *Verifier) VerifyEmptySlot(root string, index uint64, proof MerkleProof) bool {
+	cfg := v.cfg
+	if cfg.duplicateOddNodes || proof.hElement != cfg.hashLeaf(cfg.emptyLeaf) || !directionsMatchIndex(proof.directions, index, len(proof.directions)) {
+		return false
+	}
+	return v.VerifyProof(root, proof)
+}
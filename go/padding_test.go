@@ -0,0 +1,297 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestPaddingHashes(t *testing.T) {
+	ladder := config{}.paddingHashes(3)
+	mt, _ := NewMerkleTree([]string{""})
+	padded, _ := NewMerkleTree(make([]string, 8))
+
+	if ladder[0] != mt.GetRoot() {
+		t.Errorf("got %s, want %s", ladder[0], mt.GetRoot())
+	}
+	if ladder[3] != padded.GetRoot() {
+		t.Errorf("got %s, want %s", ladder[3], padded.GetRoot())
+	}
+}
+
+func TestProveEmptySlot(t *testing.T) {
+	mt, err := NewMerkleTree(testElements(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for index := uint64(5); index < 8; index++ {
+		testname := fmt.Sprintf("proves slot %d is empty", index)
+		t.Run(testname, func(t *testing.T) {
+			proof, err := mt.ProveEmptySlot(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyEmptySlot(mt.GetRoot(), index, proof) {
+				t.Error("invalid empty slot proof")
+			}
+			if VerifyEmptySlot(mt.GetRoot(), index^1, proof) {
+				t.Error("verified against the wrong index")
+			}
+		})
+	}
+}
+
+func TestProveEmptySlotRejectsOccupied(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+
+	for index := uint64(0); index < 5; index++ {
+		if _, err := mt.ProveEmptySlot(index); !errors.Is(err, ErrSlotOccupied) {
+			t.Errorf("index %d: got %v, want %v", index, err, ErrSlotOccupied)
+		}
+	}
+	if _, err := mt.ProveEmptySlot(8); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+
+	// an inclusion proof for a real element must not pass as an empty slot
+	proof, _ := mt.GetProof(4)
+	if VerifyEmptySlot(mt.GetRoot(), 4, proof) {
+		t.Error("verified an occupied slot as empty")
+	}
+}
+
+func TestEmptyElementIsNotPadding(t *testing.T) {
+	// an explicitly committed empty string shares the padding hash, but is still occupied
+	mt, _ := NewMerkleTree([]string{"some", ""})
+	if _, err := mt.ProveEmptySlot(1); !errors.Is(err, ErrSlotOccupied) {
+		t.Errorf("got %v, want %v", err, ErrSlotOccupied)
+	}
+}
+
+func TestZeroHashesGolden(t *testing.T) {
+	cases := map[string]struct {
+		opts []Option
+		want []string
+	}{
+		"empty string": {nil, []string{
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			"3b7546ed79e3e5a7907381b093c5a182cbf364c5dd0443dfa956c8cca271cc33",
+			"62cfa62f49fc5da600bb09626289731c6a0573c21e01e540b7e292d58e2c675e",
+		}},
+		"32 zero bytes": {[]Option{WithEmptyLeaf(make([]byte, 32))}, []string{
+			"66687aadf862bd776c8fc18b8e9f8e20089714856ee233b3902a591d0d5f2925",
+			"43a12f4c4a1649be51777572fad7b054e78cbce6fa47aa8199464a20a2d4d3e5",
+			"e7f2ea84bf5cdf8baace54642c5a7a16843f8a3b39646d47e1209b029d7a77c8",
+		}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ZeroHashes(2, c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+
+	if _, err := ZeroHashes(-1); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+}
+
+func TestEmptyLeafOption(t *testing.T) {
+	empty := WithEmptyLeaf([]byte("nothing"))
+
+	padded, _ := NewMerkleTree(testElements(3), empty)
+	expected := hashNode(
+		hashNode(hashLeaf("element-0"), hashLeaf("element-1")),
+		hashNode(hashLeaf("element-2"), hashLeaf("nothing")),
+	)
+	if padded.GetRoot() != expected {
+		t.Errorf("got %s, want %s", padded.GetRoot(), expected)
+	}
+
+	full, _ := NewMerkleTree(testElements(4), empty)
+	reference, _ := NewMerkleTree(testElements(4))
+	if full.GetRoot() != reference.GetRoot() {
+		t.Error("empty leaf changed the root of a tree without padding")
+	}
+
+	proof, _ := padded.ProveEmptySlot(3)
+	if !VerifyEmptySlot(padded.GetRoot(), 3, proof, empty) {
+		t.Error("invalid empty slot proof")
+	}
+	if VerifyEmptySlot(padded.GetRoot(), 3, proof) {
+		t.Error("verified with a different empty leaf")
+	}
+}
+
+func TestFixedDepth(t *testing.T) {
+	var m CountingMetrics
+	elements := testElements(5)
+	mt, err := NewMerkleTree(elements, WithFixedDepth(32), WithMetrics(&m))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mt.Height() != 32 || mt.PaddedLeafCount() != 1<<32 {
+		t.Errorf("got height %d with %d slots, want 32 and %d", mt.Height(), mt.PaddedLeafCount(), uint64(1)<<32)
+	}
+	if m.Hashes() > 160 {
+		t.Errorf("got %d hashes, want at most 160", m.Hashes())
+	}
+
+	// above the minimum height every level folds in the padding ladder
+	minimal, _ := NewMerkleTree(elements)
+	zero := config{}.paddingHashes(32)
+	want := minimal.GetRoot()
+	for level := minimal.Height(); level < 32; level++ {
+		want = hashNode(want, zero[level])
+	}
+	if mt.GetRoot() != want {
+		t.Errorf("got %s, want %s", mt.GetRoot(), want)
+	}
+
+	computed, err := ComputeRoot(elements, WithFixedDepth(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if computed != want {
+		t.Errorf("got %s, want %s", computed, want)
+	}
+
+	for i := uint64(0); i < 5; i++ {
+		proof, _ := mt.GetProof(i)
+		if len(proof.siblings) != 32 || !VerifyProof(mt.GetRoot(), proof) {
+			t.Errorf("invalid proof for element %d", i)
+		}
+	}
+
+	if err := mt.UpdateElement(4, "updated"); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := NewMerkleTree([]string{"element-0", "element-1", "element-2", "element-3", "updated"}, WithFixedDepth(32))
+	if mt.GetRoot() != updated.GetRoot() {
+		t.Errorf("got %s, want %s", mt.GetRoot(), updated.GetRoot())
+	}
+
+	proof, err := mt.ProveEmptySlot(1<<32 - 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyEmptySlot(mt.GetRoot(), 1<<32-1, proof) {
+		t.Error("invalid empty slot proof")
+	}
+}
+
+func TestFixedDepthTooSmall(t *testing.T) {
+	if _, err := NewMerkleTree(testElements(5), WithFixedDepth(2)); !errors.Is(err, ErrCapacityExceeded) {
+		t.Errorf("got %v, want %v", err, ErrCapacityExceeded)
+	}
+	if _, err := NewMerkleTree(testElements(5), WithFixedDepth(64)); !errors.Is(err, ErrCapacityExceeded) {
+		t.Errorf("got %v, want %v", err, ErrCapacityExceeded)
+	}
+	if _, err := ComputeRoot(testElements(5), WithFixedDepth(2)); !errors.Is(err, ErrCapacityExceeded) {
+		t.Errorf("got %v, want %v", err, ErrCapacityExceeded)
+	}
+}
+
+func TestIsPadded(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+
+	for index := uint64(0); index < 8; index++ {
+		got, err := mt.IsPadded(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := index >= 5; got != want {
+			t.Errorf("slot %d: got %v, want %v", index, got, want)
+		}
+	}
+	if _, err := mt.IsPadded(8); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	if got, want := mt.OriginalElements(), testElements(5); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// appending fills the first padded slot, and past a full tree opens a new level of padding
+	mt.Append("element-5")
+	if padded, _ := mt.IsPadded(5); padded {
+		t.Error("slot 5 still padded after append")
+	}
+	if padded, _ := mt.IsPadded(6); !padded {
+		t.Error("slot 6 no longer padded after one append")
+	}
+	mt.Append("element-6")
+	mt.Append("element-7")
+	if _, err := mt.IsPadded(8); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("full tree: got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	mt.Append("element-8")
+	if padded, err := mt.IsPadded(9); err != nil || !padded {
+		t.Errorf("got %v, %v after growing, want true", padded, err)
+	}
+	if got, want := mt.OriginalElements(), testElements(9); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	elements := mt.OriginalElements()
+	elements[0] = "changed"
+	if mt.OriginalElements()[0] != "element-0" {
+		t.Error("OriginalElements shares the tree's storage")
+	}
+
+	layout, data := mt.ExportNodes()
+	imported, _ := ImportNodes(layout, data)
+	if got := imported.OriginalElements(); got != nil {
+		t.Errorf("imported tree: got %v, want nil", got)
+	}
+}
+
+func TestPaddedSlotPolicy(t *testing.T) {
+	rejecting, _ := NewMerkleTree(testElements(5))
+	if err := rejecting.UpdateElement(5, "element-5"); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("default policy: got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+
+	mt, _ := NewMerkleTree(testElements(5), WithPaddedSlotPolicy(PaddedSlotAllow))
+	if err := mt.UpdateElement(6, "gap"); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("gap: got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+
+	previous, err := mt.UpdateElementSwap(5, "element-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous != "" {
+		t.Errorf("got previous element %q, want none", previous)
+	}
+	if padded, _ := mt.IsPadded(5); padded || mt.LeafCount() != 6 || mt.Height() != 3 {
+		t.Errorf("got padded %v, leaf count %d, height %d, want false, 6, 3", padded, mt.LeafCount(), mt.Height())
+	}
+	want, _ := NewMerkleTree(testElements(6))
+	if mt.GetRoot() != want.GetRoot() {
+		t.Errorf("got root %s, want %s", mt.GetRoot(), want.GetRoot())
+	}
+	proof, err := mt.GetProof(5)
+	if err != nil || !VerifyProof(mt.GetRoot(), proof) {
+		t.Errorf("activated slot: got proof error %v or invalid proof", err)
+	}
+
+	mt.UpdateElement(6, "element-6")
+	mt.UpdateElement(7, "element-7")
+	if err := mt.UpdateElement(8, "element-8"); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("full tree: got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+
+	set, _ := NewMerkleTree(testElements(5), WithSetSemantics(), WithPaddedSlotPolicy(PaddedSlotAllow))
+	if err := set.UpdateElement(5, "element-5"); !errors.Is(err, ErrSetOrder) {
+		t.Errorf("set semantics: got %v, want %v", err, ErrSetOrder)
+	}
+}
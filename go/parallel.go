@@ -0,0 +1,153 @@
+package merkletree
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Most leaves hashed and reduced as one unit of a pipelined build, see hashPipelined.
+const maxPipelineChunkHeight = 12
+
+// Builds trees with the given number of goroutines hashing at once, as a pipeline:
+// a reader hands out aligned runs of leaves, workers hash the leaves of each run,
+// and reducers hash the subtree over it, each stage waiting on the next through a
+// channel holding at most one run per worker. The few levels above the runs are
+// hashed as before. Roots are the same as without the option.
+// A workers count of zero uses runtime.GOMAXPROCS; one or less than zero hashes
+// sequentially, as do trees of too few elements to be split.
+// Metrics sinks are still called from the building goroutine only.
+func WithParallelism(workers int) Option {
+	return func(cfg *config) {
+		if workers == 0 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		cfg.parallelism = workers
+	}
+}
+
+// Returns the height of the runs a pipelined build hashes at once,
+// or zero when the tree should be built sequentially. Runs depend on the element
+// count alone, never on the number of workers, so neither it nor the order the
+// workers take runs in can change the tree.
+func (cfg config) pipelineChunkHeight(count uint64, height int) int {
+	if cfg.parallelism <= 1 {
+		return 0
+	}
+	chunkHeight := min(maxPipelineChunkHeight, height)
+	if count < 2<<chunkHeight {
+		return 0
+	}
+	return chunkHeight
+}
+
+// The per-run counts a pipeline stage reports once the build is done.
+type pipelineStats struct {
+	cache  *leafCache
+	hashed int // leaves or nodes
+}
+
+// Hashes the leaves and every level up to chunkHeight, one run of 1<<chunkHeight leaves
+// at a time. Leaf hashes already computed by the caller are copied rather than hashed.
+func (t *MerkleTree) hashPipelined(chunkHeight int, leaves []Hash) {
+	// the last run reads padding; computed here, the stages never assign t.zero
+	if !t.cfg.duplicateOddNodes && t.count != t.paddedLeafCount() {
+		t.zeroHashes()
+	}
+
+	chunks := (t.count + 1<<chunkHeight - 1) >> chunkHeight
+	workers := t.cfg.parallelism
+	t.workers = workers
+	read := make(chan uint64, workers)
+	hashed := make(chan uint64, workers)
+
+	go func() {
+		for chunk := uint64(0); chunk < chunks; chunk++ {
+			read <- chunk
+		}
+		close(read)
+	}()
+
+	// leaf workers hold their own cache, so elements repeating across runs may be hashed once per worker
+	leafStats := make([]pipelineStats, workers)
+	var hashing sync.WaitGroup
+	for w := range leafStats {
+		stats := &leafStats[w]
+		if leaves == nil {
+			stats.cache = t.cfg.newLeafCache()
+		}
+		hashing.Add(1)
+		go func() {
+			defer hashing.Done()
+			for chunk := range read {
+				start := chunk << chunkHeight
+				end := min(start+1<<chunkHeight, t.count)
+				if leaves != nil {
+					copy(t.nodes[start:end], leaves[start:end])
+				} else {
+					for i := start; i < end; i++ {
+						t.nodes[i] = stats.cache.digest(t.cfg, t.elements[i])
+					}
+				}
+				stats.hashed += int(end - start)
+				hashed <- chunk
+			}
+		}()
+	}
+	go func() {
+		hashing.Wait()
+		close(hashed)
+	}()
+
+	reduceStats := make([]pipelineStats, workers)
+	var reducing sync.WaitGroup
+	for w := range reduceStats {
+		stats := &reduceStats[w]
+		reducing.Add(1)
+		go func() {
+			defer reducing.Done()
+			for chunk := range hashed {
+				stats.hashed += t.reduceChunk(chunkHeight, chunk)
+			}
+		}()
+	}
+	reducing.Wait()
+
+	if leaves != nil && t.cfg.metrics != nil {
+		t.cfg.metrics.LeafHashed(len(leaves))
+	}
+	covered, nodes := 0, 0
+	for w := range leafStats {
+		if leaves == nil {
+			leafStats[w].cache.flush(t.cfg.metrics, leafStats[w].hashed)
+		}
+		covered += leafStats[w].hashed
+		nodes += reduceStats[w].hashed
+	}
+	// runs overlapping or missing a leaf would change the root with the worker count
+	wantNodes := 0
+	for level := 1; level <= chunkHeight; level++ {
+		wantNodes += int(t.levelSize(level))
+	}
+	if uint64(covered) != t.count || nodes != wantNodes {
+		panic(fmt.Sprintf("merkletree: pipelined build covered %d of %d leaves and %d of %d nodes", covered, t.count, nodes, wantNodes))
+	}
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.NodeHashed(nodes)
+	}
+}
+
+// Hashes the subtree of chunkHeight levels over one run of hashed leaves,
+// returning the number of nodes hashed.
+func (t *MerkleTree) reduceChunk(chunkHeight int, chunk uint64) int {
+	hashed := 0
+	for level := 1; level <= chunkHeight; level++ {
+		start := chunk << (chunkHeight - level)
+		end := min(start+1<<(chunkHeight-level), t.levelSize(level))
+		for i := start; i < end; i++ {
+			t.nodes[t.nodeIndex(level, i)] = t.cfg.nodeDigest(t.node(level-1, 2*i), t.node(level-1, 2*i+1))
+		}
+		hashed += int(end - start)
+	}
+	return hashed
+}
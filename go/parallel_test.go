@@ -0,0 +1,153 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func TestParallelBuildMatchesSequential(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"keccak", []Option{WithKeccak256(), WithRawNodeHashing()}},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}},
+		{"set semantics", []Option{WithSetSemantics()}},
+		{"fixed depth", []Option{WithFixedDepth(20)}},
+		{"leaf cache", []Option{WithLeafHashCache(16)}},
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	sizes := []int{1, 5, 1 << 13, 1<<13 + 1, 3<<12 - 1, 1 << 14}
+	for i := 0; i < 4; i++ {
+		sizes = append(sizes, 1<<13+rng.Intn(1<<15))
+	}
+
+	for _, c := range cases {
+		for _, size := range sizes {
+			t.Run(fmt.Sprintf("%s/%d", c.name, size), func(t *testing.T) {
+				elements := testElements(size)
+				for i := range elements {
+					elements[i] = fmt.Sprintf("e%d", rng.Intn(size))
+				}
+				var sequential, parallel CountingMetrics
+				want, err := NewMerkleTree(elements, append(c.opts, WithMetrics(&sequential))...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := NewMerkleTree(elements, append(c.opts, WithMetrics(&parallel), WithParallelism(4))...)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got.GetRoot() != want.GetRoot() {
+					t.Errorf("got root %s, want %s", got.GetRoot(), want.GetRoot())
+				}
+				proof, _ := got.GetProof(uint64(size - 1))
+				wantProof, _ := want.GetProof(uint64(size - 1))
+				if fmt.Sprint(proof.siblings) != fmt.Sprint(wantProof.siblings) {
+					t.Error("proofs of the last element differ")
+				}
+				if got, want := parallel.NodeHashes.Load(), sequential.NodeHashes.Load(); got != want {
+					t.Errorf("got %d node hashes, want %d", got, want)
+				}
+				if got, want := parallel.LeafHashes.Load(), sequential.LeafHashes.Load(); c.name != "leaf cache" && got != want {
+					t.Errorf("got %d leaf hashes, want %d", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestParallelDeterminism(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	sizes := []int{1}
+	for _, exp := range []int{1, 3, 12, 13, 14, 15} {
+		sizes = append(sizes, 1<<exp-1, 1<<exp, 1<<exp+1)
+	}
+	for i := 0; i < 4; i++ {
+		sizes = append(sizes, 1+rng.Intn(1<<16))
+	}
+	workerCounts := []int{1, 4, runtime.GOMAXPROCS(0), 7}
+
+	for _, size := range sizes {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			elements := testElements(size)
+			indices := []uint64{0, uint64(size - 1), uint64(rng.Intn(size))}
+			if size > 1<<maxPipelineChunkHeight {
+				indices = append(indices, 1<<maxPipelineChunkHeight-1, 1<<maxPipelineChunkHeight)
+			}
+
+			var wantRoot Hash
+			var wantProofs [][]byte
+			for _, workers := range workerCounts {
+				mt, err := NewMerkleTree(elements, WithParallelism(workers))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var proofs [][]byte
+				for _, index := range indices {
+					proof, _ := mt.GetProof(index)
+					encoded, _ := proof.MarshalBinary()
+					proofs = append(proofs, encoded)
+				}
+				if wantProofs == nil {
+					wantRoot, wantProofs = mt.GetRootHash(), proofs
+					continue
+				}
+				if mt.GetRootHash() != wantRoot {
+					t.Errorf("got root %s with %d workers, want %s", mt.GetRootHash(), workers, wantRoot)
+				}
+				for i := range proofs {
+					if !bytes.Equal(proofs[i], wantProofs[i]) {
+						t.Errorf("proof of element %d differs with %d workers", indices[i], workers)
+					}
+				}
+
+				want := 1
+				if workers > 1 && size >= 2<<maxPipelineChunkHeight {
+					want = workers
+				}
+				if got := mt.Stats().Parallelism; got != want {
+					t.Errorf("got parallelism %d with %d workers, want %d", got, workers, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParallelReset(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(1<<14), WithParallelism(3))
+	if err := mt.Reset(testElements(1<<13 + 7)); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := NewMerkleTree(testElements(1<<13 + 7))
+	if mt.GetRoot() != want.GetRoot() {
+		t.Errorf("got root %s, want %s", mt.GetRoot(), want.GetRoot())
+	}
+}
+
+func BenchmarkParallelBuild(b *testing.B) {
+	for _, size := range []int{1 << 16, 1 << 23} {
+		var elements []string
+		for _, workers := range []int{1, 4} {
+			b.Run(fmt.Sprintf("%d/workers=%d", size, workers), func(b *testing.B) {
+				if elements == nil {
+					elements = testElements(size)
+				}
+				b.ResetTimer()
+				b.ReportAllocs()
+
+				for i := 0; i < b.N; i++ {
+					if _, err := NewMerkleTree(elements, WithParallelism(workers)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
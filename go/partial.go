@@ -0,0 +1,312 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrNotRetained = errors.New("merkletree: leaf not retained by partial tree")
+
+const partialTreeVersion = 0x01 // leading byte of the binary partial tree format
+
+// A pruned tree holding only some leaves, plus the minimal set of nodes needed to
+// link them to the root. Interior nodes above the retained leaves are recomputed on demand,
+// so the structure is the size of a multiproof for those leaves rather than of the tree.
+type PartialTree struct {
+	depth    int
+	leaves   map[uint64]Hash    // retained leaf hashes
+	siblings map[NodeCoord]Hash // nodes which cannot be derived from the retained leaves
+	indices  []uint64           // ascending retained leaf indices
+	root     Hash
+	cfg      config // hashes under the application tag of the tree, see tagConfig
+}
+
+// Extracts a partial tree retaining the leaves at the given indices.
+func (t *MerkleTree) Extract(indices []uint64) (*PartialTree, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return nil, err
+	}
+	defer t.mu.RUnlock()
+
+	p := &PartialTree{
+		depth:    t.height(),
+		leaves:   make(map[uint64]Hash, len(indices)),
+		siblings: make(map[NodeCoord]Hash),
+		cfg:      tagConfig(t.cfg.tag),
+	}
+
+	for _, index := range indices {
+		if index >= t.leafCount() {
+			return nil, t.outOfBounds(index)
+		}
+		p.leaves[index] = t.node(0, index)
+	}
+	p.sortIndices()
+
+	p.siblings = p.requiredSiblings(func(coord NodeCoord) Hash {
+		return t.node(coord.Level, coord.Index)
+	})
+
+	p.root = t.rootHash()
+	return p, nil
+}
+
+// Returns the minimal set of nodes linking the retained leaves to the root,
+// reading each node's hash through lookup.
+func (p *PartialTree) requiredSiblings(lookup func(NodeCoord) Hash) map[NodeCoord]Hash {
+	siblings := make(map[NodeCoord]Hash)
+
+	positions := p.indices
+	for level := 0; level < p.depth; level++ {
+		var parents []uint64
+		for i := 0; i < len(positions); i++ {
+			position := positions[i]
+			if position%2 == 0 && i+1 < len(positions) && positions[i+1] == position+1 {
+				i++
+			} else {
+				coord := NodeCoord{level, position ^ 1}
+				siblings[coord] = lookup(coord)
+			}
+			parents = append(parents, position/2)
+		}
+		positions = parents
+	}
+
+	return siblings
+}
+
+// Adds a leaf to the partial tree, given its proof against the partial tree's root.
+// Siblings which the new leaf makes derivable are discarded. If the proof does not
+// verify for that element and index, the partial tree is left unchanged.
+func (p *PartialTree) AddLeaf(index uint64, element string, proof MerkleProof) error {
+	if proof.hElement != p.cfg.hashLeaf(element) {
+		return fmt.Errorf("%w: proof is for a different element", ErrMalformedProof)
+	}
+	if !directionsMatchIndex(proof.directions, index, p.depth) {
+		return fmt.Errorf("%w: proof is not for index %d at depth %d", ErrMalformedProof, index, p.depth)
+	}
+	if !VerifyProofHash(p.root, proof, WithApplicationTag(p.cfg.tag)) {
+		return fmt.Errorf("%w: proof does not verify against the partial tree's root", ErrInconsistentProofs)
+	}
+
+	learned := make(map[NodeCoord]Hash, len(proof.siblings))
+	_, coords := ProofPath(index, p.depth)
+	for level, coord := range coords {
+		learned[coord], _ = ParseHash(proof.siblings[level])
+	}
+
+	updated := PartialTree{
+		depth:  p.depth,
+		leaves: make(map[uint64]Hash, len(p.leaves)+1),
+		root:   p.root,
+		cfg:    p.cfg,
+	}
+	for i, leaf := range p.leaves {
+		updated.leaves[i] = leaf
+	}
+	updated.leaves[index] = p.cfg.leafDigest(element)
+	updated.sortIndices()
+
+	updated.siblings = updated.requiredSiblings(func(coord NodeCoord) Hash {
+		if sibling, ok := p.siblings[coord]; ok {
+			return sibling
+		}
+		return learned[coord]
+	})
+
+	if !updated.VerifyAgainst(p.root.String()) {
+		return fmt.Errorf("%w: proof conflicts with retained nodes", ErrInconsistentProofs)
+	}
+
+	*p = updated
+	return nil
+}
+
+func (p *PartialTree) Root() string {
+	return p.root.String()
+}
+
+// Returns the retained leaf indices, ascending.
+func (p *PartialTree) Indices() []uint64 {
+	return append([]uint64(nil), p.indices...)
+}
+
+// Generates the proof for a retained leaf, identical to the full tree's GetProof.
+func (p *PartialTree) GetProof(index uint64) (MerkleProof, error) {
+	leaf, ok := p.leaves[index]
+	if !ok {
+		return MerkleProof{}, fmt.Errorf("%w: index %d", ErrNotRetained, index)
+	}
+
+	proof := MerkleProof{
+		hElement:   leaf.String(),
+		siblings:   make([]string, 0, p.depth),
+		directions: make([]bool, 0, p.depth),
+		tag:        p.cfg.tag,
+		algorithm:  p.cfg.algorithm(),
+	}
+	memo := make(map[NodeCoord]Hash)
+
+	for level := 0; level < p.depth; level++ {
+		sibling, ok := p.node(NodeCoord{level, index ^ 1}, memo)
+		if !ok {
+			return MerkleProof{}, fmt.Errorf("%w: node %d at level %d is missing", ErrMalformedProof, index^1, level)
+		}
+		proof.siblings = append(proof.siblings, sibling.String())
+		proof.directions = append(proof.directions, index%2 == 1)
+		index /= 2
+	}
+
+	return proof, nil
+}
+
+// Reports whether the retained nodes recompute to the given root.
+func (p *PartialTree) VerifyAgainst(root string) bool {
+	expected, err := ParseHash(root)
+	if err != nil {
+		return false
+	}
+	computed, ok := p.node(NodeCoord{p.depth, 0}, make(map[NodeCoord]Hash))
+	return ok && computed == expected
+}
+
+// Returns the hash of the node at coord, deriving it from retained nodes below when needed.
+func (p *PartialTree) node(coord NodeCoord, memo map[NodeCoord]Hash) (Hash, bool) {
+	if sibling, ok := p.siblings[coord]; ok {
+		return sibling, true
+	}
+	if coord.Level == 0 {
+		leaf, ok := p.leaves[coord.Index]
+		return leaf, ok
+	}
+	if derived, ok := memo[coord]; ok {
+		return derived, true
+	}
+	if !p.retainsUnder(coord) {
+		return Hash{}, false
+	}
+
+	left, ok := p.node(NodeCoord{coord.Level - 1, 2 * coord.Index}, memo)
+	if !ok {
+		return Hash{}, false
+	}
+	right, ok := p.node(NodeCoord{coord.Level - 1, 2*coord.Index + 1}, memo)
+	if !ok {
+		return Hash{}, false
+	}
+
+	derived := p.cfg.nodeDigest(left, right)
+	memo[coord] = derived
+	return derived, true
+}
+
+// Reports whether any retained leaf lies beneath the node at coord.
+func (p *PartialTree) retainsUnder(coord NodeCoord) bool {
+	first := coord.Index << coord.Level
+	i := sort.Search(len(p.indices), func(i int) bool { return p.indices[i] >= first })
+	return i < len(p.indices) && p.indices[i]>>coord.Level == coord.Index
+}
+
+func (p *PartialTree) sortIndices() {
+	p.indices = p.indices[:0]
+	for index := range p.leaves {
+		p.indices = append(p.indices, index)
+	}
+	sort.Slice(p.indices, func(i, j int) bool { return p.indices[i] < p.indices[j] })
+}
+
+// Encodes the partial tree as:
+//
+//	version (1 byte) | depth (uvarint) | [tag length (uvarint) | tag] | leaf count (uvarint)
+//	| (index (uvarint) | digest) per leaf
+//	| sibling count (uvarint) | (level (uvarint) | index (uvarint) | digest) per sibling
+//
+// Leaves are ordered by index and siblings by level then index, so encoding is deterministic.
+// The tag is present when binaryTagFlag is set in the version byte.
+func (p *PartialTree) MarshalBinary() ([]byte, error) {
+	out := []byte{partialTreeVersion}
+	if p.cfg.tag != "" {
+		out[0] |= binaryTagFlag
+	}
+	out = binary.AppendUvarint(out, uint64(p.depth))
+	out = appendTag(out, p.cfg.tag)
+
+	out = binary.AppendUvarint(out, uint64(len(p.indices)))
+	for _, index := range p.indices {
+		leaf := p.leaves[index]
+		out = binary.AppendUvarint(out, index)
+		out = append(out, leaf[:]...)
+	}
+
+	coords := make([]NodeCoord, 0, len(p.siblings))
+	for coord := range p.siblings {
+		coords = append(coords, coord)
+	}
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i].Level != coords[j].Level {
+			return coords[i].Level < coords[j].Level
+		}
+		return coords[i].Index < coords[j].Index
+	})
+
+	out = binary.AppendUvarint(out, uint64(len(coords)))
+	for _, coord := range coords {
+		sibling := p.siblings[coord]
+		out = binary.AppendUvarint(out, uint64(coord.Level))
+		out = binary.AppendUvarint(out, coord.Index)
+		out = append(out, sibling[:]...)
+	}
+
+	return out, nil
+}
+
+// Decodes a partial tree, recomputing its root from the retained nodes.
+func (p *PartialTree) UnmarshalBinary(data []byte) error {
+	r := byteReader{data: data}
+	version := r.byte()
+	if version&^binaryTagFlag != partialTreeVersion {
+		return fmt.Errorf("%w: unsupported partial tree version", ErrMalformedProof)
+	}
+
+	decoded := PartialTree{
+		depth:    int(r.uvarint(maxProofDepth)),
+		leaves:   make(map[uint64]Hash),
+		siblings: make(map[NodeCoord]Hash),
+	}
+	decoded.cfg = tagConfig(r.tag(version))
+
+	leafCount := r.uvarint(uint64(len(data)))
+	for i := uint64(0); i < leafCount && r.err == nil; i++ {
+		index := r.uvarint(1<<64 - 1)
+		decoded.leaves[index] = r.digest()
+	}
+
+	siblingCount := r.uvarint(uint64(len(data)))
+	for i := uint64(0); i < siblingCount && r.err == nil; i++ {
+		level := int(r.uvarint(maxProofDepth))
+		index := r.uvarint(1<<64 - 1)
+		decoded.siblings[NodeCoord{level, index}] = r.digest()
+	}
+
+	if r.err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedProof, r.err)
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrMalformedProof, len(r.data))
+	}
+	if len(decoded.leaves) == 0 {
+		return fmt.Errorf("%w: no retained leaves", ErrMalformedProof)
+	}
+
+	decoded.sortIndices()
+	root, ok := decoded.node(NodeCoord{decoded.depth, 0}, make(map[NodeCoord]Hash))
+	if !ok {
+		return fmt.Errorf("%w: retained nodes do not link every leaf to the root", ErrMalformedProof)
+	}
+	decoded.root = root
+
+	*p = decoded
+	return nil
+}
@@ -0,0 +1,168 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	cases := []struct {
+		size    int
+		indices []uint64
+	}{
+		{1, []uint64{0}},
+		{8, []uint64{3}},
+		{13, []uint64{0, 1, 2, 3}},
+		{100, []uint64{5, 50, 99, 50}},
+	}
+
+	for _, c := range cases {
+		testname := fmt.Sprintf("extracts %v of %d elements", c.indices, c.size)
+		t.Run(testname, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(c.size))
+			partial, err := mt.Extract(c.indices)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if partial.Root() != mt.GetRoot() {
+				t.Errorf("got %s, want %s", partial.Root(), mt.GetRoot())
+			}
+			if !partial.VerifyAgainst(mt.GetRoot()) {
+				t.Error("partial tree is inconsistent with its root")
+			}
+
+			for _, index := range c.indices {
+				got, err := partial.GetProof(index)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want, _ := mt.GetProof(index)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("index %d: got %+v, want %+v", index, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractIsMultiproofSized(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(1 << 12))
+	indices := []uint64{10, 11, 500, 4000}
+	partial, _ := mt.Extract(indices)
+
+	var proofs []MerkleProof
+	for _, index := range indices {
+		proof, _ := mt.GetProof(index)
+		proofs = append(proofs, proof)
+	}
+	multi, _ := CombineProofs(proofs)
+
+	if len(partial.siblings) != len(multi.siblings) || len(partial.leaves) != len(multi.leaves) {
+		t.Errorf("got %d leaves and %d siblings, want %d and %d",
+			len(partial.leaves), len(partial.siblings), len(multi.leaves), len(multi.siblings))
+	}
+}
+
+func TestPartialTreeNotRetained(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(8))
+	partial, _ := mt.Extract([]uint64{2})
+
+	if _, err := partial.GetProof(3); !errors.Is(err, ErrNotRetained) {
+		t.Errorf("got %v, want %v", err, ErrNotRetained)
+	}
+	if _, err := mt.Extract([]uint64{8}); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	if partial.VerifyAgainst(hashLeaf("other")) {
+		t.Error("verified against the wrong root")
+	}
+}
+
+func TestPartialTreeRoundTrip(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(37))
+	partial, _ := mt.Extract([]uint64{0, 17, 18, 36})
+
+	encoded, err := partial.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PartialTree
+	if err := decoded.UnmarshalBinary(encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(&decoded, partial) {
+		t.Errorf("got %+v, want %+v", decoded, partial)
+	}
+	reencoded, _ := decoded.MarshalBinary()
+	if !reflect.DeepEqual(reencoded, encoded) {
+		t.Error("encoding is not deterministic")
+	}
+
+	// dropping the last sibling leaves a retained leaf unlinked
+	truncated, _ := mt.Extract([]uint64{5})
+	delete(truncated.siblings, NodeCoord{Level: 0, Index: 4})
+	encoded, _ = truncated.MarshalBinary()
+	if err := decoded.UnmarshalBinary(encoded); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
+
+func TestAddLeaf(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(16))
+	partial, _ := mt.Extract([]uint64{9})
+
+	for _, index := range []uint64{0, 3, 1, 2, 15} {
+		proof, _ := mt.GetProof(index)
+		if err := partial.AddLeaf(index, fmt.Sprintf("element-%d", index), proof); err != nil {
+			t.Fatal(err)
+		}
+
+		want, _ := mt.Extract(partial.Indices())
+		if !reflect.DeepEqual(partial, want) {
+			t.Errorf("after adding %d: got %+v, want %+v", index, partial, want)
+		}
+	}
+
+	// leaves 0 to 3 are now held densely, so nothing beneath their subtree is stored as a sibling
+	for coord := range partial.siblings {
+		if coord.Level < 2 && coord.Index>>(2-coord.Level) == 0 {
+			t.Errorf("sibling %v is derivable from the retained leaves", coord)
+		}
+	}
+	for index := uint64(0); index < 4; index++ {
+		got, _ := partial.GetProof(index)
+		want, _ := mt.GetProof(index)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("index %d: got %+v, want %+v", index, got, want)
+		}
+	}
+}
+
+func TestAddLeafRejectsConflicts(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(16))
+	other, _ := NewMerkleTree(testElements(15))
+	partial, _ := mt.Extract([]uint64{9})
+	before, _ := partial.MarshalBinary()
+
+	foreign, _ := other.GetProof(4)
+	if err := partial.AddLeaf(4, "element-4", foreign); !errors.Is(err, ErrInconsistentProofs) {
+		t.Errorf("got %v, want %v", err, ErrInconsistentProofs)
+	}
+
+	genuine, _ := mt.GetProof(4)
+	if err := partial.AddLeaf(5, "element-4", genuine); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+	if err := partial.AddLeaf(4, "element-5", genuine); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+
+	after, _ := partial.MarshalBinary()
+	if !reflect.DeepEqual(before, after) {
+		t.Error("rejected leaves mutated the partial tree")
+	}
+}
@@ -0,0 +1,304 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+var (
+	ErrFieldElement   = errors.New("merkletree: element is not a field element")
+	ErrPoseidonParams = errors.New("merkletree: invalid Poseidon parameters")
+)
+
+// Parameters of a Poseidon permutation of width 3 with the x^5 S-box, so that nodes hash
+// two children into one field element. The round constants and MDS matrix are not
+// parameters: they are drawn from the Grain LFSR the reference implementation seeds with
+// these, as its generate_parameters_grain.sage does. Unlike that script, the matrix is
+// the first one drawn, without its checks against infinitely long subspace trails, which
+// the BN254 matrix passes.
+type PoseidonParams struct {
+	Modulus       *big.Int `json:"modulus"`       // prime of the field, below 2^256, with 5 coprime to Modulus-1
+	FullRounds    int      `json:"fullRounds"`    // rounds applying the S-box to every element of the state, even
+	PartialRounds int      `json:"partialRounds"` // rounds applying it to the first element only
+}
+
+// Returns the parameters circomlib and iden3 use over the scalar field of BN254, whose
+// hashes of two inputs are circomlib's poseidon([left, right]).
+func PoseidonBN254() PoseidonParams {
+	modulus, _ := new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	return PoseidonParams{Modulus: modulus, FullRounds: 8, PartialRounds: 57}
+}
+
+// Hashes with Poseidon over the field of params instead of SHA-256, so that proofs can be
+// verified cheaply inside an arithmetic circuit. Every digest is a field element in its
+// 32-byte big-endian encoding, which is how proofs carry siblings:
+//
+//   - an element is read as a big-endian integer of up to 32 bytes, the empty element
+//     being zero, and is its own leaf digest; elements which differ only in leading zero
+//     bytes therefore share a leaf
+//   - a node is the 2-to-1 Poseidon hash of its children, the first element of the state
+//     after permuting 0, left, right; a child which is no field element, as a forged
+//     proof may carry, yields all ones, which is none either, so the root never matches
+//
+// Elements beyond the field, the padding element included, make constructors and
+// mutations fail with ErrFieldElement, and invalid parameters make them fail with
+// ErrPoseidonParams. Application tags, RFC 6962 hashing, raw node hashing, double
+// hashing and HMAC keys have no meaning over field elements and fail with
+// ErrHashConflict; WithSortedPairs and WithEmptyLeaf apply as before. It replaces
+// the hash functions other options set, which replace it when given later, and modes fail with
+// ErrModeConflict when it follows them. Verifiers and Scheme-aware readers need the
+// same option, which the tree's Scheme carries. Arithmetic is in math/big, so building
+// is far slower than under SHA-256; see BenchmarkPoseidonNode.
+func WithPoseidon(params PoseidonParams) Option {
+	return func(cfg *config) {
+		cfg.leafHash, cfg.nodeHash = HashSHA256, HashSHA256
+		cfg.backend = newPoseidon(params)
+	}
+}
+
+// A Poseidon permutation with its generated constants. Instances are shared between equal
+// parameters, so that configurations with equal parameters compare equal.
+type poseidon struct {
+	params    PoseidonParams
+	constants []*big.Int // added to the state before each round, three per round
+	mds       [3][3]*big.Int
+	err       error // why the parameters are invalid, nil when they are not
+}
+
+type poseidonKey struct {
+	modulus       string
+	fullRounds    int
+	partialRounds int
+}
+
+var poseidons struct {
+	sync.Mutex
+	instances map[poseidonKey]*poseidon
+}
+
+func newPoseidon(params PoseidonParams) *poseidon {
+	key := poseidonKey{fullRounds: params.FullRounds, partialRounds: params.PartialRounds}
+	if params.Modulus != nil {
+		key.modulus = params.Modulus.String()
+	}
+	poseidons.Lock()
+	defer poseidons.Unlock()
+	if p, ok := poseidons.instances[key]; ok {
+		return p
+	}
+	if poseidons.instances == nil {
+		poseidons.instances = make(map[poseidonKey]*poseidon)
+	}
+	p := &poseidon{err: params.check()}
+	if p.err == nil {
+		p.params = PoseidonParams{Modulus: new(big.Int).Set(params.Modulus), FullRounds: params.FullRounds, PartialRounds: params.PartialRounds}
+		p.generate()
+	}
+	poseidons.instances[key] = p
+	return p
+}
+
+func (p *poseidon) name() string {
+	return "poseidon"
+}
+
+func (p *poseidon) algorithm() AlgorithmID {
+	return AlgorithmPoseidon
+}
+
+func (p *poseidon) check() error {
+	return p.err
+}
+
+func (params PoseidonParams) check() error {
+	p := params.Modulus
+	switch {
+	case p == nil || p.Sign() <= 0 || p.BitLen() > 8*digestSize || !p.ProbablyPrime(20):
+		return fmt.Errorf("%w: modulus is not a prime below 2^%d", ErrPoseidonParams, 8*digestSize)
+	case new(big.Int).GCD(nil, nil, big.NewInt(5), new(big.Int).Sub(p, big.NewInt(1))).Cmp(big.NewInt(1)) != 0:
+		return fmt.Errorf("%w: x^5 is not a permutation of the field", ErrPoseidonParams)
+	case params.FullRounds <= 0 || params.FullRounds%2 != 0 || params.FullRounds >= 1<<10:
+		return fmt.Errorf("%w: %d full rounds, want a positive even number", ErrPoseidonParams, params.FullRounds)
+	case params.PartialRounds < 0 || params.PartialRounds >= 1<<10:
+		return fmt.Errorf("%w: %d partial rounds", ErrPoseidonParams, params.PartialRounds)
+	}
+	return nil
+}
+
+// The Grain LFSR of the reference implementation, 80 bits of state.
+type grain struct {
+	bits [80]byte
+	pos  int // index of the oldest bit
+}
+
+func newGrain(params PoseidonParams) *grain {
+	g := &grain{}
+	n := 0
+	push := func(value uint64, width int) {
+		for i := width - 1; i >= 0; i-- {
+			g.bits[n] = byte(value>>i) & 1
+			n++
+		}
+	}
+	push(1, 2) // a prime field
+	push(0, 4) // the x^alpha S-box
+	push(uint64(params.Modulus.BitLen()), 12)
+	push(3, 12)
+	push(uint64(params.FullRounds), 10)
+	push(uint64(params.PartialRounds), 10)
+	push(1<<30-1, 30)
+	for i := 0; i < 160; i++ {
+		g.step()
+	}
+	return g
+}
+
+func (g *grain) step() byte {
+	at := func(i int) byte { return g.bits[(g.pos+i)%len(g.bits)] }
+	bit := at(62) ^ at(51) ^ at(38) ^ at(23) ^ at(13) ^ at(0)
+	g.bits[g.pos] = bit
+	g.pos = (g.pos + 1) % len(g.bits)
+	return bit
+}
+
+// Returns the next output bit: of each pair of bits stepped out, the second when the
+// first is set, discarding the pair otherwise.
+func (g *grain) bit() byte {
+	for g.step() == 0 {
+		g.step()
+	}
+	return g.step()
+}
+
+// Returns the next integer of the given number of bits, most significant first.
+func (g *grain) integer(width int) *big.Int {
+	v := new(big.Int)
+	for i := 0; i < width; i++ {
+		v.Lsh(v, 1)
+		v.SetBit(v, 0, uint(g.bit()))
+	}
+	return v
+}
+
+func (p *poseidon) generate() {
+	modulus, width := p.params.Modulus, p.params.Modulus.BitLen()
+	g := newGrain(p.params)
+
+	p.constants = make([]*big.Int, 3*(p.params.FullRounds+p.params.PartialRounds))
+	for i := range p.constants {
+		c := g.integer(width)
+		for c.Cmp(modulus) >= 0 {
+			c = g.integer(width)
+		}
+		p.constants[i] = c
+	}
+
+	// a Cauchy matrix, 1/(x_i + y_j) over distinct x and y whose sums are never zero
+	for {
+		var xy [6]*big.Int
+		distinct := make(map[string]bool)
+		for i := range xy {
+			xy[i] = g.integer(width)
+			xy[i].Mod(xy[i], modulus)
+			distinct[xy[i].String()] = true
+		}
+		if len(distinct) < len(xy) {
+			continue
+		}
+		valid := true
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				sum := new(big.Int).Add(xy[i], xy[3+j])
+				if sum.Mod(sum, modulus).Sign() == 0 {
+					valid = false
+					continue
+				}
+				p.mds[i][j] = sum.ModInverse(sum, modulus)
+			}
+		}
+		if valid {
+			return
+		}
+	}
+}
+
+// Reads a digest as a field element, failing for an encoding of a value beyond the field.
+func (p *poseidon) element(h Hash) (*big.Int, bool) {
+	v := new(big.Int).SetBytes(h[:])
+	return v, v.Cmp(p.params.Modulus) < 0
+}
+
+// Encodes a field element as a digest, big-endian.
+func fieldDigest(v *big.Int) Hash {
+	var h Hash
+	v.FillBytes(h[:])
+	return h
+}
+
+// The digest of a child which is no field element, itself none.
+var poseidonInvalid = Hash{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+}
+
+// Fails for an element which does not encode a field element.
+func (p *poseidon) checkElement(element string) error {
+	if len(element) > digestSize {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrFieldElement, len(element), digestSize)
+	}
+	if new(big.Int).SetBytes([]byte(element)).Cmp(p.params.Modulus) >= 0 {
+		return fmt.Errorf("%w: exceeds the modulus", ErrFieldElement)
+	}
+	return nil
+}
+
+// Returns the leaf digest of an element, the field element it encodes.
+func (p *poseidon) leaf(element string) Hash {
+	if p.checkElement(element) != nil {
+		return poseidonInvalid
+	}
+	var h Hash
+	copy(h[digestSize-len(element):], element)
+	return h
+}
+
+// Returns the 2-to-1 Poseidon hash of two field elements.
+func (p *poseidon) node(left Hash, right Hash) Hash {
+	l, okLeft := p.element(left)
+	r, okRight := p.element(right)
+	if !okLeft || !okRight {
+		return poseidonInvalid
+	}
+	return fieldDigest(p.hash(l, r))
+}
+
+func (p *poseidon) hash(left *big.Int, right *big.Int) *big.Int {
+	modulus := p.params.Modulus
+	state := [3]*big.Int{new(big.Int), new(big.Int).Set(left), new(big.Int).Set(right)}
+	mixed := [3]*big.Int{new(big.Int), new(big.Int), new(big.Int)}
+	square, product := new(big.Int), new(big.Int)
+
+	half := p.params.FullRounds / 2
+	for round := 0; round < p.params.FullRounds+p.params.PartialRounds; round++ {
+		full := round < half || round >= half+p.params.PartialRounds
+		for i, x := range state {
+			x.Add(x, p.constants[3*round+i])
+			if full || i == 0 {
+				square.Mul(x, x).Mod(square, modulus)
+				square.Mul(square, square).Mod(square, modulus)
+				x.Mul(x, square).Mod(x, modulus)
+			}
+		}
+		for i, sum := range mixed {
+			sum.SetInt64(0)
+			for j, x := range state {
+				sum.Add(sum, product.Mul(p.mds[i][j], x))
+			}
+			sum.Mod(sum, modulus)
+		}
+		state, mixed = mixed, state
+	}
+	return state[0]
+}
@@ -0,0 +1,197 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// Returns the element encoding the field element v, its minimal big-endian bytes.
+func fieldElement(v int64) string {
+	return string(big.NewInt(v).Bytes())
+}
+
+func TestPoseidon(t *testing.T) {
+	p := newPoseidon(PoseidonBN254())
+	if p.err != nil {
+		t.Fatal(p.err)
+	}
+
+	// generated constants, as circomlib's poseidon_constants has them for two inputs
+	if got := fieldDigest(p.constants[0]).String(); got != "0ee9a592ba9a9518d05986d656f40c2114c4993c11bb29938d21d47304cd8e6e" {
+		t.Errorf("got first round constant %s", got)
+	}
+	if got := fieldDigest(p.mds[0][0]).String(); got != "109b7f411ba0e4c9b2b70caf5c36a7b194be7c11ad24378bfedb68592ba8118b" {
+		t.Errorf("got first MDS entry %s", got)
+	}
+
+	// circomlib's poseidon([left, right])
+	cases := []struct {
+		left, right int64
+		want        string
+	}{
+		{1, 2, "7853200120776062878684798364095072458815029376092732009249414926327459813530"},
+		{3, 4, "14763215145315200506921711489642608356394854266165572616578112107564877678998"},
+	}
+	for _, c := range cases {
+		if got := p.hash(big.NewInt(c.left), big.NewInt(c.right)); got.String() != c.want {
+			t.Errorf("got poseidon(%d, %d) = %v, want %s", c.left, c.right, got, c.want)
+		}
+	}
+	if newPoseidon(PoseidonBN254()) != p {
+		t.Error("equal parameters gave distinct instances")
+	}
+}
+
+func TestPoseidonTree(t *testing.T) {
+	opt := WithPoseidon(PoseidonBN254())
+	pair, err := NewMerkleTree([]string{fieldElement(1), fieldElement(2)}, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "115cc0f5e7d690413df64c6b9662e9cf2a3617f2743245519e19607a4417189a"; pair.GetRoot() != want {
+		t.Errorf("got root %s, want %s", pair.GetRoot(), want)
+	}
+
+	elements := []string{fieldElement(1), fieldElement(2), fieldElement(3), fieldElement(4), ""}
+	mt, err := NewMerkleTree(elements, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, _ := mt.GetProofBytes(2)
+	if want := fieldDigest(big.NewInt(3)); proof.Element != want {
+		t.Errorf("got leaf %s, want %s", proof.Element, want)
+	}
+	if want := fieldDigest(big.NewInt(4)); proof.Siblings[0] != want {
+		t.Errorf("got sibling %s, want %s", proof.Siblings[0], want)
+	}
+	if want := pair.GetRoot(); proof.Siblings[1].String() != want {
+		t.Errorf("got sibling %s, want %s", proof.Siblings[1], want)
+	}
+
+	for i := uint64(0); i < mt.LeafCount(); i++ {
+		proof, _ := mt.GetProof(i)
+		if !VerifyProof(mt.GetRoot(), proof, opt) {
+			t.Errorf("proof %d rejected", i)
+		}
+		if VerifyProof(mt.GetRoot(), proof) {
+			t.Errorf("proof %d verified under SHA-256", i)
+		}
+	}
+	if got := mt.Stats().HashAlgorithm; got != "poseidon" {
+		t.Errorf("got hash algorithm %s, want poseidon", got)
+	}
+}
+
+func TestPoseidonFieldElements(t *testing.T) {
+	opt := WithPoseidon(PoseidonBN254())
+	modulus := string(PoseidonBN254().Modulus.Bytes())
+	below := string(new(big.Int).Sub(PoseidonBN254().Modulus, big.NewInt(1)).Bytes())
+
+	if _, err := NewMerkleTree([]string{"a", below}, opt); err != nil {
+		t.Errorf("got %v for the largest field element, want nil", err)
+	}
+	for _, element := range []string{modulus, strings.Repeat("\xff", 32), strings.Repeat("a", 33)} {
+		if _, err := NewMerkleTree([]string{"a", element}, opt); !errors.Is(err, ErrFieldElement) {
+			t.Errorf("got %v building with %x, want ErrFieldElement", err, element)
+		}
+	}
+
+	mt, _ := NewMerkleTree([]string{"a", "b", "c"}, opt)
+	if err := mt.Append(modulus); !errors.Is(err, ErrFieldElement) {
+		t.Errorf("got %v appending, want ErrFieldElement", err)
+	}
+	if err := mt.UpdateElement(0, modulus); !errors.Is(err, ErrFieldElement) {
+		t.Errorf("got %v updating, want ErrFieldElement", err)
+	}
+
+	// a sibling beyond the field never hashes into a field element
+	proof, _ := mt.GetProof(0)
+	forged, _ := mt.GetProofBytes(0)
+	forged.Siblings[0] = poseidonInvalid
+	if VerifyProof(mt.GetRoot(), forged.Proof(), opt) {
+		t.Error("forged proof verified")
+	}
+	if !VerifyProof(mt.GetRoot(), proof, opt) {
+		t.Error("proof rejected")
+	}
+}
+
+func TestPoseidonOptions(t *testing.T) {
+	opt := WithPoseidon(PoseidonBN254())
+	cases := []struct {
+		name string
+		opts []Option
+		want error
+	}{
+		{"application tag", []Option{opt, WithApplicationTag("circuit")}, ErrHashConflict},
+		{"RFC 6962", []Option{opt, WithRFC6962Hashing()}, ErrHashConflict},
+		{"raw nodes", []Option{opt, WithRawNodeHashing()}, ErrHashConflict},
+		{"HMAC key", []Option{opt, WithHMACKey([]byte("secret"))}, ErrHashConflict},
+		{"after a mode", []Option{WithMode(ModeBitcoin), opt}, ErrModeConflict},
+		{"padding element", []Option{opt, WithEmptyLeaf([]byte(strings.Repeat("\xff", 32)))}, ErrFieldElement},
+		{"even modulus", []Option{WithPoseidon(PoseidonParams{Modulus: big.NewInt(1 << 20), FullRounds: 8, PartialRounds: 57})}, ErrPoseidonParams},
+		{"x^5 not a permutation", []Option{WithPoseidon(PoseidonParams{Modulus: big.NewInt(11), FullRounds: 8, PartialRounds: 57})}, ErrPoseidonParams},
+		{"odd full rounds", []Option{WithPoseidon(PoseidonParams{Modulus: PoseidonBN254().Modulus, FullRounds: 7, PartialRounds: 57})}, ErrPoseidonParams},
+		{"no modulus", []Option{WithPoseidon(PoseidonParams{FullRounds: 8})}, ErrPoseidonParams},
+		{"sorted pairs", []Option{opt, WithSortedPairs()}, nil},
+		{"replaced by BLAKE3", []Option{opt, WithBLAKE3(), WithApplicationTag("tag")}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := NewMerkleTree([]string{"a", "b"}, c.opts...); !errors.Is(err, c.want) {
+				t.Errorf("got %v, want %v", err, c.want)
+			}
+			if _, err := NewVerifier(c.opts...); !errors.Is(err, c.want) {
+				t.Errorf("got %v creating a verifier, want %v", err, c.want)
+			}
+		})
+	}
+}
+
+func TestPoseidonScheme(t *testing.T) {
+	mt, _ := NewMerkleTree([]string{"a", "b", "c"}, WithPoseidon(PoseidonBN254()), WithEmptyLeaf([]byte{1}))
+	scheme := mt.Scheme()
+	if scheme.Poseidon == nil || scheme.Poseidon.Modulus.Cmp(PoseidonBN254().Modulus) != 0 || scheme.Poseidon.PartialRounds != 57 {
+		t.Fatalf("got %+v, want the BN254 parameters", scheme.Poseidon)
+	}
+	proof, _ := mt.GetProof(1)
+
+	text, _ := mt.Commitment().MarshalText()
+	var commitment Commitment
+	if err := commitment.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if commitment.String() != mt.Commitment().String() || commitment.Scheme.Poseidon == nil {
+		t.Errorf("got %+v, want %+v", commitment.Scheme, scheme)
+	}
+	if err := VerifyCommitmentProof(commitment, proof); err != nil {
+		t.Errorf("got %v against the decoded commitment, want nil", err)
+	}
+
+	data, _ := json.Marshal(scheme)
+	var decoded Scheme
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if verifier, err := NewVerifier(WithScheme(decoded)); err != nil || !verifier.VerifyProof(mt.GetRoot(), proof) {
+		t.Errorf("got %v, proof rejected under the scheme decoded from %s", err, data)
+	}
+
+	// Poseidon and another hash function at once, as no scheme of a tree has them
+	commitment.Scheme.Keccak256 = true
+	text, _ = commitment.MarshalText()
+	if err := new(Commitment).UnmarshalText(text); err == nil {
+		t.Error("decoded a commitment holding both Poseidon and Keccak-256")
+	}
+}
+
+func BenchmarkPoseidonNode(b *testing.B) {
+	p := newPoseidon(PoseidonBN254())
+	left, right := fieldDigest(big.NewInt(1)), fieldDigest(big.NewInt(2))
+	for i := 0; i < b.N; i++ {
+		left = p.node(left, right)
+	}
+}
@@ -0,0 +1,98 @@
+package merkletree
+
+import "fmt"
+
+// Proves that a sub-root commits to exactly the first n elements of a larger tree.
+//
+// The proof is the inclusion proof of element n-1. Its left-hand siblings cover elements
+// before n-1 in both trees, while its right-hand siblings differ: real subtrees in the full
+// tree, padding subtrees in the prefix tree. Folding the proof both ways yields both roots.
+type PrefixProof struct {
+	prefixRoot string      // root of the tree built over the first n elements alone
+	boundary   MerkleProof // inclusion proof of element n-1 in the full tree
+}
+
+// Generates a proof linking the root of the first n elements to the root of the full tree.
+// n must be between 1 and LeafCount() inclusive.
+func (t *MerkleTree) GetPrefixProof(n uint64) (PrefixProof, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return PrefixProof{}, err
+	}
+	defer t.mu.RUnlock()
+
+	if n == 0 || n > t.leafCount() {
+		return PrefixProof{}, fmt.Errorf("%w: prefix length %d, element count %d", ErrIndexOutOfBounds, n, t.leafCount())
+	}
+
+	boundary, err := t.getProof(n - 1)
+	if err != nil {
+		return PrefixProof{}, err
+	}
+
+	return PrefixProof{
+		prefixRoot: foldPrefix(boundary, t.cfg.height(n), t.cfg),
+		boundary:   boundary,
+	}, nil
+}
+
+// Returns the root of the tree built over the first n elements alone.
+func (p PrefixProof) PrefixRoot() string {
+	return p.prefixRoot
+}
+
+// Verifies that prefixRoot commits to exactly the first n elements of the tree with the given root.
+func VerifyPrefixProof(root string, n uint64, prefixRoot string, proof PrefixProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyPrefixProof(root, n, prefixRoot, proof)
+}
+
+// Verifies that prefixRoot commits to exactly the first n elements of the tree with
+// the given root, as VerifyPrefixProof does. This is the consistency check between a tree
+// and the earlier tree over its first n elements, for trees only ever appended to.
+func (v *Verifier) VerifyPrefixProof(root string, n uint64, prefixRoot string, proof PrefixProof) bool {
+	if n == 0 {
+		return false
+	}
+
+	boundary := proof.boundary
+	if !directionsMatchIndex(boundary.directions, n-1, len(boundary.directions)) {
+		return false
+	}
+
+	prefixHeight := v.cfg.height(n)
+	if prefixHeight > len(boundary.directions) || foldPrefix(boundary, prefixHeight, v.cfg) != prefixRoot {
+		return false
+	}
+
+	return v.VerifyProof(root, boundary)
+}
+
+// Verifies that the tree with newRoot extends the tree of oldSize elements with oldRoot,
+// appending to it only: VerifyPrefixProof with its roots named for the two trees.
+func VerifyConsistency(oldRoot string, oldSize uint64, newRoot string, proof PrefixProof, opts ...Option) bool {
+	return newVerifier(opts).VerifyConsistency(oldRoot, oldSize, newRoot, proof)
+}
+
+// Verifies that the tree with newRoot extends the tree of oldSize elements with oldRoot,
+// as VerifyConsistency does, checking the prefix proof under the verifier's options.
+func (v *Verifier) VerifyConsistency(oldRoot string, oldSize uint64, newRoot string, proof PrefixProof) bool {
+	return v.VerifyPrefixProof(newRoot, oldSize, oldRoot, proof)
+}
+
+// Folds the lowest height levels of the boundary proof, substituting padding for every
+// right-hand sibling, or the node itself under ModeBitcoin, which produces the root of the tree over the elements up to the boundary.
+func foldPrefix(boundary MerkleProof, height int, cfg config) string {
+	padding := cfg.paddingHashes(height)
+	current := boundary.hElement
+
+	for level := 0; level < height; level++ {
+		if boundary.directions[level] {
+			current = cfg.hashNode(boundary.siblings[level], current)
+		} else if cfg.duplicateOddNodes {
+			current = cfg.hashNode(current, current)
+		} else {
+			current = cfg.hashNode(current, padding[level])
+		}
+	}
+
+	return current
+}
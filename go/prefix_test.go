@@ -0,0 +1,78 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPrefixProof(t *testing.T) {
+	for _, size := range []int{1, 5, 8, 13} {
+		elements := testElements(size)
+		mt, err := NewMerkleTree(elements)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for n := 1; n <= size; n++ {
+			testname := fmt.Sprintf("proves first %d of %d elements", n, size)
+			t.Run(testname, func(t *testing.T) {
+				prefix, _ := NewMerkleTree(elements[:n])
+
+				proof, err := mt.GetPrefixProof(uint64(n))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if proof.PrefixRoot() != prefix.GetRoot() {
+					t.Errorf("got %s, want %s", proof.PrefixRoot(), prefix.GetRoot())
+				}
+				if !VerifyPrefixProof(mt.GetRoot(), uint64(n), prefix.GetRoot(), proof) {
+					t.Error("invalid prefix proof")
+				}
+			})
+		}
+	}
+}
+
+func TestPrefixProofPowerOfTwoBoundaries(t *testing.T) {
+	elements := testElements(20)
+	mt, _ := NewMerkleTree(elements)
+
+	for _, n := range []int{1, 2, 4, 8, 16} {
+		testname := fmt.Sprintf("prefix root at boundary %d", n)
+		t.Run(testname, func(t *testing.T) {
+			proof, _ := mt.GetPrefixProof(uint64(n))
+
+			// a full power-of-two prefix is exactly the left-most subtree at that height
+			if got, want := proof.PrefixRoot(), mt.node(treeHeight(uint64(n)), 0).String(); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+			if !VerifyPrefixProof(mt.GetRoot(), uint64(n), proof.PrefixRoot(), proof) {
+				t.Error("invalid prefix proof")
+			}
+		})
+	}
+}
+
+func TestPrefixProofRejectsMismatches(t *testing.T) {
+	elements := testElements(13)
+	mt, _ := NewMerkleTree(elements)
+	proof, _ := mt.GetPrefixProof(6)
+
+	other, _ := NewMerkleTree(elements[:5])
+	if VerifyPrefixProof(mt.GetRoot(), 6, other.GetRoot(), proof) {
+		t.Error("verified a prefix root over the wrong elements")
+	}
+	if VerifyPrefixProof(mt.GetRoot(), 7, proof.PrefixRoot(), proof) {
+		t.Error("verified against the wrong prefix length")
+	}
+	if VerifyPrefixProof(other.GetRoot(), 6, proof.PrefixRoot(), proof) {
+		t.Error("verified against the wrong root")
+	}
+
+	for _, n := range []uint64{0, 14} {
+		if _, err := mt.GetPrefixProof(n); !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("prefix length %d: got %v, want %v", n, err, ErrIndexOutOfBounds)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// A proof holding raw digests rather than hex text, for verifiers that work on bytes.
+// It carries the same information as a MerkleProof and converts to and from one without loss.
+type MerkleProofBytes struct {
+	Element    Hash   // leaf hash of the proven element
+	Siblings   []Hash // path of siblings from the element up to the root
+	Directions []bool // true where the sibling is on the left
+	Epoch      uint64 // epoch of the tree the proof was generated from
+	Tag        string // application tag of the tree, see WithApplicationTag
+
+	Algorithm AlgorithmID // algorithm the siblings were hashed with, see AlgorithmID
+	Data      []byte      // data committed with the element, see MerkleProof.Data
+}
+
+// Returns the proof for the element at index with raw digests.
+func (t *MerkleTree) GetProofBytes(index uint64) (MerkleProofBytes, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return MerkleProofBytes{}, err
+	}
+	defer t.mu.RUnlock()
+
+	if index >= t.leafCount() {
+		return MerkleProofBytes{}, t.outOfBounds(index)
+	}
+
+	var start time.Time
+	if t.cfg.timed() {
+		start = time.Now()
+	}
+	leaf := index
+
+	proof := MerkleProofBytes{
+		Element:    t.node(0, index),
+		Siblings:   make([]Hash, t.height()),
+		Directions: make([]bool, t.height()),
+		Epoch:      t.epoch,
+		Tag:        t.cfg.tag,
+		Algorithm:  t.cfg.algorithm(),
+		Data:       slices.Clone(t.leafData(index)),
+	}
+	for level := range proof.Siblings {
+		proof.Siblings[level] = t.node(level, index^1)
+		proof.Directions[level] = index%2 == 1
+		index /= 2
+	}
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.ProofGenerated(len(proof.Siblings))
+	}
+	if t.cfg.timed() {
+		t.reportProofs(leaf, 1, len(proof.Siblings), time.Since(start))
+	}
+
+	return proof, nil
+}
+
+// Converts the proof to raw digests. Fails with ErrMalformedProof for a proof of
+// inconsistent shape or, wrapping an *InvalidDigestError, for a digest that is not a valid hash.
+func (p MerkleProof) Bytes() (MerkleProofBytes, error) {
+	if err := p.validateShape(); err != nil {
+		return MerkleProofBytes{}, err
+	}
+
+	element, err := ParseHash(p.hElement)
+	if err != nil {
+		return MerkleProofBytes{}, fmt.Errorf("%w: %w", ErrMalformedProof, &InvalidDigestError{Field: "element", Err: err})
+	}
+	siblings := make([]Hash, len(p.siblings))
+	for i, sibling := range p.siblings {
+		if siblings[i], err = ParseHash(sibling); err != nil {
+			return MerkleProofBytes{}, fmt.Errorf("%w: %w", ErrMalformedProof, &InvalidDigestError{Field: "sibling", Index: i, Err: err})
+		}
+	}
+
+	return MerkleProofBytes{
+		Element:    element,
+		Siblings:   siblings,
+		Directions: append([]bool(nil), p.directions...),
+		Epoch:      p.epoch,
+		Tag:        p.tag,
+		Algorithm:  p.algorithm,
+		Data:       slices.Clone(p.data),
+	}, nil
+}
+
+// Converts the proof to hex digests.
+func (p MerkleProofBytes) Proof() MerkleProof {
+	siblings := make([]string, len(p.Siblings))
+	for i, sibling := range p.Siblings {
+		siblings[i] = sibling.String()
+	}
+
+	return MerkleProof{
+		hElement:   p.Element.String(),
+		siblings:   siblings,
+		directions: append([]bool(nil), p.Directions...),
+		epoch:      p.Epoch,
+		tag:        p.Tag,
+		algorithm:  p.Algorithm,
+		data:       slices.Clone(p.Data),
+	}
+}
+
+// Returns the root the proof produces, without comparing it to anything, hashing as
+// DeriveRoot does. Only proofs of inconsistent shape produce an error.
+func (p MerkleProofBytes) Root() (Hash, error) {
+	return proofConfig(p.Tag, p.Algorithm).foldProofBytes(p)
+}
+
+func (cfg config) foldProofBytes(p MerkleProofBytes) (Hash, error) {
+	if err := checkProofShape(len(p.Siblings), len(p.Directions)); err != nil {
+		return Hash{}, err
+	}
+
+	current := p.Element
+	for i, sibling := range p.Siblings {
+		if p.Directions[i] {
+			current = cfg.nodeDigest(sibling, current)
+		} else {
+			current = cfg.nodeDigest(current, sibling)
+		}
+	}
+	return current, nil
+}
+
+// Verifies a raw digest proof against a known root, as VerifyProofHash does for a MerkleProof.
+// Nodes are hashed straight from the digests, so no hex strings are built along the way.
+func VerifyProofBytes(root Hash, proof MerkleProofBytes, opts ...Option) bool {
+	return newVerifier(opts).VerifyProofBytes(root, proof)
+}
+
+// Verifies a raw digest proof against a known root, as VerifyProofBytes does.
+func (v *Verifier) VerifyProofBytes(root Hash, proof MerkleProofBytes) bool {
+	cfg := v.cfg
+	var err error
+	if cfg.tracer != nil {
+		_, span := cfg.tracer.Start(context.Background(), spanVerifyBytes, slog.Int(attrDepth, len(proof.Siblings)))
+		defer func() { endVerifySpan(span, err) }()
+	}
+
+	err = cfg.checkTag(proof.Tag)
+	if err == nil {
+		cfg, err = cfg.forAlgorithm(proof.Algorithm)
+	}
+	if err == nil {
+		var derived Hash
+		if derived, err = cfg.foldProofBytes(proof); err == nil && derived != root {
+			err = ErrInvalidProof
+		}
+	}
+
+	if cfg.metrics != nil {
+		cfg.metrics.NodeHashed(len(proof.Siblings))
+		cfg.metrics.ProofVerified(err == nil)
+	}
+	if err != nil && cfg.debugging() {
+		if errors.Is(err, ErrInvalidProof) {
+			cfg.logVerifyFailure(root.String(), proof.Proof(), nil)
+		} else {
+			cfg.logVerifyFailure(root.String(), MerkleProof{}, err)
+		}
+	}
+
+	return err == nil
+}
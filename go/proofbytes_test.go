@@ -0,0 +1,102 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestProofBytesConversion(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(11))
+	mt.UpdateElement(4, "updated")
+
+	for i := uint64(0); i < mt.LeafCount(); i++ {
+		testname := fmt.Sprintf("raw digest proof for element: %d", i)
+		t.Run(testname, func(t *testing.T) {
+			proof, _ := mt.GetProof(i)
+			raw, err := mt.GetProofBytes(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			converted, err := proof.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(converted, raw) {
+				t.Errorf("got %v, want %v", converted, raw)
+			}
+			if back := raw.Proof(); !reflect.DeepEqual(back, proof) {
+				t.Errorf("got %v, want %v", back, proof)
+			}
+			if !VerifyProofBytes(mt.GetRootHash(), raw) {
+				t.Error("invalid proof")
+			}
+		})
+	}
+}
+
+func TestProofBytesBinaryInterop(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6))
+	mt.UpdateElement(0, "updated")
+	proof, _ := mt.GetProof(5)
+	raw, _ := mt.GetProofBytes(5)
+
+	encoded, _ := proof.MarshalBinary()
+	rawEncoded, err := raw.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, rawEncoded) {
+		t.Errorf("got %x, want %x", rawEncoded, encoded)
+	}
+
+	var decoded MerkleProofBytes
+	if err := decoded.UnmarshalBinary(encoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, raw) {
+		t.Errorf("got %v, want %v", decoded, raw)
+	}
+	if err := decoded.UnmarshalBinary(encoded[:len(encoded)-1]); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
+
+func TestProofBytesRejections(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(4))
+	proof, _ := mt.GetProof(2)
+	raw, _ := mt.GetProofBytes(2)
+
+	proof.siblings = append([]string(nil), proof.siblings...)
+	proof.siblings[1] = "not a digest"
+	_, err := proof.Bytes()
+	var invalid *InvalidDigestError
+	if !errors.Is(err, ErrMalformedProof) || !errors.As(err, &invalid) || invalid.Index != 1 {
+		t.Errorf("got %v, want an InvalidDigestError for sibling 1", err)
+	}
+
+	if VerifyProofBytes(leafDigest("other"), raw) {
+		t.Error("verified against the wrong root")
+	}
+	raw.Directions = raw.Directions[:1]
+	if VerifyProofBytes(mt.GetRootHash(), raw) {
+		t.Error("verified a malformed proof")
+	}
+	if _, err := raw.MarshalBinary(); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
+
+func BenchmarkVerifyProofBytes(b *testing.B) {
+	mt, _ := NewMerkleTree(testElements(1 << 16))
+	root := mt.GetRootHash()
+	proof, _ := mt.GetProofBytes(12345)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		VerifyProofBytes(root, proof)
+	}
+}
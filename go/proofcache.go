@@ -0,0 +1,113 @@
+package merkletree
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Optionally implemented by a MetricsSink to also count proofs looked up in the cache
+// set up by WithProofCache. Hits are not counted as ProofGenerated; misses are, as the
+// proof is then generated.
+type ProofCacheMetrics interface {
+	ProofCacheHit()  // a proof was served from the cache
+	ProofCacheMiss() // a proof was not cached at the current epoch
+}
+
+// Caches the proofs GetProof returns, keeping those of at most maxEntries of the most
+// recently proven indices, for services where a few indices take most proof requests.
+// GetProofByElement, GetProofByKey and the other lookups proving one leaf share the cache.
+// Any mutation invalidates every cached proof: a leaf changed anywhere changes a sibling
+// of every proof, at least the one below the root, so no proof survives an epoch.
+// To keep the proofs of hot indices current across mutations, Watch them instead, and
+// serve them with CurrentProof. A non-positive maxEntries disables the cache.
+func WithProofCache(maxEntries int) Option {
+	return func(cfg *config) {
+		cfg.proofCacheSize = maxEntries
+	}
+}
+
+// A bounded least-recently-used map from index to the proof generated at one epoch.
+// Proof reads share the tree's read lock, so the cache guards itself.
+type proofCache struct {
+	mu      sync.Mutex
+	epoch   uint64                   // epoch of every cached proof
+	order   *list.List               // most recently used entry at the front
+	entries map[uint64]*list.Element // values are *proofCacheEntry
+}
+
+type proofCacheEntry struct {
+	index uint64
+	proof MerkleProof
+}
+
+// Returns the cached proof of index at the epoch, dropping every proof of an earlier one.
+func (c *proofCache) get(index, epoch uint64) (MerkleProof, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.epoch != epoch {
+		c.reset(epoch)
+		return MerkleProof{}, false
+	}
+	e, ok := c.entries[index]
+	if !ok {
+		return MerkleProof{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*proofCacheEntry).proof, true
+}
+
+// Caches the proof of index, evicting the least recently used proof beyond size entries.
+// A proof of another epoch than the cache's is left out.
+func (c *proofCache) put(index uint64, proof MerkleProof, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.order == nil {
+		c.reset(proof.epoch)
+	}
+	if proof.epoch != c.epoch {
+		return
+	}
+	if e, ok := c.entries[index]; ok {
+		e.Value.(*proofCacheEntry).proof = proof
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[index] = c.order.PushFront(&proofCacheEntry{index: index, proof: proof})
+	if c.order.Len() > size {
+		oldest := c.order.Remove(c.order.Back()).(*proofCacheEntry)
+		delete(c.entries, oldest.index)
+	}
+}
+
+// Empties the cache for proofs of the epoch.
+func (c *proofCache) reset(epoch uint64) {
+	c.epoch = epoch
+	if c.order == nil {
+		c.order = list.New()
+		c.entries = make(map[uint64]*list.Element)
+		return
+	}
+	c.order.Init()
+	clear(c.entries)
+}
+
+// Returns the proof of a leaf from the cache of WithProofCache, generating and caching
+// it on a miss.
+func (t *MerkleTree) cachedProof(index uint64) MerkleProof {
+	if t.cfg.proofCacheSize <= 0 {
+		return t.proofAt(index)
+	}
+	proof, ok := t.proofs.get(index, t.epoch)
+	if m, counted := t.cfg.metrics.(ProofCacheMetrics); counted {
+		if ok {
+			m.ProofCacheHit()
+		} else {
+			m.ProofCacheMiss()
+		}
+	}
+	if !ok {
+		proof = t.proofAt(index)
+		t.proofs.put(index, proof, t.cfg.proofCacheSize)
+	}
+	return proof
+}
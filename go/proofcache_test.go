@@ -0,0 +1,118 @@
+package merkletree
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestProofCacheNeverStale(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"eager": nil,
+		"lazy":  {WithLazyRecompute()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(188))
+			metrics := &CountingMetrics{}
+			cached, _ := NewMerkleTree(testElements(13), append(opts, WithProofCache(4), WithMetrics(metrics))...)
+			reference, _ := NewMerkleTree(testElements(13), opts...)
+
+			for step := 0; step < 500; step++ {
+				switch op := rng.Intn(10); {
+				case op < 6:
+					// mostly the same few indices, as a service would prove them
+					index := uint64(rng.Intn(6))
+					if op == 0 {
+						index = uint64(rng.Intn(int(reference.LeafCount())))
+					}
+					got, _ := cached.GetProof(index)
+					want, _ := reference.GetProof(index)
+					if !reflect.DeepEqual(got, want) {
+						t.Fatalf("step %d: got a stale proof of index %d at epoch %d", step, index, got.epoch)
+					}
+				case op < 9:
+					index := uint64(rng.Intn(int(reference.LeafCount())))
+					element := fmt.Sprintf("updated-%d", step)
+					cached.UpdateElement(index, element)
+					reference.UpdateElement(index, element)
+				default:
+					element := fmt.Sprintf("appended-%d", step)
+					cached.Append(element)
+					reference.Append(element)
+				}
+			}
+			if metrics.ProofCacheHits.Load() == 0 || metrics.ProofCacheMisses.Load() == 0 {
+				t.Errorf("got %d hits and %d misses, want both", metrics.ProofCacheHits.Load(), metrics.ProofCacheMisses.Load())
+			}
+			if got, want := metrics.ProofsGenerated.Load(), metrics.ProofCacheMisses.Load(); got != want {
+				t.Errorf("got %d proofs generated, want one per miss, %d", got, want)
+			}
+		})
+	}
+}
+
+func TestProofCacheEviction(t *testing.T) {
+	metrics := &CountingMetrics{}
+	mt, _ := NewMerkleTree(testElements(8), WithProofCache(2), WithMetrics(metrics))
+	for _, index := range []uint64{0, 1, 0, 2, 0, 1} {
+		mt.GetProof(index)
+	}
+	// 1 was evicted by 2, while 0 stayed the most recently used
+	if hits, misses := metrics.ProofCacheHits.Load(), metrics.ProofCacheMisses.Load(); hits != 2 || misses != 4 {
+		t.Errorf("got %d hits and %d misses, want 2 and 4", hits, misses)
+	}
+
+	// lookups proving one leaf share the cache
+	mt.GetProofByElement("element-1")
+	if hits := metrics.ProofCacheHits.Load(); hits != 3 {
+		t.Errorf("got %d hits, want GetProofByElement served from the cache", hits)
+	}
+
+	plain, _ := NewMerkleTree(testElements(8), WithProofCache(0), WithMetrics(metrics))
+	plain.GetProof(0)
+	plain.GetProof(0)
+	if misses := metrics.ProofCacheMisses.Load(); misses != 4 {
+		t.Errorf("got %d misses, want none counted without the cache", misses)
+	}
+}
+
+func TestProofCacheConcurrentReads(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(64), WithProofCache(8))
+	roots := sync.Map{}
+	roots.Store(uint64(0), mt.GetRoot())
+
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				before := mt.Epoch()
+				proof, err := mt.GetProof(uint64((r + i) % 8))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				// the writer records each root before releasing the tree at its epoch
+				root, _ := roots.Load(proof.Epoch())
+				if proof.Epoch() < before || !VerifyProof(root.(string), proof) {
+					t.Errorf("got a proof at epoch %d, read at epoch %d or later", proof.Epoch(), before)
+					return
+				}
+			}
+		}(r)
+	}
+	for i := 0; i < 100; i++ {
+		err := mt.mutate(func(*journalRecord) error {
+			_, err := mt.updateElementSwap(uint64(i%16), fmt.Sprintf("updated-%d", i))
+			roots.Store(mt.epoch, mt.rootHash().String())
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,159 @@
+package merkletree
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"time"
+)
+
+var (
+	ErrReaderLeaf = errors.New("merkletree: tree holding elements cannot take a leaf hashed from a reader")
+	ErrLeafStream = errors.New("merkletree: hashing cannot read leaves from a stream")
+)
+
+// Creates a tree over leaves whose content is read from each reader in turn, to its end,
+// rather than held as an element: only the leaf hash of each is kept, computed as the
+// leaf hash of an element holding the same bytes, so a tree over objects too large for
+// memory proves them alongside trees built from strings. Readers opening their source on
+// first read keep a single source open at a time.
+// The content is not kept: element lookups such as GetProofByElement fail with
+// ErrElementsUnknown, as do UpdateElement and the like, so leaves are proven by index;
+// AppendFromReader appends further leaves. Under WithSetSemantics the leaves are
+// sorted and exact duplicates dropped, as for NewMerkleTreeFromRoots.
+// Fails with ErrEmptyTree for no readers, with the first read error, and with
+// ErrLeafStream under WithPoseidon and ModeSSZ, whose short leaves Append takes.
+func NewMerkleTreeFromReaders(readers []io.Reader, opts ...Option) (*MerkleTree, error) {
+	t := &MerkleTree{cfg: newConfig(opts)}
+	var start time.Time
+	if t.cfg.timed() {
+		start = time.Now()
+	}
+
+	leaves := make([]Hash, len(readers))
+	for i, r := range readers {
+		leaf, err := t.cfg.leafDigestFrom(r)
+		if err != nil {
+			return nil, fmt.Errorf("reader %d: %w", i, err)
+		}
+		leaves[i] = leaf
+	}
+	if err := t.buildLeaves(leaves); err != nil {
+		return nil, err
+	}
+
+	if t.cfg.timed() {
+		t.reportBuild(time.Since(start))
+	}
+	return t, nil
+}
+
+// Appends a leaf whose content is read from r to its end, as NewMerkleTreeFromReaders
+// hashes them, growing the tree as Append does. The content is read before the tree is
+// locked, so a slow reader holds up no one else, and only its leaf hash is kept.
+// Trees holding elements fail with ErrReaderLeaf, since the leaf has none to keep beside
+// theirs, and so do trees with WithJournal, whose records carry elements; the trees of
+// NewMerkleTreeFromRoots and ImportNodes take reader leaves too. Read errors are
+// returned as they are, leaving the tree unchanged.
+func (t *MerkleTree) AppendFromReader(r io.Reader) error {
+	if err := t.rlockBuiltAsIs(); err != nil {
+		return err
+	}
+	cfg, known := t.cfg, t.elements != nil
+	t.mu.RUnlock()
+	if known || cfg.journal != nil {
+		return ErrReaderLeaf
+	}
+
+	leaf, err := cfg.leafDigestFrom(r)
+	if err != nil {
+		return err
+	}
+	return t.mutate(func(rec *journalRecord) error {
+		if t.elements != nil {
+			return ErrReaderLeaf
+		}
+		if rec != nil {
+			*rec = journalRecord{op: journalAppend}
+		}
+		return t.appendLeafHash(leaf)
+	})
+}
+
+// Appends a leaf hash to a tree without elements, inserting it at its sorted position
+// by rebuilding under WithSetSemantics.
+func (t *MerkleTree) appendLeafHash(leaf Hash) error {
+	if t.cfg.setSemantics {
+		// the rebuild is at the minimum height, so regrow any height ExtendCapacity added
+		height := t.height()
+		if err := t.buildLeaves(append(slices.Clone(t.level(0)), leaf)); err != nil {
+			return err
+		}
+		if height > t.height() {
+			t.grow(height, t.reserved())
+		}
+		t.epoch++
+		return nil
+	}
+
+	height, err := t.appendHeight()
+	if err != nil {
+		return err
+	}
+	t.appendLeaf(height, leaf)
+	return nil
+}
+
+// Verifies that the proof commits to the content read from r to its end under root, as
+// VerifyProof does for a proof of the element holding the same bytes.
+func VerifyProofForReader(root string, r io.Reader, proof MerkleProof, opts ...Option) (bool, error) {
+	return newVerifier(opts).VerifyProofForReader(root, r, proof)
+}
+
+// Verifies that the proof commits to the content read from r, as VerifyProofForReader does.
+// Errors are the reader's, or ErrLeafStream for hashing that cannot read a stream; a
+// proof of other content, or one VerifyProof rejects, returns false alone.
+func (v *Verifier) VerifyProofForReader(root string, r io.Reader, proof MerkleProof) (bool, error) {
+	leaf, err := v.cfg.leafDigestFrom(r)
+	if err != nil {
+		return false, err
+	}
+	element, err := canonicalDigest(proof.hElement)
+	return err == nil && element == leaf.String() && v.VerifyProof(root, proof), nil
+}
+
+// Hashes the content read from r into a leaf digest, as leafDigest does for an element
+// of the same bytes, writing the tag and any RFC 6962 prefix to the hash first.
+// Backends and ModeSSZ take short elements whole, so fail with ErrLeafStream.
+func (cfg config) leafDigestFrom(r io.Reader) (Hash, error) {
+	if cfg.backend != nil || cfg.chunkLeaves {
+		return Hash{}, ErrLeafStream
+	}
+
+	var h hash.Hash
+	if cfg.keyed {
+		h = hmac.New(cfg.leafHash.newHash, []byte(cfg.hmacKey))
+		io.WriteString(h, hmacLeafInfo)
+	} else {
+		h = cfg.leafHash.newHash()
+	}
+	if cfg.tag != "" {
+		h.Write(cfg.tagDigest[:])
+	}
+	if cfg.rfc6962 {
+		h.Write([]byte{rfc6962LeafPrefix})
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return Hash{}, err
+	}
+
+	var digest Hash
+	h.Sum(digest[:0])
+	if cfg.doubleHashLeaves {
+		digest = cfg.digest(cfg.leafHash, hmacLeafInfo, digest[:])
+	}
+	return digest, nil
+}
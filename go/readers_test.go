@@ -0,0 +1,108 @@
+package merkletree
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func readersOf(elements []string) []io.Reader {
+	readers := make([]io.Reader, len(elements))
+	for i, element := range elements {
+		readers[i] = iotest.OneByteReader(strings.NewReader(element))
+	}
+	return readers
+}
+
+func TestReaderLeavesMatchElements(t *testing.T) {
+	options := map[string][]Option{
+		"default":     nil,
+		"tagged":      {WithApplicationTag("files")},
+		"rfc6962":     {WithRFC6962Hashing()},
+		"keccak":      {WithKeccak256()},
+		"blake3":      {WithBLAKE3()},
+		"sha512/256":  {WithSHA512_256()},
+		"double hash": {WithDoubleHashedLeaves()},
+		"hmac":        {WithHMACKey([]byte("secret")), WithApplicationTag("files")},
+		"set":         {WithSetSemantics()},
+	}
+	elements := append(testElements(12), strings.Repeat("large ", 1000))
+	for name, opts := range options {
+		t.Run(name, func(t *testing.T) {
+			want, _ := NewMerkleTree(elements, opts...)
+			got, err := NewMerkleTreeFromReaders(readersOf(elements), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.GetRoot() != want.GetRoot() {
+				t.Errorf("got root %s, want %s", got.GetRoot(), want.GetRoot())
+			}
+
+			proof, _ := got.GetProof(3)
+			element := want.elements[3]
+			ok, err := VerifyProofForReader(got.GetRoot(), strings.NewReader(element), proof, opts...)
+			if !ok || err != nil {
+				t.Errorf("got %v, %v, want the proof verified", ok, err)
+			}
+			if ok, err := VerifyProofForReader(got.GetRoot(), strings.NewReader("other"), proof, opts...); ok || err != nil {
+				t.Errorf("got %v, %v for other content, want false", ok, err)
+			}
+		})
+	}
+}
+
+func TestAppendFromReader(t *testing.T) {
+	elements := testElements(9)
+	want, _ := NewMerkleTree(elements[:1])
+	got, _ := NewMerkleTreeFromReaders(readersOf(elements[:1]))
+	for _, element := range elements[1:] {
+		want.Append(element)
+		if err := got.AppendFromReader(strings.NewReader(element)); err != nil {
+			t.Fatal(err)
+		}
+		if got.GetRoot() != want.GetRoot() {
+			t.Fatalf("%d leaves: got root %s, want %s", got.LeafCount(), got.GetRoot(), want.GetRoot())
+		}
+	}
+
+	set, _ := NewMerkleTreeFromReaders(readersOf(elements[:4]), WithSetSemantics())
+	set.AppendFromReader(strings.NewReader(elements[4]))
+	set.AppendFromReader(strings.NewReader(elements[0]))
+	wantSet, _ := NewMerkleTree(elements[:5], WithSetSemantics())
+	if set.GetRoot() != wantSet.GetRoot() {
+		t.Errorf("got set root %s, want %s", set.GetRoot(), wantSet.GetRoot())
+	}
+
+	// a failed read leaves the tree as it was
+	root := got.GetRoot()
+	failing := iotest.ErrReader(errors.New("disk gone"))
+	if err := got.AppendFromReader(io.MultiReader(strings.NewReader("partial"), failing)); err == nil || got.GetRoot() != root {
+		t.Errorf("got %v, root %s, want the read error and root %s", err, got.GetRoot(), root)
+	}
+}
+
+func TestReaderLeafErrors(t *testing.T) {
+	mt, _ := NewMerkleTreeFromReaders(readersOf(testElements(4)))
+	if _, err := mt.GetProofByElement("element-1"); !errors.Is(err, ErrElementsUnknown) {
+		t.Errorf("got %v, want %v", err, ErrElementsUnknown)
+	}
+	if err := mt.UpdateElement(1, "other"); !errors.Is(err, ErrElementsUnknown) {
+		t.Errorf("got %v, want %v", err, ErrElementsUnknown)
+	}
+
+	withElements, _ := NewMerkleTree(testElements(4))
+	if err := withElements.AppendFromReader(strings.NewReader("more")); !errors.Is(err, ErrReaderLeaf) {
+		t.Errorf("got %v, want %v", err, ErrReaderLeaf)
+	}
+	if _, err := NewMerkleTreeFromReaders(nil); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+	if _, err := NewMerkleTreeFromReaders(readersOf(testElements(2)), WithMode(ModeSSZ)); !errors.Is(err, ErrLeafStream) {
+		t.Errorf("got %v, want %v", err, ErrLeafStream)
+	}
+	if _, err := VerifyProofForReader(mt.GetRoot(), iotest.ErrReader(io.ErrClosedPipe), MerkleProof{}); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("got %v, want the read error", err)
+	}
+}
@@ -0,0 +1,50 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrLeafChanged = errors.New("merkletree: leaf at the proof's index has changed")
+
+// Regenerates a proof against the current tree, for a holder whose proof went stale as
+// other elements changed. The index is read from the proof's directions, and the proof
+// is only refreshed while that index still holds the leaf it proves, failing with
+// ErrLeafChanged otherwise, so the caller learns their own element changed rather than
+// getting a proof of something else. Proofs of trees that have since grown or shrunk
+// refresh at the current height.
+// Fails with ErrIndexOutOfBounds for an index the tree no longer holds, with
+// ErrDomainMismatch for a proof under another application tag, with ErrAlgorithmMismatch
+// for one hashed with another algorithm, and for malformed proofs as VerifyProofWithReason
+// does.
+func (t *MerkleTree) RefreshProof(old MerkleProof) (MerkleProof, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return MerkleProof{}, err
+	}
+	defer t.mu.RUnlock()
+
+	old, err := old.normalized()
+	if err != nil {
+		return MerkleProof{}, err
+	}
+
+	if err := t.cfg.checkTag(old.tag); err != nil {
+		return MerkleProof{}, err
+	}
+	if _, err := t.cfg.forAlgorithm(old.algorithm); err != nil {
+		return MerkleProof{}, err
+	}
+	for level := 64; level < len(old.directions); level++ {
+		if old.directions[level] {
+			return MerkleProof{}, fmt.Errorf("%w: proof reaches beyond index 2^64", ErrIndexOutOfBounds)
+		}
+	}
+	index := proofIndex(old)
+	if index >= t.leafCount() {
+		return MerkleProof{}, t.outOfBounds(index)
+	}
+	if leaf := t.node(0, index).String(); leaf != old.hElement {
+		return MerkleProof{}, fmt.Errorf("%w: index %d", ErrLeafChanged, index)
+	}
+	return t.getProof(index)
+}
@@ -0,0 +1,61 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRefreshProof(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6))
+	old, _ := mt.GetProof(2)
+
+	if err := mt.UpdateElement(4, "changed"); err != nil {
+		t.Fatal(err)
+	}
+	if VerifyProof(mt.GetRoot(), old) {
+		t.Fatal("stale proof still verifies")
+	}
+	refreshed, err := mt.RefreshProof(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyProof(mt.GetRoot(), refreshed) || refreshed.Epoch() != mt.Epoch() {
+		t.Errorf("got %+v, want a proof at epoch %d verifying against the current root", refreshed, mt.Epoch())
+	}
+
+	// grown past a power of two, the refreshed proof gains a level
+	for _, element := range testElements(3) {
+		mt.Append("appended " + element)
+	}
+	grown, err := mt.RefreshProof(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grown.siblings) != mt.Height() || !VerifyProof(mt.GetRoot(), grown) {
+		t.Errorf("got %d siblings, want a verifying proof of %d", len(grown.siblings), mt.Height())
+	}
+}
+
+func TestRefreshProofFailures(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(8))
+	proofOf2, _ := mt.GetProof(2)
+	proofOf7, _ := mt.GetProof(7)
+
+	mt.UpdateElement(2, "changed")
+	if _, err := mt.RefreshProof(proofOf2); !errors.Is(err, ErrLeafChanged) {
+		t.Errorf("got %v for a changed leaf, want ErrLeafChanged", err)
+	}
+	mt.Delete(5)
+	if _, err := mt.RefreshProof(proofOf7); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v for an index deleted from the end, want ErrIndexOutOfBounds", err)
+	}
+
+	tagged, _ := NewMerkleTree(testElements(8), WithApplicationTag("app"))
+	if _, err := tagged.RefreshProof(proofOf7); !errors.Is(err, ErrDomainMismatch) {
+		t.Errorf("got %v for another tag, want ErrDomainMismatch", err)
+	}
+	malformed := MerkleProof{hElement: proofOf7.hElement, siblings: proofOf7.siblings, directions: proofOf7.directions[:1]}
+	if _, err := mt.RefreshProof(malformed); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want ErrMalformedProof", err)
+	}
+}
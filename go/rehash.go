@@ -0,0 +1,46 @@
+package merkletree
+
+// Builds a new tree over the tree's elements under different options, as when migrating
+// a deployment to another hash function or adding an application tag. The options apply
+// on top of the tree's own, so Rehash(WithKeccak256()) changes only the hash function,
+// while WithScheme or WithMode replaces the hashing altogether. The journal set by
+// WithJournal is not carried over, nor are subscribers. Keys of a tree built with
+// NewMerkleTreeFromMap follow their pairs, and leaf data its leaf, see SetLeafData.
+// The new tree starts at epoch zero.
+// Trees restored with ImportNodes hold no elements and fail with ErrElementsUnknown.
+func (t *MerkleTree) Rehash(opts ...Option) (*MerkleTree, error) {
+	if err := t.rlockBuiltAsIs(); err != nil {
+		return nil, err
+	}
+	defer t.mu.RUnlock()
+
+	if t.elements == nil {
+		return nil, ErrElementsUnknown
+	}
+
+	cfg := t.cfg
+	cfg.journal = nil
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	rehashed := &MerkleTree{cfg: cfg}
+	var err error
+	if t.data != nil {
+		err = rehashed.buildWithData(t.elements, t.data)
+	} else {
+		err = rehashed.build(t.elements)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if t.keys != nil {
+		// pairs are distinct, so each key's pair is at exactly one index, wherever set semantics moved it
+		rehashed.keys = make(map[string]uint64, len(t.keys))
+		for key, index := range t.keys {
+			rehashed.keys[key] = rehashed.indices[t.elements[index]][0]
+		}
+	}
+	return rehashed, nil
+}
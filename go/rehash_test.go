@@ -0,0 +1,79 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRehash(t *testing.T) {
+	elements := testElements(11)
+	cases := []struct {
+		name string
+		base []Option
+		opts []Option
+		want []Option // options of the fresh build Rehash must match
+	}{
+		{"keccak", nil, []Option{WithKeccak256()}, []Option{WithKeccak256()}},
+		{"tag", []Option{WithFixedDepth(6)}, []Option{WithApplicationTag("app")}, []Option{WithFixedDepth(6), WithApplicationTag("app")}},
+		{"mode", []Option{WithKeccak256()}, []Option{WithMode(ModeBitcoin)}, []Option{WithMode(ModeBitcoin)}},
+		{"set semantics", []Option{WithSetSemantics()}, []Option{WithRawNodeHashing()}, []Option{WithSetSemantics(), WithRawNodeHashing()}},
+		{"scheme", []Option{WithMode(ModeSSZ)}, []Option{WithScheme(Scheme{})}, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(elements, c.base...)
+			before := mt.GetRoot()
+
+			rehashed, err := mt.Rehash(c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fresh, _ := NewMerkleTree(elements, c.want...)
+			if rehashed.GetRoot() != fresh.GetRoot() {
+				t.Errorf("got %s, want %s", rehashed.GetRoot(), fresh.GetRoot())
+			}
+			if mt.GetRoot() != before {
+				t.Errorf("original changed to %s", mt.GetRoot())
+			}
+
+			proof, _ := rehashed.GetProof(4)
+			if !VerifyProof(rehashed.GetRoot(), proof, c.want...) {
+				t.Error("proof of the rehashed tree failed to verify under the new options")
+			}
+		})
+	}
+}
+
+func TestRehashKeys(t *testing.T) {
+	m := map[string]string{"alice": "1", "bob": "2", "carol": "3"}
+	mt, _ := NewMerkleTreeFromMap(m)
+
+	rehashed, err := mt.Rehash(WithSetSemantics(), WithKeccak256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range m {
+		proof, err := rehashed.GetProofForKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyKeyValueProof(rehashed.GetRoot(), key, value, proof, WithKeccak256()) {
+			t.Errorf("proof for %s failed to verify", key)
+		}
+	}
+}
+
+func TestRehashFailures(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(4))
+	layout, nodes := mt.ExportNodes()
+	imported, _ := ImportNodes(layout, nodes)
+	if _, err := imported.Rehash(WithKeccak256()); !errors.Is(err, ErrElementsUnknown) {
+		t.Errorf("got %v, want %v", err, ErrElementsUnknown)
+	}
+
+	preset, _ := NewMerkleTree(testElements(4), WithMode(ModeOpenZeppelin))
+	if _, err := preset.Rehash(WithApplicationTag("app")); !errors.Is(err, ErrModeConflict) {
+		t.Errorf("got %v, want %v", err, ErrModeConflict)
+	}
+}
@@ -0,0 +1,72 @@
+package merkletree
+
+import "fmt"
+
+const (
+	rfc6962LeafPrefix = 0x00 // prefixed to every leaf under RFC 6962 hashing
+	rfc6962NodePrefix = 0x01 // prefixed to every pair of children under RFC 6962 hashing
+)
+
+// Hashes as RFC 6962 (Certificate Transparency) does: a leaf as sha256(0x00 | element)
+// and a node as sha256(0x01 | left | right) over the raw child digests.
+// Proofs from a log, see ParseCTInclusionProof, verify under this option. A tree built
+// with it pads to a power of two rather than splitting as RFC 6962 does, so its root
+// only matches a log's for power-of-two sizes.
+func WithRFC6962Hashing() Option {
+	return func(cfg *config) {
+		cfg.rfc6962 = true
+	}
+}
+
+// Converts an RFC 6962 inclusion proof, as returned by a Certificate Transparency log's
+// get-proof-by-hash with the audit path base64-decoded, into a MerkleProof for the leaf hash.
+// The directions follow from the leaf's index in a tree of treeSize leaves, which
+// RFC 6962 splits at the largest power of two below each subtree's size.
+// Verify the result with WithRFC6962Hashing against the log's root hash.
+func ParseCTInclusionProof(leafHash []byte, leafIndex uint64, treeSize uint64, auditPath [][]byte) (MerkleProof, error) {
+	if leafIndex >= treeSize {
+		return MerkleProof{}, fmt.Errorf("%w: leaf %d in a tree of %d", ErrIndexOutOfBounds, leafIndex, treeSize)
+	}
+	if len(leafHash) != digestSize {
+		return MerkleProof{}, fmt.Errorf("%w: leaf hash has %d bytes, want %d", ErrMalformedProof, len(leafHash), digestSize)
+	}
+	if len(auditPath) > maxProofDepth {
+		return MerkleProof{}, fmt.Errorf("%w: audit path of %d hashes", ErrMalformedProof, len(auditPath))
+	}
+
+	proof := MerkleProof{
+		hElement:   Hash(leafHash).String(),
+		siblings:   make([]string, 0, len(auditPath)),
+		directions: make([]bool, 0, len(auditPath)),
+	}
+
+	// walks up from the leaf as RFC 9162 section 2.1.3.2 verifies: index and last are
+	// the positions of the current node and of the last node on its level
+	index, last := leafIndex, treeSize-1
+	for i, sibling := range auditPath {
+		if len(sibling) != digestSize {
+			return MerkleProof{}, fmt.Errorf("%w: audit path hash %d has %d bytes, want %d", ErrMalformedProof, i, len(sibling), digestSize)
+		}
+		if last == 0 {
+			return MerkleProof{}, fmt.Errorf("%w: audit path of %d hashes is too long for leaf %d of %d", ErrMalformedProof, len(auditPath), leafIndex, treeSize)
+		}
+
+		left := index&1 == 1 || index == last
+		if left && index&1 == 0 {
+			// a right edge node without a sibling on its own level is carried up unchanged
+			for index&1 == 0 && index != 0 {
+				index >>= 1
+				last >>= 1
+			}
+		}
+		proof.siblings = append(proof.siblings, Hash(sibling).String())
+		proof.directions = append(proof.directions, left)
+		index >>= 1
+		last >>= 1
+	}
+
+	if last != 0 {
+		return MerkleProof{}, fmt.Errorf("%w: audit path of %d hashes is too short for leaf %d of %d", ErrMalformedProof, len(auditPath), leafIndex, treeSize)
+	}
+	return proof, nil
+}
@@ -0,0 +1,151 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// The leaves and roots of the RFC 6962 reference test vectors of the Certificate Transparency
+// implementations; ctRoots[n-1] is the root of the first n leaves.
+var (
+	ctLeaves = []string{
+		"", "\x00", "\x10", "\x20\x21", "\x30\x31", "\x40\x41\x42\x43",
+		"\x50\x51\x52\x53\x54\x55\x56\x57",
+		"\x60\x61\x62\x63\x64\x65\x66\x67\x68\x69\x6a\x6b\x6c\x6d\x6e\x6f",
+	}
+	ctRoots = []string{
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+)
+
+func TestParseCTInclusionProofGolden(t *testing.T) {
+	// audit paths of the reference vectors
+	for _, c := range []struct {
+		index, size uint64
+		path        []string
+	}{
+		{0, 8, []string{
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+		}},
+		{5, 8, []string{
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		}},
+		{2, 5, []string{
+			"07506a85fd9dd2f120eb694f86011e5bb4662e5c415a62917033d4a9624487e7",
+			"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		}},
+		{0, 1, nil},
+	} {
+		testname := fmt.Sprintf("leaf %d of %d", c.index, c.size)
+		t.Run(testname, func(t *testing.T) {
+			path := make([][]byte, len(c.path))
+			for i, sibling := range c.path {
+				path[i], _ = hex.DecodeString(sibling)
+			}
+			leafHash := ctLeafHash(ctLeaves[c.index])
+
+			proof, err := ParseCTInclusionProof(leafHash[:], c.index, c.size, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root := ctRoots[c.size-1]
+			if err := VerifyProofWithReason(root, proof, WithRFC6962Hashing()); err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+			if c.size > 1 && VerifyProof(root, proof) {
+				t.Error("verified without RFC 6962 hashing")
+			}
+		})
+	}
+}
+
+func TestParseCTInclusionProofShapes(t *testing.T) {
+	for size := uint64(1); size <= uint64(len(ctLeaves)); size++ {
+		for index := uint64(0); index < size; index++ {
+			testname := fmt.Sprintf("leaf %d of %d", index, size)
+			t.Run(testname, func(t *testing.T) {
+				path := ctAuditPath(index, ctLeaves[:size])
+				leafHash := ctLeafHash(ctLeaves[index])
+
+				proof, err := ParseCTInclusionProof(leafHash[:], index, size, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !VerifyProof(ctRoots[size-1], proof, WithRFC6962Hashing()) {
+					t.Error("failed to verify")
+				}
+
+				if _, err := ParseCTInclusionProof(leafHash[:], index, size, append(path, leafHash[:])); !errors.Is(err, ErrMalformedProof) {
+					t.Errorf("got %v, want %v", err, ErrMalformedProof)
+				}
+				if len(path) > 0 {
+					if _, err := ParseCTInclusionProof(leafHash[:], index, size, path[1:]); !errors.Is(err, ErrMalformedProof) {
+						t.Errorf("got %v, want %v", err, ErrMalformedProof)
+					}
+				}
+			})
+		}
+	}
+
+	leafHash := ctLeafHash("")
+	if _, err := ParseCTInclusionProof(leafHash[:], 3, 3, nil); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	if _, err := ParseCTInclusionProof(leafHash[:4], 0, 1, nil); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
+
+func TestRFC6962HashingTree(t *testing.T) {
+	// at a power-of-two size padding plays no part, so the root is the log's
+	mt, _ := NewMerkleTree(ctLeaves, WithRFC6962Hashing())
+	if mt.GetRoot() != ctRoots[7] {
+		t.Errorf("got %s, want %s", mt.GetRoot(), ctRoots[7])
+	}
+	proof, _ := mt.GetProof(6)
+	if !VerifyProof(ctRoots[7], proof, WithRFC6962Hashing()) {
+		t.Error("failed to verify")
+	}
+}
+
+func ctLeafHash(leaf string) Hash {
+	return sha256.Sum256(append([]byte{0x00}, leaf...))
+}
+
+func ctSubtreeHash(leaves []string) Hash {
+	if len(leaves) == 1 {
+		return ctLeafHash(leaves[0])
+	}
+	k := splitPoint(uint64(len(leaves)))
+	left, right := ctSubtreeHash(leaves[:k]), ctSubtreeHash(leaves[k:])
+	return sha256.Sum256(append(append([]byte{0x01}, left[:]...), right[:]...))
+}
+
+// Returns the RFC 6962 audit path of the leaf at index, from the leaf up.
+func ctAuditPath(index uint64, leaves []string) [][]byte {
+	if len(leaves) == 1 {
+		return nil
+	}
+	k := splitPoint(uint64(len(leaves)))
+	if index < k {
+		sibling := ctSubtreeHash(leaves[k:])
+		return append(ctAuditPath(index, leaves[:k]), sibling[:])
+	}
+	sibling := ctSubtreeHash(leaves[:k])
+	return append(ctAuditPath(index-k, leaves[k:]), sibling[:])
+}
@@ -0,0 +1,47 @@
+package merkletree
+
+import (
+	"crypto/subtle"
+	"strings"
+)
+
+// Verifies a Merkle proof against any of several acceptable roots, such as the previous
+// and current root during a rotation, returning the first of them it matches.
+// The proof is checked and folded once, then compared in constant time with every root.
+// Roots which are not valid hashes never match; an empty roots slice always fails.
+func VerifyProofAgainstRoots(roots []string, proof MerkleProof, opts ...Option) (matchedRoot string, ok bool) {
+	cfg := newConfig(opts)
+
+	proof, err := proof.normalized()
+	if err == nil {
+		err = cfg.checkTag(proof.tag)
+	}
+	if err == nil {
+		cfg, err = cfg.forAlgorithm(proof.algorithm)
+	}
+	var derived Hash
+	if err == nil {
+		derived, err = ParseHash(cfg.foldProof(proof))
+	}
+
+	if err == nil {
+		for _, root := range roots {
+			candidate, err := parseRoot(root)
+			if err == nil && subtle.ConstantTimeCompare(candidate[:], derived[:]) == 1 && !ok {
+				matchedRoot, ok = root, true
+			}
+		}
+	}
+
+	if cfg.metrics != nil {
+		cfg.metrics.NodeHashed(len(proof.siblings))
+		cfg.metrics.ProofVerified(ok)
+	}
+	if !ok {
+		if err == nil {
+			err = ErrInvalidProof
+		}
+		cfg.logVerifyFailure(strings.Join(roots, ","), proof, err)
+	}
+	return matchedRoot, ok
+}
@@ -0,0 +1,37 @@
+package merkletree
+
+import "testing"
+
+func TestVerifyProofAgainstRoots(t *testing.T) {
+	previous, _ := NewMerkleTree(testElements(6))
+	current, _ := NewMerkleTree(testElements(7))
+	other, _ := NewMerkleTree(testElements(8))
+	proof, _ := current.GetProof(3)
+
+	tests := []struct {
+		name  string
+		roots []string
+		want  string
+		ok    bool
+	}{
+		{"second root", []string{previous.GetRoot(), current.GetRoot()}, current.GetRoot(), true},
+		{"no root", []string{previous.GetRoot(), other.GetRoot()}, "", false},
+		{"duplicated root", []string{current.GetRoot(), current.GetRoot()}, current.GetRoot(), true},
+		{"invalid root before the match", []string{"not a root", "0x" + current.GetRoot()}, "0x" + current.GetRoot(), true},
+		{"empty roots", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, ok := VerifyProofAgainstRoots(tt.roots, proof)
+			if matched != tt.want || ok != tt.ok {
+				t.Errorf("got %q, %v, want %q, %v", matched, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	malformed := proof
+	malformed.directions = malformed.directions[1:]
+	if _, ok := VerifyProofAgainstRoots([]string{current.GetRoot()}, malformed); ok {
+		t.Error("verified a malformed proof")
+	}
+}
@@ -0,0 +1,239 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"math/big"
+)
+
+// Describes how a tree hashes, in a form producers can serialize and ship alongside
+// their proofs so that verifiers configure themselves to match.
+// The zero Scheme is the default hashing.
+type Scheme struct {
+	Mode        Mode   `json:"mode,omitempty"`        // preset the options below were set by, see WithMode
+	Tag         string `json:"tag,omitempty"`         // application tag, see WithApplicationTag
+	EmptyLeaf   []byte `json:"emptyLeaf,omitempty"`   // element of padding slots, see WithEmptyLeaf
+	RFC6962     bool   `json:"rfc6962,omitempty"`     // see WithRFC6962Hashing
+	RawNodes    bool   `json:"rawNodes,omitempty"`    // see WithRawNodeHashing
+	SortedPairs bool   `json:"sortedPairs,omitempty"` // see WithSortedPairs
+	Keccak256   bool   `json:"keccak256,omitempty"`   // see WithKeccak256
+	DoubleHash  bool   `json:"doubleHash,omitempty"`  // see WithDoubleHashedLeaves
+	BLAKE3      bool   `json:"blake3,omitempty"`      // see WithBLAKE3; never set with Keccak256
+
+	Poseidon *PoseidonParams `json:"poseidon,omitempty"` // see WithPoseidon, which replaces the hashing the other fields set
+
+	// Hash functions of leaves and of nodes when they differ, see WithLeafHasher, or when
+	// they are SHA-512/256, in place of Keccak256 and BLAKE3, which describe both alike
+	// and are then unset.
+	LeafHash HashFunction `json:"leafHash,omitempty"`
+	NodeHash HashFunction `json:"nodeHash,omitempty"`
+}
+
+const (
+	schemeTagFlag         = 0x01 // set when a tag follows the flags
+	schemeEmptyLeafFlag   = 0x02 // set when a padding element follows any tag
+	schemeRFC6962Flag     = 0x04
+	schemeRawNodesFlag    = 0x08
+	schemeSortedPairsFlag = 0x10
+	schemeKeccak256Flag   = 0x20
+	schemeDoubleHashFlag  = 0x40
+	schemeModeFlag        = 0x80 // set when a mode byte follows any padding element
+
+	schemeBLAKE3Bit   = 0x80 // set in the mode byte to hash with BLAKE3, whatever the mode
+	schemePoseidonBit = 0x40 // set in the mode byte when Poseidon parameters follow it
+	schemeHashesBit   = 0x20 // set in the mode byte when the leaf and node hash functions follow it
+)
+
+// Hashes as the scheme describes, overriding the options it covers.
+// A scheme with a Mode sets its preset first, so fields that disagree with it
+// make constructors fail with ErrModeConflict.
+func WithScheme(s Scheme) Option {
+	return func(cfg *config) {
+		if s.Mode != ModeDefault {
+			WithMode(s.Mode)(cfg)
+		} else {
+			cfg.hashing = hashing{}
+			cfg.mode, cfg.presetMode = ModeDefault, false
+		}
+		cfg.setTag(s.Tag)
+		cfg.emptyLeaf = string(s.EmptyLeaf)
+		cfg.rfc6962 = s.RFC6962
+		cfg.rawNodeHashing = s.RawNodes
+		cfg.sortedPairs = s.SortedPairs
+		switch {
+		case s.LeafHash != HashSHA256 || s.NodeHash != HashSHA256:
+			cfg.leafHash, cfg.nodeHash = s.LeafHash, s.NodeHash
+		case s.BLAKE3:
+			cfg.setHashFunction(HashBLAKE3)
+		case s.Keccak256:
+			cfg.setHashFunction(HashKeccak256)
+		default:
+			cfg.setHashFunction(HashSHA256)
+		}
+		cfg.doubleHashLeaves = s.DoubleHash
+		if s.Poseidon != nil {
+			WithPoseidon(*s.Poseidon)(cfg)
+		}
+	}
+}
+
+// Returns the hashing scheme of the tree.
+func (t *MerkleTree) Scheme() Scheme {
+	if t.rlockBuiltAsIs() != nil {
+		return Scheme{}
+	}
+	defer t.mu.RUnlock()
+	return t.cfg.scheme()
+}
+
+func (cfg config) scheme() Scheme {
+	s := Scheme{
+		Mode:        cfg.mode,
+		Tag:         cfg.tag,
+		RFC6962:     cfg.rfc6962,
+		RawNodes:    cfg.rawNodeHashing,
+		SortedPairs: cfg.sortedPairs,
+		DoubleHash:  cfg.doubleHashLeaves,
+	}
+	switch {
+	case cfg.leafHash != cfg.nodeHash || cfg.leafHash == HashSHA512_256:
+		s.LeafHash, s.NodeHash = cfg.leafHash, cfg.nodeHash
+	case cfg.leafHash == HashKeccak256:
+		s.Keccak256 = true
+	case cfg.leafHash == HashBLAKE3:
+		s.BLAKE3 = true
+	}
+	if cfg.emptyLeaf != "" {
+		s.EmptyLeaf = []byte(cfg.emptyLeaf)
+	}
+	if p, ok := cfg.backend.(*poseidon); ok {
+		params := p.params
+		params.Modulus = new(big.Int).Set(p.params.Modulus)
+		s.Poseidon = &params
+	}
+	return s
+}
+
+// Appends the scheme in the binary formats embedding it:
+//
+//	flags (1 byte) | [tag length (uvarint) | tag] | [padding element length (uvarint) | padding element] | [mode (1 byte)]
+//	| [Poseidon modulus (32 bytes, big-endian) | full rounds (uvarint) | partial rounds (uvarint)]
+//	| [leaf hash function << 4 | node hash function (1 byte)]
+func appendScheme(out []byte, s Scheme) []byte {
+	var flags byte
+	if s.Tag != "" {
+		flags |= schemeTagFlag
+	}
+	if len(s.EmptyLeaf) > 0 {
+		flags |= schemeEmptyLeafFlag
+	}
+	if s.RFC6962 {
+		flags |= schemeRFC6962Flag
+	}
+	if s.RawNodes {
+		flags |= schemeRawNodesFlag
+	}
+	if s.SortedPairs {
+		flags |= schemeSortedPairsFlag
+	}
+	if s.Keccak256 {
+		flags |= schemeKeccak256Flag
+	}
+	if s.DoubleHash {
+		flags |= schemeDoubleHashFlag
+	}
+	hashes := s.LeafHash != HashSHA256 || s.NodeHash != HashSHA256
+	if s.Mode != ModeDefault || s.BLAKE3 || s.Poseidon != nil || hashes {
+		flags |= schemeModeFlag
+	}
+
+	out = append(out, flags)
+	if s.Tag != "" {
+		out = binary.AppendUvarint(out, uint64(len(s.Tag)))
+		out = append(out, s.Tag...)
+	}
+	if len(s.EmptyLeaf) > 0 {
+		out = binary.AppendUvarint(out, uint64(len(s.EmptyLeaf)))
+		out = append(out, s.EmptyLeaf...)
+	}
+	if s.Mode != ModeDefault || s.BLAKE3 || s.Poseidon != nil || hashes {
+		mode := byte(s.Mode)
+		if s.BLAKE3 {
+			mode |= schemeBLAKE3Bit
+		}
+		if s.Poseidon != nil {
+			mode |= schemePoseidonBit
+		}
+		if hashes {
+			mode |= schemeHashesBit
+		}
+		out = append(out, mode)
+	}
+	if p := s.Poseidon; p != nil {
+		var modulus Hash
+		if p.Modulus != nil && p.Modulus.Sign() >= 0 && p.Modulus.BitLen() <= 8*digestSize {
+			modulus = fieldDigest(p.Modulus)
+		}
+		out = append(out, modulus[:]...)
+		out = binary.AppendUvarint(out, uint64(max(p.FullRounds, 0)))
+		out = binary.AppendUvarint(out, uint64(max(p.PartialRounds, 0)))
+	}
+	if hashes {
+		out = append(out, byte(s.LeafHash)<<4|byte(s.NodeHash)&0x0f)
+	}
+	return out
+}
+
+// Reads a scheme written by appendScheme.
+func (r *byteReader) scheme() Scheme {
+	flags := r.byte()
+
+	s := Scheme{
+		RFC6962:     flags&schemeRFC6962Flag != 0,
+		RawNodes:    flags&schemeRawNodesFlag != 0,
+		SortedPairs: flags&schemeSortedPairsFlag != 0,
+		Keccak256:   flags&schemeKeccak256Flag != 0,
+		DoubleHash:  flags&schemeDoubleHashFlag != 0,
+	}
+	if flags&schemeTagFlag != 0 {
+		if s.Tag = string(r.bytes(int(r.uvarint(uint64(len(r.data)))))); s.Tag == "" {
+			r.fail("tag flag set for an empty tag")
+		}
+	}
+	if flags&schemeEmptyLeafFlag != 0 {
+		if s.EmptyLeaf = append([]byte(nil), r.bytes(int(r.uvarint(uint64(len(r.data)))))...); len(s.EmptyLeaf) == 0 {
+			r.fail("padding element flag set for an empty element")
+		}
+	}
+	if flags&schemeModeFlag != 0 {
+		mode := r.byte()
+		s.Mode, s.BLAKE3 = Mode(mode&^(schemeBLAKE3Bit|schemePoseidonBit|schemeHashesBit)), mode&schemeBLAKE3Bit != 0
+		poseidon, hashes := mode&schemePoseidonBit != 0, mode&schemeHashesBit != 0
+		if (s.Mode == ModeDefault && !s.BLAKE3 && !poseidon && !hashes) || !s.Mode.valid() {
+			r.fail("mode flag set for the default or an unknown mode")
+		}
+		if (s.Keccak256 && s.BLAKE3) || (s.Keccak256 && poseidon) || (s.BLAKE3 && poseidon) {
+			r.fail("more than one hash function set")
+		}
+		if poseidon {
+			modulus := r.digest()
+			s.Poseidon = &PoseidonParams{
+				Modulus:       new(big.Int).SetBytes(modulus[:]),
+				FullRounds:    int(r.uvarint(1 << 10)),
+				PartialRounds: int(r.uvarint(1 << 10)),
+			}
+		}
+		if hashes {
+			functions := r.byte()
+			s.LeafHash, s.NodeHash = HashFunction(functions>>4), HashFunction(functions&0x0f)
+			switch {
+			case !s.LeafHash.valid() || !s.NodeHash.valid():
+				r.fail("unknown hash function")
+			case s.LeafHash == s.NodeHash && s.LeafHash != HashSHA512_256:
+				r.fail("hash functions set for leaves and nodes alike")
+			case s.Keccak256 || s.BLAKE3 || poseidon:
+				r.fail("more than one hash function set")
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+var ErrSetOrder = errors.New("merkletree: operation would break the leaf hash order of a set")
+
+// Commits to the elements as a set: leaves are sorted by leaf hash and exact duplicates
+// dropped before building, so the same members give the same root in any order.
+// Indices, for GetProof and IndexOf, are positions in that sorted order.
+// UpdateElement and Swap would break the order and fail with ErrSetOrder;
+// Apply, Append and Reset re-sort instead. IncrementalBuilder cannot sort and rejects the option.
+func WithSetSemantics() Option {
+	return func(cfg *config) {
+		cfg.setSemantics = true
+	}
+}
+
+// Returns the distinct elements ordered by leaf hash, along with their leaf hashes.
+func (cfg config) setOrder(elements []string) ([]string, []Hash) {
+	type member struct {
+		digest  Hash
+		element string
+	}
+	members := make([]member, len(elements))
+	for i, element := range elements {
+		members[i] = member{cfg.leafDigest(element), element}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return bytes.Compare(members[i].digest[:], members[j].digest[:]) < 0
+	})
+
+	sorted := make([]string, 0, len(members))
+	digests := make([]Hash, 0, len(members))
+	for i, m := range members {
+		if i > 0 && m.digest == members[i-1].digest {
+			continue
+		}
+		sorted = append(sorted, m.element)
+		digests = append(digests, m.digest)
+	}
+	return sorted, digests
+}
@@ -0,0 +1,102 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSetSemanticsIgnoresOrder(t *testing.T) {
+	members := testElements(11)
+	mt, _ := NewMerkleTree(members, WithSetSemantics())
+
+	for shift := 1; shift < len(members); shift += 3 {
+		testname := fmt.Sprintf("rotation by %d with a duplicate", shift)
+		t.Run(testname, func(t *testing.T) {
+			permuted := append(append([]string(nil), members[shift:]...), members[:shift]...)
+			permuted = append(permuted, members[shift])
+
+			other, _ := NewMerkleTree(permuted, WithSetSemantics())
+			if other.GetRoot() != mt.GetRoot() {
+				t.Errorf("got %s, want %s", other.GetRoot(), mt.GetRoot())
+			}
+			if other.LeafCount() != uint64(len(members)) {
+				t.Errorf("got %d leaves, want %d", other.LeafCount(), len(members))
+			}
+		})
+	}
+
+	different := append(append([]string(nil), members[1:]...), "someone else")
+	other, _ := NewMerkleTree(different, WithSetSemantics())
+	if other.GetRoot() == mt.GetRoot() {
+		t.Error("a different member produced the same root")
+	}
+}
+
+func TestSetSemanticsSortsByLeafHash(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6), WithSetSemantics())
+	previous := Hash{}
+	for i := uint64(0); i < mt.LeafCount(); i++ {
+		leaf := mt.nodes[mt.nodeIndex(0, i)]
+		if i > 0 && leaf.String() <= previous.String() {
+			t.Errorf("leaf %d is not above leaf %d", i, i-1)
+		}
+		previous = leaf
+	}
+
+	built, _ := NewBuilder(6, WithSetSemantics()).Build(testElements(6))
+	if built.GetRoot() != mt.GetRoot() {
+		t.Errorf("got %s, want %s", built.GetRoot(), mt.GetRoot())
+	}
+}
+
+func TestSetSemanticsProofs(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(7), WithSetSemantics())
+
+	for _, element := range testElements(7) {
+		proof, err := mt.GetProofByElement(element)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.hElement != hashLeaf(element) || !VerifyProof(mt.GetRoot(), proof) {
+			t.Errorf("invalid proof for %s", element)
+		}
+	}
+	if _, err := mt.GetProofByElement("missing"); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("got %v, want %v", err, ErrElementNotFound)
+	}
+
+	pairs, _ := NewMerkleTreeFromMap(map[string]string{"a": "1", "b": "2", "c": "3"}, WithSetSemantics())
+	proof, _ := pairs.GetProofForKey("b")
+	if !VerifyKeyValueProof(pairs.GetRoot(), "b", "2", proof) {
+		t.Error("invalid proof for key b")
+	}
+}
+
+func TestSetSemanticsMutations(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5), WithSetSemantics())
+
+	if err := mt.UpdateElement(0, "new"); !errors.Is(err, ErrSetOrder) {
+		t.Errorf("got %v, want %v", err, ErrSetOrder)
+	}
+	if err := mt.Swap(0, 1); !errors.Is(err, ErrSetOrder) {
+		t.Errorf("got %v, want %v", err, ErrSetOrder)
+	}
+	if err := NewIncrementalBuilder(WithSetSemantics()).Add("a"); !errors.Is(err, ErrSetOrder) {
+		t.Errorf("got %v, want %v", err, ErrSetOrder)
+	}
+
+	err := mt.Apply(func(index uint64, element string) (string, error) {
+		if element == "element-2" {
+			return "element-0", nil
+		}
+		return element, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := NewMerkleTree([]string{"element-4", "element-3", "element-1", "element-0"}, WithSetSemantics())
+	if mt.GetRoot() != expected.GetRoot() || mt.LeafCount() != 4 {
+		t.Errorf("got %s over %d leaves, want %s over 4", mt.GetRoot(), mt.LeafCount(), expected.GetRoot())
+	}
+}
@@ -0,0 +1,108 @@
+package merkletree
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+)
+
+// Yields elements one at a time on demand, as a database cursor or file scanner does.
+// Next returns the next element with ok set, or ok unset once the source is exhausted.
+// A non-nil error ends the source, whatever ok and element hold.
+type LeafSource interface {
+	Next() (element string, ok bool, err error)
+}
+
+// Reports the failure of a LeafSource while yielding the element at Leaf, counted
+// from zero; elements before it were received.
+type SourceError struct {
+	Leaf uint64
+	Err  error // returned by the source's Next
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("merkletree: leaf source failed at leaf %d: %v", e.Leaf, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// Builds the tree NewMerkleTree would over the elements src yields, pulling each in turn
+// and appending it as NewMerkleTreeFromChannel does, until src is exhausted.
+// A source yielding nothing fails with ErrEmptyTree, and one failing midway with a
+// *SourceError wrapping its error. ctx is checked before every element, failing with
+// context.Cause(ctx) once it is done; a source blocking in Next has to watch ctx itself.
+func NewMerkleTreeFromSource(ctx context.Context, src LeafSource, opts ...Option) (*MerkleTree, error) {
+	return buildStreamed(ctx, spanFromSource, opts, func(add func(element string) error) error {
+		return drain(ctx, src, add)
+	})
+}
+
+// Computes the root NewMerkleTreeFromSource would produce for the elements src yields,
+// through an IncrementalBuilder, without building the tree, failing as it does.
+// Options with WithSetSemantics fail with ErrSetOrder.
+func ComputeRootFromSource(ctx context.Context, src LeafSource, opts ...Option) (string, error) {
+	b := NewIncrementalBuilder(opts...)
+	if err := drain(ctx, src, b.Add); err != nil {
+		return "", err
+	}
+	return b.Root()
+}
+
+// Passes every element src yields to add until it is exhausted, failing with add's
+// first error, the source's wrapped in a *SourceError, or the cause of ctx once it is done.
+func drain(ctx context.Context, src LeafSource, add func(element string) error) error {
+	for leaf := uint64(0); ; leaf++ {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		element, ok, err := src.Next()
+		if err != nil {
+			return &SourceError{Leaf: leaf, Err: err}
+		}
+		if !ok {
+			return nil
+		}
+		if err := add(element); err != nil {
+			return err
+		}
+	}
+}
+
+// Returns a LeafSource yielding the elements in order. The slice is not copied and
+// must not change while the source is drained.
+func SliceSource(elements []string) LeafSource {
+	return &sliceSource{elements: elements}
+}
+
+type sliceSource struct {
+	elements []string
+	next     int
+}
+
+func (s *sliceSource) Next() (string, bool, error) {
+	if s.next == len(s.elements) {
+		return "", false, nil
+	}
+	s.next++
+	return s.elements[s.next-1], true, nil
+}
+
+// Returns a LeafSource yielding every token the scanner scans, each line of its input
+// unless another split function is set, and failing with the scanner's error, such as
+// bufio.ErrTooLong for a line beyond its buffer; see bufio.Scanner.Buffer.
+func ScannerSource(s *bufio.Scanner) LeafSource {
+	return scannerSource{s}
+}
+
+type scannerSource struct {
+	s *bufio.Scanner
+}
+
+func (s scannerSource) Next() (string, bool, error) {
+	if s.s.Scan() {
+		return s.s.Text(), true, nil
+	}
+	return "", false, s.s.Err()
+}
@@ -0,0 +1,123 @@
+package merkletree
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// Yields the elements, then fails with err in place of the next.
+type failingSource struct {
+	elements []string
+	err      error
+}
+
+func (s *failingSource) Next() (string, bool, error) {
+	if len(s.elements) == 0 {
+		return "", false, s.err
+	}
+	element := s.elements[0]
+	s.elements = s.elements[1:]
+	return element, true, nil
+}
+
+func TestNewMerkleTreeFromSource(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithSetSemantics()}, {WithLazyRecompute()}} {
+		for _, n := range []int{1, 2, 7, 33} {
+			elements := testElements(n)
+			want, _ := NewMerkleTree(elements, opts...)
+
+			mt, err := NewMerkleTreeFromSource(context.Background(), SliceSource(elements), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mt.GetRoot() != want.GetRoot() || mt.LeafCount() != want.LeafCount() || mt.Epoch() != 0 {
+				t.Errorf("%d elements: got %s over %d at epoch %d, want %s over %d at epoch 0",
+					n, mt.GetRoot(), mt.LeafCount(), mt.Epoch(), want.GetRoot(), want.LeafCount())
+			}
+
+			lines := bufio.NewScanner(strings.NewReader(strings.Join(elements, "\n") + "\n"))
+			scanned, err := NewMerkleTreeFromSource(context.Background(), ScannerSource(lines), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scanned.GetRoot() != want.GetRoot() {
+				t.Errorf("%d lines: got %s, want %s", n, scanned.GetRoot(), want.GetRoot())
+			}
+		}
+	}
+
+	root, err := ComputeRootFromSource(context.Background(), SliceSource(testElements(9)))
+	want, _ := NewMerkleTree(testElements(9))
+	if err != nil || root != want.GetRoot() {
+		t.Errorf("got %s, %v, want %s", root, err, want.GetRoot())
+	}
+	if _, err := NewMerkleTreeFromSource(context.Background(), SliceSource(nil)); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+}
+
+func TestNewMerkleTreeFromSourceErrors(t *testing.T) {
+	cause := errors.New("cursor closed")
+	for _, opts := range [][]Option{nil, {WithSetSemantics()}} {
+		_, err := NewMerkleTreeFromSource(context.Background(), &failingSource{testElements(5), cause}, opts...)
+		var sourceErr *SourceError
+		if !errors.Is(err, cause) || !errors.As(err, &sourceErr) || sourceErr.Leaf != 5 {
+			t.Errorf("got %v, want %v at leaf 5", err, cause)
+		}
+	}
+	if _, err := ComputeRootFromSource(context.Background(), &failingSource{testElements(3), cause}); !errors.Is(err, cause) {
+		t.Errorf("got %v, want %v", err, cause)
+	}
+
+	failing := bufio.NewScanner(iotest.ErrReader(cause))
+	if _, err := NewMerkleTreeFromSource(context.Background(), ScannerSource(failing)); !errors.Is(err, cause) {
+		t.Errorf("got %v, want %v", err, cause)
+	}
+	long := bufio.NewScanner(strings.NewReader("short\n" + strings.Repeat("x", 64) + "\n"))
+	long.Buffer(nil, 16)
+	_, err := NewMerkleTreeFromSource(context.Background(), ScannerSource(long))
+	var sourceErr *SourceError
+	if !errors.Is(err, bufio.ErrTooLong) || !errors.As(err, &sourceErr) || sourceErr.Leaf != 1 {
+		t.Errorf("got %v, want %v at leaf 1", err, bufio.ErrTooLong)
+	}
+
+	_, err = NewMerkleTreeFromSource(context.Background(), SliceSource([]string{"a", "b", "a"}), WithRejectDuplicates())
+	var dup *DuplicateLeafError
+	if !errors.As(err, &dup) {
+		t.Errorf("got %v, want a *DuplicateLeafError", err)
+	}
+}
+
+func TestNewMerkleTreeFromSourceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	stop := errors.New("shutting down")
+	src := &cancellingSource{elements: testElements(10), after: 4, cancel: func() { cancel(stop) }}
+	if _, err := NewMerkleTreeFromSource(ctx, src); !errors.Is(err, stop) {
+		t.Errorf("got %v, want %v", err, stop)
+	}
+	if src.pulled != 4 {
+		t.Errorf("got %d elements pulled, want none after cancellation", src.pulled)
+	}
+}
+
+// Yields the elements, cancelling once after elements have been pulled.
+type cancellingSource struct {
+	elements      []string
+	after, pulled int
+	cancel        func()
+}
+
+func (s *cancellingSource) Next() (string, bool, error) {
+	if s.pulled == len(s.elements) {
+		return "", false, nil
+	}
+	s.pulled++
+	if s.pulled == s.after {
+		s.cancel()
+	}
+	return s.elements[s.pulled-1], true, nil
+}
@@ -0,0 +1,158 @@
+package merkletree
+
+import (
+	"slices"
+	"sort"
+)
+
+// Inserts an element at index, shifting the element there and every one after it one
+// index to the right, so the tree equals one built over the spliced elements. Only the
+// nodes from the first shifted leaf rightward are recomputed, and the tree grows as
+// for Append; an index of LeafCount() appends. Keys of a tree built with
+// NewMerkleTreeFromMap follow their pairs to the new positions.
+// Fails with ErrIndexOutOfBounds beyond LeafCount(), ErrSetOrder under WithSetSemantics
+// and ErrElementsUnknown on imported trees, and for duplicates as Append does.
+func (t *MerkleTree) InsertAt(index uint64, element string) error {
+	return t.mutate(func(rec *journalRecord) error {
+		if rec != nil {
+			*rec = journalRecord{op: journalInsert, indices: []uint64{index}, values: []string{element}}
+		}
+		return t.insertAt(index, element)
+	})
+}
+
+func (t *MerkleTree) insertAt(index uint64, element string) error {
+	if index > t.leafCount() {
+		return t.outOfBounds(index)
+	}
+	if t.elements == nil {
+		return ErrElementsUnknown
+	}
+	if index == t.leafCount() {
+		return t.append(element)
+	}
+	if t.cfg.setSemantics {
+		return ErrSetOrder
+	}
+
+	height, err := t.cfg.checkedHeight(t.count + 1)
+	if err != nil {
+		return err
+	}
+	if err := t.cfg.checkElement(element); err != nil {
+		return err
+	}
+	// no index the element is at can stay, as every one of them is about to be taken twice
+	if err := t.checkDuplicate(t.count, element); err != nil {
+		return err
+	}
+	height = max(height, t.height())
+	if height != t.height() || t.count == t.reserved() {
+		t.relayout(height, min(max(2*t.reserved(), t.count+1), uint64(1)<<height))
+	}
+
+	// shifted right to left, so each element's indices stay sorted as they move
+	for i := t.count; i > index; i-- {
+		t.shiftIndex(t.elements[i-1], i-1, i)
+	}
+	t.elements = slices.Insert(t.elements, int(index), element)
+	if t.data != nil {
+		t.data = slices.Insert(t.data, int(index), nil)
+	}
+	t.count++
+	leaves := t.level(0)
+	copy(leaves[index+1:], leaves[index:])
+	leaves[index] = t.cfg.leafDigest(element)
+	t.addIndex(element, index)
+	t.bloomAdd(index)
+	t.epoch++
+
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.LeafHashed(1)
+	}
+	t.rehashFrom(index)
+	return nil
+}
+
+// Deletes the element at index, shifting every element after it one index to the left,
+// so the tree equals one built over the spliced elements. Only the nodes from the
+// deleted leaf rightward are recomputed. A tree at its minimum height shrinks to the
+// minimum for one element fewer; one grown by ExtendCapacity keeps its height.
+// Keys of a tree built with NewMerkleTreeFromMap follow their pairs, and the key of
+// the deleted pair is dropped.
+// Fails with ErrIndexOutOfBounds beyond the elements, ErrEmptyTree for the only element
+// and ErrElementsUnknown on imported trees.
+func (t *MerkleTree) Delete(index uint64) error {
+	return t.mutate(func(rec *journalRecord) error {
+		if rec != nil && index < uint64(len(t.elements)) {
+			*rec = journalRecord{op: journalDelete, indices: []uint64{index}, values: []string{t.elements[index]}}
+		}
+		return t.delete(index)
+	})
+}
+
+func (t *MerkleTree) delete(index uint64) error {
+	if index >= t.leafCount() {
+		return t.outOfBounds(index)
+	}
+	if t.elements == nil {
+		return ErrElementsUnknown
+	}
+	if t.count == 1 {
+		return ErrEmptyTree
+	}
+
+	removed := t.elements[index]
+	t.removeIndex(removed, index)
+	if key, ok := decodeKey(removed); ok && t.keys != nil && t.keys[key] == index {
+		delete(t.keys, key)
+	}
+	// shifted left to right, so each element's indices stay sorted as they move
+	for i := index + 1; i < t.count; i++ {
+		t.shiftIndex(t.elements[i], i, i-1)
+	}
+
+	t.elements = slices.Delete(t.elements, int(index), int(index)+1)
+	if t.data != nil {
+		t.data = slices.Delete(t.data, int(index), int(index)+1)
+	}
+	leaves := t.level(0)
+	copy(leaves[index:], leaves[index+1:])
+	minimal := t.height() == t.cfg.height(t.count)
+	t.count--
+	if height := t.cfg.height(t.count); minimal && height < t.height() {
+		t.relayout(height, min(t.reserved(), uint64(1)<<height))
+	}
+	t.epoch++
+
+	t.rehashFrom(index)
+	return nil
+}
+
+// Moves one index of the element, and its key if it has one, from one position to the next.
+func (t *MerkleTree) shiftIndex(element string, from uint64, to uint64) {
+	indices := t.indices[element]
+	if i := sort.Search(len(indices), func(i int) bool { return indices[i] >= from }); i < len(indices) && indices[i] == from {
+		indices[i] = to
+	}
+	t.moveKey(element, from, to)
+}
+
+// Recomputes every node covering a leaf from index on, level by level.
+// Nodes left of index cover only leaves that did not move, so they keep their hashes.
+// Leaves from index on need no recomputing once this is done, so they are no longer dirty.
+func (t *MerkleTree) rehashFrom(index uint64) {
+	t.dirty = slices.DeleteFunc(t.dirty, func(dirty uint64) bool { return dirty >= index })
+	hashed := 0
+	for level := 1; level <= t.height(); level++ {
+		index /= 2
+		parents := t.level(level)
+		for i := index; i < uint64(len(parents)); i++ {
+			parents[i] = t.cfg.nodeDigest(t.node(level-1, 2*i), t.node(level-1, 2*i+1))
+		}
+		hashed += len(parents) - int(index)
+	}
+	if t.cfg.metrics != nil {
+		t.cfg.metrics.NodeHashed(hashed)
+	}
+}
@@ -0,0 +1,123 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// Checks that the tree equals a fresh build over the elements, lookups included.
+func checkFreshTree(t *testing.T, mt *MerkleTree, elements []string, opts ...Option) {
+	t.Helper()
+	want, err := NewMerkleTree(elements, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt.GetRoot() != want.GetRoot() || mt.Height() != want.Height() {
+		t.Errorf("got root %s at height %d, want %s at %d", mt.GetRoot(), mt.Height(), want.GetRoot(), want.Height())
+	}
+	if !reflect.DeepEqual(mt.OriginalElements(), elements) {
+		t.Errorf("got elements %v, want %v", mt.OriginalElements(), elements)
+	}
+	if !reflect.DeepEqual(mt.indices, want.indices) {
+		t.Errorf("got indices %v, want %v", mt.indices, want.indices)
+	}
+}
+
+func TestInsertAt(t *testing.T) {
+	for _, size := range []int{1, 2, 4, 5, 8} {
+		for index := 0; index <= size; index++ {
+			t.Run(fmt.Sprintf("%d elements at %d", size, index), func(t *testing.T) {
+				elements := testElements(size)
+				elements = append(elements, elements[0])
+				mt, _ := NewMerkleTree(elements)
+
+				if err := mt.InsertAt(uint64(index), "element-1"); err != nil {
+					t.Fatal(err)
+				}
+				checkFreshTree(t, mt, slices.Insert(elements, index, "element-1"))
+			})
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	for _, size := range []int{2, 3, 5, 8, 9} {
+		for index := 0; index < size; index++ {
+			t.Run(fmt.Sprintf("%d elements at %d", size, index), func(t *testing.T) {
+				elements := testElements(size)
+				elements[size-1] = elements[0]
+				mt, _ := NewMerkleTree(elements, WithMode(ModeBitcoin))
+
+				if err := mt.Delete(uint64(index)); err != nil {
+					t.Fatal(err)
+				}
+				checkFreshTree(t, mt, slices.Delete(elements, index, index+1), WithMode(ModeBitcoin))
+			})
+		}
+	}
+}
+
+func TestSpliceErrors(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(3), WithRejectDuplicates())
+	if err := mt.InsertAt(4, "element-9"); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("insert: got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	if err := mt.InsertAt(1, "element-2"); !errors.Is(err, ErrDuplicateLeaf) {
+		t.Errorf("insert: got %v, want %v", err, ErrDuplicateLeaf)
+	}
+	if err := mt.Delete(3); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("delete: got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	if mt.Epoch() != 0 {
+		t.Errorf("got epoch %d after failed splices, want 0", mt.Epoch())
+	}
+
+	single, _ := NewMerkleTree([]string{"only"})
+	if err := single.Delete(0); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+	set, _ := NewMerkleTree(testElements(3), WithSetSemantics())
+	if err := set.InsertAt(0, "other"); !errors.Is(err, ErrSetOrder) {
+		t.Errorf("got %v, want %v", err, ErrSetOrder)
+	}
+}
+
+func TestSpliceSequence(t *testing.T) {
+	elements := testElements(6)
+	mt, _ := NewMerkleTree(elements, WithLazyRecompute())
+
+	mt.UpdateElement(5, "updated")
+	elements[5] = "updated"
+	mt.InsertAt(0, "first")
+	elements = slices.Insert(elements, 0, "first")
+	mt.Delete(5)
+	elements = slices.Delete(elements, 5, 6)
+	mt.UpdateElement(2, "again")
+	elements[2] = "again"
+	for len(elements) > 2 {
+		mt.Delete(1)
+		elements = slices.Delete(elements, 1, 2)
+	}
+	checkFreshTree(t, mt, elements)
+
+	if index, ok := mt.IndexOf("updated"); !ok || index != 1 {
+		t.Errorf("got index %d, %v, want 1", index, ok)
+	}
+}
+
+func TestSpliceKeys(t *testing.T) {
+	mt, _ := NewMerkleTreeFromMap(map[string]string{"a": "1", "b": "2", "c": "3"})
+	mt.InsertAt(0, "unkeyed")
+	mt.Delete(2)
+
+	proof, err := mt.GetProofForKey("c")
+	if err != nil || !VerifyKeyValueProof(mt.GetRoot(), "c", "3", proof) {
+		t.Errorf("key c: got error %v or an invalid proof", err)
+	}
+	if _, err := mt.GetProofForKey("b"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("deleted key b: got %v, want %v", err, ErrKeyNotFound)
+	}
+}
@@ -0,0 +1,257 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+var ErrInvalidDump = errors.New("merkletree: invalid standard tree dump")
+
+const standardTreeFormat = "standard-v1"
+
+// A tree compatible with OpenZeppelin's StandardMerkleTree, shared with its JS tooling and
+// its Solidity MerkleProof library, as used for airdrops.
+//
+// Every value is a tuple encoded with abi.encode for the leaf encoding, and its leaf is
+// keccak256(keccak256(encoding)). Nodes hash their children in sorted order. Leaves are
+// sorted by hash and laid out as a complete binary tree in an array, the root first and
+// the children of node i at 2i+1 and 2i+2, so only the last level need be full.
+// That layout differs from MerkleTree's padding, hence the separate type; its proofs
+// verify with VerifyProof and StandardMerkleTreeOptions all the same.
+// The tree is never modified once built, so it is safe for concurrent use.
+type StandardMerkleTree struct {
+	cfg          config
+	leafEncoding []string
+	values       [][]string
+	treeIndices  []int  // of each value's leaf in tree
+	tree         []Hash // root first, leaves last in reverse order
+}
+
+// Returns the options under which proofs of a StandardMerkleTree verify:
+// Keccak-256, raw and sorted node hashing, and double hashed leaves.
+func StandardMerkleTreeOptions() []Option {
+	return []Option{WithMode(ModeOpenZeppelin)}
+}
+
+// Builds the tree StandardMerkleTree.of would over the values, each a tuple of the types
+// in leafEncoding. Integers may be decimal or 0x hex, addresses and bytes 0x hex, and bools
+// true or false. Values keep their order, while their leaves are sorted by hash.
+func NewStandardMerkleTree(values [][]string, leafEncoding []string) (*StandardMerkleTree, error) {
+	if len(values) == 0 {
+		return nil, ErrEmptyTree
+	}
+	t, leaves, err := newStandardMerkleTree(values, leafEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return bytes.Compare(leaves[a][:], leaves[b][:]) })
+
+	t.tree = make([]Hash, 2*len(values)-1)
+	for leafIndex, valueIndex := range order {
+		t.treeIndices[valueIndex] = len(t.tree) - 1 - leafIndex
+		t.tree[t.treeIndices[valueIndex]] = leaves[valueIndex]
+	}
+	for i := len(t.tree) - 1 - len(values); i >= 0; i-- {
+		t.tree[i] = t.cfg.nodeDigest(t.tree[2*i+1], t.tree[2*i+2])
+	}
+	return t, nil
+}
+
+// Copies the values and hashes their leaves.
+func newStandardMerkleTree(values [][]string, leafEncoding []string) (*StandardMerkleTree, []Hash, error) {
+	types, err := parseABITypes(leafEncoding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t := &StandardMerkleTree{
+		cfg:          newConfig(StandardMerkleTreeOptions()),
+		leafEncoding: slices.Clone(leafEncoding),
+		values:       make([][]string, len(values)),
+		treeIndices:  make([]int, len(values)),
+	}
+	leaves := make([]Hash, len(values))
+	for i, value := range values {
+		encoded, err := abiEncode(types, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("value %d: %w", i, err)
+		}
+		t.values[i] = slices.Clone(value)
+		leaves[i] = t.cfg.leafDigest(string(encoded))
+	}
+	return t, leaves, nil
+}
+
+// Returns the root hash.
+func (t *StandardMerkleTree) GetRoot() string {
+	return t.tree[0].String()
+}
+
+// Returns the number of values.
+func (t *StandardMerkleTree) LeafCount() uint64 {
+	return uint64(len(t.values))
+}
+
+// Returns the Solidity types of every value.
+func (t *StandardMerkleTree) LeafEncoding() []string {
+	return slices.Clone(t.leafEncoding)
+}
+
+// Returns the value at the index, in the order the values were given.
+func (t *StandardMerkleTree) Value(index uint64) ([]string, error) {
+	if index >= t.LeafCount() {
+		return nil, ErrIndexOutOfBounds
+	}
+	return slices.Clone(t.values[index]), nil
+}
+
+// Returns a proof for the value at the index, as getProof does, with the directions
+// MerkleProof carries alongside the siblings, although sorted hashing ignores them.
+func (t *StandardMerkleTree) GetProof(index uint64) (MerkleProof, error) {
+	if index >= t.LeafCount() {
+		return MerkleProof{}, ErrIndexOutOfBounds
+	}
+
+	i := t.treeIndices[index]
+	proof := MerkleProof{hElement: t.tree[i].String(), algorithm: AlgorithmKeccak256}
+	for i > 0 {
+		// a left child has an odd index and its sibling follows it
+		sibling := i + 1
+		if i%2 == 0 {
+			sibling = i - 1
+		}
+		proof.siblings = append(proof.siblings, t.tree[sibling].String())
+		proof.directions = append(proof.directions, i%2 == 0)
+		i = (i - 1) / 2
+	}
+	return proof, nil
+}
+
+type standardTreeDump struct {
+	Format       string              `json:"format"`
+	Tree         []string            `json:"tree"`
+	Values       []standardTreeValue `json:"values"`
+	LeafEncoding []string            `json:"leafEncoding"`
+}
+
+type standardTreeValue struct {
+	Value     []json.RawMessage `json:"value"`
+	TreeIndex int               `json:"treeIndex"`
+}
+
+// Writes the tree as StandardMerkleTree.dump does, in the standard-v1 format.
+// Values of type bool are written as JSON booleans and all others as strings.
+func (t *StandardMerkleTree) Dump(w io.Writer) error {
+	dump := standardTreeDump{
+		Format:       standardTreeFormat,
+		Tree:         make([]string, len(t.tree)),
+		Values:       make([]standardTreeValue, len(t.values)),
+		LeafEncoding: t.leafEncoding,
+	}
+	for i, node := range t.tree {
+		dump.Tree[i] = "0x" + node.String()
+	}
+	for i, value := range t.values {
+		fields := make([]json.RawMessage, len(value))
+		for j, field := range value {
+			if t.leafEncoding[j] == "bool" {
+				fields[j] = json.RawMessage(field)
+			} else {
+				fields[j], _ = json.Marshal(field)
+			}
+		}
+		dump.Values[i] = standardTreeValue{Value: fields, TreeIndex: t.treeIndices[i]}
+	}
+	return json.NewEncoder(w).Encode(dump)
+}
+
+// Reads a tree written by StandardMerkleTree.dump or Dump, checking that every leaf is the
+// hash of its value and every node the hash of its children, as StandardMerkleTree.load and
+// validate do. Values may be JSON strings, numbers or booleans.
+// A dump in another format or not matching its values fails with ErrInvalidDump.
+func LoadStandardMerkleTreeDump(r io.Reader) (*StandardMerkleTree, error) {
+	var dump standardTreeDump
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDump, err)
+	}
+	if dump.Format != standardTreeFormat {
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidDump, dump.Format)
+	}
+	if len(dump.Values) == 0 {
+		return nil, ErrEmptyTree
+	}
+	if len(dump.Tree) != 2*len(dump.Values)-1 {
+		return nil, fmt.Errorf("%w: %d nodes for %d values", ErrInvalidDump, len(dump.Tree), len(dump.Values))
+	}
+
+	values := make([][]string, len(dump.Values))
+	for i, value := range dump.Values {
+		values[i] = make([]string, len(value.Value))
+		for j, field := range value.Value {
+			var err error
+			if values[i][j], err = standardTreeField(field); err != nil {
+				return nil, fmt.Errorf("%w: value %d field %d: %v", ErrInvalidDump, i, j, err)
+			}
+		}
+	}
+	t, leaves, err := newStandardMerkleTree(values, dump.LeafEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	t.tree = make([]Hash, len(dump.Tree))
+	for i, node := range dump.Tree {
+		if t.tree[i], err = ParseHash(node); err != nil {
+			return nil, fmt.Errorf("%w: node %d: %v", ErrInvalidDump, i, err)
+		}
+	}
+
+	firstLeaf := len(t.tree) - len(values)
+	seen := make([]bool, len(t.tree))
+	for i, value := range dump.Values {
+		if value.TreeIndex < firstLeaf || value.TreeIndex >= len(t.tree) || seen[value.TreeIndex] {
+			return nil, fmt.Errorf("%w: value %d has tree index %d", ErrInvalidDump, i, value.TreeIndex)
+		}
+		seen[value.TreeIndex] = true
+		if t.tree[value.TreeIndex] != leaves[i] {
+			return nil, fmt.Errorf("%w: leaf of value %d does not match", ErrInvalidDump, i)
+		}
+		t.treeIndices[i] = value.TreeIndex
+	}
+	for i := firstLeaf - 1; i >= 0; i-- {
+		if t.tree[i] != t.cfg.nodeDigest(t.tree[2*i+1], t.tree[2*i+2]) {
+			return nil, fmt.Errorf("%w: node %d does not match its children", ErrInvalidDump, i)
+		}
+	}
+	return t, nil
+}
+
+// Returns the text of a JSON string, number or boolean.
+func standardTreeField(field json.RawMessage) (string, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(field))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("unsupported JSON value %s", field)
+	}
+}
@@ -0,0 +1,132 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The root and proof published in the README of OpenZeppelin's merkle-tree package
+// for the values of testdata/standard-v1.json.
+const (
+	standardReadmeRoot  = "d4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77"
+	standardReadmeProof = "b92c48e9d7abe27fd8dfd6b5dfdbfb1c9a463f80c712b66f3a5180a090cccafc"
+)
+
+func TestLoadStandardMerkleTreeDump(t *testing.T) {
+	f, err := os.Open("testdata/standard-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	st, err := LoadStandardMerkleTreeDump(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.GetRoot() != standardReadmeRoot {
+		t.Errorf("got %s, want %s", st.GetRoot(), standardReadmeRoot)
+	}
+
+	proof, _ := st.GetProof(0)
+	if len(proof.siblings) != 1 || proof.siblings[0] != standardReadmeProof {
+		t.Errorf("got %v, want [%s]", proof.siblings, standardReadmeProof)
+	}
+	if !VerifyProof(st.GetRoot(), proof, StandardMerkleTreeOptions()...) {
+		t.Error("failed to verify")
+	}
+
+	var dumped bytes.Buffer
+	st.Dump(&dumped)
+	want, _ := os.ReadFile("testdata/standard-v1.json")
+	if dumped.String() != string(want) {
+		t.Errorf("got %s, want %s", dumped.String(), want)
+	}
+}
+
+func TestStandardMerkleTreeProofs(t *testing.T) {
+	encoding := []string{"address", "uint256", "bool", "string", "int8"}
+	for n := 1; n <= 9; n++ {
+		values := make([][]string, n)
+		for i := range values {
+			values[i] = []string{fmt.Sprintf("0x%040x", i+1), fmt.Sprint(1000 * i), fmt.Sprint(i%2 == 0), fmt.Sprintf("holder %d", i), fmt.Sprint(-i)}
+		}
+
+		st, err := NewStandardMerkleTree(values, encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dump bytes.Buffer
+		if err := st.Dump(&dump); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadStandardMerkleTreeDump(&dump)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := uint64(0); i < st.LeafCount(); i++ {
+			testname := fmt.Sprintf("%d values, value %d", n, i)
+			t.Run(testname, func(t *testing.T) {
+				proof, _ := loaded.GetProof(i)
+				if !VerifyProof(st.GetRoot(), proof, StandardMerkleTreeOptions()...) {
+					t.Error("failed to verify")
+				}
+				value, _ := loaded.Value(i)
+				if strings.Join(value, ",") != strings.Join(values[i], ",") {
+					t.Errorf("got %v, want %v", value, values[i])
+				}
+			})
+		}
+	}
+}
+
+func TestLoadStandardMerkleTreeDumpRejects(t *testing.T) {
+	valid, _ := os.ReadFile("testdata/standard-v1.json")
+	for _, c := range []struct {
+		name, old, new string
+		want           error
+	}{
+		{"format", "standard-v1", "standard-v2", ErrInvalidDump},
+		{"value", "5000000000000000000", "6000000000000000000", ErrInvalidDump},
+		{"tree index", `"treeIndex":2`, `"treeIndex":1`, ErrInvalidDump},
+		{"node", "0xd4de", "0xd4df", ErrInvalidDump},
+		{"encoding", `"uint256"]`, `"uint256[]"]`, ErrLeafEncoding},
+		{"address", "0x1111111111111111111111111111111111111111", "0x11", ErrLeafEncoding},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			tampered := strings.Replace(string(valid), c.old, c.new, 1)
+			if _, err := LoadStandardMerkleTreeDump(strings.NewReader(tampered)); !errors.Is(err, c.want) {
+				t.Errorf("got %v, want %v", err, c.want)
+			}
+		})
+	}
+}
+
+func TestABIEncode(t *testing.T) {
+	types, _ := parseABITypes([]string{"uint8", "string", "int16", "bytes2"})
+	encoded, err := abiEncode(types, []string{"0xff", "hi", "-2", "0xabcd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"00000000000000000000000000000000000000000000000000000000000000ff",
+		"0000000000000000000000000000000000000000000000000000000000000080",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe",
+		"abcd000000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"6869000000000000000000000000000000000000000000000000000000000000",
+	}, "")
+	if got := fmt.Sprintf("%x", encoded); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	for _, bad := range [][]string{{"256", "", "0", "0x0000"}, {"1", "", "-32769", "0x0000"}, {"1", "", "0", "0x00"}, {"-1", "", "0", "0x0000"}} {
+		if _, err := abiEncode(types, bad); !errors.Is(err, ErrLeafEncoding) {
+			t.Errorf("%v: got %v, want %v", bad, err, ErrLeafEncoding)
+		}
+	}
+}
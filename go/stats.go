@@ -0,0 +1,111 @@
+package merkletree
+
+import "fmt"
+
+const arity = 2 // children per interior node
+
+// Summarises the shape of a tree, e.g. for exposure on a debug endpoint.
+type Stats struct {
+	LeafCount       uint64  `json:"leafCount"`
+	PaddedLeafCount uint64  `json:"paddedLeafCount"`
+	Height          int     `json:"height"`
+	PaddingRatio    float64 `json:"paddingRatio"` // fraction of leaf slots holding padding
+	HashAlgorithm   string  `json:"hashAlgorithm"`
+	Arity           int     `json:"arity"`
+	Parallelism     int     `json:"parallelism"` // goroutines which hashed the last build at once, 1 when sequential, see WithParallelism
+	Epoch           uint64  `json:"epoch"`       // mutations applied since the tree was built, see Epoch
+
+	// estimated chance that MightContain passes an absent element, zero without WithBloomFilter
+	BloomFalsePositiveRate float64 `json:"bloomFalsePositiveRate,omitempty"`
+}
+
+// The root of a tree with the leaf count and height it commits to.
+type RootInfo struct {
+	Root      string `json:"root"`
+	LeafCount uint64 `json:"leafCount"`
+	Height    int    `json:"height"`
+}
+
+// Returns the root with the leaf count and height it commits to, read under one lock so
+// that they agree while the tree is mutated, as GetRoot and Stats called in turn may not.
+func (t *MerkleTree) RootInfo() RootInfo {
+	if t.rlockBuilt() != nil {
+		return RootInfo{}
+	}
+	defer t.mu.RUnlock()
+	return RootInfo{Root: t.rootHash().String(), LeafCount: t.leafCount(), Height: t.height()}
+}
+
+// Returns a summary of the tree's current shape and epoch, and of how its last build was
+// hashed, read under one lock so that they agree while the tree is mutated. Every field is
+// already tracked by the tree, so this is cheap to call.
+func (t *MerkleTree) Stats() Stats {
+	if t.rlockBuiltAsIs() != nil {
+		return Stats{}
+	}
+	defer t.mu.RUnlock()
+	return t.stats()
+}
+
+func (t *MerkleTree) stats() Stats {
+	padded := t.paddedLeafCount()
+
+	return Stats{
+		LeafCount:       t.leafCount(),
+		PaddedLeafCount: padded,
+		Height:          t.height(),
+		PaddingRatio:    float64(padded-t.leafCount()) / float64(padded),
+		HashAlgorithm:   t.cfg.hashAlgorithm(),
+		Arity:           arity,
+		Parallelism:     max(t.workers, 1),
+		Epoch:           t.epoch,
+
+		BloomFalsePositiveRate: t.bloomFalsePositiveRate(),
+	}
+}
+
+// Names the digest the configuration hashes leaves and nodes with, as Stats reports it:
+// the leaf function followed by the node function, as in sha256/keccak256, when they differ.
+func (cfg config) hashAlgorithm() string {
+	switch {
+	case cfg.backend != nil:
+		return cfg.backend.name()
+	case cfg.leafHash != cfg.nodeHash:
+		return cfg.leafHash.String() + "/" + cfg.nodeHash.String()
+	}
+	return cfg.leafHash.String()
+}
+
+// Summarises the tree on one line, as merkletree{leaves=5, height=3, root=ab12…ef90, mode=default},
+// so that printing a tree with %v logs its shape rather than its nodes. Elements are
+// never printed. Works on the zero value, which has no root.
+func (t *MerkleTree) String() string {
+	if t == nil {
+		return "merkletree{nil}"
+	}
+	t.rlock()
+	defer t.mu.RUnlock()
+
+	root := "none"
+	if len(t.offsets) > 0 {
+		full := t.rootHash().String()
+		root = full[:4] + "…" + full[len(full)-4:]
+	}
+	return fmt.Sprintf("merkletree{leaves=%d, height=%d, root=%s, mode=%v}", t.leafCount(), max(t.height(), 0), root, t.cfg.mode)
+}
+
+// Describes the tree for %#v as String does, adding the full root, the padded leaf
+// count, the epoch and the application tag. Elements are never printed.
+func (t *MerkleTree) GoString() string {
+	if t == nil {
+		return "(*merkletree.MerkleTree)(nil)"
+	}
+	t.rlock()
+	defer t.mu.RUnlock()
+
+	if len(t.offsets) == 0 {
+		return "&merkletree.MerkleTree{}"
+	}
+	return fmt.Sprintf("&merkletree.MerkleTree{leaves: %d, paddedLeaves: %d, height: %d, root: %q, mode: %v, tag: %q, epoch: %d}",
+		t.leafCount(), t.paddedLeafCount(), t.height(), t.rootHash().String(), t.cfg.mode, t.cfg.tag, t.epoch)
+}
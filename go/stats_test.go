@@ -0,0 +1,145 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	cases := []struct {
+		size   int
+		padded uint64
+		height int
+		ratio  float64
+	}{
+		{1, 1, 0, 0},
+		{2, 2, 1, 0},
+		{3, 4, 2, 0.25},
+		{8, 8, 3, 0},
+		{9, 16, 4, 0.4375},
+		{1025, 2048, 11, 1023.0 / 2048},
+	}
+
+	for _, c := range cases {
+		testname := fmt.Sprintf("stats for %d elements", c.size)
+		t.Run(testname, func(t *testing.T) {
+			mt, err := NewMerkleTree(testElements(c.size))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := Stats{
+				LeafCount:       uint64(c.size),
+				PaddedLeafCount: c.padded,
+				Height:          c.height,
+				PaddingRatio:    c.ratio,
+				HashAlgorithm:   "sha256",
+				Arity:           2,
+				Parallelism:     1,
+			}
+			if got := mt.Stats(); got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestStatsUnchangedByUpdate(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	before := mt.Stats()
+	if before.Epoch != 0 {
+		t.Fatalf("got epoch %d, want 0", before.Epoch)
+	}
+
+	// the shape stays as it was, while the epoch counts the update
+	if err := mt.UpdateElement(4, "updated"); err != nil {
+		t.Fatal(err)
+	}
+	want := before
+	want.Epoch = 1
+	if got := mt.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if err := mt.Append("appended"); err != nil {
+		t.Fatal(err)
+	}
+	if got := mt.Stats(); got.Epoch != mt.Epoch() || got.Epoch != 2 || got.LeafCount != 6 {
+		t.Errorf("got epoch %d with %d elements, want epoch 2 with 6", got.Epoch, got.LeafCount)
+	}
+}
+
+func TestRootInfo(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5), WithLazyRecompute())
+	if err := mt.UpdateElement(4, "updated"); err != nil {
+		t.Fatal(err)
+	}
+	elements := testElements(5)
+	elements[4] = "updated"
+	fresh, _ := NewMerkleTree(elements)
+	want := RootInfo{Root: fresh.GetRoot(), LeafCount: 5, Height: 3}
+	if got := mt.RootInfo(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := new(MerkleTree).RootInfo(); got != (RootInfo{}) {
+		t.Errorf("got %+v, want the zero value", got)
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(3))
+
+	encoded, err := json.Marshal(mt.Stats())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Stats
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != mt.Stats() {
+		t.Errorf("got %+v, want %+v", decoded, mt.Stats())
+	}
+}
+
+func TestString(t *testing.T) {
+	var zero MerkleTree
+	if got, want := zero.String(), "merkletree{leaves=0, height=0, root=none, mode=default}"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := fmt.Sprintf("%#v", &zero), "&merkletree.MerkleTree{}"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	var none *MerkleTree
+	if got, want := fmt.Sprint(none), "merkletree{nil}"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	mt, _ := NewMerkleTree([]string{"secret-a", "secret-b", "secret-c"})
+	root := mt.GetRoot()
+	if got, want := fmt.Sprintf("%v", mt), "merkletree{leaves=3, height=2, root="+root[:4]+"…"+root[60:]+", mode=default}"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	bitcoin, _ := NewMerkleTree([]string{"secret-a", "secret-b", "secret-c"}, WithMode(ModeBitcoin))
+	summary := fmt.Sprint(bitcoin)
+	if !strings.Contains(summary, "mode=bitcoin") || strings.Contains(summary, bitcoin.GetRoot()) {
+		t.Errorf("got %s, want the bitcoin mode and a truncated root", summary)
+	}
+
+	tagged, _ := NewMerkleTree([]string{"secret-a", "secret-b"}, WithApplicationTag("app"))
+	tagged.UpdateElement(1, "secret-c")
+	detail := fmt.Sprintf("%#v", tagged)
+	want := `&merkletree.MerkleTree{leaves: 2, paddedLeaves: 2, height: 1, root: "` + tagged.GetRoot() + `", mode: default, tag: "app", epoch: 1}`
+	if detail != want {
+		t.Errorf("got %s, want %s", detail, want)
+	}
+	for _, s := range []string{fmt.Sprint(mt), fmt.Sprintf("%#v", mt), summary, detail} {
+		if strings.Contains(s, "secret") {
+			t.Errorf("%s prints an element", s)
+		}
+	}
+}
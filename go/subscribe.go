@@ -0,0 +1,153 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+var ErrSubscriberPanic = errors.New("merkletree: root subscriber panicked")
+
+// Called with the roots before and after a mutation, and the epoch it advanced the tree to.
+type RootSubscriber func(oldRoot string, newRoot string, epoch uint64)
+
+// The subscribers of a tree, guarded apart from the tree so that callbacks,
+// which run once the tree is unlocked, may subscribe and unsubscribe freely.
+type subscribers struct {
+	mu    sync.Mutex
+	next  uint64
+	funcs []subscriber // in subscription order
+}
+
+type subscriber struct {
+	id uint64
+	fn RootSubscriber
+}
+
+// Receives the errors of subscribers that panicked, as ErrSubscriberPanic wrapping the
+// panic value. Without a handler, failures are logged at error level when WithLogger is set.
+func WithSubscriberErrorHandler(handler func(err error)) Option {
+	return func(cfg *config) {
+		cfg.subscriberErrors = handler
+	}
+}
+
+// Calls fn after every mutation that changes the root, with the tree unlocked, so fn
+// may read or even mutate the tree. Mutations leaving the root as it was, such as
+// updating an element to itself, are not reported. Subscribers are called in the order
+// they subscribed, on the goroutine of the mutation, which waits for them.
+// Under WithLazyRecompute, every mutation recomputes the root while anyone is subscribed.
+// The returned function unsubscribes fn and may be called any number of times.
+// Subscribing to a nil tree does nothing, as it never changes.
+func (t *MerkleTree) Subscribe(fn RootSubscriber) (unsubscribe func()) {
+	if t == nil {
+		return func() {}
+	}
+	s := &t.subscribers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.next++
+	id := s.next
+	s.funcs = append(s.funcs, subscriber{id, fn})
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, sub := range s.funcs {
+			if sub.id == id {
+				s.funcs = append(s.funcs[:i:i], s.funcs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Returns the current subscribers, or nil when there are none.
+func (s *subscribers) snapshot() []subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.funcs) == 0 {
+		return nil
+	}
+	return append([]subscriber(nil), s.funcs...)
+}
+
+// Runs the mutation with the tree locked for writing, journaling it when it advances
+// the epoch, then reports a changed root to the subscribers present when it started,
+// once the lock is released. The mutation describes itself in the record it is passed,
+// which is nil without WithJournal or watched proofs, see witness.go.
+func (t *MerkleTree) mutate(f func(rec *journalRecord) error) error {
+	if t == nil {
+		return ErrUninitializedTree
+	}
+	subs := t.subscribers.snapshot()
+
+	if err := t.lockBuilt(); err != nil {
+		return err
+	}
+	var oldRoot Hash
+	if subs != nil {
+		t.recomputeDirty()
+		oldRoot = t.rootHash()
+	}
+
+	var rec *journalRecord
+	if t.cfg.journal != nil || t.witnesses != nil {
+		rec = &journalRecord{}
+	}
+	if t.shared {
+		t.unshare()
+	}
+	before, oldCount, oldHeight := t.epoch, t.count, t.height()
+	err := f(rec)
+	if t.epoch != before && t.witnesses != nil {
+		if err != nil {
+			*rec = journalRecord{}
+		}
+		t.patchWitnesses(*rec, oldCount, oldHeight)
+	}
+	if err == nil && t.cfg.journal != nil && t.epoch != before {
+		err = t.journal(*rec)
+	}
+	if t.epoch != before && t.cfg.proofCacheSize > 0 {
+		t.proofs.reset(t.epoch)
+	}
+
+	// a failed journal write leaves the mutation applied, so subscribers still hear of it
+	applied := err == nil || errors.Is(err, ErrJournalWrite)
+	var newRoot Hash
+	epoch := t.epoch
+	if subs != nil && applied {
+		t.recomputeDirty()
+		newRoot = t.rootHash()
+	}
+	cfg := t.cfg
+	t.mu.Unlock()
+
+	if subs != nil && applied && newRoot != oldRoot {
+		for _, sub := range subs {
+			cfg.notify(sub.fn, oldRoot.String(), newRoot.String(), epoch)
+		}
+	}
+	return err
+}
+
+// Calls the subscriber, recovering a panic and reporting it as an error.
+func (cfg config) notify(fn RootSubscriber, oldRoot string, newRoot string, epoch uint64) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err := fmt.Errorf("%w: %v", ErrSubscriberPanic, r)
+		switch {
+		case cfg.subscriberErrors != nil:
+			cfg.subscriberErrors(err)
+		case cfg.logger != nil:
+			cfg.logger.Error("merkletree: subscriber failed", slog.String("reason", err.Error()), slog.Uint64("epoch", epoch))
+		}
+	}()
+	fn(oldRoot, newRoot, epoch)
+}
@@ -0,0 +1,102 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+type rootChange struct {
+	oldRoot, newRoot string
+	epoch            uint64
+}
+
+func TestSubscribe(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+
+	var first, second []rootChange
+	unsubscribeFirst := mt.Subscribe(func(oldRoot, newRoot string, epoch uint64) {
+		first = append(first, rootChange{oldRoot, newRoot, epoch})
+	})
+	mt.Subscribe(func(oldRoot, newRoot string, epoch uint64) {
+		second = append(second, rootChange{oldRoot, newRoot, epoch})
+	})
+
+	before := mt.GetRoot()
+	mt.UpdateElement(1, "changed")
+	want := rootChange{before, mt.GetRoot(), mt.Epoch()}
+	if len(first) != 1 || first[0] != want {
+		t.Errorf("first: got %v, want [%v]", first, want)
+	}
+	if len(second) != 1 || second[0] != want {
+		t.Errorf("second: got %v, want [%v]", second, want)
+	}
+
+	mt.UpdateElement(1, "changed")
+	mt.Swap(2, 2)
+	mt.UpdateElement(9, "out of range")
+	if len(first) != 1 || len(second) != 1 {
+		t.Errorf("got %d and %d changes after no-op updates, want 1 each", len(first), len(second))
+	}
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	mt.Append("element-5")
+	mt.Swap(0, 1)
+	mt.Apply(func(index uint64, element string) (string, error) { return element + "!", nil })
+	mt.Reset(testElements(3))
+	if len(first) != 1 {
+		t.Errorf("got %d changes after unsubscribing, want 1", len(first))
+	}
+	if len(second) != 5 {
+		t.Fatalf("got %d changes, want 5", len(second))
+	}
+	for i := 1; i < len(second); i++ {
+		if second[i].oldRoot != second[i-1].newRoot {
+			t.Errorf("change %d starts from %s, want %s", i, second[i].oldRoot, second[i-1].newRoot)
+		}
+	}
+	if last := second[len(second)-1]; last.newRoot != mt.GetRoot() || last.epoch != mt.Epoch() {
+		t.Errorf("got %v, want root %s at epoch %d", last, mt.GetRoot(), mt.Epoch())
+	}
+}
+
+func TestSubscriberMayUseTree(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(4), WithLazyRecompute())
+
+	var seen []string
+	mt.Subscribe(func(oldRoot, newRoot string, epoch uint64) {
+		seen = append(seen, mt.GetRoot())
+		if len(seen) == 1 {
+			mt.UpdateElement(0, "from subscriber")
+		}
+	})
+
+	mt.UpdateElement(3, "changed")
+	if len(seen) != 2 || seen[1] != mt.GetRoot() {
+		t.Errorf("got %v, want two roots ending in %s", seen, mt.GetRoot())
+	}
+}
+
+func TestSubscriberPanicRecovered(t *testing.T) {
+	var reported []error
+	mt, _ := NewMerkleTree(testElements(4), WithSubscriberErrorHandler(func(err error) {
+		reported = append(reported, err)
+	}))
+
+	called := false
+	mt.Subscribe(func(oldRoot, newRoot string, epoch uint64) { panic("boom") })
+	mt.Subscribe(func(oldRoot, newRoot string, epoch uint64) { called = true })
+
+	if err := mt.UpdateElement(0, "changed"); err != nil {
+		t.Fatal(err)
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], ErrSubscriberPanic) {
+		t.Errorf("got %v, want one %v", reported, ErrSubscriberPanic)
+	}
+	if !called {
+		t.Error("subscriber after the panicking one was not called")
+	}
+	if err := mt.UpdateElement(1, "changed"); err != nil {
+		t.Errorf("tree unusable after a subscriber panicked: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package merkletree
+
+import "encoding/binary"
+
+// Exchanges the elements at indices i and j, recomputing only the union of their paths
+// to the root. Swapping an index with itself, or two equal elements, changes nothing
+// and leaves the epoch as it is. Keys of a tree built with NewMerkleTreeFromMap follow
+// their pairs to the new positions.
+// Only leaf hashes are needed, so trees restored with ImportNodes can be swapped too.
+// Trees built with WithSetSemantics fail with ErrSetOrder.
+func (t *MerkleTree) Swap(i uint64, j uint64) error {
+	return t.mutate(func(rec *journalRecord) error {
+		if rec != nil {
+			*rec = journalRecord{op: journalSwap, indices: []uint64{i, j}}
+		}
+		return t.swap(i, j)
+	})
+}
+
+func (t *MerkleTree) swap(i uint64, j uint64) error {
+	for _, index := range []uint64{i, j} {
+		if index >= t.leafCount() {
+			return t.outOfBounds(index)
+		}
+	}
+
+	if t.cfg.setSemantics && i != j {
+		return ErrSetOrder
+	}
+
+	left, right := t.nodeIndex(0, i), t.nodeIndex(0, j)
+	if t.nodes[left] == t.nodes[right] {
+		return nil
+	}
+	t.nodes[left], t.nodes[right] = t.nodes[right], t.nodes[left]
+
+	if t.elements != nil {
+		a, b := t.elements[i], t.elements[j]
+		t.reindex(i, a, b)
+		t.reindex(j, b, a)
+		t.elements[i], t.elements[j] = b, a
+		t.moveKey(a, i, j)
+		t.moveKey(b, j, i)
+	}
+	if t.data != nil {
+		t.data[i], t.data[j] = t.data[j], t.data[i]
+	}
+	t.epoch++
+
+	t.markDirty(i)
+	t.markDirty(j)
+	if !t.cfg.lazyRecompute {
+		t.recomputeDirty()
+	}
+
+	return nil
+}
+
+// Moves the key of a pair leaf from index from to index to, if the tree tracks keys.
+func (t *MerkleTree) moveKey(element string, from uint64, to uint64) {
+	if t.keys == nil {
+		return
+	}
+	if key, ok := decodeKey(element); ok && t.keys[key] == from {
+		t.keys[key] = to
+	}
+}
+
+// Returns the key of a leaf encoded by EncodeKeyValue.
+func decodeKey(element string) (string, bool) {
+	if len(element) < 8 {
+		return "", false
+	}
+	n := binary.BigEndian.Uint64([]byte(element[:8]))
+	if n > uint64(len(element)-8) {
+		return "", false
+	}
+	return element[8 : 8+n], true
+}
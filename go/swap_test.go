@@ -0,0 +1,89 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSwap(t *testing.T) {
+	for _, c := range []struct{ i, j uint64 }{{0, 1}, {1, 6}, {6, 0}, {2, 5}, {3, 3}} {
+		testname := fmt.Sprintf("swap %d and %d", c.i, c.j)
+		t.Run(testname, func(t *testing.T) {
+			elements := testElements(7)
+			mt, _ := NewMerkleTree(elements)
+			if err := mt.Swap(c.i, c.j); err != nil {
+				t.Fatal(err)
+			}
+
+			elements[c.i], elements[c.j] = elements[c.j], elements[c.i]
+			expected, _ := NewMerkleTree(elements)
+			if mt.GetRoot() != expected.GetRoot() {
+				t.Errorf("got %s, want %s", mt.GetRoot(), expected.GetRoot())
+			}
+			for index, element := range elements {
+				if got, _ := mt.IndexOf(element); got != uint64(index) {
+					t.Errorf("got index %d for %s, want %d", got, element, index)
+				}
+			}
+			if c.i == c.j && mt.Epoch() != 0 {
+				t.Errorf("got epoch %d for a no-op swap, want 0", mt.Epoch())
+			}
+		})
+	}
+}
+
+func TestSwapHashesUnionOfPaths(t *testing.T) {
+	metrics := &CountingMetrics{}
+	mt, _ := NewMerkleTree(testElements(8), WithMetrics(metrics))
+	metrics.Reset()
+
+	// leaves 0 and 2 have separate parents but share every ancestor above them
+	mt.Swap(0, 2)
+	if got := metrics.NodeHashes.Load(); got != 4 {
+		t.Errorf("got %d node hashes, want 4", got)
+	}
+	if got := metrics.LeafHashes.Load(); got != 0 {
+		t.Errorf("got %d leaf hashes, want 0", got)
+	}
+}
+
+func TestSwapEqualElements(t *testing.T) {
+	mt, _ := NewMerkleTree([]string{"a", "b", "a"})
+	root := mt.GetRoot()
+	if err := mt.Swap(0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if mt.GetRoot() != root || mt.Epoch() != 0 {
+		t.Errorf("swapping equal elements changed the tree")
+	}
+	if got := mt.AllIndices("a"); len(got) != 2 || got[0] != 0 || got[1] != 2 {
+		t.Errorf("got indices %v, want [0 2]", got)
+	}
+}
+
+func TestSwapOutOfBounds(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(3))
+	for _, c := range []struct{ i, j uint64 }{{0, 3}, {3, 0}, {4, 4}} {
+		if err := mt.Swap(c.i, c.j); !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+		}
+	}
+}
+
+func TestSwapMovesKeys(t *testing.T) {
+	mt, _ := NewMerkleTreeFromMap(map[string]string{"a": "1", "b": "2", "c": "3"})
+	if err := mt.Swap(0, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, value := range map[string]string{"a": "1", "c": "3"} {
+		proof, err := mt.GetProofForKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyKeyValueProof(mt.GetRoot(), key, value, proof) {
+			t.Errorf("invalid proof for key %s", key)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrSyncResponse = errors.New("merkletree: malformed answer from the remote replica")
+
+// Answers the node queries SyncDiff makes of a remote replica, over whatever transport
+// the caller has. A MerkleTree answers them for itself, so the serving side of the
+// transport only has to relay its Shape, NodeDigests and RequestChildren.
+type NodeFetcher interface {
+	// Returns the number of elements and the height of the remote tree.
+	Shape() (leafCount uint64, height int, err error)
+	// Returns the stored digests of a level, as MerkleTree.NodeDigests does.
+	NodeDigests(level uint) ([]string, error)
+	// Returns the digests of the children of each node, as MerkleTree.RequestChildren does.
+	RequestChildren(coords []NodeCoord) ([][2]string, error)
+}
+
+// Returns the number of elements and the height of the tree, as NodeFetcher does.
+func (t *MerkleTree) Shape() (leafCount uint64, height int, err error) {
+	if err := t.rlockBuiltAsIs(); err != nil {
+		return 0, 0, err
+	}
+	defer t.mu.RUnlock()
+	return t.leafCount(), t.height(), nil
+}
+
+// Returns the digest of every node of the level covering an element, from the left.
+// The nodes beyond them are padding, see NodeAt. Fails with ErrIndexOutOfBounds
+// above the root.
+func (t *MerkleTree) NodeDigests(level uint) ([]string, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return nil, err
+	}
+	defer t.mu.RUnlock()
+
+	if level > uint(t.height()) {
+		return nil, fmt.Errorf("%w: level %d, height %d", ErrIndexOutOfBounds, level, t.height())
+	}
+	nodes := t.level(int(level))
+	digests := make([]string, len(nodes))
+	for i, node := range nodes {
+		digests[i] = node.String()
+	}
+	return digests, nil
+}
+
+// Returns the digests of the left and right child of each node, padding included, which
+// is what a replica sends in answer to SyncDiff descending into those nodes.
+// Fails with ErrIndexOutOfBounds for leaves and nodes outside the tree.
+func (t *MerkleTree) RequestChildren(coords []NodeCoord) ([][2]string, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return nil, err
+	}
+	defer t.mu.RUnlock()
+	return t.children(coords)
+}
+
+func (t *MerkleTree) children(coords []NodeCoord) ([][2]string, error) {
+	children := make([][2]string, len(coords))
+	for i, coord := range coords {
+		if coord.Level < 1 || coord.Level > t.height() || coord.Index >= t.paddedLeafCount()>>coord.Level {
+			return nil, fmt.Errorf("%w: children of node %d at level %d", ErrIndexOutOfBounds, coord.Index, coord.Level)
+		}
+		children[i] = [2]string{
+			t.node(coord.Level-1, 2*coord.Index).String(),
+			t.node(coord.Level-1, 2*coord.Index+1).String(),
+		}
+	}
+	return children, nil
+}
+
+// Returns the indices, in increasing order, at which the local tree and a remote replica
+// hashing the same way hold different elements, including those only one of them holds.
+// Both trees are compared level by level from the highest level they share, and only
+// nodes whose digests differ are descended into, one RequestChildren call per level,
+// so the traffic grows with the number of differences rather than with the trees.
+// The local tree is read locked throughout.
+func SyncDiff(local *MerkleTree, remote NodeFetcher) ([]uint64, error) {
+	remoteCount, remoteHeight, err := remote.Shape()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := local.rlockBuilt(); err != nil {
+		return nil, err
+	}
+	defer local.mu.RUnlock()
+
+	level := min(local.height(), remoteHeight)
+	remoteDigests, err := remote.NodeDigests(uint(level))
+	if err != nil {
+		return nil, err
+	}
+	if want := (remoteCount + 1<<level - 1) >> level; uint64(len(remoteDigests)) != want {
+		return nil, fmt.Errorf("%w: %d digests at level %d, want %d", ErrSyncResponse, len(remoteDigests), level, want)
+	}
+
+	total := max(local.leafCount(), remoteCount)
+	var diff []uint64
+	var mismatched []NodeCoord
+	localNodes := local.level(level)
+	for index := 0; index < max(len(localNodes), len(remoteDigests)); index++ {
+		if index >= len(localNodes) || index >= len(remoteDigests) {
+			// held by one side alone, so every element under it differs
+			for leaf := uint64(index) << level; leaf < min(uint64(index+1)<<level, total); leaf++ {
+				diff = append(diff, leaf)
+			}
+			continue
+		}
+		same, err := sameDigest(localNodes[index], remoteDigests[index], NodeCoord{level, uint64(index)})
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			mismatched = append(mismatched, NodeCoord{level, uint64(index)})
+		}
+	}
+
+	for ; level > 0 && len(mismatched) > 0; level-- {
+		children, err := remote.RequestChildren(mismatched)
+		if err != nil {
+			return nil, err
+		}
+		if len(children) != len(mismatched) {
+			return nil, fmt.Errorf("%w: children of %d nodes, want %d", ErrSyncResponse, len(children), len(mismatched))
+		}
+
+		next := mismatched[:0:0]
+		for i, coord := range mismatched {
+			for side, digest := range children[i] {
+				child := NodeCoord{level - 1, 2*coord.Index + uint64(side)}
+				if child.Index<<child.Level >= total {
+					continue // padding on both sides
+				}
+				same, err := sameDigest(local.node(child.Level, child.Index), digest, child)
+				if err != nil {
+					return nil, err
+				}
+				if !same {
+					next = append(next, child)
+				}
+			}
+		}
+		mismatched = next
+	}
+
+	// mismatched leaves, merged with those only one side holds, which all lie beyond the rest
+	leaves := make([]uint64, 0, len(mismatched)+len(diff))
+	for _, coord := range mismatched {
+		leaves = append(leaves, coord.Index)
+	}
+	return append(leaves, diff...), nil
+}
+
+// Reports whether the remote digest of the node at coord, in any form ParseHash accepts,
+// is the local one.
+func sameDigest(node Hash, remote string, coord NodeCoord) (bool, error) {
+	digest, err := ParseHash(remote)
+	if err != nil {
+		return false, fmt.Errorf("%w: node %d at level %d: %w", ErrSyncResponse, coord.Index, coord.Level, err)
+	}
+	return digest == node, nil
+}
@@ -0,0 +1,175 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Serves a replica from memory, as a transport would, counting what was asked of it.
+type memoryFetcher struct {
+	tree      *MerkleTree
+	requested int // nodes whose children were requested
+	calls     int
+	corrupt   bool // answer every request with one child too few
+}
+
+func (f *memoryFetcher) Shape() (uint64, int, error) {
+	return f.tree.Shape()
+}
+
+func (f *memoryFetcher) NodeDigests(level uint) ([]string, error) {
+	return f.tree.NodeDigests(level)
+}
+
+func (f *memoryFetcher) RequestChildren(coords []NodeCoord) ([][2]string, error) {
+	f.calls++
+	f.requested += len(coords)
+	children, err := f.tree.RequestChildren(coords)
+	if f.corrupt && err == nil {
+		children = children[1:]
+	}
+	return children, err
+}
+
+func TestSyncDiff(t *testing.T) {
+	cases := []struct {
+		name   string
+		opts   []Option
+		local  int
+		remote int
+		diff   []int // indices whose remote element differs
+	}{
+		{"identical", nil, 100, 100, nil},
+		{"one difference", nil, 100, 100, []int{42}},
+		{"first and last", nil, 64, 64, []int{0, 63}},
+		{"scattered", nil, 1000, 1000, []int{3, 4, 100, 511, 512, 999}},
+		{"remote longer", nil, 10, 13, nil},
+		{"remote much longer", nil, 10, 1000, []int{7}},
+		{"local longer", nil, 1000, 10, []int{2}},
+		{"single elements", nil, 1, 1, []int{0}},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}, 99, 101, []int{50, 97}},
+		{"fixed depth", []Option{WithFixedDepth(12)}, 300, 200, []int{150}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			localElements := testElements(c.local)
+			remoteElements := testElements(c.remote)
+			for _, i := range c.diff {
+				remoteElements[i] = fmt.Sprintf("changed %d", i)
+			}
+			local, err := NewMerkleTree(localElements, c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			remoteTree, err := NewMerkleTree(remoteElements, c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := []uint64{}
+			for _, i := range c.diff {
+				want = append(want, uint64(i))
+			}
+			for i := min(c.local, c.remote); i < max(c.local, c.remote); i++ {
+				want = append(want, uint64(i))
+			}
+			remote := &memoryFetcher{tree: remoteTree}
+			got, err := SyncDiff(local, remote)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil {
+				got = []uint64{}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSyncDiffDescendsOnlyIntoMismatches(t *testing.T) {
+	const size = 1 << 12
+	rng := rand.New(rand.NewSource(7))
+	local, _ := NewMerkleTree(testElements(size))
+	remoteTree, _ := NewMerkleTree(testElements(size))
+
+	changed := map[uint64]bool{}
+	for len(changed) < 5 {
+		index := uint64(rng.Intn(size))
+		changed[index] = true
+		if err := remoteTree.UpdateElement(index, fmt.Sprintf("changed %d", index)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var want []uint64
+	for index := range changed {
+		want = append(want, index)
+	}
+	sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+
+	remote := &memoryFetcher{tree: remoteTree}
+	got, err := SyncDiff(local, remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// every mismatching node lies on the path of a changed leaf
+	if limit := len(changed) * local.Height(); remote.requested > limit {
+		t.Errorf("got %d nodes requested, want at most %d", remote.requested, limit)
+	}
+	if remote.calls != local.Height() {
+		t.Errorf("got %d round trips, want %d", remote.calls, local.Height())
+	}
+
+	same := &memoryFetcher{tree: local}
+	if diff, err := SyncDiff(local, same); err != nil || len(diff) != 0 || same.calls != 0 {
+		t.Errorf("got %v, %v after %d round trips, want no differences and none", diff, err, same.calls)
+	}
+}
+
+func TestSyncDiffMalformedAnswer(t *testing.T) {
+	local, _ := NewMerkleTree(testElements(16))
+	remoteTree, _ := NewMerkleTree(testElements(16))
+	remoteTree.UpdateElement(3, "changed")
+
+	remote := &memoryFetcher{tree: remoteTree, corrupt: true}
+	if _, err := SyncDiff(local, remote); !errors.Is(err, ErrSyncResponse) {
+		t.Errorf("got %v, want ErrSyncResponse", err)
+	}
+}
+
+func TestRequestChildren(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+
+	children, err := mt.RequestChildren([]NodeCoord{{1, 0}, {3, 0}, {1, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, coord := range []NodeCoord{{1, 0}, {3, 0}, {1, 3}} {
+		left, _ := mt.NodeAt(NodeCoord{coord.Level - 1, 2 * coord.Index})
+		right, _ := mt.NodeAt(NodeCoord{coord.Level - 1, 2*coord.Index + 1})
+		if children[i] != [2]string{left, right} {
+			t.Errorf("got %v, want %v", children[i], [2]string{left, right})
+		}
+	}
+
+	for _, coord := range []NodeCoord{{0, 0}, {4, 0}, {1, 4}} {
+		if _, err := mt.RequestChildren([]NodeCoord{coord}); !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("got %v for %v, want ErrIndexOutOfBounds", err, coord)
+		}
+	}
+	if digests, _ := mt.NodeDigests(1); len(digests) != 3 {
+		t.Errorf("got %d digests at level 1, want 3", len(digests))
+	}
+	if _, err := mt.NodeDigests(4); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want ErrIndexOutOfBounds", err)
+	}
+}
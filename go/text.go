@@ -0,0 +1,156 @@
+package merkletree
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	commitmentTextVersion          = 0x01 // leading byte of a commitment's text form
+	commitmentTextAlgorithmVersion = 0x02 // leading byte of the text form naming an algorithm
+)
+
+// Encodes the proof as a URL-safe token, base64url without padding over MarshalBinary,
+// so proofs fit YAML scalars, query parameters and flags. The zero proof encodes as
+// empty text, letting flag.TextVar take it as a default.
+func (p MerkleProof) MarshalText() ([]byte, error) {
+	if p.hElement == "" && len(p.siblings) == 0 && len(p.directions) == 0 {
+		return []byte{}, nil
+	}
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.RawURLEncoding.EncodeToString(data)), nil
+}
+
+// Decodes a token from MarshalText, validating it as UnmarshalBinary does and failing
+// with ErrMalformedProof. Empty text decodes as the zero proof, which verifies nothing.
+func (p *MerkleProof) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = MerkleProof{}
+		return nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(string(text))
+	if err != nil {
+		return fmt.Errorf("%w: proof token: %v", ErrMalformedProof, err)
+	}
+	return p.UnmarshalBinary(data)
+}
+
+// Returns the proof's token, as MarshalText does, for text/template and logging.
+func (p MerkleProof) String() string {
+	text, err := p.MarshalText()
+	if err != nil {
+		return fmt.Sprintf("invalid proof: %v", err)
+	}
+	return string(text)
+}
+
+// Encodes the commitment as a URL-safe token, base64url without padding over
+//
+//	version (1 byte) | root (32 bytes) | leaf count (uvarint) | [algorithm (1 byte)]
+//	| scheme (see appendScheme)
+//
+// The algorithm is present under commitmentTextAlgorithmVersion, so commitments naming
+// none keep their original encoding. The zero commitment encodes as empty text.
+func (c Commitment) MarshalText() ([]byte, error) {
+	if c.Root == "" && c.LeafCount == 0 {
+		return []byte{}, nil
+	}
+	root, err := parseRoot(c.Root)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Algorithm.check(); err != nil {
+		return nil, err
+	}
+	out := []byte{commitmentTextVersion}
+	if c.Algorithm != AlgorithmUnspecified {
+		out[0] = commitmentTextAlgorithmVersion
+	}
+	out = append(out, root[:]...)
+	out = binary.AppendUvarint(out, c.LeafCount)
+	if c.Algorithm != AlgorithmUnspecified {
+		out = append(out, byte(c.Algorithm))
+	}
+	out = appendScheme(out, c.Scheme)
+	return []byte(base64.RawURLEncoding.EncodeToString(out)), nil
+}
+
+// Decodes a token from MarshalText, failing with ErrMalformedProof for anything else,
+// wrapping ErrUnknownAlgorithm or ErrAlgorithmMismatch for an algorithm that is not
+// registered or that the scheme does not hash with. Empty text decodes as the zero
+// commitment.
+func (c *Commitment) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = Commitment{}
+		return nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(string(text))
+	if err != nil {
+		return fmt.Errorf("%w: commitment token: %v", ErrMalformedProof, err)
+	}
+	if len(data) == 0 || (data[0] != commitmentTextVersion && data[0] != commitmentTextAlgorithmVersion) {
+		return fmt.Errorf("%w: unsupported commitment token version", ErrMalformedProof)
+	}
+
+	r := byteReader{data: data[1:]}
+	decoded := Commitment{Root: r.digest().String()}
+	decoded.LeafCount = r.uvarint(^uint64(0))
+	if data[0] == commitmentTextAlgorithmVersion {
+		if decoded.Algorithm = AlgorithmID(r.byte()); decoded.Algorithm == AlgorithmUnspecified {
+			r.fail("algorithm version for no algorithm")
+		}
+	}
+	decoded.Scheme = r.scheme()
+	if r.err == nil && len(r.data) > 0 {
+		r.fail("trailing data")
+	}
+	if r.err != nil {
+		return fmt.Errorf("%w: commitment token: %v", ErrMalformedProof, r.err)
+	}
+	if err := decoded.checkAlgorithm(); err != nil {
+		return fmt.Errorf("%w: commitment token: %w", ErrMalformedProof, err)
+	}
+	*c = decoded
+	return nil
+}
+
+// Returns the commitment's token, as MarshalText does, for text/template and logging.
+func (c Commitment) String() string {
+	text, err := c.MarshalText()
+	if err != nil {
+		return fmt.Sprintf("invalid commitment: %v", err)
+	}
+	return string(text)
+}
+
+// The fields of a Commitment, encoded as JSON objects rather than through MarshalText.
+type commitmentFields Commitment
+
+// Encodes the commitment as a JSON object of its fields, as before it had a text form.
+func (c Commitment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(commitmentFields(c))
+}
+
+func (c *Commitment) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*commitmentFields)(c))
+}
+
+// Encodes the digest as canonical hex.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// Decodes a digest as ParseHash does.
+func (h *Hash) UnmarshalText(text []byte) error {
+	parsed, err := ParseHash(string(text))
+	if err != nil {
+		return err
+	}
+	*h = parsed
+	return nil
+}
@@ -0,0 +1,187 @@
+package merkletree
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// Reads the scalars of a YAML document nested one level deep, which is all the fixture holds.
+func readYAMLScalars(t *testing.T, path string) map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	scalars := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if key, value, ok := strings.Cut(line, ": "); ok && !strings.HasPrefix(line, "#") {
+			scalars[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return scalars
+}
+
+func TestTextFixture(t *testing.T) {
+	scalars := readYAMLScalars(t, "testdata/claim.yaml")
+
+	var root Hash
+	var commitment Commitment
+	var proof MerkleProof
+	for key, value := range map[string]encoding.TextUnmarshaler{"root": &root, "commitment": &commitment, "proof": &proof} {
+		if err := value.UnmarshalText([]byte(scalars[key])); err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+	}
+
+	if commitment.Root != root.String() || commitment.LeafCount != 5 || commitment.Scheme.Tag != "airdrop" {
+		t.Errorf("got commitment %+v, want root %s over 5 elements tagged airdrop", commitment, root)
+	}
+	if err := VerifyCommitmentProof(commitment, proof); err != nil {
+		t.Errorf("got %v, want the fixture proof to verify", err)
+	}
+	if proof.hElement != newConfig([]Option{WithApplicationTag("airdrop")}).hashLeaf(scalars["element"]) {
+		t.Error("fixture proof is not of its element")
+	}
+
+	want, _ := NewMerkleTree([]string{"alice", "bob", "carol", "dave", "erin"}, WithApplicationTag("airdrop"))
+	if root.String() != want.GetRoot() {
+		t.Errorf("got root %s, want %s", root, want.GetRoot())
+	}
+	for key, value := range map[string]encoding.TextMarshaler{"root": root, "commitment": commitment, "proof": proof} {
+		if text, err := value.MarshalText(); err != nil || string(text) != scalars[key] {
+			t.Errorf("got %s, %v re-encoding %s, want %s", text, err, key, scalars[key])
+		}
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}},
+		{"tagged", []Option{WithApplicationTag("app"), WithEmptyLeaf([]byte("pad"))}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(11), c.opts...)
+			mt.UpdateElement(3, "updated")
+			for i := uint64(0); i < mt.LeafCount(); i++ {
+				proof, _ := mt.GetProof(i)
+				text, err := proof.MarshalText()
+				if err != nil {
+					t.Fatal(err)
+				}
+				var decoded MerkleProof
+				if err := decoded.UnmarshalText(text); err != nil {
+					t.Fatal(err)
+				}
+				if decoded.String() != proof.String() || decoded.Epoch() != proof.Epoch() {
+					t.Errorf("got %s, want %s", decoded, proof)
+				}
+				if !VerifyProof(mt.GetRoot(), decoded, c.opts...) {
+					t.Errorf("decoded proof of element %d rejected", i)
+				}
+			}
+
+			text, _ := mt.Commitment().MarshalText()
+			var commitment Commitment
+			if err := commitment.UnmarshalText(text); err != nil {
+				t.Fatal(err)
+			}
+			if commitment.String() != mt.Commitment().String() || commitment.Root != mt.GetRoot() {
+				t.Errorf("got %+v, want %+v", commitment, mt.Commitment())
+			}
+		})
+	}
+}
+
+func TestTextMalformed(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(4))
+	proof, _ := mt.GetProof(1)
+	proofText, _ := proof.MarshalText()
+	commitmentText, _ := mt.Commitment().MarshalText()
+
+	cases := []struct {
+		name  string
+		value encoding.TextUnmarshaler
+		text  string
+	}{
+		{"proof not base64", &MerkleProof{}, "not*base64"},
+		{"proof truncated", &MerkleProof{}, string(proofText[:len(proofText)-8])},
+		{"proof trailing data", &MerkleProof{}, string(proofText) + "AAAA"},
+		{"commitment truncated", &Commitment{}, string(commitmentText[:20])},
+		{"commitment trailing data", &Commitment{}, string(commitmentText) + "AAAA"},
+		{"commitment version", &Commitment{}, "AiEh"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.value.UnmarshalText([]byte(c.text)); !errors.Is(err, ErrMalformedProof) {
+				t.Errorf("got %v, want ErrMalformedProof", err)
+			}
+		})
+	}
+
+	var h Hash
+	if err := h.UnmarshalText([]byte("abcd")); !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("got %v, want ErrInvalidHash", err)
+	}
+}
+
+func TestTextIntegrations(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6))
+	proof, _ := mt.GetProof(4)
+
+	var fromFlag MerkleProof
+	var root Hash
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.TextVar(&fromFlag, "proof", MerkleProof{}, "proof to verify")
+	flags.TextVar(&root, "root", Hash{}, "root to verify against")
+	if err := flags.Parse([]string{"-proof", proof.String(), "-root", "0x" + mt.GetRoot()}); err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyProofHash(root, fromFlag) {
+		t.Error("proof parsed from flags rejected")
+	}
+
+	var out strings.Builder
+	tmpl := template.Must(template.New("").Parse("?root={{.Root}}&proof={{.Proof}}"))
+	if err := tmpl.Execute(&out, struct {
+		Root  Hash
+		Proof MerkleProof
+	}{mt.GetRootHash(), proof}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "?root=" + mt.GetRoot() + "&proof=" + proof.String(); out.String() != want {
+		t.Errorf("got %s, want %s", out.String(), want)
+	}
+
+	// commitments stay JSON objects, while digests become hex strings
+	encoded, _ := json.Marshal(struct {
+		Commitment Commitment
+		Root       Hash
+	}{mt.Commitment(), mt.GetRootHash()})
+	if want := `{"Commitment":{"root":"` + mt.GetRoot() + `","leafCount":6,"scheme":{},"algorithm":"sha256"},"Root":"` + mt.GetRoot() + `"}`; string(encoded) != want {
+		t.Errorf("got %s, want %s", encoded, want)
+	}
+	var decoded Commitment
+	if err := json.Unmarshal([]byte(`{"root":"`+mt.GetRoot()+`","leafCount":6}`), &decoded); err != nil || decoded.LeafCount != 6 {
+		t.Errorf("got %+v, %v decoding a JSON commitment", decoded, err)
+	}
+}
@@ -0,0 +1,83 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Names of the spans tree operations start, see WithTracer.
+const (
+	spanNewMerkleTree = "merkletree.NewMerkleTree"
+	spanBuildAsync    = "merkletree.BuildAsync"
+	spanFromChannel   = "merkletree.NewMerkleTreeFromChannel"
+	spanFromSource    = "merkletree.NewMerkleTreeFromSource"
+	spanFromCSV       = "merkletree.NewMerkleTreeFromCSV"
+	spanGetProof      = "merkletree.GetProof"
+	spanGetProofs     = "merkletree.GetProofs"
+	spanVerifyProof   = "merkletree.VerifyProof"
+	spanVerifyBytes   = "merkletree.VerifyProofBytes"
+	spanVerifyMulti   = "merkletree.VerifyMultiProof"
+)
+
+// Keys of the attributes spans carry.
+const (
+	attrLeafCount  = "merkletree.leaf_count"  // elements of the tree
+	attrDepth      = "merkletree.depth"       // height of the tree, or siblings of the proof verified
+	attrIndex      = "merkletree.index"       // leaf proven
+	attrIndexCount = "merkletree.index_count" // leaves proven at once
+	attrResult     = "merkletree.result"      // whether the proof verified
+	attrReason     = "merkletree.reason"      // why a malformed proof did not verify
+)
+
+// Starts spans around tree operations for a tracing system such as OpenTelemetry, which
+// the package does not depend on. An adapter starts a span of the tracing system under
+// ctx, converting each slog.Attr to an attribute of it, and returns the context carrying
+// the span along with it; for OpenTelemetry, merkleotel.New wraps a trace.Tracer.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// A span a Tracer started, ended by the tree once the operation returns.
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	RecordError(err error) // the operation failed with err; called before End
+	End()
+}
+
+// Starts a span around building, proving and verifying: NewMerkleTree, BuildAsync and
+// the streaming constructors, GetProof and GetProofs, and VerifyProof, VerifyProofBytes
+// and VerifyMultiProof along with their Verifier methods and the verifiers built on them.
+// Spans carry the tree's leaf count and depth, the index proven, and whether the proof
+// verified; failures are recorded on them. The constructors taking a context start their
+// span under it, so it nests under the caller's; other operations start theirs under
+// context.Background. Without this option, tracing costs one nil check per operation.
+// The tracer must be safe for concurrent use if the tree is.
+func WithTracer(tracer Tracer) Option {
+	return func(cfg *config) {
+		cfg.tracer = tracer
+	}
+}
+
+// Ends span of an operation on t, which failed with err unless it is nil, adding the
+// tree's leaf count and depth once it is built.
+func (t *MerkleTree) endSpan(span Span, err error, attrs ...slog.Attr) {
+	if err != nil {
+		span.RecordError(err)
+	} else {
+		attrs = append(attrs, slog.Uint64(attrLeafCount, t.leafCount()), slog.Int(attrDepth, t.height()))
+	}
+	span.SetAttributes(attrs...)
+	span.End()
+}
+
+// Ends span of a verification, which failed with err unless it is nil. Proofs of another
+// root end with their result alone; malformed ones also record why.
+func endVerifySpan(span Span, err error) {
+	span.SetAttributes(slog.Bool(attrResult, err == nil))
+	if err != nil && !errors.Is(err, ErrInvalidProof) {
+		span.SetAttributes(slog.String(attrReason, err.Error()))
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,155 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// A span recordingTracer started, with everything the tree set on it.
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]slog.Value
+	err    error
+	ended  bool
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...slog.Attr) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) { s.err = err }
+func (s *recordedSpan) End()                  { s.ended = true }
+
+type spanKey struct{}
+
+// Keeps every span in memory, as an in-memory exporter would, parenting each under
+// the span its context carries.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attrs: map[string]slog.Value{}}
+	span.SetAttributes(attrs...)
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Returns the spans recorded since the last call, all of them ended.
+func (r *recordingTracer) take(t *testing.T) []*recordedSpan {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := r.spans
+	r.spans = nil
+	for _, span := range spans {
+		if !span.ended {
+			t.Errorf("got span %s left open", span.name)
+		}
+	}
+	return spans
+}
+
+func TestTracerSpans(t *testing.T) {
+	tracer := &recordingTracer{}
+	mt, err := NewMerkleTree(testElements(5), WithTracer(tracer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := tracer.take(t)
+	if len(spans) != 1 || spans[0].name != "merkletree.NewMerkleTree" {
+		t.Fatalf("got %d spans, want one merkletree.NewMerkleTree", len(spans))
+	}
+	if got := spans[0].attrs["merkletree.leaf_count"].Uint64(); got != 5 {
+		t.Errorf("got leaf count %d, want 5", got)
+	}
+	if got := spans[0].attrs["merkletree.depth"].Int64(); got != 3 {
+		t.Errorf("got depth %d, want 3", got)
+	}
+
+	proof, _ := mt.GetProof(3)
+	mt.GetProofs([]uint64{0, 4})
+	spans = tracer.take(t)
+	if len(spans) != 2 || spans[0].name != "merkletree.GetProof" || spans[1].name != "merkletree.GetProofs" {
+		t.Fatalf("got %d spans, want GetProof then GetProofs", len(spans))
+	}
+	if index, count := spans[0].attrs["merkletree.index"].Uint64(), spans[1].attrs["merkletree.index_count"].Int64(); index != 3 || count != 2 {
+		t.Errorf("got index %d and index count %d, want 3 and 2", index, count)
+	}
+	if _, err := mt.GetProof(9); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Fatalf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	if spans = tracer.take(t); len(spans) != 1 || !errors.Is(spans[0].err, ErrIndexOutOfBounds) {
+		t.Errorf("got %d spans, want the failure recorded", len(spans))
+	}
+
+	verifier := mt.Verifier()
+	verifier.VerifyProof(mt.GetRoot(), proof)
+	verifier.VerifyProof(hashLeaf("other"), proof)
+	verifier.VerifyProof("not a root", proof)
+	bytesProof, _ := proof.Bytes()
+	verifier.VerifyProofBytes(mt.GetRootHash(), bytesProof)
+	multi, _ := CombineProofs([]MerkleProof{proof})
+	verifier.VerifyMultiProof(mt.GetRoot(), multi)
+	spans = tracer.take(t)
+	want := []struct {
+		name   string
+		result bool
+		failed bool
+	}{
+		{"merkletree.VerifyProof", true, false},
+		{"merkletree.VerifyProof", false, false},
+		{"merkletree.VerifyProof", false, true},
+		{"merkletree.VerifyProofBytes", true, false},
+		{"merkletree.VerifyMultiProof", true, false},
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("got %d spans, want %d", len(spans), len(want))
+	}
+	for i, w := range want {
+		span := spans[i]
+		if span.name != w.name || span.attrs["merkletree.result"].Bool() != w.result || (span.err != nil) != w.failed {
+			t.Errorf("span %d: got %s with result %v and error %v, want %s with result %v",
+				i, span.name, span.attrs["merkletree.result"], span.err, w.name, w.result)
+		}
+	}
+	if depth := spans[0].attrs["merkletree.depth"].Int64(); depth != 3 {
+		t.Errorf("got verified depth %d, want 3", depth)
+	}
+}
+
+func TestTracerNestsUnderContext(t *testing.T) {
+	tracer := &recordingTracer{}
+	ctx, parent := tracer.Start(context.Background(), "request")
+	parent.End()
+
+	NewMerkleTreeFromChannel(ctx, elementChannel(testElements(6)), WithTracer(tracer))
+	NewMerkleTreeFromSource(ctx, SliceSource(testElements(6)), WithTracer(tracer))
+	h := BuildAsync(ctx, testElements(6), WithTracer(tracer))
+	<-h.Done()
+	NewMerkleTreeFromSource(ctx, SliceSource(nil), WithTracer(tracer))
+
+	spans := tracer.take(t)[1:]
+	names := []string{"merkletree.NewMerkleTreeFromChannel", "merkletree.NewMerkleTreeFromSource", "merkletree.BuildAsync", "merkletree.NewMerkleTreeFromSource"}
+	if len(spans) != len(names) {
+		t.Fatalf("got %d spans, want one per build, none nested within them", len(spans))
+	}
+	for i, span := range spans {
+		if span.name != names[i] || span.parent == nil || span.parent.name != "request" {
+			t.Errorf("got span %s, want %s under the request's", span.name, names[i])
+		}
+	}
+	if spans[2].attrs["merkletree.leaf_count"].Uint64() != 6 || !errors.Is(spans[3].err, ErrEmptyTree) {
+		t.Errorf("got leaf count %v and error %v, want 6 and %v", spans[2].attrs["merkletree.leaf_count"], spans[3].err, ErrEmptyTree)
+	}
+}
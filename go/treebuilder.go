@@ -0,0 +1,93 @@
+package merkletree
+
+import "time"
+
+// Builds many trees under the same options, keeping its scratch space between builds.
+// Each tree's element index is laid out in one shared array rather than a slice
+// per element, so a build allocates a small, constant number of times.
+// Trees built are independent of each other and of the Builder.
+// A Builder is not safe for concurrent use.
+type Builder struct {
+	cfg    config
+	counts map[string]uint64 // occurrences of each element in the current build, emptied afterwards
+}
+
+// Creates a Builder with room for trees of up to capacity elements
+// before its scratch space has to grow.
+func NewBuilder(capacity uint64, opts ...Option) *Builder {
+	return &Builder{
+		cfg:    newConfig(opts),
+		counts: make(map[string]uint64, capacity),
+	}
+}
+
+// Builds the tree NewMerkleTree would for the elements with the Builder's options.
+func (b *Builder) Build(elements []string) (*MerkleTree, error) {
+	var start time.Time
+	if b.cfg.timed() {
+		start = time.Now()
+	}
+
+	var leaves []Hash
+	if b.cfg.setSemantics {
+		elements, leaves = b.cfg.setOrder(elements)
+	}
+
+	height, err := b.cfg.checkedHeight(uint64(len(elements)))
+	if err != nil {
+		return nil, err
+	}
+	if err := b.cfg.checkElements(elements); err != nil {
+		return nil, err
+	}
+
+	defer b.resetCounts()
+	for i, element := range elements {
+		b.counts[element]++
+		if b.cfg.rejectDuplicates && b.counts[element] > 1 {
+			return nil, &DuplicateLeafError{Element: element, Existing: firstIndex(elements, element), Index: uint64(i)}
+		}
+	}
+
+	t := &MerkleTree{
+		cfg:      b.cfg,
+		elements: append([]string(nil), elements...),
+		indices:  make(map[string][]uint64, len(b.counts)),
+	}
+
+	// each element's indices get a slice of one shared array, capped at its count
+	// so that a later reindex reallocates rather than overwrite its neighbour
+	slab := make([]uint64, len(elements))
+	offset := uint64(0)
+	for i, element := range elements {
+		indices, ok := t.indices[element]
+		if !ok {
+			count := b.counts[element]
+			indices = slab[offset : offset : offset+count]
+			offset += count
+		}
+		t.indices[element] = append(indices, uint64(i))
+	}
+
+	t.hashLevels(height, leaves)
+
+	if b.cfg.timed() {
+		t.reportBuild(time.Since(start))
+	}
+	return t, nil
+}
+
+func (b *Builder) resetCounts() {
+	for element := range b.counts {
+		delete(b.counts, element)
+	}
+}
+
+func firstIndex(elements []string, element string) uint64 {
+	for i, e := range elements {
+		if e == element {
+			return uint64(i)
+		}
+	}
+	return 0
+}
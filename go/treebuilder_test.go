@@ -0,0 +1,111 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBuilderMatchesNewMerkleTree(t *testing.T) {
+	optionSets := map[string][]Option{
+		"default":     nil,
+		"empty leaf":  {WithEmptyLeaf([]byte("nothing"))},
+		"fixed depth": {WithFixedDepth(12)},
+	}
+
+	for name, opts := range optionSets {
+		b := NewBuilder(16, opts...)
+		for _, size := range []int{1, 2, 3, 7, 16, 33} {
+			testname := fmt.Sprintf("%s with %d elements", name, size)
+			t.Run(testname, func(t *testing.T) {
+				elements := testElements(size)
+				elements[size/2] = "repeated"
+				elements[size-1] = "repeated"
+
+				built, err := b.Build(elements)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fresh, _ := NewMerkleTree(elements, opts...)
+
+				if built.GetRoot() != fresh.GetRoot() {
+					t.Errorf("got %s, want %s", built.GetRoot(), fresh.GetRoot())
+				}
+				if !reflect.DeepEqual(built.nodes, fresh.nodes) || !reflect.DeepEqual(built.indices, fresh.indices) {
+					t.Error("stored state differs from NewMerkleTree")
+				}
+				if len(b.counts) != 0 {
+					t.Errorf("got %d counts left after the build, want 0", len(b.counts))
+				}
+			})
+		}
+	}
+}
+
+func TestBuilderTreesAreIndependent(t *testing.T) {
+	b := NewBuilder(8)
+	first, _ := b.Build([]string{"a", "b", "c", "a", "d"})
+	second, _ := b.Build([]string{"a", "b", "c", "a", "d"})
+	root := second.GetRoot()
+
+	// moving index 4 onto "a" grows its capped index slice, then index 1 leaves "b" empty
+	if err := first.UpdateElement(4, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.UpdateElement(1, "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	if second.GetRoot() != root {
+		t.Error("updating one tree changed another")
+	}
+	if got := first.AllIndices("a"); !reflect.DeepEqual(got, []uint64{0, 3, 4}) {
+		t.Errorf("got %v, want [0 3 4]", got)
+	}
+	if got := first.AllIndices("c"); !reflect.DeepEqual(got, []uint64{1, 2}) {
+		t.Errorf("got %v, want [1 2]", got)
+	}
+	if got := second.AllIndices("a"); !reflect.DeepEqual(got, []uint64{0, 3}) {
+		t.Errorf("got %v, want [0 3]", got)
+	}
+
+	updated, _ := NewMerkleTree([]string{"a", "c", "c", "a", "a"})
+	if first.GetRoot() != updated.GetRoot() {
+		t.Errorf("got %s, want %s", first.GetRoot(), updated.GetRoot())
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	b := NewBuilder(4, WithRejectDuplicates())
+
+	if _, err := b.Build(nil); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+
+	var duplicate *DuplicateLeafError
+	if _, err := b.Build([]string{"a", "b", "c", "b"}); !errors.As(err, &duplicate) {
+		t.Fatalf("got %v, want a DuplicateLeafError", err)
+	}
+	if duplicate.Existing != 1 || duplicate.Index != 3 {
+		t.Errorf("got indices %d and %d, want 1 and 3", duplicate.Existing, duplicate.Index)
+	}
+
+	// a failed build leaves no counts behind to trip the next one
+	if _, err := b.Build([]string{"b", "c"}); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	elements := testElements(10000)
+	builder := NewBuilder(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := builder.Build(elements); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package merkletree
+
+import "errors"
+
+// Verifies a Merkle proof against a known root, stopping early at the first node the fold
+// reaches that the trusted callback accepts, for verifiers keeping known-good interior
+// nodes in a store. The callback sees each node on the path below the root with its level,
+// starting from the leaf hash at level 0, as canonical hex. Accepted proofs report the
+// level of the node accepted, or the proof's depth when the fold reached the root; a
+// deep proof meeting a warm node low down skips most of its hashing. A nil callback
+// trusts nothing, verifying as VerifyProofWithReason does.
+//
+// An accepted node is taken as the root is, so the result is only as sound as the
+// callback: one accepting a node that is not in the tree accepts a proof whatever the
+// root. Failures return false with the error VerifyProofWithReason would, at the depth
+// for a root that does not match and at level 0 for a proof that cannot be checked.
+func VerifyProofPartial(trusted func(level int, hash string) bool, root string, proof MerkleProof, opts ...Option) (bool, int, error) {
+	return newVerifier(opts).VerifyProofPartial(trusted, root, proof)
+}
+
+// Verifies a Merkle proof against a known root or a trusted node, as VerifyProofPartial does.
+func (v *Verifier) VerifyProofPartial(trusted func(level int, hash string) bool, root string, proof MerkleProof) (ok bool, level int, err error) {
+	cfg := v.cfg
+
+	parsed, err := parseRoot(root)
+	if err == nil {
+		proof, err = proof.normalized()
+	}
+	if err == nil {
+		err = cfg.checkTag(proof.tag)
+	}
+	if err == nil {
+		cfg, err = cfg.forAlgorithm(proof.algorithm)
+	}
+
+	hashed := 0
+	if err == nil {
+		current := proof.hElement
+		for ; level < len(proof.siblings); level++ {
+			if trusted != nil && trusted(level, current) {
+				ok = true
+				break
+			}
+			if proof.directions[level] {
+				current = cfg.hashNode(proof.siblings[level], current)
+			} else {
+				current = cfg.hashNode(current, proof.siblings[level])
+			}
+			hashed++
+		}
+		if !ok && current != parsed.String() {
+			err = ErrInvalidProof
+		}
+		ok = err == nil
+	}
+
+	if cfg.metrics != nil {
+		cfg.metrics.NodeHashed(hashed)
+		cfg.metrics.ProofVerified(ok)
+	}
+	switch {
+	case errors.Is(err, ErrInvalidProof):
+		cfg.logVerifyFailure(root, proof, nil)
+	case err != nil:
+		cfg.logVerifyFailure(root, proof, err)
+	}
+	return ok, level, err
+}
@@ -0,0 +1,101 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+// Returns a callback trusting the tree's nodes at the given level, recording every level
+// it was asked about.
+func trustLevel(t *testing.T, mt *MerkleTree, trustedLevel int, asked *[]int) func(level int, hash string) bool {
+	known := make(map[string]bool)
+	for index := uint64(0); index < mt.PaddedLeafCount()>>trustedLevel; index++ {
+		node, err := mt.NodeAt(NodeCoord{trustedLevel, index})
+		if err != nil {
+			t.Fatal(err)
+		}
+		known[node] = true
+	}
+	return func(level int, hash string) bool {
+		*asked = append(*asked, level)
+		return level == trustedLevel && known[hash]
+	}
+}
+
+func TestVerifyProofPartialEarlyExit(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(1000))
+	proof, _ := mt.GetProof(617)
+
+	var asked []int
+	metrics := &CountingMetrics{}
+	ok, level, err := VerifyProofPartial(trustLevel(t, mt, 3, &asked), mt.GetRoot(), proof, WithMetrics(metrics))
+	if !ok || level != 3 || err != nil {
+		t.Fatalf("got %v at level %d, %v, want true at level 3", ok, level, err)
+	}
+	if len(asked) != 4 || asked[3] != 3 {
+		t.Errorf("got callback asked at levels %v, want 0 to 3", asked)
+	}
+	if got := metrics.NodeHashes.Load(); got != 3 {
+		t.Errorf("got %d nodes hashed, want 3", got)
+	}
+
+	// trusting the leaf itself hashes nothing
+	ok, level, _ = VerifyProofPartial(func(level int, hash string) bool { return hash == proof.hElement }, mt.GetRoot(), proof)
+	if !ok || level != 0 {
+		t.Errorf("got %v at level %d, want true at level 0", ok, level)
+	}
+}
+
+func TestVerifyProofPartialNoExit(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(1000))
+	proof, _ := mt.GetProof(617)
+
+	var asked []int
+	never := func(level int, hash string) bool {
+		asked = append(asked, level)
+		return false
+	}
+	ok, level, err := VerifyProofPartial(never, mt.GetRoot(), proof)
+	if !ok || level != mt.Height() || err != nil {
+		t.Fatalf("got %v at level %d, %v, want true at level %d", ok, level, err, mt.Height())
+	}
+	if len(asked) != mt.Height() {
+		t.Errorf("got callback asked at levels %v, want every level below the root", asked)
+	}
+	if ok, _, err := VerifyProofPartial(nil, mt.GetRoot(), proof); !ok || err != nil {
+		t.Errorf("got %v, %v with no callback, want true", ok, err)
+	}
+
+	other, _ := NewMerkleTree(testElements(999))
+	if ok, level, err := VerifyProofPartial(never, other.GetRoot(), proof); ok || level != mt.Height() || !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v at level %d, %v against another root, want ErrInvalidProof", ok, level, err)
+	}
+	if ok, level, err := VerifyProofPartial(never, "not a root", proof); ok || level != 0 || !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("got %v at level %d, %v, want ErrInvalidHash", ok, level, err)
+	}
+}
+
+func TestVerifyProofPartialUntrustedPaths(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(64))
+	other, _ := NewMerkleTree(testElements(5))
+	proof, _ := mt.GetProof(20)
+
+	// a forged sibling below the trusted level leads the fold away from trusted nodes,
+	// so it falls back to the root, which rejects it
+	var asked []int
+	forged, _ := mt.GetProofBytes(20)
+	forged.Siblings[1][0] ^= 1
+	if ok, level, err := VerifyProofPartial(trustLevel(t, mt, 4, &asked), mt.GetRoot(), forged.Proof()); ok || level != mt.Height() || !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v at level %d, %v for a forged proof, want ErrInvalidProof", ok, level, err)
+	}
+
+	// a lying callback is believed whatever the root, so the soundness of the result is
+	// the callback's
+	liar := func(level int, hash string) bool { return level == 2 }
+	if ok, level, err := VerifyProofPartial(liar, other.GetRoot(), proof); !ok || level != 2 || err != nil {
+		t.Errorf("got %v at level %d, %v, want the lying callback believed at level 2", ok, level, err)
+	}
+	if ok, _, err := VerifyProofPartial(func(int, string) bool { return false }, other.GetRoot(), proof); ok || !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("got %v, %v against the wrong root without the liar, want ErrInvalidProof", ok, err)
+	}
+}
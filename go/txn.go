@@ -0,0 +1,129 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrConflict = errors.New("merkletree: tree changed since the transaction began")
+	ErrTxnDone  = errors.New("merkletree: transaction already committed or rolled back")
+)
+
+// A batch of mutations staged on a private copy of a tree, invisible to the tree's readers
+// until Commit makes them visible all at once. A Txn is not safe for concurrent use.
+type Txn struct {
+	tree    *MerkleTree
+	work    *MerkleTree  // copy the staged mutations apply to; nil once done
+	base    uint64       // epoch of tree when the transaction began
+	records bytes.Buffer // journal records of the staged mutations, when the tree journals
+	err     error        // why the transaction cannot go on, such as ErrTxnDone
+}
+
+// Begins a transaction over a copy of the tree as it is now, so the batch's prospective
+// root can be checked before anything is made visible. Beginning costs a copy of the
+// tree; the tree stays free for readers and writers meanwhile.
+// Transactions do not wait for each other: Commit fails with ErrConflict once the tree
+// has changed since Begin, whether directly or by another transaction's Commit.
+func (t *MerkleTree) Begin() *Txn {
+	work, err := t.clone()
+	if err != nil {
+		return &Txn{err: err}
+	}
+	txn := &Txn{tree: t, work: work, base: work.epoch}
+	if t.cfg.journal != nil {
+		work.cfg.journal = &txn.records
+	}
+	return txn
+}
+
+// Stages UpdateElement(index, element).
+func (x *Txn) Update(index uint64, element string) error {
+	if x.err != nil {
+		return x.err
+	}
+	return x.work.UpdateElement(index, element)
+}
+
+// Stages Append(element).
+func (x *Txn) Append(element string) error {
+	if x.err != nil {
+		return x.err
+	}
+	return x.work.Append(element)
+}
+
+// Stages deleting every element from index n on, keeping the first n. The height
+// shrinks with the count as for Delete. Fails with ErrIndexOutOfBounds beyond the
+// elements and ErrEmptyTree for n of zero, staging nothing.
+func (x *Txn) Truncate(n uint64) error {
+	if x.err != nil {
+		return x.err
+	}
+	if count := x.work.LeafCount(); n > count {
+		return fmt.Errorf("%w: truncating to %d of %d elements", ErrIndexOutOfBounds, n, count)
+	}
+	if n == 0 {
+		return ErrEmptyTree
+	}
+	for last := x.work.LeafCount(); last > n; last-- {
+		if err := x.work.Delete(last - 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the root the tree would have were the transaction committed now.
+func (x *Txn) Root() (string, error) {
+	if x.err != nil {
+		return "", x.err
+	}
+	return x.work.GetRoot(), nil
+}
+
+// Makes every staged mutation visible at once: readers see the tree entirely before or
+// after them, and subscribers hear of one root change. The journal receives the records
+// of the staged mutations in one Write, and watched proofs are regenerated.
+// Fails with ErrConflict, leaving the tree as it was, when the tree changed since Begin;
+// either way the transaction is done. A transaction staging nothing commits nothing.
+func (x *Txn) Commit() error {
+	if x.err != nil {
+		return x.err
+	}
+	work := x.work
+	x.err, x.work = ErrTxnDone, nil
+	if work.epoch == x.base {
+		return nil
+	}
+
+	return x.tree.mutate(func(rec *journalRecord) error {
+		t := x.tree
+		if t.epoch != x.base {
+			return fmt.Errorf("%w: epoch %d, transaction began at %d", ErrConflict, t.epoch, x.base)
+		}
+		if rec != nil {
+			*rec = journalRecord{framed: x.records.Bytes()}
+		}
+		t.adopt(work)
+		return nil
+	})
+}
+
+// Discards every staged mutation, leaving the tree exactly as if the transaction had
+// never begun. Rolling back a transaction already done does nothing.
+func (x *Txn) Rollback() {
+	if x.work != nil {
+		x.err, x.work = ErrTxnDone, nil
+		x.records = bytes.Buffer{}
+	}
+}
+
+// Takes over the state of work, a copy of the tree with mutations applied, keeping the
+// tree's own options, watched proofs and subscribers. Expects t.mu held for writing.
+func (t *MerkleTree) adopt(work *MerkleTree) {
+	t.elements, t.indices, t.data, t.keys = work.elements, work.indices, work.data, work.keys
+	t.nodes, t.count, t.offsets, t.zero = work.nodes, work.count, work.offsets, work.zero
+	t.epoch, t.dirty, t.workers, t.bloom = work.epoch, work.dirty, work.workers, work.bloom
+}
@@ -0,0 +1,125 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTxnIsolatedUntilCommit(t *testing.T) {
+	var journal bytes.Buffer
+	mt, _ := NewMerkleTree(testElements(6), WithJournal(&journal))
+	base, _ := NewMerkleTree(testElements(6))
+	mt.Watch(1)
+	var changes int
+	mt.Subscribe(func(oldRoot, newRoot string, epoch uint64) { changes++ })
+	root, epoch := mt.GetRoot(), mt.Epoch()
+
+	txn := mt.Begin()
+	txn.Update(1, "updated")
+	txn.Append("appended")
+	txn.Append("appended again")
+	txn.Truncate(7)
+	prospective, err := txn.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt.GetRoot() != root || mt.Epoch() != epoch || mt.LeafCount() != 6 || journal.Len() != 0 || changes != 0 {
+		t.Fatal("got staged mutations visible before Commit")
+	}
+
+	want, _ := NewMerkleTree(append(testElements(6)[:1], "updated", "element-2", "element-3", "element-4", "element-5", "appended"))
+	if prospective != want.GetRoot() {
+		t.Fatalf("got prospective root %s, want %s", prospective, want.GetRoot())
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if mt.GetRoot() != prospective || !reflect.DeepEqual(mt.OriginalElements(), want.OriginalElements()) {
+		t.Errorf("got root %s after Commit, want %s", mt.GetRoot(), prospective)
+	}
+	if changes != 1 {
+		t.Errorf("got %d root changes, want one for the whole transaction", changes)
+	}
+	if witness, _ := mt.CurrentProof(1); !VerifyProof(mt.GetRoot(), witness) {
+		t.Error("got the watched proof left behind by Commit")
+	}
+	replayed, err := ReplayJournal(base, &journal)
+	if err != nil || replayed.GetRoot() != mt.GetRoot() || replayed.Epoch() != mt.Epoch() {
+		t.Errorf("got %v, replayed root or epoch differs", err)
+	}
+	if err := txn.Commit(); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("got %v, want %v", err, ErrTxnDone)
+	}
+	if err := txn.Append("late"); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("got %v, want %v", err, ErrTxnDone)
+	}
+}
+
+func TestTxnRollbackLeavesNoResidue(t *testing.T) {
+	var journal bytes.Buffer
+	mt, _ := NewMerkleTree(testElements(5), WithJournal(&journal), WithBloomFilter(10), WithProofCache(4))
+	before, _ := mt.Levels()
+	epoch := mt.Epoch()
+	proof, _ := mt.GetProof(2)
+
+	txn := mt.Begin()
+	txn.Update(2, "updated")
+	txn.Append("appended")
+	txn.Rollback()
+	txn.Rollback()
+
+	after, _ := mt.Levels()
+	if !reflect.DeepEqual(after, before) || mt.Epoch() != epoch || journal.Len() != 0 {
+		t.Error("got the tree changed by a rolled back transaction")
+	}
+	if mt.Contains("appended") || mt.LeafCount() != 5 {
+		t.Error("got a rolled back element in the tree")
+	}
+	if again, _ := mt.GetProof(2); !reflect.DeepEqual(again, proof) {
+		t.Error("got another proof after Rollback")
+	}
+	if _, err := txn.Root(); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("got %v, want %v", err, ErrTxnDone)
+	}
+}
+
+func TestTxnConflicts(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(4))
+	first, second := mt.Begin(), mt.Begin()
+	first.Update(0, "first")
+	second.Update(1, "second")
+	if err := first.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	root := mt.GetRoot()
+	if err := second.Commit(); !errors.Is(err, ErrConflict) || mt.GetRoot() != root {
+		t.Errorf("got %v, want %v and the first commit kept", err, ErrConflict)
+	}
+
+	direct := mt.Begin()
+	direct.Append("staged")
+	mt.Append("direct")
+	if err := direct.Commit(); !errors.Is(err, ErrConflict) {
+		t.Errorf("got %v, want %v", err, ErrConflict)
+	}
+
+	unchanged := mt.Begin()
+	mt.Append("meanwhile")
+	if err := unchanged.Commit(); err != nil {
+		t.Errorf("got %v committing nothing, want nil", err)
+	}
+
+	txn := mt.Begin()
+	if err := txn.Truncate(0); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("got %v, want %v", err, ErrEmptyTree)
+	}
+	if err := txn.Truncate(mt.LeafCount() + 1); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	var uninitialized MerkleTree
+	if err := uninitialized.Begin().Append("x"); !errors.Is(err, ErrUninitializedTree) {
+		t.Errorf("got %v, want %v", err, ErrUninitializedTree)
+	}
+}
@@ -0,0 +1,49 @@
+package merkletree
+
+import "errors"
+
+// Returned by the methods of a nil *MerkleTree, or of a MerkleTree declared rather than
+// built by a constructor, such as one zero-initialized within a larger struct. Methods
+// without an error result return their zero result instead.
+var ErrUninitializedTree = errors.New("merkletree: tree is nil or was not built by a constructor")
+
+// Takes t.mu for reading as rlock does, failing with ErrUninitializedTree, and leaving
+// t unlocked, for a tree no constructor built. Every built tree has its level offsets.
+func (t *MerkleTree) rlockBuilt() error {
+	if t == nil {
+		return ErrUninitializedTree
+	}
+	t.rlock()
+	if t.offsets == nil {
+		t.mu.RUnlock()
+		return ErrUninitializedTree
+	}
+	return nil
+}
+
+// As rlockBuilt, for methods reading no interior nodes, which leave any lazily updated
+// ancestors to be recomputed later.
+func (t *MerkleTree) rlockBuiltAsIs() error {
+	if t == nil {
+		return ErrUninitializedTree
+	}
+	t.mu.RLock()
+	if t.offsets == nil {
+		t.mu.RUnlock()
+		return ErrUninitializedTree
+	}
+	return nil
+}
+
+// Takes t.mu for writing, failing as rlockBuilt does.
+func (t *MerkleTree) lockBuilt() error {
+	if t == nil {
+		return ErrUninitializedTree
+	}
+	t.mu.Lock()
+	if t.offsets == nil {
+		t.mu.Unlock()
+		return ErrUninitializedTree
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Returns arguments for calling method: zero values, except for callbacks, which return
+// zero values themselves, and contexts, which are never done.
+func zeroArguments(method reflect.Method) []reflect.Value {
+	var args []reflect.Value
+	contextType := reflect.TypeOf((*context.Context)(nil)).Elem()
+	for i := 1; i < method.Type.NumIn(); i++ {
+		in := method.Type.In(i)
+		switch {
+		case method.Type.IsVariadic() && i == method.Type.NumIn()-1:
+			return args
+		case in.Kind() == reflect.Func:
+			args = append(args, reflect.MakeFunc(in, func([]reflect.Value) []reflect.Value {
+				results := make([]reflect.Value, in.NumOut())
+				for k := range results {
+					results[k] = reflect.Zero(in.Out(k))
+				}
+				return results
+			}))
+		case in == contextType:
+			args = append(args, reflect.ValueOf(context.Background()))
+		default:
+			args = append(args, reflect.Zero(in))
+		}
+	}
+	return args
+}
+
+func TestUninitializedTree(t *testing.T) {
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	trees := []struct {
+		name string
+		tree func() *MerkleTree
+	}{
+		{"nil", func() *MerkleTree { return nil }},
+		{"zero value", func() *MerkleTree { return new(MerkleTree) }},
+	}
+
+	methods := reflect.TypeOf((*MerkleTree)(nil))
+	for _, c := range trees {
+		for m := 0; m < methods.NumMethod(); m++ {
+			method := methods.Method(m)
+			t.Run(c.name+"/"+method.Name, func(t *testing.T) {
+				// a method looping over the missing levels of the zero value would never return
+				results := make(chan []reflect.Value, 1)
+				go func() {
+					defer func() {
+						if r := recover(); r != nil {
+							t.Errorf("panicked: %v", r)
+							close(results)
+						}
+					}()
+					args := append([]reflect.Value{reflect.ValueOf(c.tree())}, zeroArguments(method)...)
+					results <- method.Func.Call(args)
+				}()
+
+				var out []reflect.Value
+				select {
+				case out = <-results:
+				case <-time.After(5 * time.Second):
+					t.Fatal("did not return")
+				}
+				if n := len(out); n > 0 && method.Type.Out(n-1) == errorType {
+					if err, _ := out[n-1].Interface().(error); !errors.Is(err, ErrUninitializedTree) {
+						t.Errorf("got %v, want ErrUninitializedTree", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestUninitializedTreeFunctions(t *testing.T) {
+	built, _ := NewMerkleTree(testElements(4))
+	var zero MerkleTree
+
+	if _, _, err := FirstDivergence(built, &zero); !errors.Is(err, ErrUninitializedTree) {
+		t.Errorf("got %v diverging from the zero value, want ErrUninitializedTree", err)
+	}
+	if _, _, err := FirstDivergence(nil, built); !errors.Is(err, ErrUninitializedTree) {
+		t.Errorf("got %v diverging from nil, want ErrUninitializedTree", err)
+	}
+	if _, err := SyncDiff(nil, built); !errors.Is(err, ErrUninitializedTree) {
+		t.Errorf("got %v syncing nil, want ErrUninitializedTree", err)
+	}
+	if _, err := SyncDiff(built, &zero); !errors.Is(err, ErrUninitializedTree) {
+		t.Errorf("got %v syncing with the zero value, want ErrUninitializedTree", err)
+	}
+	if _, err := ReplayJournal(&zero, bytes.NewReader(nil)); !errors.Is(err, ErrUninitializedTree) {
+		t.Errorf("got %v replaying onto the zero value, want ErrUninitializedTree", err)
+	}
+
+	// trees embedded in zero-initialized structs stay unusable rather than half-built
+	var holder struct{ Tree MerkleTree }
+	if err := holder.Tree.Append("element"); !errors.Is(err, ErrUninitializedTree) {
+		t.Errorf("got %v appending, want ErrUninitializedTree", err)
+	}
+	if root := holder.Tree.GetRoot(); root != "" {
+		t.Errorf("got root %q, want none", root)
+	}
+	if VerifyProof(holder.Tree.GetRoot(), MerkleProof{}) {
+		t.Error("zero proof verified against the zero tree's root")
+	}
+}
@@ -0,0 +1,70 @@
+package merkletree
+
+import "fmt"
+
+// Computes the root the tree would have after replacing the proven element with newElement,
+// by folding the new leaf hash through the proof's siblings. The proof itself is not verified.
+// Any data the proof carries stays with the leaf, as UpdateElement keeps it. Options are taken
+// as DeriveRoot takes them, so the root matches a tree built with the same options.
+func ComputeUpdatedRoot(proof MerkleProof, newElement string, opts ...Option) (string, error) {
+	return proofVerifier(proof, opts).ComputeUpdatedRoot(proof, newElement)
+}
+
+// Computes the root after replacing the proven element with newElement under the verifier's
+// options, as ComputeUpdatedRoot does. Under ModeBitcoin a right-hand sibling equal to the
+// node it pairs with is that node duplicated, so it is replaced along with the node.
+func (v *Verifier) ComputeUpdatedRoot(proof MerkleProof, newElement string) (string, error) {
+	cfg, proof, err := v.cfg.forProof(proof)
+	if err != nil {
+		return "", err
+	}
+
+	old, _ := ParseHash(proof.hElement)
+	current := cfg.dataLeafDigest(newElement, proof.data)
+	for i, s := range proof.siblings {
+		sibling, _ := ParseHash(s)
+		switch {
+		case proof.directions[i]:
+			current = cfg.nodeDigest(sibling, current)
+		case cfg.duplicateOddNodes && sibling == old:
+			current = cfg.nodeDigest(current, current)
+		default:
+			current = cfg.nodeDigest(current, sibling)
+		}
+		if cfg.duplicateOddNodes {
+			if proof.directions[i] {
+				old = cfg.nodeDigest(sibling, old)
+			} else {
+				old = cfg.nodeDigest(old, sibling)
+			}
+		}
+	}
+	return current.String(), nil
+}
+
+// Verifies that the proof holds under oldRoot and that replacing its element
+// with newElement produces newRoot, both roots folded under the options.
+func VerifyUpdate(oldRoot string, newRoot string, proof MerkleProof, newElement string, opts ...Option) bool {
+	v := newVerifier(opts)
+	if !v.VerifyProof(oldRoot, proof) {
+		return false
+	}
+
+	root, err := v.ComputeUpdatedRoot(proof, newElement)
+	return err == nil && root == newRoot
+}
+
+// Checks the proof is structurally sound, regardless of what root it produces.
+func (p MerkleProof) validateShape() error {
+	return checkProofShape(len(p.siblings), len(p.directions))
+}
+
+func checkProofShape(siblings int, directions int) error {
+	if siblings != directions {
+		return fmt.Errorf("%w: %d siblings but %d directions", ErrMalformedProof, siblings, directions)
+	}
+	if siblings > maxProofDepth {
+		return fmt.Errorf("%w: depth %d exceeds %d", ErrMalformedProof, siblings, maxProofDepth)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestComputeUpdatedRootMatchesUpdateElement(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"defaults":     nil,
+		"raw nodes":    {WithRawNodeHashing()},
+		"sorted pairs": {WithSortedPairs()},
+		"rfc6962":      {WithRFC6962Hashing()},
+		"hmac":         {WithHMACKey([]byte("key"))},
+		"bitcoin":      {WithMode(ModeBitcoin)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(109))
+
+			for i := 0; i < 200; i++ {
+				size := 1 + rng.Intn(40)
+				index := uint64(rng.Intn(size))
+				newElement := fmt.Sprintf("updated-%d", rng.Int())
+
+				mt, err := NewMerkleTree(testElements(size), opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				oldRoot := mt.GetRoot()
+				proof, err := mt.GetProof(index)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				newRoot, err := ComputeUpdatedRoot(proof, newElement, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if root, err := mt.Verifier().ComputeUpdatedRoot(proof, newElement); err != nil || root != newRoot {
+					t.Fatalf("size %d index %d: got %s, %v from the tree's verifier, want %s", size, index, root, err, newRoot)
+				}
+				if err := mt.UpdateElement(index, newElement); err != nil {
+					t.Fatal(err)
+				}
+				if newRoot != mt.GetRoot() {
+					t.Fatalf("size %d index %d: got %s, want %s", size, index, newRoot, mt.GetRoot())
+				}
+				if !VerifyUpdate(oldRoot, newRoot, proof, newElement, opts...) {
+					t.Fatalf("size %d index %d: update did not verify", size, index)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyUpdateRejects(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6))
+	oldRoot := mt.GetRoot()
+	proof, _ := mt.GetProof(2)
+	newRoot, _ := ComputeUpdatedRoot(proof, "new")
+
+	if VerifyUpdate(oldRoot, newRoot, proof, "other") {
+		t.Error("verified the wrong new element")
+	}
+	if VerifyUpdate(newRoot, newRoot, proof, "new") {
+		t.Error("verified against the wrong old root")
+	}
+
+	malformed := MerkleProof{hElement: proof.hElement, siblings: proof.siblings}
+	if _, err := ComputeUpdatedRoot(malformed, "new"); !errors.Is(err, ErrMalformedProof) {
+		t.Errorf("got %v, want %v", err, ErrMalformedProof)
+	}
+}
@@ -0,0 +1,98 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrUpgradeMismatch = errors.New("merkletree: proof upgrade does not fit the proof")
+
+// The siblings a proof from an earlier, smaller tree lacks to be a proof in the current
+// tree, see GetProofUpgrade. Siblings covering only elements the earlier tree held are
+// left out, as the proof already has them.
+type ProofUpgrade struct {
+	index     uint64
+	oldSize   uint64
+	oldHeight int
+	height    int
+	levels    []int    // levels of the siblings below, in increasing order
+	siblings  []string // the current sibling at each level
+	epoch     uint64
+}
+
+// Returns the number of siblings the upgrade carries.
+func (u ProofUpgrade) Len() int {
+	return len(u.siblings)
+}
+
+// Returns what a proof of the element at index, taken when the tree held its first
+// oldSize elements, needs to become the proof GetProof returns now: the siblings at
+// every level whose subtree covers an element appended since, or lies above the earlier
+// tree's height. Like prefix proofs, upgrades assume the tree was only appended to since
+// then; a proof upgraded after other mutations fails to verify. The earlier tree is taken
+// to have had the height its options give oldSize elements, so not one grown by
+// ExtendCapacity. Fails with ErrIndexOutOfBounds unless index < oldSize <= LeafCount().
+func (t *MerkleTree) GetProofUpgrade(index uint64, oldSize uint64) (ProofUpgrade, error) {
+	if err := t.rlockBuilt(); err != nil {
+		return ProofUpgrade{}, err
+	}
+	defer t.mu.RUnlock()
+
+	if index >= oldSize || oldSize > t.leafCount() {
+		return ProofUpgrade{}, fmt.Errorf("%w: index %d, earlier size %d, element count %d", ErrIndexOutOfBounds, index, oldSize, t.leafCount())
+	}
+
+	upgrade := ProofUpgrade{
+		index:     index,
+		oldSize:   oldSize,
+		oldHeight: t.cfg.height(oldSize),
+		height:    t.height(),
+		epoch:     t.epoch,
+	}
+	for level := 0; level < upgrade.height; level++ {
+		sibling := index>>level ^ 1
+		if level >= upgrade.oldHeight || (sibling+1)<<level > oldSize {
+			upgrade.levels = append(upgrade.levels, level)
+			upgrade.siblings = append(upgrade.siblings, t.node(level, sibling).String())
+		}
+	}
+	return upgrade, nil
+}
+
+// Applies an upgrade from GetProofUpgrade to a proof taken when the tree held oldSize
+// elements, returning the proof GetProof returns for the same element now, epoch included.
+// Fails with ErrUpgradeMismatch when the upgrade was made for another index, size or
+// proof depth, and as VerifyProof would for a malformed proof.
+func UpgradeProof(oldProof MerkleProof, oldSize uint64, upgrade ProofUpgrade) (MerkleProof, error) {
+	proof, err := oldProof.normalized()
+	if err != nil {
+		return MerkleProof{}, err
+	}
+	if len(proof.siblings) != upgrade.oldHeight || !directionsMatchIndex(proof.directions, upgrade.index, upgrade.oldHeight) || oldSize != upgrade.oldSize {
+		return MerkleProof{}, fmt.Errorf("%w: proof of depth %d from %d elements, upgrade for index %d at depth %d from %d", ErrUpgradeMismatch, len(proof.siblings), oldSize, upgrade.index, upgrade.oldHeight, upgrade.oldSize)
+	}
+
+	upgraded := MerkleProof{
+		hElement:   proof.hElement,
+		siblings:   make([]string, upgrade.height),
+		directions: make([]bool, upgrade.height),
+		epoch:      upgrade.epoch,
+		tag:        proof.tag,
+		algorithm:  proof.algorithm,
+		data:       proof.data,
+	}
+	next := 0
+	for level := range upgraded.siblings {
+		upgraded.directions[level] = upgrade.index>>level&1 == 1
+		if next < len(upgrade.levels) && upgrade.levels[next] == level {
+			upgraded.siblings[level] = upgrade.siblings[next]
+			next++
+			continue
+		}
+		if level >= len(proof.siblings) {
+			return MerkleProof{}, fmt.Errorf("%w: no sibling for level %d", ErrUpgradeMismatch, level)
+		}
+		upgraded.siblings[level] = proof.siblings[level]
+	}
+	return upgraded, nil
+}
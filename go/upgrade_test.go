@@ -0,0 +1,86 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestUpgradeProof(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}},
+		{"tagged keccak", []Option{WithKeccak256(), WithApplicationTag("app")}},
+		{"fixed depth", []Option{WithFixedDepth(9)}},
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	elements := testElements(300)
+	for _, c := range cases {
+		for trial := 0; trial < 40; trial++ {
+			n := 1 + rng.Intn(150)
+			m := n + rng.Intn(150)
+			i := uint64(rng.Intn(n))
+			t.Run(fmt.Sprintf("%s/%d to %d at %d", c.name, n, m, i), func(t *testing.T) {
+				mt, _ := NewMerkleTree(elements[:n], c.opts...)
+				old, _ := mt.GetProof(i)
+				for _, element := range elements[n:m] {
+					if err := mt.Append(element); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				upgrade, err := mt.GetProofUpgrade(i, uint64(n))
+				if err != nil {
+					t.Fatal(err)
+				}
+				upgraded, err := UpgradeProof(old, uint64(n), upgrade)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fresh, _ := mt.GetProof(i)
+				got, _ := upgraded.MarshalBinary()
+				want, _ := fresh.MarshalBinary()
+				if !bytes.Equal(got, want) {
+					t.Errorf("got %x, want %x", got, want)
+				}
+				if n == m && upgrade.Len() != 0 {
+					t.Errorf("got %d siblings for an unchanged tree, want 0", upgrade.Len())
+				}
+			})
+		}
+	}
+}
+
+func TestUpgradeProofMismatch(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5))
+	old, _ := mt.GetProof(1)
+	other, _ := mt.GetProof(2)
+	for _, element := range testElements(12)[5:] {
+		mt.Append(element)
+	}
+	upgrade, _ := mt.GetProofUpgrade(1, 5)
+
+	if _, err := UpgradeProof(other, 5, upgrade); !errors.Is(err, ErrUpgradeMismatch) {
+		t.Errorf("other index: got %v, want %v", err, ErrUpgradeMismatch)
+	}
+	if _, err := UpgradeProof(old, 6, upgrade); !errors.Is(err, ErrUpgradeMismatch) {
+		t.Errorf("other size: got %v, want %v", err, ErrUpgradeMismatch)
+	}
+	if _, err := mt.GetProofUpgrade(5, 5); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	if _, err := mt.GetProofUpgrade(0, 13); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+
+	// siblings over elements the earlier tree held are left out
+	if upgrade.Len() >= mt.Height() {
+		t.Errorf("got %d siblings in the upgrade, want fewer than the %d of a full proof", upgrade.Len(), mt.Height())
+	}
+}
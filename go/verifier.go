@@ -0,0 +1,86 @@
+package merkletree
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Verifies proofs under one set of options, fixed when it is created, so the hashing
+// cannot drift from the tree's between calls. The package-level verifiers each create
+// one from their options. A Verifier is safe for concurrent use.
+type Verifier struct {
+	cfg config
+}
+
+// Creates a verifier hashing as a tree built with the options does.
+// Fails with ErrModeConflict or ErrUnknownMode as tree constructors do.
+func NewVerifier(opts ...Option) (*Verifier, error) {
+	v := newVerifier(opts)
+	if err := v.cfg.checkMode(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func newVerifier(opts []Option) *Verifier {
+	return &Verifier{cfg: newConfig(opts)}
+}
+
+// Returns the verifier of the options or, without any, one hashing as the proof alone
+// names: under its application tag and, when it hashes bytes, its algorithm.
+func proofVerifier(proof MerkleProof, opts []Option) *Verifier {
+	if len(opts) == 0 {
+		return &Verifier{cfg: proofConfig(proof.tag, proof.algorithm)}
+	}
+	return newVerifier(opts)
+}
+
+// Returns a verifier hashing as the tree does, reporting to the tree's metrics sink and logger.
+// A tree no constructor built returns one with the default options.
+func (t *MerkleTree) Verifier() *Verifier {
+	if t.rlockBuiltAsIs() != nil {
+		return newVerifier(nil)
+	}
+	defer t.mu.RUnlock()
+	return &Verifier{cfg: t.cfg}
+}
+
+// Returns the hashing scheme proofs are verified under.
+func (v *Verifier) Scheme() Scheme {
+	return v.cfg.scheme()
+}
+
+// Verifies a Merkle proof against a known root, as VerifyProof does.
+func (v *Verifier) VerifyProof(root string, proof MerkleProof) bool {
+	return v.VerifyProofWithReason(root, proof) == nil
+}
+
+// Verifies a Merkle proof against a known root, returning why it fails as VerifyProofWithReason does.
+func (v *Verifier) VerifyProofWithReason(root string, proof MerkleProof) error {
+	parsed, err := parseRoot(root)
+	if err != nil {
+		if v.cfg.tracer != nil {
+			_, span := v.cfg.tracer.Start(context.Background(), spanVerifyProof, slog.Int(attrDepth, len(proof.siblings)))
+			endVerifySpan(span, err)
+		}
+		if v.cfg.metrics != nil {
+			v.cfg.metrics.ProofVerified(false)
+		}
+		v.cfg.logVerifyFailure(root, proof, err)
+		return err
+	}
+	return verifyProofHash(v.cfg, parsed, proof)
+}
+
+// Verifies a Merkle proof against a known, typed root, as VerifyProofHash does.
+func (v *Verifier) VerifyProofHash(root Hash, proof MerkleProof) bool {
+	return verifyProofHash(v.cfg, root, proof) == nil
+}
+
+// Returns the root the proof produces under the verifier's options, the one VerifyProof
+// compares with its root, failing as VerifyProofWithReason does for a proof that verifies
+// against no root under them.
+func (v *Verifier) DeriveRoot(proof MerkleProof) (string, error) {
+	_, root, err := v.cfg.deriveRoot(proof)
+	return root, err
+}
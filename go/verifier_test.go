@@ -0,0 +1,121 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifierHonorsTreeOptions(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(5), WithKeccak256())
+	root := mt.GetRoot()
+	proof, _ := mt.GetProof(3)
+	proofs, _ := mt.GetProofs([]uint64{1, 3})
+	multi, err := CombineProofs([]MerkleProof{proofs[1], proofs[3]}, WithKeccak256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := mt.GetProofBytes(3)
+	last, _ := mt.GetLastLeafProof()
+	prefix, _ := mt.GetPrefixProof(2)
+
+	// the proofs name keccak256, so combining them needs no options
+	if named, err := CombineProofs([]MerkleProof{proofs[1], proofs[3]}); err != nil || !mt.Verifier().VerifyMultiProof(root, named) {
+		t.Errorf("got %v combining without options, want a multiproof verifying", err)
+	}
+
+	matching, err := NewVerifier(WithKeccak256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults, _ := NewVerifier()
+
+	cases := []struct {
+		name     string
+		verifier *Verifier
+		want     bool
+	}{
+		{"default", defaults, false},
+		{"matching", matching, true},
+		{"tree", mt.Verifier(), true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			v := c.verifier
+			if got := v.VerifyProof(root, proof); got != c.want {
+				t.Errorf("VerifyProof: got %v, want %v", got, c.want)
+			}
+			if got := v.VerifyMultiProof(root, multi); got != c.want {
+				t.Errorf("VerifyMultiProof: got %v, want %v", got, c.want)
+			}
+			if got := v.VerifyProofBytes(mt.GetRootHash(), raw); got != c.want {
+				t.Errorf("VerifyProofBytes: got %v, want %v", got, c.want)
+			}
+			if got := v.VerifyLastLeafProof(root, 5, last); got != c.want {
+				t.Errorf("VerifyLastLeafProof: got %v, want %v", got, c.want)
+			}
+			if got := v.VerifyPrefixProof(root, 2, prefix.PrefixRoot(), prefix); got != c.want {
+				t.Errorf("VerifyPrefixProof: got %v, want %v", got, c.want)
+			}
+			if got := v.VerifyConsistency(prefix.PrefixRoot(), 2, root, prefix); got != c.want {
+				t.Errorf("VerifyConsistency: got %v, want %v", got, c.want)
+			}
+		})
+	}
+
+	if VerifyProof(root, proof) || !VerifyProof(root, proof, WithKeccak256()) {
+		t.Error("package-level VerifyProof disagrees with its verifier")
+	}
+	if VerifyProofBytes(mt.GetRootHash(), raw) || !VerifyProofBytes(mt.GetRootHash(), raw, WithKeccak256()) {
+		t.Error("package-level VerifyProofBytes disagrees with its verifier")
+	}
+}
+
+func TestVerifyConsistencyUnderTreeOptions(t *testing.T) {
+	opts := []Option{WithKeccak256(), WithApplicationTag("log")}
+	elements := testElements(11)
+	mt, _ := NewMerkleTree(elements, opts...)
+	v, err := NewVerifier(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := uint64(1); n <= 11; n++ {
+		old, _ := NewMerkleTree(elements[:n], opts...)
+		proof, err := mt.GetPrefixProof(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.VerifyConsistency(old.GetRoot(), n, mt.GetRoot(), proof) {
+			t.Errorf("%d elements: got the trees inconsistent, want consistent", n)
+		}
+		if !VerifyConsistency(old.GetRoot(), n, mt.GetRoot(), proof, opts...) {
+			t.Errorf("%d elements: package-level VerifyConsistency disagrees with its verifier", n)
+		}
+		if VerifyConsistency(old.GetRoot(), n, mt.GetRoot(), proof) {
+			t.Errorf("%d elements: got the trees consistent under the default options", n)
+		}
+	}
+
+	other, _ := NewMerkleTree([]string{"a", "b", "c", "d"}, opts...)
+	proof, _ := mt.GetPrefixProof(4)
+	if v.VerifyConsistency(other.GetRoot(), 4, mt.GetRoot(), proof) {
+		t.Error("got the tree consistent with a tree it does not extend")
+	}
+}
+
+func TestNewVerifierRejectsModeConflicts(t *testing.T) {
+	if _, err := NewVerifier(WithMode(ModeSSZ), WithKeccak256()); !errors.Is(err, ErrModeConflict) {
+		t.Errorf("got %v, want %v", err, ErrModeConflict)
+	}
+
+	mt, _ := NewMerkleTree(testElements(3), WithMode(ModeSSZ))
+	v, err := NewVerifier(WithScheme(mt.Verifier().Scheme()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, _ := mt.GetProof(2)
+	if !v.VerifyProof(mt.GetRoot(), proof) {
+		t.Error("failed to verify under the tree's scheme")
+	}
+}
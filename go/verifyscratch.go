@@ -0,0 +1,101 @@
+package merkletree
+
+import "encoding/hex"
+
+// State reused across calls to VerifyProofInto, so verifying allocates nothing: the
+// options proofs are verified under and, under WithHMACKey, the keyed hash and the
+// buffer it reads from. Create one per goroutine and keep it; a VerifyScratch is not
+// safe for concurrent use.
+type VerifyScratch struct {
+	cfg config
+	mac macScratch
+}
+
+// Creates scratch verifying as a tree built with the options hashes, failing as
+// NewVerifier does. Creating it allocates; verifying with it does not.
+func NewVerifyScratch(opts ...Option) (*VerifyScratch, error) {
+	v, err := NewVerifier(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return v.Scratch(), nil
+}
+
+// Returns scratch verifying as v does, reporting to its metrics sink and logger.
+func (v *Verifier) Scratch() *VerifyScratch {
+	return &VerifyScratch{cfg: v.cfg, mac: v.cfg.newMACScratch(v.cfg.nodeHash)}
+}
+
+// Verifies a proof given as raw digests against root: leaf is the leaf hash of the
+// proven element, siblings the path up to the root and directions true where the
+// sibling is on the left, as in MerkleProofBytes. Proofs verify exactly when the
+// MerkleProof of the same digests verifies with VerifyProof under the scratch's options,
+// so digests not of 32 bytes, siblings and directions of different lengths and proofs
+// deeper than any tree all fail. Nothing is allocated, whatever the outcome, unless a
+// WithPoseidon backend hashes the nodes or a failure is logged at debug level.
+// The digests are only read, and the proof carries no tag or algorithm to check.
+func VerifyProofInto(scratch *VerifyScratch, root []byte, leaf []byte, siblings [][]byte, directions []bool) bool {
+	cfg := scratch.cfg
+	if len(root) != digestSize {
+		if cfg.metrics != nil {
+			cfg.metrics.ProofVerified(false)
+		}
+		if cfg.debugging() {
+			cfg.logVerifyFailure(hex.EncodeToString(root), MerkleProof{}, &InvalidDigestError{Field: "root", Err: ErrInvalidHash})
+		}
+		return false
+	}
+
+	derived, err := cfg.foldDigests(&scratch.mac, leaf, siblings, directions)
+	if err == nil && derived != Hash(root) {
+		err = ErrInvalidProof
+	}
+	if cfg.metrics != nil {
+		cfg.metrics.NodeHashed(len(siblings))
+		cfg.metrics.ProofVerified(err == nil)
+	}
+	if err != nil && cfg.debugging() {
+		logged := MerkleProof{}
+		if err == ErrInvalidProof {
+			logged = digestProof(leaf, siblings, directions)
+			err = nil
+		}
+		cfg.logVerifyFailure(hex.EncodeToString(root), logged, err)
+	}
+	return err == nil
+}
+
+// Folds siblings into leaf, each a raw digest, returning the root they produce: the one
+// fold behind VerifyProof and VerifyProofInto. Keyed hashing goes through mac when it is
+// not nil. Fails with ErrMalformedProof, without allocating, for a digest not of 32 bytes
+// or a proof of inconsistent shape.
+func (cfg config) foldDigests(mac *macScratch, leaf []byte, siblings [][]byte, directions []bool) (Hash, error) {
+	if len(leaf) != digestSize || len(siblings) != len(directions) || len(siblings) > maxProofDepth {
+		return Hash{}, ErrMalformedProof
+	}
+	current := Hash(leaf)
+	for i, sibling := range siblings {
+		if len(sibling) != digestSize {
+			return Hash{}, ErrMalformedProof
+		}
+		if directions[i] {
+			current = cfg.nodeDigestWith(mac, Hash(sibling), current)
+		} else {
+			current = cfg.nodeDigestWith(mac, current, Hash(sibling))
+		}
+	}
+	return current, nil
+}
+
+// Returns the MerkleProof of the raw digests, which must be well formed, for logging.
+func digestProof(leaf []byte, siblings [][]byte, directions []bool) MerkleProof {
+	proof := MerkleProof{
+		hElement:   hex.EncodeToString(leaf),
+		siblings:   make([]string, len(siblings)),
+		directions: append([]bool(nil), directions...),
+	}
+	for i, sibling := range siblings {
+		proof.siblings[i] = hex.EncodeToString(sibling)
+	}
+	return proof
+}
@@ -0,0 +1,134 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Returns the raw digests of the proof of index in mt.
+func rawProof(t *testing.T, mt *MerkleTree, index uint64) (root, leaf []byte, siblings [][]byte, directions []bool) {
+	t.Helper()
+	proof, err := mt.GetProofBytes(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootHash := mt.GetRootHash()
+	siblings = make([][]byte, len(proof.Siblings))
+	for i := range proof.Siblings {
+		siblings[i] = proof.Siblings[i][:]
+	}
+	return rootHash[:], proof.Element[:], siblings, proof.Directions
+}
+
+func TestVerifyProofIntoAllocationFree(t *testing.T) {
+	options := map[string][]Option{
+		"default":      nil,
+		"keccak":       {WithKeccak256()},
+		"blake3":       {WithBLAKE3()},
+		"tagged":       {WithApplicationTag("gateway")},
+		"hmac":         {WithHMACKey([]byte("secret"))},
+		"hmac keccak":  {WithHMACKey([]byte("secret")), WithNodeHasher(HashKeccak256)},
+		"sorted pairs": {WithSortedPairs()},
+		"rfc6962":      {WithMode(ModeRFC6962)},
+		"bitcoin":      {WithMode(ModeBitcoin)},
+		"with metrics": {WithMetrics(&CountingMetrics{})},
+	}
+	for name, opts := range options {
+		t.Run(name, func(t *testing.T) {
+			mt, _ := NewMerkleTree(testElements(37), opts...)
+			root, leaf, siblings, directions := rawProof(t, mt, 21)
+			scratch, err := NewVerifyScratch(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyProofInto(scratch, root, leaf, siblings, directions) {
+				t.Fatal("got the proof rejected")
+			}
+
+			other := bytes.Repeat([]byte{0xab}, digestSize)
+			for _, c := range []struct {
+				name string
+				root []byte
+				want bool
+			}{{"valid", root, true}, {"other root", other, false}, {"short root", root[:31], false}} {
+				var ok bool
+				allocs := testing.AllocsPerRun(100, func() {
+					ok = VerifyProofInto(scratch, c.root, leaf, siblings, directions)
+				})
+				if allocs != 0 || ok != c.want {
+					t.Errorf("%s: got %v with %v allocations, want %v with none", c.name, ok, allocs, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyProofIntoMatchesVerifyProof(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(11))
+	root, leaf, siblings, directions := rawProof(t, mt, 6)
+	single, _ := NewMerkleTree(testElements(1))
+	singleRoot, singleLeaf, _, _ := rawProof(t, single, 0)
+
+	with := func(i int, sibling []byte) [][]byte {
+		changed := append([][]byte(nil), siblings...)
+		changed[i] = sibling
+		return changed
+	}
+	flipped := append([]bool(nil), directions...)
+	flipped[1] = !flipped[1]
+	deep := make([][]byte, maxProofDepth+1)
+	for i := range deep {
+		deep[i] = leaf
+	}
+
+	cases := []struct {
+		name       string
+		root, leaf []byte
+		siblings   [][]byte
+		directions []bool
+	}{
+		{"valid", root, leaf, siblings, directions},
+		{"single leaf", singleRoot, singleLeaf, nil, nil},
+		{"other root", singleRoot, leaf, siblings, directions},
+		{"flipped direction", root, leaf, siblings, flipped},
+		{"empty root", nil, leaf, siblings, directions},
+		{"long root", append(append([]byte(nil), root...), 0), leaf, siblings, directions},
+		{"short leaf", root, leaf[:16], siblings, directions},
+		{"no leaf", root, nil, siblings, directions},
+		{"short sibling", root, leaf, with(2, siblings[2][:31]), directions},
+		{"empty sibling", root, leaf, with(0, nil), directions},
+		{"extra direction", root, leaf, siblings, append(append([]bool(nil), directions...), false)},
+		{"missing direction", root, leaf, siblings, directions[:len(directions)-1]},
+		{"too deep", root, leaf, deep, make([]bool, len(deep))},
+	}
+	scratch, _ := NewVerifyScratch()
+	for _, c := range cases {
+		proof := digestProof(c.leaf, c.siblings, c.directions)
+		want := VerifyProof(hex.EncodeToString(c.root), proof)
+		if got := VerifyProofInto(scratch, c.root, c.leaf, c.siblings, c.directions); got != want {
+			t.Errorf("%s: got %v, VerifyProof got %v", c.name, got, want)
+		}
+		if c.name == "valid" || c.name == "single leaf" {
+			if !want {
+				t.Errorf("%s: got the proof rejected", c.name)
+			}
+		} else if want {
+			t.Errorf("%s: got the proof accepted", c.name)
+		}
+	}
+}
+
+func TestVerifyProofIntoMetrics(t *testing.T) {
+	metrics := &CountingMetrics{}
+	mt, _ := NewMerkleTree(testElements(8))
+	root, leaf, siblings, directions := rawProof(t, mt, 3)
+	scratch, _ := NewVerifyScratch(WithMetrics(metrics))
+
+	VerifyProofInto(scratch, root, leaf, siblings, directions)
+	VerifyProofInto(scratch, leaf, leaf, siblings, directions)
+	VerifyProofInto(scratch, nil, leaf, siblings, directions)
+	if verified, rejected, nodes := metrics.ProofsVerified.Load(), metrics.ProofsRejected.Load(), metrics.NodeHashes.Load(); verified != 1 || rejected != 2 || nodes != 6 {
+		t.Errorf("got %d verified, %d rejected and %d nodes hashed, want 1, 2 and 6", verified, rejected, nodes)
+	}
+}
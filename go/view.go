@@ -0,0 +1,112 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+var ErrViewStale = errors.New("merkletree: tree was mutated since the view was taken")
+
+// A read-only window onto the leaves [start, end) of a tree, indexed from zero, which
+// acts as the shorter sequence it spans. The view holds only its bounds and the tree's
+// epoch: nothing is copied, and every method reads the tree, failing with ErrViewStale
+// once the tree has been mutated since View. A TreeView is safe for concurrent use.
+type TreeView struct {
+	tree       *MerkleTree
+	start, end uint64
+	epoch      uint64 // epoch of tree the view is valid at
+}
+
+// Proves a leaf of a view, see TreeView.GetProof.
+type ViewProof struct {
+	Proof   MerkleProof  // proves the leaf under the root of the whole tree
+	Subroot string       // root of the complete subtree the view spans; empty when it spans none
+	Chained ChainedProof // proves the leaf under Subroot and Subroot under the tree's root; zero without a Subroot
+}
+
+// Returns a view of the leaves [start, end), which must lie within the elements and
+// hold at least one. Fails with ErrIndexOutOfBounds otherwise.
+func (t *MerkleTree) View(start, end uint64) (*TreeView, error) {
+	if err := t.rlockBuiltAsIs(); err != nil {
+		return nil, err
+	}
+	defer t.mu.RUnlock()
+
+	if start >= end || end > t.leafCount() {
+		return nil, fmt.Errorf("%w: view [%d, %d) of %d elements", ErrIndexOutOfBounds, start, end, t.leafCount())
+	}
+	return &TreeView{tree: t, start: start, end: end, epoch: t.epoch}, nil
+}
+
+// Returns the number of leaves the view spans.
+func (v *TreeView) LeafCount() uint64 {
+	return v.end - v.start
+}
+
+// Returns the element at index within the view. Fails with ErrIndexOutOfBounds beyond
+// the view and ErrElementsUnknown on trees without elements, such as imported ones.
+func (v *TreeView) GetLeaf(index uint64) (string, error) {
+	if err := v.rlock(); err != nil {
+		return "", err
+	}
+	defer v.tree.mu.RUnlock()
+
+	if index >= v.LeafCount() {
+		return "", v.outOfBounds(index)
+	}
+	if v.tree.elements == nil {
+		return "", ErrElementsUnknown
+	}
+	return v.tree.elements[v.start+index], nil
+}
+
+// Generates the proof of the leaf at index within the view, the leaf at start+index
+// of the tree, under the tree's root. When the view spans exactly one complete subtree,
+// a power of two of leaves starting at a multiple of that power, the proof also carries
+// the subtree's root and a ChainedProof of the leaf through it, which VerifyChainedProof
+// checks against the tree's root; proofs of its leaves under Subroot alone are its Inner.
+func (v *TreeView) GetProof(index uint64) (ViewProof, error) {
+	if err := v.rlock(); err != nil {
+		return ViewProof{}, err
+	}
+	defer v.tree.mu.RUnlock()
+
+	if index >= v.LeafCount() {
+		return ViewProof{}, v.outOfBounds(index)
+	}
+	proof, err := v.tree.getProof(v.start + index)
+	if err != nil {
+		return ViewProof{}, err
+	}
+	vp := ViewProof{Proof: proof}
+
+	size := v.LeafCount()
+	if size&(size-1) != 0 || v.start%size != 0 {
+		return vp, nil
+	}
+	level := bits.TrailingZeros64(size)
+	vp.Subroot = v.tree.node(level, v.start>>level).String()
+	inner, outer := proof, proof
+	inner.siblings, inner.directions = proof.siblings[:level:level], proof.directions[:level:level]
+	outer.hElement, outer.data = vp.Subroot, nil
+	outer.siblings, outer.directions = proof.siblings[level:], proof.directions[level:]
+	vp.Chained = ChainedProof{Inner: inner, Outer: outer}
+	return vp, nil
+}
+
+// Takes the tree's read lock, failing with ErrViewStale when it has changed since View.
+func (v *TreeView) rlock() error {
+	if err := v.tree.rlockBuilt(); err != nil {
+		return err
+	}
+	if v.tree.epoch != v.epoch {
+		v.tree.mu.RUnlock()
+		return fmt.Errorf("%w: view taken at epoch %d, tree at %d", ErrViewStale, v.epoch, v.tree.epoch)
+	}
+	return nil
+}
+
+func (v *TreeView) outOfBounds(index uint64) error {
+	return fmt.Errorf("%w: index %d, view of %d leaves", ErrIndexOutOfBounds, index, v.LeafCount())
+}
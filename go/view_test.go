@@ -0,0 +1,83 @@
+package merkletree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestViewProofs(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(13))
+	root := mt.GetRoot()
+
+	aligned, err := mt.View(8, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, _ := NewMerkleTree(testElements(13)[8:12])
+	for i := uint64(0); i < aligned.LeafCount(); i++ {
+		leaf, _ := aligned.GetLeaf(i)
+		if leaf != testElements(13)[8+i] {
+			t.Errorf("got leaf %q at %d, want %q", leaf, i, testElements(13)[8+i])
+		}
+		proof, err := aligned.GetProof(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyProof(root, proof.Proof) || proofIndex(proof.Proof) != 8+i {
+			t.Errorf("index %d: got a proof not of leaf %d under the root", i, 8+i)
+		}
+		if proof.Subroot != sub.GetRoot() {
+			t.Fatalf("got subroot %s, want %s", proof.Subroot, sub.GetRoot())
+		}
+		if !VerifyChainedProof(root, proof.Chained) || !VerifyProof(proof.Subroot, proof.Chained.Inner) {
+			t.Errorf("index %d: got the chained proof rejected", i)
+		}
+		if want, _ := sub.GetProof(i); proof.Chained.Inner.hElement != want.hElement || proofIndex(proof.Chained.Inner) != i {
+			t.Errorf("index %d: got an inner proof of another leaf", i)
+		}
+	}
+
+	unaligned, _ := mt.View(3, 7)
+	proof, err := unaligned.GetProof(1)
+	if err != nil || !VerifyProof(root, proof.Proof) || proofIndex(proof.Proof) != 4 {
+		t.Errorf("got %v, want a proof of leaf 4", err)
+	}
+	if proof.Subroot != "" || len(proof.Chained.Inner.siblings) != 0 {
+		t.Error("got a subroot for a view spanning no complete subtree")
+	}
+	single, _ := mt.View(5, 6)
+	if proof, _ := single.GetProof(0); proof.Subroot != proof.Proof.hElement {
+		t.Error("got a one-leaf view without the leaf as its subroot")
+	}
+}
+
+func TestViewErrors(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(6))
+	for _, bounds := range [][2]uint64{{3, 3}, {4, 2}, {0, 7}} {
+		if _, err := mt.View(bounds[0], bounds[1]); !errors.Is(err, ErrIndexOutOfBounds) {
+			t.Errorf("%v: got %v, want %v", bounds, err, ErrIndexOutOfBounds)
+		}
+	}
+
+	view, _ := mt.View(2, 4)
+	if _, err := view.GetLeaf(2); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want %v", err, ErrIndexOutOfBounds)
+	}
+	mt.Append("appended")
+	if _, err := view.GetProof(0); !errors.Is(err, ErrViewStale) {
+		t.Errorf("got %v, want %v", err, ErrViewStale)
+	}
+	if _, err := view.GetLeaf(0); !errors.Is(err, ErrViewStale) {
+		t.Errorf("got %v, want %v", err, ErrViewStale)
+	}
+
+	layout, data := mt.ExportNodes()
+	imported, _ := ImportNodes(layout, data)
+	view, _ = imported.View(0, 2)
+	if _, err := view.GetLeaf(0); !errors.Is(err, ErrElementsUnknown) {
+		t.Errorf("got %v, want %v", err, ErrElementsUnknown)
+	}
+	if proof, err := view.GetProof(1); err != nil || !VerifyChainedProof(imported.GetRoot(), proof.Chained) {
+		t.Errorf("got %v, want a chained proof from the imported tree", err)
+	}
+}
@@ -0,0 +1,133 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+var ErrNotWatched = errors.New("merkletree: index is not watched")
+
+// Keeps a proof of each index up to date from now on, so CurrentProof can return it without
+// walking the tree. Each mutation patches the maintained proofs in place, replacing only the
+// siblings on the paths it changed, at a cost of one node read per watched index and changed
+// leaf; mutations which move elements or change the height regenerate them instead.
+// Watching an index again regenerates its proof. Fails with ErrIndexOutOfBounds, watching
+// none of the indices, when any is beyond the tree.
+// Under WithLazyRecompute, every mutation recomputes its ancestors while anything is watched.
+func (t *MerkleTree) Watch(indices ...uint64) error {
+	if err := t.lockBuilt(); err != nil {
+		return err
+	}
+	defer t.mu.Unlock()
+	for _, index := range indices {
+		if index >= t.leafCount() {
+			return t.outOfBounds(index)
+		}
+	}
+
+	t.recomputeDirty()
+	if t.witnesses == nil && len(indices) > 0 {
+		t.witnesses = make(map[uint64]MerkleProof, len(indices))
+	}
+	for _, index := range indices {
+		t.witnesses[index] = t.proofAt(index)
+	}
+	return nil
+}
+
+// Stops maintaining the proofs of the indices. Indices which are not watched are ignored.
+func (t *MerkleTree) Unwatch(indices ...uint64) {
+	if t.lockBuilt() != nil {
+		return
+	}
+	defer t.mu.Unlock()
+	for _, index := range indices {
+		delete(t.witnesses, index)
+	}
+	if len(t.witnesses) == 0 {
+		t.witnesses = nil
+	}
+}
+
+// Returns the maintained proof of a watched index, the same proof GetProof would return,
+// in constant time. Fails with ErrNotWatched for an index Watch was not given, and with
+// ErrIndexOutOfBounds for a watched index a mutation has since left beyond the tree;
+// its proof is maintained again once the tree grows back over it.
+func (t *MerkleTree) CurrentProof(index uint64) (MerkleProof, error) {
+	if err := t.rlockBuiltAsIs(); err != nil {
+		return MerkleProof{}, err
+	}
+	defer t.mu.RUnlock()
+	proof, ok := t.witnesses[index]
+	if !ok {
+		return MerkleProof{}, fmt.Errorf("%w: index %d", ErrNotWatched, index)
+	}
+	if proof.hElement == "" {
+		return MerkleProof{}, t.outOfBounds(index)
+	}
+	return proof, nil
+}
+
+// Brings the watched proofs up to date after a mutation described by rec, which found
+// oldCount leaves at oldHeight. A zero rec, as for a mutation which failed after
+// advancing the epoch, regenerates every proof.
+func (t *MerkleTree) patchWitnesses(rec journalRecord, oldCount uint64, oldHeight int) {
+	t.recomputeDirty()
+
+	var changed []uint64
+	regenerate := t.height() != oldHeight || t.cfg.setSemantics
+	switch rec.op {
+	case journalUpdate, journalSwap, journalApply, journalData:
+		changed = rec.indices
+	case journalAppend:
+		for index := oldCount; index < t.count; index++ {
+			changed = append(changed, index)
+		}
+	default:
+		regenerate = true
+	}
+	// past one change per level, walking each proof afresh reads fewer nodes
+	regenerate = regenerate || len(changed) > t.height()
+
+	for index, proof := range t.witnesses {
+		switch {
+		case index >= t.leafCount():
+			t.witnesses[index] = MerkleProof{}
+		case regenerate || proof.hElement == "":
+			t.witnesses[index] = t.proofAt(index)
+		default:
+			t.witnesses[index] = t.patchWitness(index, proof, changed)
+		}
+	}
+}
+
+// Returns the proof of index with the siblings on the paths of the changed leaves read
+// again, leaving the proof it was given, which callers may hold, as it was.
+func (t *MerkleTree) patchWitness(index uint64, proof MerkleProof, changed []uint64) MerkleProof {
+	proof.siblings = slices.Clone(proof.siblings)
+	proof.epoch = t.epoch
+	for _, leaf := range changed {
+		if leaf == index {
+			proof.hElement = t.node(0, index).String()
+			proof.data = slices.Clone(t.leafData(index))
+			continue
+		}
+		// the paths meet above the highest differing bit, which is where the sibling lies
+		level := bits.Len64(index^leaf) - 1
+		if level < len(proof.siblings) {
+			proof.siblings[level] = t.node(level, (index>>level)^1).String()
+		}
+	}
+	// a sibling standing in for a missing node duplicates this proof's own path above
+	// the change, and the level sizes the duplicates follow may have grown with the count
+	if t.cfg.duplicateOddNodes {
+		for level := range proof.siblings {
+			if sibling := (index >> level) ^ 1; sibling >= t.levelSize(level) {
+				proof.siblings[level] = t.node(level, sibling).String()
+			}
+		}
+	}
+	return proof
+}
@@ -0,0 +1,162 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Fails unless the maintained proof of every watched index is the one GetProof returns.
+func checkWitnesses(t *testing.T, mt *MerkleTree, watched []uint64, step string) {
+	t.Helper()
+	for _, index := range watched {
+		got, err := mt.CurrentProof(index)
+		want, wantErr := mt.GetProof(index)
+		if !errors.Is(err, wantErr) || (wantErr == nil && err != nil) {
+			t.Fatalf("%s: got %v for index %d, want %v", step, err, index, wantErr)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: got %+v for index %d, want %+v", step, got, index, want)
+		}
+	}
+}
+
+func TestWatchRandomized(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"bitcoin", []Option{WithMode(ModeBitcoin)}},
+		{"lazy", []Option{WithLazyRecompute()}},
+		{"fixed depth", []Option{WithFixedDepth(7)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(173))
+			mt, _ := NewMerkleTree(testElements(5), c.opts...)
+			watched := []uint64{0, 3, 4}
+			if err := mt.Watch(watched...); err != nil {
+				t.Fatal(err)
+			}
+
+			for step := 0; step < 300; step++ {
+				count := mt.LeafCount()
+				var desc string
+				switch op := rng.Intn(4); {
+				case op == 0 && count < 100:
+					desc = "append"
+					if err := mt.Append(fmt.Sprintf("appended-%d", step)); err != nil {
+						t.Fatal(err)
+					}
+				case op == 1:
+					i, j := uint64(rng.Int63n(int64(count))), uint64(rng.Int63n(int64(count)))
+					desc = fmt.Sprintf("swap %d and %d", i, j)
+					if err := mt.Swap(i, j); err != nil {
+						t.Fatal(err)
+					}
+				case op == 2:
+					desc = "apply"
+					err := mt.Apply(func(index uint64, element string) (string, error) {
+						if rng.Intn(8) == 0 {
+							return fmt.Sprintf("applied-%d-%d", step, index), nil
+						}
+						return element, nil
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+				default:
+					index := uint64(rng.Int63n(int64(count)))
+					desc = fmt.Sprintf("update %d", index)
+					if err := mt.UpdateElement(index, fmt.Sprintf("updated-%d", step)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if rng.Intn(10) == 0 {
+					index := uint64(rng.Int63n(int64(mt.LeafCount())))
+					if err := mt.Watch(index); err != nil {
+						t.Fatal(err)
+					}
+					watched = append(watched, index)
+				}
+				checkWitnesses(t, mt, watched, fmt.Sprintf("step %d, %s", step, desc))
+			}
+		})
+	}
+}
+
+func TestWatchPatchesWithoutProofs(t *testing.T) {
+	metrics := &CountingMetrics{}
+	mt, _ := NewMerkleTree(testElements(12), WithMetrics(metrics))
+	mt.Watch(2, 9)
+	metrics.Reset()
+
+	mt.UpdateElement(5, "updated")
+	mt.Append("appended")
+	if got := metrics.ProofsGenerated.Load(); got != 0 {
+		t.Errorf("got %d proofs generated patching, want 0", got)
+	}
+	checkWitnesses(t, mt, []uint64{2, 9}, "patched")
+
+	// appending past a power of two changes the height, which regenerates both
+	metrics.Reset()
+	for mt.LeafCount() <= 16 {
+		mt.Append(fmt.Sprintf("appended-%d", mt.LeafCount()))
+	}
+	if got := metrics.ProofsGenerated.Load(); got != 2 {
+		t.Errorf("got %d proofs generated growing, want 2", got)
+	}
+	checkWitnesses(t, mt, []uint64{2, 9}, "grown")
+}
+
+func TestWatchShrinking(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(9))
+	if err := mt.Watch(1, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mt.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	checkWitnesses(t, mt, []uint64{1}, "deleted")
+	if _, err := mt.CurrentProof(8); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v for a watched index beyond the tree, want ErrIndexOutOfBounds", err)
+	}
+
+	mt.Append("back")
+	checkWitnesses(t, mt, []uint64{1, 8}, "appended")
+
+	if err := mt.Reset(testElements(12)); err != nil {
+		t.Fatal(err)
+	}
+	checkWitnesses(t, mt, []uint64{1, 8}, "reset")
+}
+
+func TestWatchErrors(t *testing.T) {
+	mt, _ := NewMerkleTree(testElements(4))
+	if err := mt.Watch(1, 4); !errors.Is(err, ErrIndexOutOfBounds) {
+		t.Errorf("got %v, want ErrIndexOutOfBounds", err)
+	}
+	if _, err := mt.CurrentProof(1); !errors.Is(err, ErrNotWatched) {
+		t.Errorf("got %v after a failed watch, want ErrNotWatched", err)
+	}
+
+	mt.Watch(1, 2)
+	mt.Unwatch(1, 3)
+	if _, err := mt.CurrentProof(1); !errors.Is(err, ErrNotWatched) {
+		t.Errorf("got %v after unwatching, want ErrNotWatched", err)
+	}
+	checkWitnesses(t, mt, []uint64{2}, "unwatched")
+
+	// a proof handed out stays as it was when later mutations patch its successor
+	before, _ := mt.CurrentProof(2)
+	mt.UpdateElement(3, "updated")
+	after, _ := mt.CurrentProof(2)
+	if !VerifyProof(mt.GetRoot(), after) || VerifyProof(mt.GetRoot(), before) {
+		t.Error("got the proof handed out patched in place")
+	}
+}